@@ -0,0 +1,364 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/go-i2p/go-sam-bridge/lib/bridge"
+	"github.com/go-i2p/go-sam-bridge/lib/session"
+	"github.com/pelletier/go-toml/v2"
+	"go.yaml.in/yaml/v3"
+)
+
+// fileConfig is the on-disk form of Config. The format is chosen by file
+// extension: .toml, .yaml/.yml or .json. Durations are strings such as
+// "30s" or "5m", and file modes are octal strings such as "0660".
+type fileConfig struct {
+	Listen     string         `json:"listen" yaml:"listen" toml:"listen"`
+	UnixSocket fileUnixSocket `json:"unix_socket" yaml:"unix_socket" toml:"unix_socket"`
+	I2CP       string         `json:"i2cp" yaml:"i2cp" toml:"i2cp"`
+	UDP        string         `json:"udp" yaml:"udp" toml:"udp"`
+	Debug      bool           `json:"debug" yaml:"debug" toml:"debug"`
+	I2CPUser   string         `json:"i2cp_user" yaml:"i2cp_user" toml:"i2cp_user"`
+	I2CPPass   string         `json:"i2cp_pass" yaml:"i2cp_pass" toml:"i2cp_pass"`
+	Metrics    string         `json:"metrics" yaml:"metrics" toml:"metrics"`
+	Admin      string         `json:"admin" yaml:"admin" toml:"admin"`
+	AuditLog   string         `json:"audit_log" yaml:"audit_log" toml:"audit_log"`
+
+	TranscriptDir string `json:"transcript_dir" yaml:"transcript_dir" toml:"transcript_dir"`
+
+	ShutdownTimeout       duration `json:"shutdown_timeout" yaml:"shutdown_timeout" toml:"shutdown_timeout"`
+	EmbeddedRouterTimeout duration `json:"embedded_router_timeout" yaml:"embedded_router_timeout" toml:"embedded_router_timeout"`
+
+	TLS           fileTLS           `json:"tls" yaml:"tls" toml:"tls"`
+	Auth          fileAuth          `json:"auth" yaml:"auth" toml:"auth"`
+	Timeouts      fileTimeouts      `json:"timeouts" yaml:"timeouts" toml:"timeouts"`
+	Limits        fileLimits        `json:"limits" yaml:"limits" toml:"limits"`
+	Access        fileAccess        `json:"access" yaml:"access" toml:"access"`
+	Stream        fileStream        `json:"stream" yaml:"stream" toml:"stream"`
+	RateLimit     fileRateLimit     `json:"rate_limit" yaml:"rate_limit" toml:"rate_limit"`
+	ProxyProtocol fileProxyProtocol `json:"proxy_protocol" yaml:"proxy_protocol" toml:"proxy_protocol"`
+}
+
+type fileUnixSocket struct {
+	Mode  fileMode `json:"mode" yaml:"mode" toml:"mode"`
+	Owner string   `json:"owner" yaml:"owner" toml:"owner"`
+}
+
+type fileTLS struct {
+	Cert       string `json:"cert" yaml:"cert" toml:"cert"`
+	Key        string `json:"key" yaml:"key" toml:"key"`
+	ClientCA   string `json:"client_ca" yaml:"client_ca" toml:"client_ca"`
+	ClientUser string `json:"client_user" yaml:"client_user" toml:"client_user"`
+}
+
+type fileAuth struct {
+	Users    map[string]string     `json:"users" yaml:"users" toml:"users"`
+	File     string                `json:"file" yaml:"file" toml:"file"`
+	Lockout  fileLockout           `json:"lockout" yaml:"lockout" toml:"lockout"`
+	Roles    map[string]string     `json:"roles" yaml:"roles" toml:"roles"`
+	Policies map[string]filePolicy `json:"policies" yaml:"policies" toml:"policies"`
+}
+
+// filePolicy is a bridge.Policy. Omitted lists allow everything; omitted
+// flags are false.
+type filePolicy struct {
+	Commands       []string `json:"commands" yaml:"commands" toml:"commands"`
+	Styles         []string `json:"styles" yaml:"styles" toml:"styles"`
+	PersistentKeys bool     `json:"persistent_keys" yaml:"persistent_keys" toml:"persistent_keys"`
+	Forward        bool     `json:"forward" yaml:"forward" toml:"forward"`
+	Admin          bool     `json:"admin" yaml:"admin" toml:"admin"`
+}
+
+type fileLockout struct {
+	MaxFailures int      `json:"max_failures" yaml:"max_failures" toml:"max_failures"`
+	Duration    duration `json:"duration" yaml:"duration" toml:"duration"`
+	MaxDuration duration `json:"max_duration" yaml:"max_duration" toml:"max_duration"`
+}
+
+type fileTimeouts struct {
+	Handshake    duration `json:"handshake" yaml:"handshake" toml:"handshake"`
+	Command      duration `json:"command" yaml:"command" toml:"command"`
+	Idle         duration `json:"idle" yaml:"idle" toml:"idle"`
+	Pong         duration `json:"pong" yaml:"pong" toml:"pong"`
+	Keepalive    duration `json:"keepalive" yaml:"keepalive" toml:"keepalive"`
+	StreamLinger duration `json:"stream_linger" yaml:"stream_linger" toml:"stream_linger"`
+}
+
+type fileLimits struct {
+	ReadBufferSize          int `json:"read_buffer_size" yaml:"read_buffer_size" toml:"read_buffer_size"`
+	MaxLineLength           int `json:"max_line_length" yaml:"max_line_length" toml:"max_line_length"`
+	MaxConnections          int `json:"max_connections" yaml:"max_connections" toml:"max_connections"`
+	MaxConnectionsPerClient int `json:"max_connections_per_client" yaml:"max_connections_per_client" toml:"max_connections_per_client"`
+	MaxSessionsPerClient    int `json:"max_sessions_per_client" yaml:"max_sessions_per_client" toml:"max_sessions_per_client"`
+}
+
+type fileAccess struct {
+	Allow []string `json:"allow" yaml:"allow" toml:"allow"`
+	Deny  []string `json:"deny" yaml:"deny" toml:"deny"`
+}
+
+type fileStream struct {
+	AccessListDir    string `json:"access_list_dir" yaml:"access_list_dir" toml:"access_list_dir"`
+	ForwardSocketDir string `json:"forward_socket_dir" yaml:"forward_socket_dir" toml:"forward_socket_dir"`
+}
+
+type fileProxyProtocol struct {
+	TrustedProxies []string `json:"trusted_proxies" yaml:"trusted_proxies" toml:"trusted_proxies"`
+}
+
+// fileRateLimit is a bridge.RateLimitConfig. Costs are merged into the
+// default costs rather than replacing them.
+type fileRateLimit struct {
+	ConnectionRate  float64        `json:"connection_rate" yaml:"connection_rate" toml:"connection_rate"`
+	ConnectionBurst int            `json:"connection_burst" yaml:"connection_burst" toml:"connection_burst"`
+	ClientRate      float64        `json:"client_rate" yaml:"client_rate" toml:"client_rate"`
+	ClientBurst     int            `json:"client_burst" yaml:"client_burst" toml:"client_burst"`
+	Costs           map[string]int `json:"costs" yaml:"costs" toml:"costs"`
+}
+
+// duration is a time.Duration written as a string such as "30s".
+type duration time.Duration
+
+func (d *duration) UnmarshalText(text []byte) error {
+	v, err := time.ParseDuration(string(text))
+	if err != nil {
+		return err
+	}
+	*d = duration(v)
+	return nil
+}
+
+func (d duration) MarshalText() ([]byte, error) {
+	return []byte(time.Duration(d).String()), nil
+}
+
+// fileMode is an os.FileMode written as an octal string such as "0660".
+type fileMode os.FileMode
+
+func (m *fileMode) UnmarshalText(text []byte) error {
+	v, err := parseFileMode(string(text))
+	if err != nil {
+		return err
+	}
+	*m = fileMode(v)
+	return nil
+}
+
+func (m fileMode) MarshalText() ([]byte, error) {
+	return []byte(fmt.Sprintf("%04o", uint32(m))), nil
+}
+
+// loadConfigFile reads the configuration file at path into cfg. Settings
+// missing from the file keep their current values in cfg; unknown settings
+// are an error.
+func loadConfigFile(path string, cfg *Config) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("config file: %w", err)
+	}
+
+	fc := newFileConfig(cfg)
+	switch ext := strings.ToLower(filepath.Ext(path)); ext {
+	case ".toml":
+		err = unknownTOMLFields(toml.NewDecoder(bytes.NewReader(data)).DisallowUnknownFields().Decode(fc))
+	case ".yaml", ".yml":
+		dec := yaml.NewDecoder(bytes.NewReader(data))
+		dec.KnownFields(true)
+		if err = dec.Decode(fc); errors.Is(err, io.EOF) {
+			err = nil // An empty YAML document sets nothing.
+		}
+	case ".json":
+		dec := json.NewDecoder(bytes.NewReader(data))
+		dec.DisallowUnknownFields()
+		err = dec.Decode(fc)
+	default:
+		return fmt.Errorf("config file %s: unsupported format %q (use .toml, .yaml, .yml or .json)", path, ext)
+	}
+	if err != nil {
+		return fmt.Errorf("config file %s: %w", path, err)
+	}
+
+	fc.apply(cfg)
+	return nil
+}
+
+// unknownTOMLFields replaces go-toml's strict mode error, which does not
+// name the offending keys, with one that does.
+func unknownTOMLFields(err error) error {
+	var strict *toml.StrictMissingError
+	if !errors.As(err, &strict) {
+		return err
+	}
+	keys := make([]string, 0, len(strict.Errors))
+	for _, e := range strict.Errors {
+		row, _ := e.Position()
+		keys = append(keys, fmt.Sprintf("%s (line %d)", strings.Join(e.Key(), "."), row))
+	}
+	return fmt.Errorf("unknown fields: %s", strings.Join(keys, ", "))
+}
+
+// newFileConfig returns cfg in file form, so that decoding a file over it
+// leaves unset fields unchanged.
+func newFileConfig(cfg *Config) *fileConfig {
+	fc := &fileConfig{
+		Listen:                cfg.ListenAddr,
+		UnixSocket:            fileUnixSocket{Mode: fileMode(cfg.SocketMode), Owner: cfg.SocketOwner},
+		I2CP:                  cfg.I2CPAddr,
+		UDP:                   cfg.UDPAddr,
+		Debug:                 cfg.Debug,
+		I2CPUser:              cfg.Username,
+		I2CPPass:              cfg.Password,
+		Metrics:               cfg.MetricsAddr,
+		Admin:                 cfg.AdminAddr,
+		AuditLog:              cfg.AuditLog,
+		TranscriptDir:         cfg.TranscriptDir,
+		ShutdownTimeout:       duration(cfg.ShutdownTimeout),
+		EmbeddedRouterTimeout: duration(cfg.EmbeddedRouterTimeout),
+		TLS: fileTLS{
+			Cert:       cfg.TLSCert,
+			Key:        cfg.TLSKey,
+			ClientCA:   cfg.TLSClientCA,
+			ClientUser: cfg.TLSClientUser,
+		},
+		Auth: fileAuth{
+			Users: make(map[string]string, len(cfg.AuthUsers)),
+			File:  cfg.AuthFile,
+			Lockout: fileLockout{
+				MaxFailures: cfg.AuthLockout.MaxFailures,
+				Duration:    duration(cfg.AuthLockout.Duration),
+				MaxDuration: duration(cfg.AuthLockout.MaxDuration),
+			},
+		},
+		Timeouts: fileTimeouts{
+			Handshake:    duration(cfg.Timeouts.Handshake),
+			Command:      duration(cfg.Timeouts.Command),
+			Idle:         duration(cfg.Timeouts.Idle),
+			Pong:         duration(cfg.Timeouts.PongTimeout),
+			Keepalive:    duration(cfg.Timeouts.KeepaliveInterval),
+			StreamLinger: duration(cfg.Timeouts.StreamLinger),
+		},
+		Limits: fileLimits{
+			ReadBufferSize:          cfg.Limits.ReadBufferSize,
+			MaxLineLength:           cfg.Limits.MaxLineLength,
+			MaxConnections:          cfg.Limits.MaxConnections,
+			MaxConnectionsPerClient: cfg.Limits.MaxConnectionsPerClient,
+			MaxSessionsPerClient:    cfg.Limits.MaxSessionsPerClient,
+		},
+		Access: fileAccess{
+			Allow: cfg.AllowNetworks,
+			Deny:  cfg.DenyNetworks,
+		},
+		Stream: fileStream{
+			AccessListDir:    cfg.StreamListDir,
+			ForwardSocketDir: cfg.ForwardSocketDir,
+		},
+		ProxyProtocol: fileProxyProtocol{
+			TrustedProxies: cfg.TrustedProxies,
+		},
+		RateLimit: fileRateLimit{
+			ConnectionRate:  cfg.RateLimit.PerConnection.Rate,
+			ConnectionBurst: cfg.RateLimit.PerConnection.Burst,
+			ClientRate:      cfg.RateLimit.PerClient.Rate,
+			ClientBurst:     cfg.RateLimit.PerClient.Burst,
+		},
+	}
+	for user, pass := range cfg.AuthUsers {
+		fc.Auth.Users[user] = pass
+	}
+	if cfg.AuthRoles != nil {
+		fc.Auth.Roles = make(map[string]string, len(cfg.AuthRoles))
+		for user, role := range cfg.AuthRoles {
+			fc.Auth.Roles[user] = role
+		}
+	}
+	if cfg.AuthPolicies != nil {
+		fc.Auth.Policies = make(map[string]filePolicy, len(cfg.AuthPolicies))
+		for role, p := range cfg.AuthPolicies {
+			fp := filePolicy{Commands: p.Commands, PersistentKeys: p.PersistentKeys, Forward: p.Forward, Admin: p.Admin}
+			for _, style := range p.Styles {
+				fp.Styles = append(fp.Styles, string(style))
+			}
+			fc.Auth.Policies[role] = fp
+		}
+	}
+	return fc
+}
+
+// apply copies the file settings into cfg.
+func (fc *fileConfig) apply(cfg *Config) {
+	cfg.ListenAddr = fc.Listen
+	cfg.SocketMode = os.FileMode(fc.UnixSocket.Mode)
+	cfg.SocketOwner = fc.UnixSocket.Owner
+	cfg.I2CPAddr = fc.I2CP
+	cfg.UDPAddr = fc.UDP
+	cfg.Debug = fc.Debug
+	cfg.Username = fc.I2CPUser
+	cfg.Password = fc.I2CPPass
+	cfg.MetricsAddr = fc.Metrics
+	cfg.AdminAddr = fc.Admin
+	cfg.AuditLog = fc.AuditLog
+	cfg.TranscriptDir = fc.TranscriptDir
+	cfg.ShutdownTimeout = time.Duration(fc.ShutdownTimeout)
+	cfg.EmbeddedRouterTimeout = time.Duration(fc.EmbeddedRouterTimeout)
+	cfg.TLSCert = fc.TLS.Cert
+	cfg.TLSKey = fc.TLS.Key
+	cfg.TLSClientCA = fc.TLS.ClientCA
+	cfg.TLSClientUser = fc.TLS.ClientUser
+	cfg.AuthUsers = fc.Auth.Users
+	cfg.AuthFile = fc.Auth.File
+	cfg.AuthLockout.MaxFailures = fc.Auth.Lockout.MaxFailures
+	cfg.AuthLockout.Duration = time.Duration(fc.Auth.Lockout.Duration)
+	cfg.AuthLockout.MaxDuration = time.Duration(fc.Auth.Lockout.MaxDuration)
+	cfg.AuthRoles = fc.Auth.Roles
+	cfg.AuthPolicies = nil
+	if fc.Auth.Policies != nil {
+		cfg.AuthPolicies = make(map[string]bridge.Policy, len(fc.Auth.Policies))
+		for role, fp := range fc.Auth.Policies {
+			p := bridge.Policy{Commands: fp.Commands, PersistentKeys: fp.PersistentKeys, Forward: fp.Forward, Admin: fp.Admin}
+			for _, style := range fp.Styles {
+				p.Styles = append(p.Styles, session.Style(style))
+			}
+			cfg.AuthPolicies[role] = p
+		}
+	}
+
+	cfg.Timeouts.Handshake = time.Duration(fc.Timeouts.Handshake)
+	cfg.Timeouts.Command = time.Duration(fc.Timeouts.Command)
+	cfg.Timeouts.Idle = time.Duration(fc.Timeouts.Idle)
+	cfg.Timeouts.PongTimeout = time.Duration(fc.Timeouts.Pong)
+	cfg.Timeouts.KeepaliveInterval = time.Duration(fc.Timeouts.Keepalive)
+	cfg.Timeouts.StreamLinger = time.Duration(fc.Timeouts.StreamLinger)
+
+	cfg.Limits.ReadBufferSize = fc.Limits.ReadBufferSize
+	cfg.Limits.MaxLineLength = fc.Limits.MaxLineLength
+	cfg.Limits.MaxConnections = fc.Limits.MaxConnections
+	cfg.Limits.MaxConnectionsPerClient = fc.Limits.MaxConnectionsPerClient
+	cfg.Limits.MaxSessionsPerClient = fc.Limits.MaxSessionsPerClient
+
+	cfg.AllowNetworks = fc.Access.Allow
+	cfg.DenyNetworks = fc.Access.Deny
+	cfg.TrustedProxies = fc.ProxyProtocol.TrustedProxies
+	cfg.StreamListDir = fc.Stream.AccessListDir
+	cfg.ForwardSocketDir = fc.Stream.ForwardSocketDir
+
+	cfg.RateLimit.PerConnection = bridge.TokenBucketConfig{Rate: fc.RateLimit.ConnectionRate, Burst: fc.RateLimit.ConnectionBurst}
+	cfg.RateLimit.PerClient = bridge.TokenBucketConfig{Rate: fc.RateLimit.ClientRate, Burst: fc.RateLimit.ClientBurst}
+	if len(fc.RateLimit.Costs) > 0 {
+		costs := make(map[string]int, len(cfg.RateLimit.Costs)+len(fc.RateLimit.Costs))
+		for command, cost := range cfg.RateLimit.Costs {
+			costs[command] = cost
+		}
+		for command, cost := range fc.RateLimit.Costs {
+			costs[strings.ToUpper(command)] = cost
+		}
+		cfg.RateLimit.Costs = costs
+	}
+}
@@ -0,0 +1,452 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-i2p/go-sam-bridge/lib/bridge"
+	"github.com/go-i2p/go-sam-bridge/lib/session"
+)
+
+// writeConfigFile writes content to a temporary file named name.
+func writeConfigFile(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+	return path
+}
+
+// parseArgs runs parseFlags with a fresh flag set and the given arguments.
+func parseArgs(t *testing.T, args ...string) (*Config, error) {
+	t.Helper()
+
+	oldCmdLine := flag.CommandLine
+	oldArgs := os.Args
+	t.Cleanup(func() {
+		flag.CommandLine = oldCmdLine
+		os.Args = oldArgs
+	})
+
+	flag.CommandLine = flag.NewFlagSet("sam-bridge", flag.ContinueOnError)
+	os.Args = append([]string{"sam-bridge"}, args...)
+	return parseFlags()
+}
+
+func TestLoadConfigFile_Formats(t *testing.T) {
+	tests := []struct {
+		name    string
+		file    string
+		content string
+	}{
+		{
+			name: "toml",
+			file: "sam.toml",
+			content: `
+listen = "127.0.0.1:17656"
+debug = true
+shutdown_timeout = "5s"
+audit_log = "/var/log/sam-bridge/audit.log"
+transcript_dir = "/var/lib/sam-bridge/transcripts"
+
+[unix_socket]
+mode = "0600"
+
+[auth]
+file = "/var/lib/sam-bridge/users"
+
+[auth.lockout]
+max_failures = 3
+
+[auth.users]
+alice = "secret"
+
+[auth.roles]
+alice = "web"
+
+[auth.policies.web]
+commands = ["SESSION", "STREAM CONNECT"]
+styles = ["STREAM"]
+
+[access]
+allow = ["127.0.0.1", "172.17.0.0/16"]
+deny = ["172.17.0.99"]
+
+[stream]
+access_list_dir = "/etc/sam-bridge/peers"
+forward_socket_dir = "/run/sam-bridge"
+
+[proxy_protocol]
+trusted_proxies = ["10.0.0.0/8"]
+
+[rate_limit]
+connection_rate = 5
+connection_burst = 20
+
+[rate_limit.costs]
+"NAMING LOOKUP" = 2
+
+[timeouts]
+handshake = "10s"
+keepalive = "1m"
+stream_linger = "5s"
+
+[limits]
+max_line_length = 4096
+max_connections = 100
+`,
+		},
+		{
+			name: "yaml",
+			file: "sam.yaml",
+			content: `
+listen: 127.0.0.1:17656
+debug: true
+shutdown_timeout: 5s
+audit_log: /var/log/sam-bridge/audit.log
+transcript_dir: /var/lib/sam-bridge/transcripts
+unix_socket:
+  mode: "0600"
+auth:
+  file: /var/lib/sam-bridge/users
+  lockout:
+    max_failures: 3
+  users:
+    alice: secret
+  roles:
+    alice: web
+  policies:
+    web:
+      commands: [SESSION, STREAM CONNECT]
+      styles: [STREAM]
+access:
+  allow: [127.0.0.1, 172.17.0.0/16]
+  deny: [172.17.0.99]
+stream:
+  access_list_dir: /etc/sam-bridge/peers
+  forward_socket_dir: /run/sam-bridge
+proxy_protocol:
+  trusted_proxies: [10.0.0.0/8]
+rate_limit:
+  connection_rate: 5
+  connection_burst: 20
+  costs:
+    NAMING LOOKUP: 2
+timeouts:
+  handshake: 10s
+  keepalive: 1m
+  stream_linger: 5s
+limits:
+  max_line_length: 4096
+  max_connections: 100
+`,
+		},
+		{
+			name: "json",
+			file: "sam.json",
+			content: `{
+  "listen": "127.0.0.1:17656",
+  "debug": true,
+  "shutdown_timeout": "5s",
+  "audit_log": "/var/log/sam-bridge/audit.log",
+  "transcript_dir": "/var/lib/sam-bridge/transcripts",
+  "unix_socket": {"mode": "0600"},
+  "auth": {"file": "/var/lib/sam-bridge/users", "lockout": {"max_failures": 3}, "users": {"alice": "secret"},
+    "roles": {"alice": "web"}, "policies": {"web": {"commands": ["SESSION", "STREAM CONNECT"], "styles": ["STREAM"]}}},
+  "access": {"allow": ["127.0.0.1", "172.17.0.0/16"], "deny": ["172.17.0.99"]},
+  "stream": {"access_list_dir": "/etc/sam-bridge/peers", "forward_socket_dir": "/run/sam-bridge"},
+  "proxy_protocol": {"trusted_proxies": ["10.0.0.0/8"]},
+  "rate_limit": {"connection_rate": 5, "connection_burst": 20, "costs": {"NAMING LOOKUP": 2}},
+  "timeouts": {"handshake": "10s", "keepalive": "1m", "stream_linger": "5s"},
+  "limits": {"max_line_length": 4096, "max_connections": 100}
+}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg, err := parseArgs(t)
+			if err != nil {
+				t.Fatalf("parseFlags() error = %v", err)
+			}
+			if err := loadConfigFile(writeConfigFile(t, tt.file, tt.content), cfg); err != nil {
+				t.Fatalf("loadConfigFile() error = %v", err)
+			}
+
+			if cfg.ListenAddr != "127.0.0.1:17656" {
+				t.Errorf("ListenAddr = %q, want %q", cfg.ListenAddr, "127.0.0.1:17656")
+			}
+			if !cfg.Debug {
+				t.Error("Debug = false, want true")
+			}
+			if cfg.ShutdownTimeout != 5*time.Second {
+				t.Errorf("ShutdownTimeout = %v, want 5s", cfg.ShutdownTimeout)
+			}
+			if cfg.AuditLog != "/var/log/sam-bridge/audit.log" {
+				t.Errorf("AuditLog = %q, want %q", cfg.AuditLog, "/var/log/sam-bridge/audit.log")
+			}
+			if cfg.TranscriptDir != "/var/lib/sam-bridge/transcripts" {
+				t.Errorf("TranscriptDir = %q, want %q", cfg.TranscriptDir, "/var/lib/sam-bridge/transcripts")
+			}
+			if cfg.SocketMode != 0o600 {
+				t.Errorf("SocketMode = %o, want 600", cfg.SocketMode)
+			}
+			if cfg.AuthUsers["alice"] != "secret" {
+				t.Errorf("AuthUsers = %v, want alice", cfg.AuthUsers)
+			}
+			if cfg.AuthFile != "/var/lib/sam-bridge/users" {
+				t.Errorf("AuthFile = %q, want %q", cfg.AuthFile, "/var/lib/sam-bridge/users")
+			}
+			if cfg.AuthLockout.MaxFailures != 3 {
+				t.Errorf("AuthLockout.MaxFailures = %d, want 3", cfg.AuthLockout.MaxFailures)
+			}
+			if cfg.AuthLockout.Duration != 30*time.Second {
+				t.Errorf("AuthLockout.Duration = %v, want default 30s", cfg.AuthLockout.Duration)
+			}
+			if cfg.AuthRoles["alice"] != "web" {
+				t.Errorf("AuthRoles = %v, want alice=web", cfg.AuthRoles)
+			}
+			if p := cfg.AuthPolicies["web"]; len(p.Commands) != 2 || len(p.Styles) != 1 || p.Styles[0] != session.StyleStream || p.Forward {
+				t.Errorf("AuthPolicies[web] = %+v, want SESSION and STREAM CONNECT for STREAM sessions", p)
+			}
+			if strings.Join(cfg.AllowNetworks, ",") != "127.0.0.1,172.17.0.0/16" || strings.Join(cfg.DenyNetworks, ",") != "172.17.0.99" {
+				t.Errorf("AllowNetworks, DenyNetworks = %v, %v, want the access lists", cfg.AllowNetworks, cfg.DenyNetworks)
+			}
+			if cfg.StreamListDir != "/etc/sam-bridge/peers" {
+				t.Errorf("StreamListDir = %q, want the directory", cfg.StreamListDir)
+			}
+			if cfg.ForwardSocketDir != "/run/sam-bridge" {
+				t.Errorf("ForwardSocketDir = %q, want the directory", cfg.ForwardSocketDir)
+			}
+			if strings.Join(cfg.TrustedProxies, ",") != "10.0.0.0/8" {
+				t.Errorf("TrustedProxies = %v, want 10.0.0.0/8", cfg.TrustedProxies)
+			}
+			if cfg.RateLimit.PerConnection != (bridge.TokenBucketConfig{Rate: 5, Burst: 20}) || cfg.RateLimit.PerClient.Rate != 0 {
+				t.Errorf("RateLimit = %+v, want 5/s bursts of 20 per connection", cfg.RateLimit)
+			}
+			if cfg.RateLimit.Costs["NAMING LOOKUP"] != 2 || cfg.RateLimit.Costs["SESSION CREATE"] != 10 {
+				t.Errorf("RateLimit.Costs = %v, want NAMING LOOKUP=2 and the default SESSION CREATE", cfg.RateLimit.Costs)
+			}
+			if cfg.Timeouts.Handshake != 10*time.Second {
+				t.Errorf("Timeouts.Handshake = %v, want 10s", cfg.Timeouts.Handshake)
+			}
+			if cfg.Timeouts.KeepaliveInterval != time.Minute {
+				t.Errorf("Timeouts.KeepaliveInterval = %v, want 1m", cfg.Timeouts.KeepaliveInterval)
+			}
+			if cfg.Timeouts.StreamLinger != 5*time.Second {
+				t.Errorf("Timeouts.StreamLinger = %v, want 5s", cfg.Timeouts.StreamLinger)
+			}
+			if cfg.Limits.MaxLineLength != 4096 {
+				t.Errorf("Limits.MaxLineLength = %d, want 4096", cfg.Limits.MaxLineLength)
+			}
+			if cfg.Limits.MaxConnections != 100 {
+				t.Errorf("Limits.MaxConnections = %d, want 100", cfg.Limits.MaxConnections)
+			}
+
+			// Settings absent from the file keep their defaults.
+			if cfg.I2CPAddr != "127.0.0.1:7654" {
+				t.Errorf("I2CPAddr = %q, want default", cfg.I2CPAddr)
+			}
+			if cfg.Timeouts.Command != 60*time.Second {
+				t.Errorf("Timeouts.Command = %v, want default 60s", cfg.Timeouts.Command)
+			}
+			if cfg.Limits.ReadBufferSize != 8192 {
+				t.Errorf("Limits.ReadBufferSize = %d, want default 8192", cfg.Limits.ReadBufferSize)
+			}
+		})
+	}
+}
+
+func TestLoadConfigFile_Errors(t *testing.T) {
+	tests := []struct {
+		name    string
+		file    string
+		content string
+		wantErr string
+	}{
+		{"unknown toml field", "sam.toml", "listen_addr = \":7656\"\n", "listen_addr"},
+		{"unknown yaml field", "sam.yaml", "timeouts:\n  hello: 5s\n", "hello"},
+		{"unknown json field", "sam.json", `{"lisen": ":7656"}`, "lisen"},
+		{"bad duration", "sam.toml", "[timeouts]\nidle = \"forever\"\n", "forever"},
+		{"bad mode", "sam.yaml", "unix_socket:\n  mode: \"0999\"\n", "0999"},
+		{"unsupported format", "sam.ini", "listen=:7656\n", "unsupported format"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &Config{}
+			err := loadConfigFile(writeConfigFile(t, tt.file, tt.content), cfg)
+			if err == nil {
+				t.Fatal("loadConfigFile() = nil, want error")
+			}
+			if !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("loadConfigFile() error = %v, want it to mention %q", err, tt.wantErr)
+			}
+		})
+	}
+
+	if err := loadConfigFile(filepath.Join(t.TempDir(), "missing.toml"), &Config{}); err == nil {
+		t.Error("loadConfigFile() on a missing file = nil, want error")
+	}
+}
+
+// TestParseFlags_Precedence verifies defaults < config file < flags < environment.
+func TestParseFlags_Precedence(t *testing.T) {
+	path := writeConfigFile(t, "sam.toml", `
+listen = "127.0.0.1:1111"
+i2cp = "10.0.0.1:7654"
+metrics = "127.0.0.1:7660"
+
+[timeouts]
+idle = "5m"
+`)
+
+	old := os.Getenv("I2CP_ADDR")
+	os.Setenv("I2CP_ADDR", "envrouter:7654")
+	defer os.Setenv("I2CP_ADDR", old)
+
+	cfg, err := parseArgs(t, "-listen", "127.0.0.1:2222", "-config", path, "-idle-timeout", "1m")
+	if err != nil {
+		t.Fatalf("parseFlags() error = %v", err)
+	}
+
+	if cfg.ListenAddr != "127.0.0.1:2222" {
+		t.Errorf("ListenAddr = %q, want the -listen flag", cfg.ListenAddr)
+	}
+	if cfg.Timeouts.Idle != time.Minute {
+		t.Errorf("Timeouts.Idle = %v, want the -idle-timeout flag", cfg.Timeouts.Idle)
+	}
+	if cfg.I2CPAddr != "envrouter:7654" {
+		t.Errorf("I2CPAddr = %q, want I2CP_ADDR", cfg.I2CPAddr)
+	}
+	if cfg.MetricsAddr != "127.0.0.1:7660" {
+		t.Errorf("MetricsAddr = %q, want the file value", cfg.MetricsAddr)
+	}
+	if cfg.UDPAddr != ":7655" {
+		t.Errorf("UDPAddr = %q, want the default", cfg.UDPAddr)
+	}
+}
+
+func TestParseFlags_CheckConfig(t *testing.T) {
+	cfg, err := parseArgs(t, "check-config", "-listen", ":9000")
+	if err != nil {
+		t.Fatalf("parseFlags() error = %v", err)
+	}
+	if !cfg.CheckConfig {
+		t.Error("CheckConfig = false, want true")
+	}
+	if cfg.ListenAddr != ":9000" {
+		t.Errorf("ListenAddr = %q, want %q", cfg.ListenAddr, ":9000")
+	}
+
+	path := writeConfigFile(t, "sam.json", `{"unknown": true}`)
+	if _, err := parseArgs(t, "check-config", "-config", path); err == nil {
+		t.Error("parseFlags() with a bad config file = nil, want error")
+	}
+}
+
+func TestCheckConfig(t *testing.T) {
+	cfg, err := parseArgs(t)
+	if err != nil {
+		t.Fatalf("parseFlags() error = %v", err)
+	}
+
+	var stdout, stderr bytes.Buffer
+	if code := checkConfig(cfg, &stdout, &stderr); code != 0 {
+		t.Fatalf("checkConfig() = %d, want 0; stderr: %s", code, stderr.String())
+	}
+	if !strings.Contains(stdout.String(), "OK") {
+		t.Errorf("stdout = %q, want OK", stdout.String())
+	}
+
+	// Every problem is reported, not just the first.
+	cfg.ListenAddr = ""
+	cfg.Timeouts.Handshake = -time.Second
+	cfg.Limits.MaxLineLength = 0
+	cfg.TLSCert = "cert.pem"
+
+	stdout.Reset()
+	stderr.Reset()
+	if code := checkConfig(cfg, &stdout, &stderr); code != 1 {
+		t.Fatalf("checkConfig() = %d, want 1", code)
+	}
+	for _, want := range []string{"listen address", "Timeouts.Handshake", "Limits.MaxLineLength", "tls"} {
+		if !strings.Contains(stderr.String(), want) {
+			t.Errorf("stderr = %q, want it to mention %q", stderr.String(), want)
+		}
+	}
+}
+
+func TestParseFlags_Access(t *testing.T) {
+	cfg, err := parseArgs(t, "-allow", "127.0.0.1, 172.17.0.0/16", "-deny", "172.17.0.99", "-trusted-proxies", "10.0.0.0/8")
+	if err != nil {
+		t.Fatalf("parseFlags() error = %v", err)
+	}
+	if strings.Join(cfg.AllowNetworks, ",") != "127.0.0.1,172.17.0.0/16" {
+		t.Errorf("AllowNetworks = %v, want the -allow list", cfg.AllowNetworks)
+	}
+	if strings.Join(cfg.DenyNetworks, ",") != "172.17.0.99" {
+		t.Errorf("DenyNetworks = %v, want the -deny list", cfg.DenyNetworks)
+	}
+	if strings.Join(cfg.TrustedProxies, ",") != "10.0.0.0/8" {
+		t.Errorf("TrustedProxies = %v, want the -trusted-proxies list", cfg.TrustedProxies)
+	}
+
+	var stdout, stderr bytes.Buffer
+	if code := checkConfig(cfg, &stdout, &stderr); code != 0 {
+		t.Fatalf("checkConfig() = %d, want 0; stderr: %s", code, stderr.String())
+	}
+
+	cfg.DenyNetworks = []string{"172.17.0.0/33"}
+	if code := checkConfig(cfg, &stdout, &stderr); code != 1 || !strings.Contains(stderr.String(), "access: deny") {
+		t.Errorf("checkConfig() = %d, stderr %q, want the invalid deny prefix reported", code, stderr.String())
+	}
+}
+
+func TestReloadConfig(t *testing.T) {
+	path := writeConfigFile(t, "sam.yaml", "limits:\n  max_connections: 10\n")
+
+	cfg, err := parseArgs(t, "-config", path, "-idle-timeout", "1m")
+	if err != nil {
+		t.Fatalf("parseFlags() error = %v", err)
+	}
+	if cfg.Limits.MaxConnections != 10 {
+		t.Fatalf("Limits.MaxConnections = %d, want 10", cfg.Limits.MaxConnections)
+	}
+
+	content := "limits:\n  max_connections: 20\ntimeouts:\n  idle: 5m\nauth:\n  users:\n    alice: secret\n"
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+
+	next, err := reloadConfig(cfg)
+	if err != nil {
+		t.Fatalf("reloadConfig() error = %v", err)
+	}
+	if next.Limits.MaxConnections != 20 {
+		t.Errorf("Limits.MaxConnections = %d, want 20 from the changed file", next.Limits.MaxConnections)
+	}
+	if next.Timeouts.Idle != time.Minute {
+		t.Errorf("Timeouts.Idle = %v, want the -idle-timeout flag to still win", next.Timeouts.Idle)
+	}
+	if next.AuthUsers["alice"] != "secret" {
+		t.Errorf("AuthUsers = %v, want alice", next.AuthUsers)
+	}
+	if cfg.Limits.MaxConnections != 10 {
+		t.Error("reloadConfig() modified the current configuration")
+	}
+
+	// A broken file is reported and leaves the caller to keep cfg.
+	if err := os.WriteFile(path, []byte("limits: [\n"), 0o600); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+	if _, err := reloadConfig(cfg); err == nil {
+		t.Error("reloadConfig() with a broken file = nil, want error")
+	}
+}
@@ -5,15 +5,37 @@
 // Usage:
 //
 //	sam-bridge [flags]
+//	sam-bridge check-config [flags]
+//	sam-bridge replay [-ignore keys] [-timeout dur] transcript.jsonl...
+//
+// check-config loads the configuration as the bridge would at startup,
+// reports every problem found and exits non-zero if there are any.
+//
+// replay plays the client side of transcripts recorded with
+// -transcript-dir against a bridge with a stand-in I2CP router, and reports
+// replies that differ from the recorded ones.
 //
 // Flags:
 //
-//	-listen string     SAM listen address (default ":7656")
+//	-config string     Load settings from a TOML, YAML or JSON file (optional)
+//	-listen string     SAM listen address, TCP or unix:/path (default ":7656")
+//	-socket-mode mode  File mode of a unix: listen socket (default 0660)
+//	-socket-owner str  Owner of a unix: listen socket, as user[:group] (optional)
 //	-i2cp string       I2CP router address (default "127.0.0.1:7654")
 //	-udp string        UDP datagram port (default ":7655")
 //	-debug             Enable debug logging
 //	-user string       I2CP username (optional)
 //	-pass string       I2CP password (optional)
+//	-keepalive dur     Send PING to SAM 3.2+ clients at this interval (0 = off)
+//	-idle-timeout dur  Close control connections idle this long (0 = off)
+//	-stream-linger dur  Keep forwarding a stream's reply after the other side stops sending (default 30s)
+//	-metrics string    Serve Prometheus metrics on this address (optional)
+//	-admin string      Serve the admin API on a loopback or unix: address (optional)
+//	-audit-log path    Append a JSON line per SAM command to this file (optional)
+//	-transcript-dir path  Record each connection's control lines in this directory (optional)
+//	-stream-list-dir path  Directory of peer lists for sam.accessListFile (optional)
+//	-forward-socket-dir path  Directory of Unix sockets for STREAM FORWARD PATH (optional)
+//	-shutdown-timeout dur  Time to let active streams finish on shutdown (default 30s)
 //	-version           Show version information
 //	-help              Show help message
 //
@@ -23,26 +45,39 @@
 //	I2CP_ADDR     I2CP router address (overrides -i2cp)
 //	SAM_DEBUG     Enable debug logging (overrides -debug)
 //
+// Settings are applied in this order, each overriding the one before:
+// built-in defaults, the -config file, command-line flags, then
+// environment variables.
+//
+// On SIGHUP the configuration is re-read and auth users, access lists,
+// timeouts, limits, rate limits and debug logging are applied without
+// dropping sessions, transcripts can be turned on or off, and the audit log
+// file is reopened so that it can be rotated. Other settings require a
+// restart.
+//
 // See SAMv3.md for the complete SAM protocol specification.
 package main
 
 import (
 	"context"
+	"crypto/tls"
+	"crypto/x509"
 	"flag"
 	"fmt"
+	"io"
 	"net"
 	"os"
 	"os/signal"
 	"strconv"
+	"strings"
 	"syscall"
 	"time"
 
+	"github.com/go-i2p/go-sam-bridge/lib/bridge"
 	"github.com/go-i2p/go-sam-bridge/lib/embedding"
 	"github.com/go-i2p/go-sam-bridge/lib/handler"
 	"github.com/go-i2p/go-sam-bridge/lib/i2cp"
 	"github.com/go-i2p/go-sam-bridge/lib/session"
-	samstreaming "github.com/go-i2p/go-sam-bridge/lib/streaming"
-	"github.com/go-i2p/go-streaming"
 	"github.com/go-i2p/logger"
 )
 
@@ -56,7 +91,19 @@ var (
 )
 
 func main() {
-	cfg := parseFlags()
+	if len(os.Args) > 1 && os.Args[1] == "replay" {
+		os.Exit(runReplay(os.Args[2:], os.Stdout, os.Stderr))
+	}
+
+	cfg, err := parseFlags()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "sam-bridge: %v\n", err)
+		os.Exit(1)
+	}
+
+	if cfg.CheckConfig {
+		os.Exit(checkConfig(cfg, os.Stdout, os.Stderr))
+	}
 
 	// Configure logging
 	log := logger.GetGoI2PLogger()
@@ -74,8 +121,11 @@ func main() {
 		"commit":    GitCommit,
 	}).Info("Starting SAM bridge server")
 
-	// Parse datagram port
-	datagramPort := parseDatagramPort(cfg.UDPAddr)
+	opts, err := baseOptions(cfg)
+	if err != nil {
+		log.WithFields(logger.Fields{"pkg": "main", "func": "main"}).WithError(err).Error("Invalid configuration")
+		os.Exit(1)
+	}
 
 	// Attempt I2CP connection; failure is non-fatal — the embedded router will be
 	// started automatically by embedding.New() when port 7654 is free.
@@ -90,17 +140,16 @@ func main() {
 		log.WithFields(logger.Fields{"pkg": "main", "func": "main", "version": i2cpClient.RouterVersion()}).Info("Connected to I2P router")
 	}
 
-	// Build bridge options — I2CP provider is optional.
-	opts := []embedding.Option{
-		embedding.WithListenAddr(cfg.ListenAddr),
-		embedding.WithI2CPAddr(cfg.I2CPAddr),
-		embedding.WithDatagramPort(datagramPort),
+	// Add runtime options — I2CP provider is optional.
+	opts = append(opts,
 		embedding.WithLogger(log),
-		embedding.WithDebug(cfg.Debug),
 		embedding.WithHandlerRegistrar(createHandlerRegistrar(i2cpClient)),
-	}
+	)
 	if i2cpClient != nil {
-		opts = append(opts, embedding.WithI2CPProvider(newI2CPProviderAdapter(i2cpClient)))
+		opts = append(opts,
+			embedding.WithI2CPProvider(newI2CPProviderAdapter(i2cpClient)),
+			embedding.WithI2CPClient(i2cpClient),
+		)
 	}
 
 	// Create bridge with embedding API
@@ -119,39 +168,104 @@ func main() {
 		os.Exit(1)
 	}
 
-	// Wait for shutdown signal
+	// Wait for shutdown signal, reloading the configuration on SIGHUP
 	sigChan := make(chan os.Signal, 1)
-	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
-	<-sigChan
+	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM, syscall.SIGHUP)
+	for sig := range sigChan {
+		if sig != syscall.SIGHUP {
+			break
+		}
+		cfg = reload(bridge, cfg, log)
+	}
+
+	log.WithFields(logger.Fields{"pkg": "main", "func": "main", "timeout": cfg.ShutdownTimeout}).Info("Received shutdown signal, draining active streams")
 
-	log.WithFields(logger.Fields{"pkg": "main", "func": "main"}).Info("Received shutdown signal")
-	bridge.Stop(context.Background())
+	// A second signal skips the rest of the drain.
+	stopCtx, stopCancel := context.WithTimeout(context.Background(), cfg.ShutdownTimeout)
+	defer stopCancel()
+	go func() {
+		for sig := range sigChan {
+			if sig != syscall.SIGHUP {
+				break
+			}
+		}
+		log.WithFields(logger.Fields{"pkg": "main", "func": "main"}).Info("Received second signal, closing active streams")
+		stopCancel()
+	}()
+
+	if err := bridge.Stop(stopCtx); err != nil {
+		log.WithFields(logger.Fields{"pkg": "main", "func": "main"}).WithError(err).Warn("Active streams were closed before they finished")
+	}
 }
 
 // Config holds command-line configuration.
 type Config struct {
+	ConfigFile  string
+	CheckConfig bool
+
 	ListenAddr string
 	I2CPAddr   string
 	UDPAddr    string
 	Debug      bool
 	Username   string
 	Password   string
+
+	SocketMode  os.FileMode
+	SocketOwner string
+
+	TLSCert       string
+	TLSKey        string
+	TLSClientCA   string
+	TLSClientUser string
+	AuthUsers     map[string]string
+	AuthFile      string
+	AuthLockout   bridge.LockoutConfig
+	AuthRoles     map[string]string
+	AuthPolicies  map[string]bridge.Policy
+
+	AllowNetworks    []string
+	DenyNetworks     []string
+	StreamListDir    string
+	ForwardSocketDir string
+	TrustedProxies   []string
+
+	Timeouts  bridge.TimeoutConfig
+	Limits    bridge.LimitConfig
+	RateLimit bridge.RateLimitConfig
+
+	MetricsAddr string
+	AdminAddr   string
+	AuditLog    string
+
+	TranscriptDir string
+
+	ShutdownTimeout       time.Duration
+	EmbeddedRouterTimeout time.Duration
+
+	// args are the command-line flags, re-parsed by reloadConfig.
+	args []string
 }
 
-func parseFlags() *Config {
-	cfg := &Config{}
+// parseFlags builds the configuration from the built-in defaults, the
+// -config file, the command-line flags and the environment, in that order
+// of precedence. A leading "check-config" argument sets CheckConfig.
+func parseFlags() (*Config, error) {
+	cfg := newConfig()
 
-	flag.StringVar(&cfg.ListenAddr, "listen", ":7656", "SAM listen address")
-	flag.StringVar(&cfg.I2CPAddr, "i2cp", "127.0.0.1:7654", "I2CP router address")
-	flag.StringVar(&cfg.UDPAddr, "udp", ":7655", "UDP datagram port")
-	flag.BoolVar(&cfg.Debug, "debug", false, "Enable debug logging")
-	flag.StringVar(&cfg.Username, "user", "", "I2CP username (optional)")
-	flag.StringVar(&cfg.Password, "pass", "", "I2CP password (optional)")
+	args := os.Args[1:]
+	if len(args) > 0 && args[0] == "check-config" {
+		cfg.CheckConfig = true
+		args = args[1:]
+	}
+	cfg.args = args
 
+	defineFlags(flag.CommandLine, cfg)
 	showVersion := flag.Bool("version", false, "Show version information")
 	showHelp := flag.Bool("help", false, "Show help message")
 
-	flag.Parse()
+	if err := flag.CommandLine.Parse(args); err != nil {
+		return nil, err
+	}
 
 	if *showVersion {
 		fmt.Printf("sam-bridge %s\n", Version)
@@ -164,6 +278,8 @@ func parseFlags() *Config {
 		fmt.Println("SAM Bridge - SAMv3.3 Protocol Bridge for I2P")
 		fmt.Println()
 		fmt.Println("Usage: sam-bridge [flags]")
+		fmt.Println("       sam-bridge check-config [flags]")
+		fmt.Println("       sam-bridge replay [-ignore keys] [-timeout dur] transcript.jsonl...")
 		fmt.Println()
 		fmt.Println("Flags:")
 		flag.PrintDefaults()
@@ -172,9 +288,105 @@ func parseFlags() *Config {
 		fmt.Println("  SAM_LISTEN    SAM listen address (overrides -listen)")
 		fmt.Println("  I2CP_ADDR     I2CP router address (overrides -i2cp)")
 		fmt.Println("  SAM_DEBUG     Enable debug logging (overrides -debug)")
+		fmt.Println()
+		fmt.Println("Precedence: defaults < -config file < flags < environment variables")
+		fmt.Println()
+		fmt.Println("Send SIGHUP to reload auth users, timeouts and limits without a restart.")
 		os.Exit(0)
 	}
 
+	if err := applyConfigFile(cfg, flag.CommandLine); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// reloadConfig builds a fresh configuration from the same command line as
+// current, re-reading the -config file and the environment.
+func reloadConfig(current *Config) (*Config, error) {
+	cfg := newConfig()
+	cfg.args = current.args
+
+	fs := flag.NewFlagSet("sam-bridge", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	defineFlags(fs, cfg)
+	fs.Bool("version", false, "")
+	fs.Bool("help", false, "")
+	if err := fs.Parse(cfg.args); err != nil {
+		return nil, err
+	}
+
+	if err := applyConfigFile(cfg, fs); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// newConfig returns the built-in defaults.
+func newConfig() *Config {
+	defaults := bridge.DefaultConfig()
+	return &Config{
+		AuthLockout:           defaults.Auth.Lockout,
+		Timeouts:              defaults.Timeouts,
+		Limits:                defaults.Limits,
+		RateLimit:             defaults.RateLimit,
+		EmbeddedRouterTimeout: embedding.DefaultEmbeddedRouterTimeout,
+	}
+}
+
+// defineFlags registers the command-line flags on fs, bound to cfg.
+func defineFlags(fs *flag.FlagSet, cfg *Config) {
+	fs.StringVar(&cfg.ConfigFile, "config", "", "Load settings from a TOML, YAML or JSON file (optional)")
+	fs.StringVar(&cfg.ListenAddr, "listen", ":7656", "SAM listen address (TCP or unix:/path)")
+	fs.Func("socket-mode", "File mode of a unix: listen socket, in octal (default 0660)", func(s string) error {
+		mode, err := parseFileMode(s)
+		cfg.SocketMode = mode
+		return err
+	})
+	fs.StringVar(&cfg.SocketOwner, "socket-owner", "", "Owner of a unix: listen socket, as user[:group] (optional)")
+	fs.StringVar(&cfg.I2CPAddr, "i2cp", "127.0.0.1:7654", "I2CP router address")
+	fs.StringVar(&cfg.UDPAddr, "udp", ":7655", "UDP datagram port")
+	fs.BoolVar(&cfg.Debug, "debug", false, "Enable debug logging")
+	fs.StringVar(&cfg.Username, "user", "", "I2CP username (optional)")
+	fs.StringVar(&cfg.Password, "pass", "", "I2CP password (optional)")
+	fs.StringVar(&cfg.AuthFile, "auth-file", "", "Keep SAM AUTH users in this htpasswd file (optional)")
+	fs.Func("allow", "Only accept SAM clients from these comma-separated CIDR networks (optional)", func(s string) error {
+		cfg.AllowNetworks = splitList(s)
+		return nil
+	})
+	fs.Func("deny", "Refuse SAM clients from these comma-separated CIDR networks (optional)", func(s string) error {
+		cfg.DenyNetworks = splitList(s)
+		return nil
+	})
+	fs.StringVar(&cfg.StreamListDir, "stream-list-dir", "", "Directory of peer lists STREAM sessions may name with sam.accessListFile (optional)")
+	fs.StringVar(&cfg.ForwardSocketDir, "forward-socket-dir", "", "Directory of Unix sockets STREAM FORWARD may forward to with PATH (optional)")
+	fs.Func("trusted-proxies", "Accept PROXY protocol headers from these comma-separated CIDR networks (optional)", func(s string) error {
+		cfg.TrustedProxies = splitList(s)
+		return nil
+	})
+	fs.DurationVar(&cfg.Timeouts.KeepaliveInterval, "keepalive", cfg.Timeouts.KeepaliveInterval, "Send PING to SAM 3.2+ clients at this interval (0 = off)")
+	fs.DurationVar(&cfg.Timeouts.Idle, "idle-timeout", cfg.Timeouts.Idle, "Close control connections idle this long (0 = off)")
+	fs.DurationVar(&cfg.Timeouts.StreamLinger, "stream-linger", cfg.Timeouts.StreamLinger, "Keep forwarding a stream's reply for up to this long after the other side stops sending (0 = close at once)")
+	fs.StringVar(&cfg.MetricsAddr, "metrics", "", "Serve Prometheus metrics on this address (optional)")
+	fs.StringVar(&cfg.AdminAddr, "admin", "", "Serve the admin API on a loopback or unix: address (optional)")
+	fs.StringVar(&cfg.AuditLog, "audit-log", "", "Append a JSON line per SAM command to this file (optional)")
+	fs.StringVar(&cfg.TranscriptDir, "transcript-dir", "", "Record each connection's control lines in this directory (optional)")
+	fs.DurationVar(&cfg.ShutdownTimeout, "shutdown-timeout", embedding.DefaultShutdownTimeout, "Time to let active streams finish on shutdown")
+}
+
+// applyConfigFile loads the -config file, if any, under the flags already
+// parsed into fs, then applies the environment variables.
+func applyConfigFile(cfg *Config, fs *flag.FlagSet) error {
+	if cfg.ConfigFile != "" {
+		if err := loadConfigFile(cfg.ConfigFile, cfg); err != nil {
+			return err
+		}
+		// Parse again so that flags given on the command line override the file.
+		if err := fs.Parse(cfg.args); err != nil {
+			return err
+		}
+	}
+
 	// Override with environment variables
 	if env := os.Getenv("SAM_LISTEN"); env != "" {
 		cfg.ListenAddr = env
@@ -185,17 +397,159 @@ func parseFlags() *Config {
 	if os.Getenv("SAM_DEBUG") != "" {
 		cfg.Debug = true
 	}
+	return nil
+}
 
-	return cfg
+// baseOptions returns the bridge options that follow from cfg alone. On an
+// access list, trusted proxy or TLS error the options built so far are
+// returned with the error.
+func baseOptions(cfg *Config) ([]embedding.Option, error) {
+	opts := []embedding.Option{
+		embedding.WithListenAddr(cfg.ListenAddr),
+		embedding.WithUnixSocketMode(cfg.SocketMode),
+		embedding.WithUnixSocketOwner(cfg.SocketOwner),
+		embedding.WithI2CPAddr(cfg.I2CPAddr),
+		embedding.WithDatagramPort(parseDatagramPort(cfg.UDPAddr)),
+		embedding.WithDebug(cfg.Debug),
+		embedding.WithAuthLockout(cfg.AuthLockout),
+		embedding.WithTimeouts(cfg.Timeouts),
+		embedding.WithLimits(cfg.Limits),
+		embedding.WithRateLimit(cfg.RateLimit),
+		embedding.WithEmbeddedRouterTimeout(cfg.EmbeddedRouterTimeout),
+		embedding.WithMetricsAddr(cfg.MetricsAddr),
+		embedding.WithAdminAddr(cfg.AdminAddr),
+		embedding.WithTranscriptDir(cfg.TranscriptDir),
+	}
+	if len(cfg.AuthUsers) > 0 {
+		opts = append(opts, embedding.WithAuth(cfg.AuthUsers))
+	}
+	if cfg.AuthFile != "" {
+		opts = append(opts, embedding.WithAuthFile(cfg.AuthFile))
+	}
+	if len(cfg.AuthRoles) > 0 {
+		opts = append(opts, embedding.WithUserRoles(cfg.AuthRoles))
+	}
+	for role, policy := range cfg.AuthPolicies {
+		opts = append(opts, embedding.WithRolePolicy(role, policy))
+	}
+	if cfg.AuditLog != "" {
+		opts = append(opts, embedding.WithAuditFile(cfg.AuditLog))
+	}
+	if cfg.TLSClientUser != "" {
+		opts = append(opts, embedding.WithClientCertAuth(bridge.CertUserField(cfg.TLSClientUser)))
+	}
+	allow, err := bridge.ParsePrefixes(cfg.AllowNetworks)
+	if err != nil {
+		return opts, fmt.Errorf("access: allow: %w", err)
+	}
+	deny, err := bridge.ParsePrefixes(cfg.DenyNetworks)
+	if err != nil {
+		return opts, fmt.Errorf("access: deny: %w", err)
+	}
+	opts = append(opts, embedding.WithAllowedNetworks(allow...), embedding.WithDeniedNetworks(deny...),
+		embedding.WithAccessListDir(cfg.StreamListDir), embedding.WithForwardSocketDir(cfg.ForwardSocketDir))
+	proxies, err := bridge.ParsePrefixes(cfg.TrustedProxies)
+	if err != nil {
+		return opts, fmt.Errorf("proxy_protocol: trusted_proxies: %w", err)
+	}
+	opts = append(opts, embedding.WithTrustedProxies(proxies...))
+	if cfg.TLSCert != "" || cfg.TLSKey != "" || cfg.TLSClientCA != "" {
+		tlsConfig, err := loadTLSConfig(cfg.TLSCert, cfg.TLSKey, cfg.TLSClientCA)
+		if err != nil {
+			return opts, err
+		}
+		opts = append(opts, embedding.WithTLS(tlsConfig))
+	}
+	return opts, nil
 }
 
-func connectI2CP(cfg *Config, log *logger.Logger) (*i2cp.Client, error) {
-	i2cpConfig := &i2cp.ClientConfig{
-		RouterAddr: cfg.I2CPAddr,
-		Username:   cfg.Username,
-		Password:   cfg.Password,
+// loadTLSConfig loads the control socket's certificate and key. If
+// clientCAFile is set, client certificates signed by one of the CAs in it
+// are verified; clients without a certificate can still connect.
+func loadTLSConfig(certFile, keyFile, clientCAFile string) (*tls.Config, error) {
+	if certFile == "" || keyFile == "" {
+		return nil, fmt.Errorf("tls: both cert and key must be set")
+	}
+	cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+	if err != nil {
+		return nil, fmt.Errorf("tls: %w", err)
+	}
+	tlsConfig := &tls.Config{Certificates: []tls.Certificate{cert}, MinVersion: tls.VersionTLS12}
+
+	if clientCAFile != "" {
+		pem, err := os.ReadFile(clientCAFile)
+		if err != nil {
+			return nil, fmt.Errorf("tls: client_ca: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("tls: client_ca: no certificates in %s", clientCAFile)
+		}
+		tlsConfig.ClientCAs = pool
+		tlsConfig.ClientAuth = tls.VerifyClientCertIfGiven
+	}
+	return tlsConfig, nil
+}
+
+// checkConfig validates cfg as the bridge would at startup and writes every
+// problem found to stderr. It returns the process exit code.
+func checkConfig(cfg *Config, stdout, stderr io.Writer) int {
+	var errs []error
+	opts, err := baseOptions(cfg)
+	if err != nil {
+		errs = append(errs, err)
+	}
+
+	bridgeCfg := embedding.DefaultConfig()
+	for _, opt := range opts {
+		opt(bridgeCfg)
+	}
+	errs = append(errs, bridgeCfg.ValidateAll()...)
+
+	if len(errs) > 0 {
+		for _, err := range errs {
+			fmt.Fprintf(stderr, "error: %v\n", err)
+		}
+		return 1
+	}
+	fmt.Fprintln(stdout, "configuration OK")
+	return 0
+}
+
+// reload re-reads the configuration on SIGHUP and applies it to the running
+// bridge, returning the configuration now in effect. Auth users, timeouts,
+// limits and the debug setting change without dropping sessions; see
+// embedding.Bridge.Reload. On error the current configuration is kept.
+func reload(b *embedding.Bridge, cfg *Config, log *logger.Logger) *Config {
+	log.WithFields(logger.Fields{"pkg": "main", "func": "reload", "config": cfg.ConfigFile}).Info("Received SIGHUP, reloading configuration")
+
+	next, err := reloadConfig(cfg)
+	if err == nil {
+		var opts []embedding.Option
+		if opts, err = baseOptions(next); err == nil {
+			err = b.Reload(opts...)
+		}
+	}
+	if err != nil {
+		log.WithFields(logger.Fields{"pkg": "main", "func": "reload"}).WithError(err).Error("Reload failed, keeping the current configuration")
+		return cfg
 	}
 
+	if next.Debug {
+		log.SetLevel(logger.DebugLevel)
+	} else {
+		log.SetLevel(logger.InfoLevel)
+	}
+	return next
+}
+
+func connectI2CP(cfg *Config, log *logger.Logger) (*i2cp.Client, error) {
+	// Start from the defaults so the client reconnects after router restarts.
+	i2cpConfig := i2cp.DefaultClientConfig()
+	i2cpConfig.RouterAddr = cfg.I2CPAddr
+	i2cpConfig.Username = cfg.Username
+	i2cpConfig.Password = cfg.Password
+
 	client := i2cp.NewClient(i2cpConfig)
 	ctx := context.Background()
 
@@ -221,6 +575,29 @@ func parseDatagramPort(addr string) int {
 	return embedding.DefaultDatagramPort
 }
 
+// splitList splits a comma-separated flag value, dropping empty entries.
+func splitList(s string) []string {
+	var list []string
+	for _, v := range strings.Split(s, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			list = append(list, v)
+		}
+	}
+	return list
+}
+
+// parseFileMode parses an octal permission mode such as "0660".
+func parseFileMode(s string) (os.FileMode, error) {
+	mode, err := strconv.ParseUint(s, 8, 32)
+	if err != nil {
+		return 0, fmt.Errorf("invalid file mode %q: must be octal", s)
+	}
+	if mode > 0o777 {
+		return 0, fmt.Errorf("invalid file mode %q: must be at most 0777", s)
+	}
+	return os.FileMode(mode), nil
+}
+
 // createHandlerRegistrar returns a custom handler registrar with optional I2CP integration.
 // When i2cpClient is nil (no external I2P router), only default handlers are registered
 // and the embedded router fallback handles connectivity.
@@ -233,69 +610,14 @@ func createHandlerRegistrar(i2cpClient *i2cp.Client) embedding.HandlerRegistrarF
 		// Use default handler registrar for base handlers
 		embedding.DefaultHandlerRegistrar()(router, deps)
 
-		// Without an I2CP client, default handlers are sufficient.
-		// The embedded router will wire transport once it is ready.
+		// The default handlers wire STREAM/DATAGRAM/RAW transport, including
+		// re-wiring after an I2CP reconnect, through deps.I2CPClient.
+		// Without an I2CP client the embedded router wires transport once ready.
 		if i2cpClient == nil {
 			log.WithFields(logger.Fields{"pkg": pkg, "func": fn}).Info("No I2CP client: using default handlers (embedded router mode)")
 			return
 		}
 
-		streamConnector := handler.NewStreamingConnector()
-		streamAcceptor := handler.NewStreamingAcceptor()
-		streamForwarder := handler.NewStreamingForwarder()
-
-		sessionHandler := handler.NewSessionHandler(deps.DestManager)
-		sessionHandler.SetI2CPProvider(deps.I2CPProvider)
-
-		// Set session created callback for StreamManager wiring
-		sessionHandler.SetSessionCreatedCallback(func(sess session.Session, i2cpHandle session.I2CPSessionHandle) {
-			if sess.Style() != session.StyleStream || i2cpHandle == nil {
-				return
-			}
-
-			i2cpSess, ok := i2cpHandle.(*i2cp.I2CPSession)
-			if !ok {
-				log.WithFields(logger.Fields{"pkg": pkg, "func": fn, "sessionID": sess.ID()}).Warn("Cannot create StreamManager: invalid I2CP session type")
-				return
-			}
-
-			underlyingSession := i2cpSess.Session()
-			underlyingClient := i2cpClient.I2CPClient()
-			if underlyingSession == nil || underlyingClient == nil {
-				log.WithFields(logger.Fields{"pkg": pkg, "func": fn, "sessionID": sess.ID()}).Warn("Cannot create StreamManager: no underlying I2CP session/client")
-				return
-			}
-
-			streamManager, err := streaming.NewStreamManagerFromSession(underlyingClient, underlyingSession)
-			if err != nil {
-				log.WithFields(logger.Fields{"pkg": pkg, "func": fn, "sessionID": sess.ID()}).WithError(err).Warn("Failed to create StreamManager from session")
-				return
-			}
-
-			adapter, err := samstreaming.NewAdapter(streamManager)
-			if err != nil {
-				log.WithFields(logger.Fields{"pkg": pkg, "func": fn, "sessionID": sess.ID()}).WithError(err).Warn("Failed to create StreamManager adapter")
-				return
-			}
-
-			streamConnector.RegisterManager(sess.ID(), adapter)
-			streamAcceptor.RegisterManager(sess.ID(), adapter)
-			streamForwarder.RegisterManager(sess.ID(), adapter)
-
-			log.WithFields(logger.Fields{"pkg": pkg, "func": fn, "sessionID": sess.ID()}).Debug("Registered StreamManager for STREAM session")
-		})
-
-		// Re-register SESSION handlers with extended callback
-		router.Register("SESSION CREATE", sessionHandler)
-		router.Register("SESSION ADD", sessionHandler)
-		router.Register("SESSION REMOVE", sessionHandler)
-
-		// Re-register STREAM handlers with new connectors
-		streamHandler := handler.NewStreamHandler(streamConnector, streamAcceptor, streamForwarder)
-		router.Register("STREAM CONNECT", streamHandler)
-		router.Register("STREAM ACCEPT", streamHandler)
-		router.Register("STREAM FORWARD", streamHandler)
-
 		// Wire destination resolver for NAMING handler
 		destResolver, err := i2cp.NewClientDestinationResolverAdapter(i2cpClient, 30*time.Second)
 		if err == nil {
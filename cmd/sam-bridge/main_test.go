@@ -1,9 +1,19 @@
 package main
 
 import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
 	"flag"
+	"math/big"
 	"os"
+	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/go-i2p/go-sam-bridge/lib/destination"
 	"github.com/go-i2p/go-sam-bridge/lib/embedding"
@@ -40,6 +50,33 @@ func TestParseDatagramPort(t *testing.T) {
 	}
 }
 
+func TestParseFileMode(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    os.FileMode
+		wantErr bool
+	}{
+		{"0660", 0o660, false},
+		{"600", 0o600, false},
+		{"0777", 0o777, false},
+		{"1777", 0, true},
+		{"0999", 0, true},
+		{"rw", 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := parseFileMode(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseFileMode(%q) error = %v, wantErr %v", tt.input, err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("parseFileMode(%q) = %o, want %o", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
 // TestParseFlags_Defaults verifies that parseFlags returns expected defaults.
 func TestParseFlags_Defaults(t *testing.T) {
 	oldCmdLine := flag.CommandLine
@@ -52,7 +89,10 @@ func TestParseFlags_Defaults(t *testing.T) {
 	flag.CommandLine = flag.NewFlagSet("sam-bridge", flag.ContinueOnError)
 	os.Args = []string{"sam-bridge"}
 
-	cfg := parseFlags()
+	cfg, err := parseFlags()
+	if err != nil {
+		t.Fatalf("parseFlags() error = %v", err)
+	}
 
 	if cfg.ListenAddr != ":7656" {
 		t.Errorf("ListenAddr = %q, want %q", cfg.ListenAddr, ":7656")
@@ -92,7 +132,10 @@ func TestParseFlags_EnvVarOverrides(t *testing.T) {
 	flag.CommandLine = flag.NewFlagSet("sam-bridge", flag.ContinueOnError)
 	os.Args = []string{"sam-bridge"}
 
-	cfg := parseFlags()
+	cfg, err := parseFlags()
+	if err != nil {
+		t.Fatalf("parseFlags() error = %v", err)
+	}
 
 	if cfg.ListenAddr != ":9876" {
 		t.Errorf("ListenAddr = %q, want %q (SAM_LISTEN override)", cfg.ListenAddr, ":9876")
@@ -134,3 +177,59 @@ func TestCreateHandlerRegistrar_RouterKeys(t *testing.T) {
 		}
 	}
 }
+
+// writeTestCert writes a self-signed certificate and its key as PEM files.
+func writeTestCert(t *testing.T) (certFile, keyFile string) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("GenerateKey() error = %v", err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "sam-bridge"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("CreateCertificate() error = %v", err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatalf("MarshalECPrivateKey() error = %v", err)
+	}
+
+	dir := t.TempDir()
+	certFile = filepath.Join(dir, "cert.pem")
+	keyFile = filepath.Join(dir, "key.pem")
+	os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o600)
+	os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0o600)
+	return certFile, keyFile
+}
+
+func TestLoadTLSConfig_ClientCA(t *testing.T) {
+	certFile, keyFile := writeTestCert(t)
+
+	cfg, err := loadTLSConfig(certFile, keyFile, "")
+	if err != nil {
+		t.Fatalf("loadTLSConfig() error = %v", err)
+	}
+	if cfg.ClientAuth != tls.NoClientCert || cfg.ClientCAs != nil {
+		t.Errorf("ClientAuth = %v, want no client certificates without client_ca", cfg.ClientAuth)
+	}
+
+	cfg, err = loadTLSConfig(certFile, keyFile, certFile)
+	if err != nil {
+		t.Fatalf("loadTLSConfig() error = %v", err)
+	}
+	if cfg.ClientAuth != tls.VerifyClientCertIfGiven || cfg.ClientCAs == nil {
+		t.Errorf("ClientAuth = %v, want VerifyClientCertIfGiven with client_ca", cfg.ClientAuth)
+	}
+
+	if _, err := loadTLSConfig(certFile, keyFile, keyFile); err == nil {
+		t.Error("loadTLSConfig() with a client_ca holding no certificates = nil, want error")
+	}
+}
@@ -0,0 +1,221 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/go-i2p/go-sam-bridge/lib/bridge"
+	"github.com/go-i2p/go-sam-bridge/lib/destination"
+	"github.com/go-i2p/go-sam-bridge/lib/embedding"
+	"github.com/go-i2p/go-sam-bridge/lib/protocol"
+	"github.com/go-i2p/go-sam-bridge/lib/session"
+	"github.com/go-i2p/logger"
+)
+
+// defaultReplayIgnore lists the reply options whose values change from run
+// to run, such as the public keys of freshly generated destinations.
+const defaultReplayIgnore = "PUB,VALUE"
+
+// replayOptions control how transcripts are replayed.
+type replayOptions struct {
+	// ignore lists reply options whose values are not compared.
+	ignore []string
+
+	// timeout is how long to wait for each reply.
+	timeout time.Duration
+}
+
+// runReplay implements "sam-bridge replay". It returns the process exit
+// code: 0 if every transcript replayed as recorded, 1 if any differed or
+// could not be replayed, and 2 on a usage error.
+func runReplay(args []string, stdout, stderr io.Writer) int {
+	fs := flag.NewFlagSet("replay", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	ignore := fs.String("ignore", defaultReplayIgnore, "Comma-separated reply options whose values are not compared")
+	timeout := fs.Duration("timeout", 5*time.Second, "Time to wait for each reply")
+	fs.Usage = func() {
+		fmt.Fprintln(stderr, "Usage: sam-bridge replay [flags] transcript.jsonl...")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if fs.NArg() == 0 {
+		fs.Usage()
+		return 2
+	}
+
+	opts := replayOptions{ignore: splitList(*ignore), timeout: *timeout}
+	code := 0
+	for _, path := range fs.Args() {
+		diffs, err := replayFile(path, opts)
+		switch {
+		case err != nil:
+			fmt.Fprintf(stderr, "%s: %v\n", path, err)
+			code = 1
+		case len(diffs) > 0:
+			for _, diff := range diffs {
+				fmt.Fprintf(stdout, "%s: %s\n", path, diff)
+			}
+			code = 1
+		default:
+			fmt.Fprintf(stdout, "%s: OK\n", path)
+		}
+	}
+	return code
+}
+
+// replayFile replays the transcript at path.
+func replayFile(path string, opts replayOptions) ([]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	entries, err := bridge.ReadTranscript(f)
+	if err != nil {
+		return nil, err
+	}
+	return replayTranscript(entries, opts)
+}
+
+// replayTranscript sends the client lines of entries to a new bridge and
+// compares its replies with the recorded ones. It returns a description of
+// each difference; replay stops at the first missing reply.
+func replayTranscript(entries []bridge.TranscriptEntry, opts replayOptions) ([]string, error) {
+	server, addr, err := startReplayServer()
+	if err != nil {
+		return nil, err
+	}
+	defer server.Close()
+
+	conn, err := net.Dial("tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+	reader := bufio.NewReader(conn)
+
+	var diffs []string
+	for i, entry := range entries {
+		switch entry.Dir {
+		case bridge.TranscriptIn:
+			conn.SetWriteDeadline(time.Now().Add(opts.timeout))
+			if _, err := conn.Write([]byte(replayLine(entry.Line) + "\n")); err != nil {
+				return append(diffs, fmt.Sprintf("entry %d: sending %q: %v", i+1, entry.Line, err)), nil
+			}
+		case bridge.TranscriptOut:
+			conn.SetReadDeadline(time.Now().Add(opts.timeout))
+			got, err := reader.ReadString('\n')
+			if err != nil {
+				return append(diffs, fmt.Sprintf("entry %d: want %q, got %v", i+1, entry.Line, err)), nil
+			}
+			got = strings.TrimRight(got, "\r\n")
+			if !repliesMatch(entry.Line, got, opts.ignore) {
+				diffs = append(diffs, fmt.Sprintf("entry %d:\n  want %s\n  got  %s", i+1, entry.Line, got))
+			}
+		}
+	}
+	return diffs, nil
+}
+
+// replayLine returns a recorded client line ready to send. A redacted
+// DESTINATION becomes TRANSIENT, so that SESSION CREATE gets a key; other
+// redacted values, such as passwords, are sent as they are.
+func replayLine(line string) string {
+	return strings.ReplaceAll(line, "DESTINATION="+bridge.Redacted, "DESTINATION=TRANSIENT")
+}
+
+// repliesMatch reports whether the reply got matches the recorded reply
+// want. Options redacted in want, or listed in ignore, match any value but
+// must be present. Lines that do not parse as SAM commands must be equal.
+func repliesMatch(want, got string, ignore []string) bool {
+	wantCmd, err := protocol.ParseLine(want)
+	if err != nil {
+		return want == got
+	}
+	gotCmd, err := protocol.ParseLine(got)
+	if err != nil {
+		return false
+	}
+	if wantCmd.Verb != gotCmd.Verb || wantCmd.Action != gotCmd.Action || len(wantCmd.Options) != len(gotCmd.Options) {
+		return false
+	}
+	for key, wantValue := range wantCmd.Options {
+		gotValue, ok := gotCmd.Options[key]
+		switch {
+		case !ok:
+			return false
+		case wantValue == bridge.Redacted || replayIgnored(key, ignore):
+		case wantValue != gotValue:
+			return false
+		}
+	}
+	return true
+}
+
+// replayIgnored reports whether ignore lists key.
+func replayIgnored(key string, ignore []string) bool {
+	for _, k := range ignore {
+		if strings.EqualFold(k, key) {
+			return true
+		}
+	}
+	return false
+}
+
+// startReplayServer starts a bridge with the default handlers on a
+// loopback port, backed by replayProvider rather than an I2P router.
+func startReplayServer() (*bridge.Server, string, error) {
+	deps := &embedding.Dependencies{
+		Registry:     session.NewRegistry(),
+		I2CPProvider: replayProvider{},
+		DestManager:  destination.NewManager(),
+		Logger:       logger.GetGoI2PLogger(),
+	}
+
+	cfg := bridge.DefaultConfig()
+	cfg.ListenAddr = "127.0.0.1:0"
+	cfg.DatagramPort = 0
+	server, err := bridge.NewServer(cfg, deps.Registry)
+	if err != nil {
+		return nil, "", err
+	}
+	embedding.DefaultHandlerRegistrar()(server.Router(), deps)
+
+	listener, err := net.Listen("tcp", cfg.ListenAddr)
+	if err != nil {
+		return nil, "", err
+	}
+	go server.Serve(listener)
+	return server, listener.Addr().String(), nil
+}
+
+// replayProvider is a stand-in I2CP provider for replay. Its sessions are
+// ready at once but carry no traffic, so replies that depend on the I2P
+// network, such as STREAM CONNECT, differ from a live bridge's.
+type replayProvider struct{}
+
+func (replayProvider) CreateSessionForSAM(ctx context.Context, samSessionID string, config *session.SessionConfig) (session.I2CPSessionHandle, error) {
+	return replayHandle{}, nil
+}
+
+func (replayProvider) IsConnected() bool { return true }
+
+var _ session.I2CPSessionProvider = replayProvider{}
+
+// replayHandle is the I2CP session of replayProvider.
+type replayHandle struct{}
+
+func (replayHandle) WaitForTunnels(ctx context.Context) error { return nil }
+func (replayHandle) IsTunnelReady() bool                      { return true }
+func (replayHandle) Close() error                             { return nil }
+func (replayHandle) DestinationBase64() string                { return "" }
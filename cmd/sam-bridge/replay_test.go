@@ -0,0 +1,81 @@
+package main
+
+import (
+	"bytes"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRepliesMatch(t *testing.T) {
+	ignore := splitList(defaultReplayIgnore)
+	tests := []struct {
+		name      string
+		want, got string
+		match     bool
+	}{
+		{"equal", "HELLO REPLY RESULT=OK VERSION=3.3", "HELLO REPLY RESULT=OK VERSION=3.3", true},
+		{"option order", "HELLO REPLY RESULT=OK VERSION=3.3", "HELLO REPLY VERSION=3.3 RESULT=OK", true},
+		{"different result", "HELLO REPLY RESULT=OK VERSION=3.3", "HELLO REPLY RESULT=NOVERSION", false},
+		{"redacted", "SESSION STATUS RESULT=OK DESTINATION=[REDACTED]", "SESSION STATUS RESULT=OK DESTINATION=AAAAkeys", true},
+		{"redacted but missing", "SESSION STATUS RESULT=OK DESTINATION=[REDACTED]", "SESSION STATUS RESULT=OK", false},
+		{"ignored", "DEST REPLY PUB=old PRIV=[REDACTED]", "DEST REPLY PUB=new PRIV=AAAApriv", true},
+		{"extra option", "SESSION STATUS RESULT=OK", `SESSION STATUS RESULT=OK MESSAGE="extra"`, false},
+		{"different verb", "PONG interop", "PING interop", false},
+		{"text", "PONG interop", "PONG interop", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := repliesMatch(tt.want, tt.got, ignore); got != tt.match {
+				t.Errorf("repliesMatch(%q, %q) = %v, want %v", tt.want, tt.got, got, tt.match)
+			}
+		})
+	}
+}
+
+func TestReplayLine(t *testing.T) {
+	got := replayLine("SESSION CREATE STYLE=STREAM ID=a DESTINATION=[REDACTED]")
+	if want := "SESSION CREATE STYLE=STREAM ID=a DESTINATION=TRANSIENT"; got != want {
+		t.Errorf("replayLine() = %q, want %q", got, want)
+	}
+}
+
+// TestReplay_Transcripts replays the transcripts in testdata/transcripts.
+// Add the transcript of a client bug report there to keep it fixed.
+func TestReplay_Transcripts(t *testing.T) {
+	paths, err := filepath.Glob(filepath.Join("testdata", "transcripts", "*.jsonl"))
+	if err != nil || len(paths) == 0 {
+		t.Fatalf("no transcripts in testdata/transcripts: %v", err)
+	}
+	opts := replayOptions{ignore: splitList(defaultReplayIgnore), timeout: 5 * time.Second}
+	for _, path := range paths {
+		t.Run(filepath.Base(path), func(t *testing.T) {
+			diffs, err := replayFile(path, opts)
+			if err != nil {
+				t.Fatalf("replayFile() error = %v", err)
+			}
+			for _, diff := range diffs {
+				t.Error(diff)
+			}
+		})
+	}
+}
+
+func TestRunReplay(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	if code := runReplay(nil, &stdout, &stderr); code != 2 {
+		t.Errorf("runReplay() without transcripts = %d, want 2", code)
+	}
+
+	stdout.Reset()
+	stderr.Reset()
+	path := writeConfigFile(t, "bad.jsonl", `{"time":"2026-10-16T09:12:03Z","dir":"in","line":"HELLO VERSION"}`+"\n"+
+		`{"time":"2026-10-16T09:12:03Z","dir":"out","line":"HELLO REPLY RESULT=NOVERSION"}`+"\n")
+	if code := runReplay([]string{path}, &stdout, &stderr); code != 1 {
+		t.Errorf("runReplay() with a differing reply = %d, want 1", code)
+	}
+	if !strings.Contains(stdout.String(), "want HELLO REPLY RESULT=NOVERSION") {
+		t.Errorf("stdout = %q, want the difference", stdout.String())
+	}
+}
@@ -0,0 +1,279 @@
+// Package admin implements a local HTTP/JSON management API for the SAM
+// bridge. It lists control connections and sessions and can forcibly close
+// either, so operators can tell which application owns which tunnel.
+//
+// The API has no authentication of its own. It must only be served on a
+// loopback address or a Unix socket; use Listen to enforce that. Requests
+// over TCP must also name localhost or a loopback IP as their Host, so that
+// a web page cannot reach the API through DNS rebinding.
+//
+// Endpoints:
+//
+//	GET    /connections        list control connections
+//	DELETE /connections/{id}   close a control connection and its session
+//	GET    /sessions           list sessions, including PRIMARY subsessions
+//	GET    /sessions/{id}      show one session
+//	DELETE /sessions/{id}      close a session or remove a subsession
+package admin
+
+import (
+	"encoding/json"
+	"net"
+	"net/http"
+	"sort"
+	"strconv"
+
+	"github.com/go-i2p/logger"
+
+	"github.com/go-i2p/go-sam-bridge/lib/bridge"
+	"github.com/go-i2p/go-sam-bridge/lib/session"
+)
+
+// ConnectionManager exposes the control connections of a SAM server.
+// It is implemented by *bridge.Server.
+type ConnectionManager interface {
+	// Connections returns a snapshot of all open control connections.
+	Connections() []bridge.ConnectionInfo
+
+	// CloseConnection closes the control connection with the given ID.
+	CloseConnection(id uint64) bool
+
+	// CloseSessionConnection closes the control connection bound to a session.
+	CloseSessionConnection(sessionID string) bool
+}
+
+// ConnectionInfo is the JSON representation of a control connection.
+type ConnectionInfo struct {
+	ID          uint64  `json:"id"`
+	RemoteAddr  string  `json:"remote_addr"`
+	State       string  `json:"state"`
+	Version     string  `json:"version,omitempty"`
+	Username    string  `json:"username,omitempty"`
+	SessionID   string  `json:"session_id,omitempty"`
+	AgeSeconds  float64 `json:"age_seconds"`
+	IdleSeconds float64 `json:"idle_seconds"`
+}
+
+// SessionInfo is the JSON representation of a session.
+type SessionInfo struct {
+	ID          string        `json:"id"`
+	Style       string        `json:"style"`
+	Status      string        `json:"status"`
+	Destination string        `json:"destination,omitempty"`
+	Owner       string        `json:"owner,omitempty"`
+	Forward     string        `json:"forward,omitempty"`
+	ControlAddr string        `json:"control_addr,omitempty"`
+	Subsessions []SessionInfo `json:"subsessions,omitempty"`
+
+	// Inbound holds the inbound stream limits of a STREAM session and the
+	// streams rejected by them or its access list.
+	Inbound *session.InboundStats `json:"inbound,omitempty"`
+}
+
+// errorResponse is the JSON body of error responses.
+type errorResponse struct {
+	Error string `json:"error"`
+}
+
+// Handler serves the management API.
+type Handler struct {
+	connections ConnectionManager
+	registry    session.Registry
+	mux         *http.ServeMux
+}
+
+// NewHandler creates a management API handler for the given server and
+// session registry.
+func NewHandler(connections ConnectionManager, registry session.Registry) *Handler {
+	h := &Handler{
+		connections: connections,
+		registry:    registry,
+		mux:         http.NewServeMux(),
+	}
+	h.mux.HandleFunc("GET /connections", h.listConnections)
+	h.mux.HandleFunc("DELETE /connections/{id}", h.closeConnection)
+	h.mux.HandleFunc("GET /sessions", h.listSessions)
+	h.mux.HandleFunc("GET /sessions/{id}", h.getSession)
+	h.mux.HandleFunc("DELETE /sessions/{id}", h.closeSession)
+	return h
+}
+
+// ServeHTTP implements http.Handler. Requests over TCP whose Host header
+// is not localhost or a loopback IP are refused with 403 Forbidden.
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if !overUnixSocket(r) && !isLoopbackHost(r.Host) {
+		log.WithFields(logger.Fields{"pkg": "admin", "func": "Handler.ServeHTTP", "host": r.Host, "remote": r.RemoteAddr}).Warn("Refused admin API request for a non-local host")
+		writeError(w, http.StatusForbidden, "host not allowed")
+		return
+	}
+	h.mux.ServeHTTP(w, r)
+}
+
+// overUnixSocket reports whether r arrived on a Unix socket, which browsers
+// cannot reach, so its Host header need not be checked.
+func overUnixSocket(r *http.Request) bool {
+	addr, ok := r.Context().Value(http.LocalAddrContextKey).(net.Addr)
+	return ok && addr.Network() == "unix"
+}
+
+func (h *Handler) listConnections(w http.ResponseWriter, r *http.Request) {
+	conns := h.connections.Connections()
+	infos := make([]ConnectionInfo, 0, len(conns))
+	for _, c := range conns {
+		infos = append(infos, ConnectionInfo{
+			ID:          c.ID,
+			RemoteAddr:  c.RemoteAddr,
+			State:       c.State.String(),
+			Version:     c.Version,
+			Username:    c.Username,
+			SessionID:   c.SessionID,
+			AgeSeconds:  c.Age.Seconds(),
+			IdleSeconds: c.Idle.Seconds(),
+		})
+	}
+	writeJSON(w, http.StatusOK, infos)
+}
+
+func (h *Handler) closeConnection(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseUint(r.PathValue("id"), 10, 64)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid connection id")
+		return
+	}
+	if !h.connections.CloseConnection(id) {
+		writeError(w, http.StatusNotFound, "connection not found")
+		return
+	}
+	log.WithFields(logger.Fields{"pkg": "admin", "func": "Handler.closeConnection", "id": id}).Info("Closed connection via admin API")
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *Handler) listSessions(w http.ResponseWriter, r *http.Request) {
+	ids := h.registry.All()
+	sort.Strings(ids)
+
+	infos := make([]SessionInfo, 0, len(ids))
+	for _, id := range ids {
+		if sess := h.registry.Get(id); sess != nil {
+			infos = append(infos, describeSession(sess))
+		}
+	}
+	writeJSON(w, http.StatusOK, infos)
+}
+
+func (h *Handler) getSession(w http.ResponseWriter, r *http.Request) {
+	sess := h.registry.Get(r.PathValue("id"))
+	if sess == nil {
+		writeError(w, http.StatusNotFound, "session not found")
+		return
+	}
+	writeJSON(w, http.StatusOK, describeSession(sess))
+}
+
+// closeSession closes a session. A session bound to a control connection is
+// closed by closing that connection, so the client sees the socket close as
+// SAMv3.md requires. A PRIMARY subsession is removed from its parent.
+func (h *Handler) closeSession(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	fields := logger.Fields{"pkg": "admin", "func": "Handler.closeSession", "session": id}
+
+	if h.connections.CloseSessionConnection(id) {
+		log.WithFields(fields).Info("Closed session control connection via admin API")
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	if sess := h.registry.Get(id); sess != nil {
+		_ = sess.Close()
+		_ = h.registry.Unregister(id)
+		log.WithFields(fields).Info("Closed session via admin API")
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	if primary := h.findPrimaryOf(id); primary != nil {
+		if err := primary.RemoveSubsession(id); err != nil {
+			writeError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		log.WithFields(fields).Info("Removed subsession via admin API")
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	writeError(w, http.StatusNotFound, "session not found")
+}
+
+// findPrimaryOf returns the PRIMARY session owning subsession id, or nil.
+func (h *Handler) findPrimaryOf(id string) session.PrimarySession {
+	for _, primaryID := range h.registry.All() {
+		primary, ok := h.registry.Get(primaryID).(session.PrimarySession)
+		if ok && primary.Subsession(id) != nil {
+			return primary
+		}
+	}
+	return nil
+}
+
+// describeSession builds the SessionInfo for sess and its subsessions.
+func describeSession(sess session.Session) SessionInfo {
+	info := SessionInfo{
+		ID:          sess.ID(),
+		Style:       string(sess.Style()),
+		Status:      sess.Status().String(),
+		Destination: sess.Destination().Base32(),
+		Owner:       session.OwnerOf(sess),
+		Forward:     forwardTarget(sess),
+	}
+	if conn := sess.ControlConn(); conn != nil && conn.RemoteAddr() != nil {
+		info.ControlAddr = conn.RemoteAddr().String()
+	}
+	if throttle := session.InboundThrottleOf(sess); throttle != nil {
+		stats := throttle.Stats()
+		info.Inbound = &stats
+	}
+
+	if primary, ok := sess.(session.PrimarySession); ok {
+		subIDs := primary.Subsessions()
+		sort.Strings(subIDs)
+		for _, subID := range subIDs {
+			if sub := primary.Subsession(subID); sub != nil {
+				info.Subsessions = append(info.Subsessions, describeSession(sub))
+			}
+		}
+	}
+	return info
+}
+
+// forwardTarget returns the host:port, or unix: and the socket path, that
+// sess forwards incoming traffic to, or empty string if it does not forward.
+func forwardTarget(sess session.Session) string {
+	if s, ok := sess.(interface{ ForwardPath() string }); ok {
+		if path := s.ForwardPath(); path != "" {
+			return "unix:" + path
+		}
+	}
+	switch s := sess.(type) {
+	case interface{ ForwardConfig() (string, int) }:
+		if host, port := s.ForwardConfig(); host != "" && port > 0 {
+			return net.JoinHostPort(host, strconv.Itoa(port))
+		}
+	case interface{ ForwardingAddr() net.Addr }:
+		if addr := s.ForwardingAddr(); addr != nil {
+			return addr.String()
+		}
+	}
+	return ""
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	_ = enc.Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, msg string) {
+	writeJSON(w, status, errorResponse{Error: msg})
+}
@@ -0,0 +1,237 @@
+package admin
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-i2p/go-sam-bridge/lib/bridge"
+	"github.com/go-i2p/go-sam-bridge/lib/session"
+)
+
+// mockConnections implements ConnectionManager for testing.
+type mockConnections struct {
+	conns        []bridge.ConnectionInfo
+	closedIDs    []uint64
+	closedByID   []string
+	boundSession string
+}
+
+func (m *mockConnections) Connections() []bridge.ConnectionInfo { return m.conns }
+
+func (m *mockConnections) CloseConnection(id uint64) bool {
+	for _, c := range m.conns {
+		if c.ID == id {
+			m.closedIDs = append(m.closedIDs, id)
+			return true
+		}
+	}
+	return false
+}
+
+func (m *mockConnections) CloseSessionConnection(sessionID string) bool {
+	if sessionID != m.boundSession {
+		return false
+	}
+	m.closedByID = append(m.closedByID, sessionID)
+	return true
+}
+
+func serve(t *testing.T, h http.Handler, method, path string) *httptest.ResponseRecorder {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest(method, path, nil)
+	req.Host = "127.0.0.1:7661"
+	h.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestHandler_ListConnections(t *testing.T) {
+	conns := &mockConnections{conns: []bridge.ConnectionInfo{{
+		ID:         7,
+		RemoteAddr: "127.0.0.1:50000",
+		State:      bridge.StateSessionBound,
+		Version:    "3.3",
+		Username:   "alice",
+		SessionID:  "app",
+		Age:        2 * time.Second,
+		Idle:       500 * time.Millisecond,
+	}}}
+	h := NewHandler(conns, session.NewRegistry())
+
+	rec := serve(t, h, http.MethodGet, "/connections")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200", rec.Code)
+	}
+
+	var got []ConnectionInfo
+	if err := json.Unmarshal(rec.Body.Bytes(), &got); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+	want := ConnectionInfo{
+		ID: 7, RemoteAddr: "127.0.0.1:50000", State: "SESSION_BOUND", Version: "3.3",
+		Username: "alice", SessionID: "app", AgeSeconds: 2, IdleSeconds: 0.5,
+	}
+	if len(got) != 1 || got[0] != want {
+		t.Errorf("connections = %+v, want [%+v]", got, want)
+	}
+}
+
+func TestHandler_CloseConnection(t *testing.T) {
+	conns := &mockConnections{conns: []bridge.ConnectionInfo{{ID: 3}}}
+	h := NewHandler(conns, session.NewRegistry())
+
+	if rec := serve(t, h, http.MethodDelete, "/connections/3"); rec.Code != http.StatusNoContent {
+		t.Errorf("DELETE existing status = %d, want 204", rec.Code)
+	}
+	if len(conns.closedIDs) != 1 || conns.closedIDs[0] != 3 {
+		t.Errorf("closed IDs = %v, want [3]", conns.closedIDs)
+	}
+	if rec := serve(t, h, http.MethodDelete, "/connections/4"); rec.Code != http.StatusNotFound {
+		t.Errorf("DELETE missing status = %d, want 404", rec.Code)
+	}
+	if rec := serve(t, h, http.MethodDelete, "/connections/abc"); rec.Code != http.StatusBadRequest {
+		t.Errorf("DELETE invalid status = %d, want 400", rec.Code)
+	}
+}
+
+func TestHandler_Sessions(t *testing.T) {
+	server, client := net.Pipe()
+	defer server.Close()
+	defer client.Close()
+
+	registry := session.NewRegistry()
+	stream := session.NewStreamSessionBasic("web", &session.Destination{PublicKey: []byte("AAAA")}, server, nil)
+	if err := stream.SetForwardConfig("127.0.0.1", 8080); err != nil {
+		t.Fatalf("SetForwardConfig() error = %v", err)
+	}
+	if err := registry.Register(stream); err != nil {
+		t.Fatalf("Register() error = %v", err)
+	}
+
+	h := NewHandler(&mockConnections{}, registry)
+
+	rec := serve(t, h, http.MethodGet, "/sessions")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200", rec.Code)
+	}
+	var got []SessionInfo
+	if err := json.Unmarshal(rec.Body.Bytes(), &got); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+	if len(got) != 1 {
+		t.Fatalf("sessions = %+v, want 1 session", got)
+	}
+	if got[0].ID != "web" || got[0].Style != "STREAM" || got[0].Forward != "127.0.0.1:8080" {
+		t.Errorf("session = %+v", got[0])
+	}
+	if got[0].Destination != "ocpibseeq6rechq64tp3t4rkqykjfuqmi5srkdampffl24hycr6a.b32.i2p" {
+		t.Errorf("destination = %q", got[0].Destination)
+	}
+	if got[0].Inbound == nil || got[0].Inbound.Active != 0 {
+		t.Errorf("inbound = %+v, want the stream session's inbound stats", got[0].Inbound)
+	}
+
+	if rec := serve(t, h, http.MethodGet, "/sessions/web"); rec.Code != http.StatusOK {
+		t.Errorf("GET /sessions/web status = %d, want 200", rec.Code)
+	}
+	if rec := serve(t, h, http.MethodGet, "/sessions/missing"); rec.Code != http.StatusNotFound {
+		t.Errorf("GET /sessions/missing status = %d, want 404", rec.Code)
+	}
+}
+
+func TestHandler_SessionForwardPath(t *testing.T) {
+	server, client := net.Pipe()
+	defer server.Close()
+	defer client.Close()
+
+	registry := session.NewRegistry()
+	stream := session.NewStreamSessionBasic("app", &session.Destination{PublicKey: []byte("AAAA")}, server, nil)
+	stream.SetForwardPath("/run/sam-bridge/app.sock")
+	if err := registry.Register(stream); err != nil {
+		t.Fatalf("Register() error = %v", err)
+	}
+
+	rec := serve(t, NewHandler(&mockConnections{}, registry), http.MethodGet, "/sessions/app")
+	var got SessionInfo
+	if err := json.Unmarshal(rec.Body.Bytes(), &got); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+	if got.Forward != "unix:/run/sam-bridge/app.sock" {
+		t.Errorf("forward = %q, want the Unix socket", got.Forward)
+	}
+}
+
+func TestHandler_CloseSession(t *testing.T) {
+	registry := session.NewRegistry()
+	orphan := session.NewBaseSession("orphan", session.StyleRaw, nil, nil, nil)
+	if err := registry.Register(orphan); err != nil {
+		t.Fatalf("Register() error = %v", err)
+	}
+
+	conns := &mockConnections{boundSession: "bound"}
+	h := NewHandler(conns, registry)
+
+	// A session with a control connection is closed through the connection.
+	if rec := serve(t, h, http.MethodDelete, "/sessions/bound"); rec.Code != http.StatusNoContent {
+		t.Errorf("DELETE bound status = %d, want 204", rec.Code)
+	}
+	if len(conns.closedByID) != 1 {
+		t.Errorf("CloseSessionConnection calls = %v, want [bound]", conns.closedByID)
+	}
+
+	// A session without a connection is closed and unregistered directly.
+	if rec := serve(t, h, http.MethodDelete, "/sessions/orphan"); rec.Code != http.StatusNoContent {
+		t.Errorf("DELETE orphan status = %d, want 204", rec.Code)
+	}
+	if registry.Get("orphan") != nil {
+		t.Error("orphan session still registered")
+	}
+	if !orphan.IsClosed() {
+		t.Error("orphan session not closed")
+	}
+
+	if rec := serve(t, h, http.MethodDelete, "/sessions/missing"); rec.Code != http.StatusNotFound {
+		t.Errorf("DELETE missing status = %d, want 404", rec.Code)
+	}
+}
+
+func TestHandler_RejectsForeignHost(t *testing.T) {
+	conns := &mockConnections{conns: []bridge.ConnectionInfo{{ID: 7}}}
+	h := NewHandler(conns, session.NewRegistry())
+
+	tests := []struct {
+		host string
+		unix bool
+		want int
+	}{
+		{"127.0.0.1:7661", false, http.StatusOK},
+		{"localhost:7661", false, http.StatusOK},
+		{"[::1]:7661", false, http.StatusOK},
+		{"localhost", false, http.StatusOK},
+		{"attacker.example:7661", false, http.StatusForbidden},
+		{"192.0.2.1", false, http.StatusForbidden},
+		{"", false, http.StatusForbidden},
+		{"attacker.example", true, http.StatusOK},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest(http.MethodDelete, "/connections/7", nil)
+		req.Host = tt.host
+		if tt.unix {
+			addr := &net.UnixAddr{Name: "/run/sam-bridge/admin.sock", Net: "unix"}
+			req = req.WithContext(context.WithValue(req.Context(), http.LocalAddrContextKey, addr))
+		}
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		if rec.Code != tt.want {
+			t.Errorf("DELETE with Host %q (unix %v): status = %d, want %d", tt.host, tt.unix, rec.Code, tt.want)
+		}
+	}
+	if len(conns.closedIDs) != 5 {
+		t.Errorf("closed %d connections, want 5 from the allowed requests", len(conns.closedIDs))
+	}
+}
@@ -0,0 +1,61 @@
+package admin
+
+import (
+	"errors"
+	"net"
+	"strings"
+
+	"github.com/go-i2p/go-sam-bridge/lib/bridge"
+)
+
+// UnixPrefix marks an admin address as a Unix socket path, e.g.
+// "unix:/run/sam-bridge/admin.sock".
+const UnixPrefix = bridge.UnixPrefix
+
+// ErrNotLocal is returned by Listen for TCP addresses that are not loopback.
+var ErrNotLocal = errors.New("admin: address must be a loopback host or a unix: socket path")
+
+// Listen opens the listener for the management API. addr is either a
+// "unix:" socket path or a TCP address whose host is localhost or a loopback
+// IP. Unix sockets are created with mode 0600; a stale socket file left by a
+// previous run is removed first.
+func Listen(addr string) (net.Listener, error) {
+	if path, ok := bridge.UnixSocketPath(addr); ok {
+		return bridge.ListenUnix(path, bridge.UnixSocketConfig{Mode: 0o600})
+	}
+
+	if !isLoopback(addr) {
+		return nil, ErrNotLocal
+	}
+	return net.Listen("tcp", addr)
+}
+
+// isLoopback reports whether the host part of a TCP address is loopback.
+func isLoopback(addr string) bool {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return false
+	}
+	return loopbackHost(host)
+}
+
+// isLoopbackHost reports whether an HTTP Host header, with or without a
+// port, names localhost or a loopback IP.
+func isLoopbackHost(hostport string) bool {
+	host := hostport
+	if h, _, err := net.SplitHostPort(hostport); err == nil {
+		host = h
+	} else if strings.HasPrefix(host, "[") && strings.HasSuffix(host, "]") {
+		host = host[1 : len(host)-1]
+	}
+	return loopbackHost(host)
+}
+
+// loopbackHost reports whether host is localhost or a loopback IP.
+func loopbackHost(host string) bool {
+	if strings.EqualFold(host, "localhost") {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
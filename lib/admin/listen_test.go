@@ -0,0 +1,106 @@
+package admin
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestIsLoopback(t *testing.T) {
+	tests := []struct {
+		addr string
+		want bool
+	}{
+		{"127.0.0.1:7660", true},
+		{"[::1]:7660", true},
+		{"localhost:7660", true},
+		{"0.0.0.0:7660", false},
+		{"192.168.1.10:7660", false},
+		{":7660", false},
+		{"127.0.0.1", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.addr, func(t *testing.T) {
+			if got := isLoopback(tt.addr); got != tt.want {
+				t.Errorf("isLoopback(%q) = %v, want %v", tt.addr, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIsLoopbackHost(t *testing.T) {
+	tests := []struct {
+		host string
+		want bool
+	}{
+		{"127.0.0.1:7661", true},
+		{"127.0.0.1", true},
+		{"[::1]:7661", true},
+		{"[::1]", true},
+		{"LocalHost:7661", true},
+		{"localhost.attacker.example", false},
+		{"attacker.example:7661", false},
+		{"0.0.0.0:7661", false},
+		{"", false},
+	}
+
+	for _, tt := range tests {
+		if got := isLoopbackHost(tt.host); got != tt.want {
+			t.Errorf("isLoopbackHost(%q) = %v, want %v", tt.host, got, tt.want)
+		}
+	}
+}
+
+func TestListen_RejectsNonLoopback(t *testing.T) {
+	ln, err := Listen("0.0.0.0:0")
+	if err == nil {
+		ln.Close()
+	}
+	if !errors.Is(err, ErrNotLocal) {
+		t.Errorf("Listen() error = %v, want ErrNotLocal", err)
+	}
+}
+
+func TestListen_Unix(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "admin.sock")
+
+	ln, err := Listen(UnixPrefix + path)
+	if err != nil {
+		t.Fatalf("Listen() error = %v", err)
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatalf("Stat() error = %v", err)
+	}
+	if perm := info.Mode().Perm(); perm != 0o600 {
+		t.Errorf("socket mode = %o, want 600", perm)
+	}
+
+	// A second listener on a live socket must fail.
+	if ln2, err := Listen(UnixPrefix + path); err == nil {
+		ln2.Close()
+		t.Error("Listen() on socket in use succeeded, want error")
+	}
+	ln.Close()
+
+	// Closing a Go unix listener unlinks the socket, so listening again works.
+	ln, err = Listen(UnixPrefix + path)
+	if err != nil {
+		t.Fatalf("Listen() after close error = %v", err)
+	}
+	ln.Close()
+}
+
+func TestListen_UnixRejectsRegularFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "admin.sock")
+	if err := os.WriteFile(path, nil, 0o600); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+	if ln, err := Listen(UnixPrefix + path); err == nil {
+		ln.Close()
+		t.Error("Listen() over regular file succeeded, want error")
+	}
+}
@@ -0,0 +1,95 @@
+package bridge
+
+import (
+	"fmt"
+	"net"
+	"net/netip"
+	"strings"
+)
+
+// AccessConfig restricts which client addresses may use the SAM control
+// port and UDP datagram port. Clients on a unix socket have no IP address
+// and are not affected; use UnixSocketConfig to restrict them.
+type AccessConfig struct {
+	// Allow lists the networks clients may connect from. Empty allows all.
+	Allow []netip.Prefix
+
+	// Deny lists networks clients may not connect from, even if Allow
+	// contains them.
+	Deny []netip.Prefix
+}
+
+// Allows reports whether a client at addr may use the bridge. Addresses
+// that are not IP addresses, such as those of unix sockets, are allowed.
+func (a AccessConfig) Allows(addr net.Addr) bool {
+	ip, ok := addrIP(addr)
+	if !ok {
+		return true
+	}
+	for _, p := range a.Deny {
+		if p.Contains(ip) {
+			return false
+		}
+	}
+	if len(a.Allow) == 0 {
+		return true
+	}
+	for _, p := range a.Allow {
+		if p.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// addrIP returns the IP address of a TCP or UDP address, with IPv4-mapped
+// IPv6 addresses unmapped so that they match IPv4 prefixes.
+func addrIP(addr net.Addr) (netip.Addr, bool) {
+	var ip net.IP
+	switch a := addr.(type) {
+	case *net.TCPAddr:
+		ip = a.IP
+	case *net.UDPAddr:
+		ip = a.IP
+	case nil:
+		return netip.Addr{}, false
+	default:
+		ap, err := netip.ParseAddrPort(addr.String())
+		if err != nil {
+			return netip.Addr{}, false
+		}
+		return ap.Addr().Unmap(), true
+	}
+	parsed, ok := netip.AddrFromSlice(ip)
+	return parsed.Unmap(), ok
+}
+
+// ParsePrefixes parses CIDR prefixes such as "172.17.0.0/16" for
+// AccessConfig. A bare address such as "127.0.0.1" matches only itself.
+func ParsePrefixes(values []string) ([]netip.Prefix, error) {
+	prefixes := make([]netip.Prefix, 0, len(values))
+	for _, v := range values {
+		v = strings.TrimSpace(v)
+		if !strings.Contains(v, "/") {
+			addr, err := netip.ParseAddr(v)
+			if err != nil {
+				return nil, fmt.Errorf("invalid address or CIDR prefix %q", v)
+			}
+			prefixes = append(prefixes, netip.PrefixFrom(addr.Unmap(), addr.Unmap().BitLen()))
+			continue
+		}
+		p, err := netip.ParsePrefix(v)
+		if err != nil {
+			return nil, fmt.Errorf("invalid address or CIDR prefix %q", v)
+		}
+		prefixes = append(prefixes, p.Masked())
+	}
+	return prefixes, nil
+}
+
+// AllowsPeer reports whether the current Access settings allow a client
+// at addr. It is checked for each control connection and each datagram on
+// the UDP port.
+func (s *Server) AllowsPeer(addr net.Addr) bool {
+	return s.Config().Access.Allows(addr)
+}
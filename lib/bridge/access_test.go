@@ -0,0 +1,99 @@
+package bridge
+
+import (
+	"bufio"
+	"net"
+	"net/netip"
+	"testing"
+	"time"
+)
+
+func TestAccessConfig_Allows(t *testing.T) {
+	access := AccessConfig{
+		Allow: []netip.Prefix{netip.MustParsePrefix("127.0.0.0/8"), netip.MustParsePrefix("172.17.0.0/16")},
+		Deny:  []netip.Prefix{netip.MustParsePrefix("172.17.0.99/32")},
+	}
+
+	tests := []struct {
+		name string
+		addr net.Addr
+		want bool
+	}{
+		{"loopback", &net.TCPAddr{IP: net.ParseIP("127.0.0.1"), Port: 5000}, true},
+		{"allowed network", &net.TCPAddr{IP: net.ParseIP("172.17.0.5"), Port: 5000}, true},
+		{"IPv4-mapped", &net.TCPAddr{IP: net.ParseIP("::ffff:172.17.0.5"), Port: 5000}, true},
+		{"UDP", &net.UDPAddr{IP: net.ParseIP("172.17.0.5"), Port: 5000}, true},
+		{"deny wins", &net.TCPAddr{IP: net.ParseIP("172.17.0.99"), Port: 5000}, false},
+		{"not allowed", &net.TCPAddr{IP: net.ParseIP("192.0.2.1"), Port: 5000}, false},
+		{"IPv6 not allowed", &net.TCPAddr{IP: net.ParseIP("2001:db8::1"), Port: 5000}, false},
+		{"unix socket", &net.UnixAddr{Name: "/run/sam.sock", Net: "unix"}, true},
+	}
+	for _, tt := range tests {
+		if got := access.Allows(tt.addr); got != tt.want {
+			t.Errorf("Allows(%s) = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+
+	if !(AccessConfig{}).Allows(&net.TCPAddr{IP: net.ParseIP("192.0.2.1")}) {
+		t.Error("empty AccessConfig denied a client, want all allowed")
+	}
+	denyOnly := AccessConfig{Deny: []netip.Prefix{netip.MustParsePrefix("192.0.2.0/24")}}
+	if denyOnly.Allows(&net.TCPAddr{IP: net.ParseIP("192.0.2.1")}) {
+		t.Error("Deny-only AccessConfig allowed a denied client")
+	}
+	if !denyOnly.Allows(&net.TCPAddr{IP: net.ParseIP("198.51.100.1")}) {
+		t.Error("Deny-only AccessConfig denied a client outside Deny")
+	}
+}
+
+func TestParsePrefixes(t *testing.T) {
+	got, err := ParsePrefixes([]string{"172.17.0.1/16", " 127.0.0.1 ", "::1", "fd00::/8"})
+	if err != nil {
+		t.Fatalf("ParsePrefixes() error = %v", err)
+	}
+	want := []string{"172.17.0.0/16", "127.0.0.1/32", "::1/128", "fd00::/8"}
+	if len(got) != len(want) {
+		t.Fatalf("ParsePrefixes() = %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i].String() != want[i] {
+			t.Errorf("ParsePrefixes()[%d] = %s, want %s", i, got[i], want[i])
+		}
+	}
+
+	for _, bad := range []string{"", "localhost", "10.0.0.0/33", "10.0.0.0/"} {
+		if _, err := ParsePrefixes([]string{bad}); err == nil {
+			t.Errorf("ParsePrefixes(%q) error = nil, want error", bad)
+		}
+	}
+}
+
+func TestServer_AccessDenied(t *testing.T) {
+	config := DefaultConfig()
+	config.Access.Deny = []netip.Prefix{netip.MustParsePrefix("127.0.0.0/8")}
+
+	server, err := NewServer(config, newMockRegistry())
+	if err != nil {
+		t.Fatalf("NewServer() error = %v", err)
+	}
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("net.Listen() error = %v", err)
+	}
+	go server.Serve(listener)
+	defer server.Close()
+
+	conn, err := net.Dial("tcp", listener.Addr().String())
+	if err != nil {
+		t.Fatalf("net.Dial() error = %v", err)
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(2 * time.Second))
+	conn.Write([]byte("HELLO VERSION MIN=3.0 MAX=3.3\n"))
+	if line, err := bufio.NewReader(conn).ReadString('\n'); err == nil {
+		t.Errorf("HELLO from a denied address = %q, want the connection closed", line)
+	}
+	if n := server.ConnectionCount(); n != 0 {
+		t.Errorf("ConnectionCount() = %d, want 0", n)
+	}
+}
@@ -0,0 +1,197 @@
+package bridge
+
+import (
+	"encoding/json"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-i2p/go-sam-bridge/lib/protocol"
+	"github.com/go-i2p/logger"
+)
+
+// Redacted replaces secret option values in audit records and transcripts.
+const Redacted = "[REDACTED]"
+
+// auditFileMode is the file mode of a newly created audit log file.
+const auditFileMode os.FileMode = 0o600
+
+// AuditFile is a Config.AuditLog that appends to the file at a path. The
+// file is opened on the first write and after each Close, so that closing
+// it after the file has been rotated starts a new one.
+type AuditFile struct {
+	path string
+
+	mu   sync.Mutex
+	file *os.File
+}
+
+// NewAuditFile returns an audit log appending to the file at path. The
+// file is created if it does not exist.
+func NewAuditFile(path string) *AuditFile {
+	return &AuditFile{path: path}
+}
+
+// Path returns the file path.
+func (f *AuditFile) Path() string {
+	return f.path
+}
+
+// Write appends p to the file, opening it if needed.
+func (f *AuditFile) Write(p []byte) (int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.file == nil {
+		file, err := os.OpenFile(f.path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, auditFileMode)
+		if err != nil {
+			return 0, err
+		}
+		f.file = file
+	}
+	return f.file.Write(p)
+}
+
+// Close closes the file. The next Write opens it again.
+func (f *AuditFile) Close() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.file == nil {
+		return nil
+	}
+	err := f.file.Close()
+	f.file = nil
+	return err
+}
+
+// AuditRecord is one line of the audit log: a command handled by the
+// server and its outcome. Secret option values are redacted.
+type AuditRecord struct {
+	// Time is when the command was received.
+	Time time.Time `json:"time"`
+
+	// Remote is the client's address.
+	Remote string `json:"remote"`
+
+	// User is the authenticated user, if any.
+	User string `json:"user,omitempty"`
+
+	Verb   string `json:"verb"`
+	Action string `json:"action,omitempty"`
+
+	// Session is the ID the command named, or else the session bound to
+	// the connection.
+	Session string `json:"session,omitempty"`
+
+	// Options holds the command's options with secrets redacted.
+	Options map[string]string `json:"options,omitempty"`
+
+	// Result is the RESULT of the reply, or "ERROR" if the handler failed
+	// and the connection was closed.
+	Result string `json:"result,omitempty"`
+
+	// Message is the MESSAGE of the reply, if any.
+	Message string `json:"message,omitempty"`
+
+	// DurationMS is how long the command took to handle, in milliseconds.
+	DurationMS float64 `json:"duration_ms"`
+}
+
+// secretOptions holds options whose values are always secret.
+var secretOptions = map[string]bool{
+	"PASSWORD":          true,
+	"PRIV":              true,
+	"OFFLINE_SIGNATURE": true,
+	"TRANSIENT_KEY":     true,
+}
+
+// secretSubstrings mark I2CP options that carry secrets, such as
+// i2cp.leaseSetPrivateKey and i2cp.leaseSetSigningPrivateKey.
+var secretSubstrings = []string{"PRIVATEKEY", "PRIVKEY", "PASSWORD", "SECRET"}
+
+// redactOption returns value, or Redacted if the option may hold a
+// secret. DESTINATION is kept only when it is TRANSIENT or an I2P hostname
+// or .b32.i2p address, since a SESSION CREATE destination is a private key
+// and a public one cannot be told apart from it without parsing.
+func redactOption(key, value string) string {
+	upper := strings.ToUpper(key)
+	if secretOptions[upper] {
+		return Redacted
+	}
+	for _, s := range secretSubstrings {
+		if strings.Contains(upper, s) {
+			return Redacted
+		}
+	}
+	if upper == "DESTINATION" && !strings.EqualFold(value, "TRANSIENT") && !strings.HasSuffix(strings.ToLower(value), ".i2p") {
+		return Redacted
+	}
+	return value
+}
+
+// auditLog writes AuditRecords as JSON lines to Config.AuditLog.
+//
+// Thread-safety: All methods are safe for concurrent use.
+type auditLog struct {
+	mu sync.Mutex
+
+	// failing is set after a write error, so that the error is logged once
+	// rather than for every command until the writer recovers.
+	failing bool
+}
+
+// write encodes rec as one JSON line.
+func (a *auditLog) write(cfg *Config, rec *AuditRecord) {
+	line, err := json.Marshal(rec)
+	if err != nil {
+		return
+	}
+	line = append(line, '\n')
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if _, err := cfg.AuditLog.Write(line); err != nil {
+		if !a.failing {
+			log.WithFields(logger.Fields{"pkg": "bridge", "func": "auditLog.write"}).WithError(err).Warn("Failed to write audit log")
+		}
+		a.failing = true
+		return
+	}
+	a.failing = false
+}
+
+// audit records a dispatched command in the audit log, if one is
+// configured. err is the internal error returned by the handler, if any.
+func (s *Server) audit(c *Connection, cmd *protocol.Command, response *protocol.Response, err error, start time.Time, elapsed time.Duration) {
+	cfg := s.Config()
+	if cfg.AuditLog == nil {
+		return
+	}
+
+	rec := &AuditRecord{
+		Time:       start.UTC(),
+		Remote:     c.RemoteAddr(),
+		User:       c.Username(),
+		Verb:       cmd.Verb,
+		Action:     cmd.Action,
+		Session:    cmd.Get("ID"),
+		DurationMS: float64(elapsed.Microseconds()) / 1000,
+	}
+	if rec.Session == "" {
+		rec.Session = c.SessionID()
+	}
+	if len(cmd.Options) > 0 {
+		rec.Options = make(map[string]string, len(cmd.Options))
+		for key, value := range cmd.Options {
+			rec.Options[key] = redactOption(key, value)
+		}
+	}
+	switch {
+	case err != nil:
+		rec.Result = "ERROR"
+	case response != nil:
+		rec.Result = getOptionValue(response.Options, "RESULT")
+		rec.Message = getOptionValue(response.Options, "MESSAGE")
+	}
+	s.auditLog.write(cfg, rec)
+}
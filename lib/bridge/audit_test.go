@@ -0,0 +1,201 @@
+package bridge
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/go-i2p/go-sam-bridge/lib/handler"
+	"github.com/go-i2p/go-sam-bridge/lib/protocol"
+)
+
+// syncBuffer is a bytes.Buffer safe for the server and test goroutines.
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+// failingWriter fails every write.
+type failingWriter struct{ writes int }
+
+func (w *failingWriter) Write(p []byte) (int, error) {
+	w.writes++
+	return 0, errors.New("disk full")
+}
+
+func TestRedactOption(t *testing.T) {
+	tests := []struct {
+		key, value, want string
+	}{
+		{"PASSWORD", "hunter2", Redacted},
+		{"password", "hunter2", Redacted},
+		{"USER", "alice", "alice"},
+		{"DESTINATION", "AAAAprivatekeybase64", Redacted},
+		{"DESTINATION", "TRANSIENT", "TRANSIENT"},
+		{"DESTINATION", "transient", "transient"},
+		{"DESTINATION", "example.i2p", "example.i2p"},
+		{"DESTINATION", "abcdefgh.b32.i2p", "abcdefgh.b32.i2p"},
+		{"OFFLINE_SIGNATURE", "sig", Redacted},
+		{"TRANSIENT_KEY", "key", Redacted},
+		{"OFFLINE_EXPIRES", "1700000000", "1700000000"},
+		{"i2cp.leaseSetPrivateKey", "key", Redacted},
+		{"i2cp.leaseSetSigningPrivateKey", "key", Redacted},
+		{"i2cp.leaseSetSecret", "secret", Redacted},
+		{"inbound.length", "3", "3"},
+	}
+	for _, tt := range tests {
+		if got := redactOption(tt.key, tt.value); got != tt.want {
+			t.Errorf("redactOption(%q, %q) = %q, want %q", tt.key, tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestAuditLog_WriteFailure(t *testing.T) {
+	w := &failingWriter{}
+	config := DefaultConfig()
+	config.AuditLog = w
+
+	var a auditLog
+	a.write(config, &AuditRecord{Verb: "PING"})
+	a.write(config, &AuditRecord{Verb: "PING"})
+	if w.writes != 2 {
+		t.Errorf("writes = %d, want 2", w.writes)
+	}
+	if !a.failing {
+		t.Error("failing = false after a write error")
+	}
+}
+
+func TestServer_AuditLog(t *testing.T) {
+	audit := &syncBuffer{}
+	config := DefaultConfig()
+	config.AuditLog = audit
+
+	server, err := NewServer(config, newMockRegistry())
+	if err != nil {
+		t.Fatalf("NewServer() error = %v", err)
+	}
+	server.Router().RegisterFunc("HELLO", func(ctx *handler.Context, cmd *protocol.Command) (*protocol.Response, error) {
+		return protocol.NewResponse("HELLO").
+			WithAction("REPLY").
+			WithResult("OK").
+			WithVersion("3.3"), nil
+	})
+	server.Router().RegisterFunc("SESSION CREATE", func(ctx *handler.Context, cmd *protocol.Command) (*protocol.Response, error) {
+		return protocol.NewResponse("SESSION").
+			WithAction("STATUS").
+			WithResult("DUPLICATED_ID"), nil
+	})
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("net.Listen() error = %v", err)
+	}
+	go server.Serve(listener)
+	defer server.Close()
+
+	conn, err := net.Dial("tcp", listener.Addr().String())
+	if err != nil {
+		t.Fatalf("net.Dial() error = %v", err)
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(2 * time.Second))
+	reader := bufio.NewReader(conn)
+
+	for _, line := range []string{
+		"HELLO VERSION MIN=3.0 MAX=3.3 USER=alice PASSWORD=hunter2",
+		"SESSION CREATE STYLE=STREAM ID=web DESTINATION=AAAAsecretkeys SIGNATURE_TYPE=7",
+	} {
+		conn.Write([]byte(line + "\n"))
+		if _, err := reader.ReadString('\n'); err != nil {
+			t.Fatalf("ReadString() error = %v", err)
+		}
+	}
+
+	written := audit.String()
+	if strings.Contains(written, "hunter2") || strings.Contains(written, "AAAAsecretkeys") {
+		t.Fatalf("audit log contains a secret:\n%s", written)
+	}
+
+	var records []AuditRecord
+	for _, line := range strings.Split(strings.TrimSpace(written), "\n") {
+		var rec AuditRecord
+		if err := json.Unmarshal([]byte(line), &rec); err != nil {
+			t.Fatalf("json.Unmarshal(%q) error = %v", line, err)
+		}
+		records = append(records, rec)
+	}
+	if len(records) != 2 {
+		t.Fatalf("audit log has %d records, want 2:\n%s", len(records), written)
+	}
+
+	hello := records[0]
+	if hello.Verb != "HELLO" || hello.Action != "VERSION" || hello.Result != "OK" {
+		t.Errorf("HELLO record = %+v", hello)
+	}
+	if hello.Remote != conn.LocalAddr().String() {
+		t.Errorf("Remote = %q, want %q", hello.Remote, conn.LocalAddr().String())
+	}
+	if hello.Options["USER"] != "alice" || hello.Options["PASSWORD"] != Redacted {
+		t.Errorf("HELLO options = %v, want USER kept and PASSWORD redacted", hello.Options)
+	}
+	if hello.Time.IsZero() || hello.DurationMS < 0 {
+		t.Errorf("HELLO time = %v, duration = %v", hello.Time, hello.DurationMS)
+	}
+
+	create := records[1]
+	if create.Session != "web" || create.Result != "DUPLICATED_ID" {
+		t.Errorf("SESSION CREATE record = %+v, want session web and RESULT=DUPLICATED_ID", create)
+	}
+	if create.Options["DESTINATION"] != Redacted || create.Options["SIGNATURE_TYPE"] != "7" {
+		t.Errorf("SESSION CREATE options = %v", create.Options)
+	}
+}
+
+func TestAuditFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.log")
+	f := NewAuditFile(path)
+	if _, err := f.Write([]byte("one\n")); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+	if err := f.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+
+	// Writes after Close reopen the file and append to it.
+	if _, err := f.Write([]byte("two\n")); err != nil {
+		t.Fatalf("Write() after Close() error = %v", err)
+	}
+	f.Close()
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("ReadFile() error = %v", err)
+	}
+	if string(data) != "one\ntwo\n" {
+		t.Errorf("file = %q, want %q", data, "one\ntwo\n")
+	}
+	if info, err := os.Stat(path); err == nil && info.Mode().Perm() != auditFileMode {
+		t.Errorf("file mode = %v, want %v", info.Mode().Perm(), auditFileMode)
+	}
+}
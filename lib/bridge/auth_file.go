@@ -0,0 +1,139 @@
+package bridge
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/go-i2p/logger"
+)
+
+// AuthBackend persists the users of an AuthStore so that changes made with
+// AUTH ADD and AUTH REMOVE survive a restart. Passwords are passed to
+// SaveUsers as bcrypt hashes only.
+type AuthBackend interface {
+	// LoadUsers returns the stored users, mapping usernames to password
+	// hashes. Entries that are not bcrypt hashes are treated as plaintext
+	// and hashed by the AuthStore. A backend with nothing stored yet
+	// returns an empty map and no error.
+	LoadUsers() (map[string]string, error)
+
+	// SaveUsers replaces the stored users with users.
+	SaveUsers(users map[string]string) error
+}
+
+// HtpasswdFile is an AuthBackend that stores users in an htpasswd-style
+// file: one "username:hash" line per user, with blank lines and lines
+// starting with '#' ignored. Only bcrypt hashes are understood, as written
+// by "htpasswd -B"; other entries are taken as plaintext passwords and
+// replaced by their hash on load. The file is rewritten atomically, so
+// comments are not preserved.
+type HtpasswdFile struct {
+	path string
+}
+
+// Ensure HtpasswdFile implements AuthBackend.
+var _ AuthBackend = (*HtpasswdFile)(nil)
+
+// htpasswdFileMode is the file mode of a newly created htpasswd file.
+const htpasswdFileMode os.FileMode = 0o600
+
+// NewHtpasswdFile returns a backend for the htpasswd file at path.
+// The file is created on the first save if it does not exist.
+func NewHtpasswdFile(path string) *HtpasswdFile {
+	return &HtpasswdFile{path: path}
+}
+
+// Path returns the file path.
+func (f *HtpasswdFile) Path() string {
+	return f.path
+}
+
+// LoadUsers reads the users from the file. A missing file holds no users.
+func (f *HtpasswdFile) LoadUsers() (map[string]string, error) {
+	data, err := os.ReadFile(f.path)
+	if os.IsNotExist(err) {
+		return make(map[string]string), nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	users := make(map[string]string)
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		user, hash, ok := strings.Cut(line, ":")
+		if !ok || user == "" {
+			return nil, fmt.Errorf("%s:%d: expected username:hash", f.path, n)
+		}
+		users[user] = hash
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return users, nil
+}
+
+// SaveUsers writes users to a temporary file in the same directory and
+// renames it over the file, so readers never see a partial write. An
+// existing file keeps its mode; a new file is created with mode 0600.
+func (f *HtpasswdFile) SaveUsers(users map[string]string) error {
+	names := make([]string, 0, len(users))
+	for user := range users {
+		names = append(names, user)
+	}
+	sort.Strings(names)
+
+	var buf bytes.Buffer
+	buf.WriteString("# SAM bridge users. AUTH ADD and AUTH REMOVE rewrite this file.\n")
+	for _, user := range names {
+		fmt.Fprintf(&buf, "%s:%s\n", user, users[user])
+	}
+
+	mode := htpasswdFileMode
+	if info, err := os.Stat(f.path); err == nil {
+		mode = info.Mode().Perm()
+	}
+
+	if err := writeFileAtomic(f.path, buf.Bytes(), mode); err != nil {
+		return err
+	}
+	log.WithFields(logger.Fields{"pkg": "bridge", "func": "HtpasswdFile.SaveUsers", "path": f.path, "users": len(users)}).Debug("Saved auth users")
+	return nil
+}
+
+// writeFileAtomic writes data to path through a temporary file that is
+// synced and then renamed into place.
+func writeFileAtomic(path string, data []byte, mode os.FileMode) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".tmp*")
+	if err != nil {
+		return err
+	}
+	tmpName := tmp.Name()
+	defer os.Remove(tmpName) // No-op once renamed
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Chmod(mode); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmpName, path)
+}
@@ -0,0 +1,134 @@
+package bridge
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestHtpasswdFile_LoadMissing(t *testing.T) {
+	f := NewHtpasswdFile(filepath.Join(t.TempDir(), "users"))
+
+	users, err := f.LoadUsers()
+	if err != nil {
+		t.Fatalf("LoadUsers() error = %v", err)
+	}
+	if len(users) != 0 {
+		t.Errorf("LoadUsers() = %v, want no users", users)
+	}
+}
+
+func TestHtpasswdFile_Load(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "users")
+	content := "# comment\n\nalice:$2y$05$abc\n  bob:plain  \n"
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+
+	users, err := NewHtpasswdFile(path).LoadUsers()
+	if err != nil {
+		t.Fatalf("LoadUsers() error = %v", err)
+	}
+	if len(users) != 2 || users["alice"] != "$2y$05$abc" || users["bob"] != "plain" {
+		t.Errorf("LoadUsers() = %v", users)
+	}
+}
+
+func TestHtpasswdFile_LoadMalformed(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "users")
+	if err := os.WriteFile(path, []byte("alice:hash\nnocolon\n"), 0o600); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+
+	_, err := NewHtpasswdFile(path).LoadUsers()
+	if err == nil || !strings.Contains(err.Error(), ":2:") {
+		t.Errorf("LoadUsers() error = %v, want an error for line 2", err)
+	}
+}
+
+func TestHtpasswdFile_SaveRoundTrip(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "users")
+	f := NewHtpasswdFile(path)
+
+	want := map[string]string{"bob": "$2a$10$b", "alice": "$2a$10$a"}
+	if err := f.SaveUsers(want); err != nil {
+		t.Fatalf("SaveUsers() error = %v", err)
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatalf("Stat() error = %v", err)
+	}
+	if info.Mode().Perm() != 0o600 {
+		t.Errorf("file mode = %o, want 600", info.Mode().Perm())
+	}
+
+	data, _ := os.ReadFile(path)
+	if !strings.Contains(string(data), "alice:$2a$10$a\nbob:$2a$10$b\n") {
+		t.Errorf("file content = %q, want users sorted by name", data)
+	}
+
+	got, err := f.LoadUsers()
+	if err != nil {
+		t.Fatalf("LoadUsers() error = %v", err)
+	}
+	if len(got) != len(want) || got["alice"] != want["alice"] || got["bob"] != want["bob"] {
+		t.Errorf("LoadUsers() = %v, want %v", got, want)
+	}
+
+	// No temporary files are left behind.
+	entries, _ := os.ReadDir(dir)
+	if len(entries) != 1 {
+		t.Errorf("directory has %d entries after save, want 1", len(entries))
+	}
+}
+
+func TestHtpasswdFile_SaveKeepsMode(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "users")
+	if err := os.WriteFile(path, nil, 0o640); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+	if err := os.Chmod(path, 0o640); err != nil {
+		t.Fatalf("Chmod() error = %v", err)
+	}
+
+	if err := NewHtpasswdFile(path).SaveUsers(map[string]string{"alice": "$2a$10$a"}); err != nil {
+		t.Fatalf("SaveUsers() error = %v", err)
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatalf("Stat() error = %v", err)
+	}
+	if info.Mode().Perm() != 0o640 {
+		t.Errorf("file mode = %o, want the existing 640", info.Mode().Perm())
+	}
+}
+
+func TestHtpasswdFile_AuthStore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "users")
+
+	store, err := NewAuthStoreWithBackend(AuthConfig{Required: true}, NewHtpasswdFile(path))
+	if err != nil {
+		t.Fatalf("NewAuthStoreWithBackend() error = %v", err)
+	}
+	if err := store.AddUser("alice", "secret"); err != nil {
+		t.Fatalf("AddUser() error = %v", err)
+	}
+
+	data, _ := os.ReadFile(path)
+	if strings.Contains(string(data), "secret") {
+		t.Errorf("file contains the plaintext password: %q", data)
+	}
+
+	// A new store, as after a restart, sees the user.
+	restarted, err := NewAuthStoreWithBackend(AuthConfig{Required: true}, NewHtpasswdFile(path))
+	if err != nil {
+		t.Fatalf("NewAuthStoreWithBackend() error = %v", err)
+	}
+	if !restarted.CheckPassword("alice", "secret") {
+		t.Error("CheckPassword(alice) = false after restart, want true")
+	}
+}
@@ -0,0 +1,135 @@
+package bridge
+
+import (
+	"sync"
+	"time"
+
+	"github.com/go-i2p/logger"
+)
+
+// authLockoutPruneInterval is how often stale failure records are dropped.
+const authLockoutPruneInterval = time.Minute
+
+// authLockout counts failed password checks per client IP and per username
+// and decides when either is locked out, as described by LockoutConfig.
+//
+// Thread-safety: All methods are safe for concurrent use.
+type authLockout struct {
+	mu sync.Mutex
+
+	// now returns the current time; replaced in tests.
+	now func() time.Time
+
+	clients map[string]*authFailures
+	users   map[string]*authFailures
+
+	lastPrune time.Time
+}
+
+// authFailures records the consecutive failures for one client or user.
+type authFailures struct {
+	count       int
+	lastFailure time.Time
+	lockedUntil time.Time
+}
+
+// expired reports whether MaxDuration has passed since the last failure or
+// the end of the lockout, whichever is later, so that the count starts over.
+func (f *authFailures) expired(now time.Time, cfg LockoutConfig) bool {
+	last := f.lastFailure
+	if f.lockedUntil.After(last) {
+		last = f.lockedUntil
+	}
+	return now.Sub(last) > cfg.MaxDuration
+}
+
+// newAuthLockout creates an authLockout with no recorded failures.
+func newAuthLockout() *authLockout {
+	return &authLockout{
+		now:     time.Now,
+		clients: make(map[string]*authFailures),
+		users:   make(map[string]*authFailures),
+	}
+}
+
+// locked returns how much longer the client or the user is locked out,
+// or zero if neither is.
+func (l *authLockout) locked(client, user string) time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	var remaining time.Duration
+	for _, f := range []*authFailures{l.clients[client], l.users[user]} {
+		if f != nil && f.lockedUntil.After(now) {
+			remaining = max(remaining, f.lockedUntil.Sub(now))
+		}
+	}
+	return remaining
+}
+
+// failure records a failed check by client for user under cfg and logs any
+// lockout it starts.
+func (l *authLockout) failure(client, user string, cfg LockoutConfig) {
+	if cfg.MaxFailures <= 0 {
+		return
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	l.prune(now, cfg)
+	if d := l.record(l.clients, client, now, cfg); d > 0 {
+		log.WithFields(logger.Fields{"pkg": "bridge", "func": "authLockout.failure", "client": client, "user": user, "duration": d}).Warn("Locking out client after repeated authentication failures")
+	}
+	if d := l.record(l.users, user, now, cfg); d > 0 {
+		log.WithFields(logger.Fields{"pkg": "bridge", "func": "authLockout.failure", "client": client, "user": user, "duration": d}).Warn("Locking out user after repeated authentication failures")
+	}
+}
+
+// success clears the failures recorded for client and user.
+func (l *authLockout) success(client, user string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	delete(l.clients, client)
+	delete(l.users, user)
+}
+
+// record counts a failure for key and returns the lockout it starts, if any.
+func (l *authLockout) record(failures map[string]*authFailures, key string, now time.Time, cfg LockoutConfig) time.Duration {
+	f := failures[key]
+	if f == nil || f.expired(now, cfg) {
+		f = &authFailures{}
+		failures[key] = f
+	}
+	f.count++
+	f.lastFailure = now
+
+	if f.count < cfg.MaxFailures {
+		return 0
+	}
+	d := cfg.Duration
+	for i := cfg.MaxFailures; i < f.count && d < cfg.MaxDuration; i++ {
+		d *= 2
+	}
+	d = min(d, cfg.MaxDuration)
+	f.lockedUntil = now.Add(d)
+	return d
+}
+
+// prune drops expired records, at most once per prune interval, so
+// that guesses from many addresses do not grow the maps without bound.
+func (l *authLockout) prune(now time.Time, cfg LockoutConfig) {
+	if now.Sub(l.lastPrune) < authLockoutPruneInterval {
+		return
+	}
+	l.lastPrune = now
+	for _, failures := range []map[string]*authFailures{l.clients, l.users} {
+		for key, f := range failures {
+			if f.expired(now, cfg) {
+				delete(failures, key)
+			}
+		}
+	}
+}
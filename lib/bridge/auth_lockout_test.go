@@ -0,0 +1,115 @@
+package bridge
+
+import (
+	"testing"
+	"time"
+)
+
+// newTestLockout returns an authLockout whose clock is *now.
+func newTestLockout(now *time.Time) *authLockout {
+	l := newAuthLockout()
+	l.now = func() time.Time { return *now }
+	return l
+}
+
+func TestAuthLockout_LocksAfterMaxFailures(t *testing.T) {
+	now := time.Unix(1000, 0)
+	l := newTestLockout(&now)
+	cfg := LockoutConfig{MaxFailures: 3, Duration: 10 * time.Second, MaxDuration: time.Minute}
+
+	for i := 0; i < 2; i++ {
+		l.failure("192.0.2.1", "alice", cfg)
+	}
+	if d := l.locked("192.0.2.1", "alice"); d != 0 {
+		t.Fatalf("locked() = %v after 2 failures, want 0", d)
+	}
+
+	l.failure("192.0.2.1", "alice", cfg)
+	if d := l.locked("192.0.2.1", "alice"); d != 10*time.Second {
+		t.Errorf("locked() = %v after 3 failures, want 10s", d)
+	}
+
+	// The lockout applies to the client for any user and to the user from
+	// any client.
+	if d := l.locked("192.0.2.1", "bob"); d == 0 {
+		t.Error("locked(client, bob) = 0, want the client locked out")
+	}
+	if d := l.locked("198.51.100.7", "alice"); d == 0 {
+		t.Error("locked(other client, alice) = 0, want the user locked out")
+	}
+	if d := l.locked("198.51.100.7", "bob"); d != 0 {
+		t.Errorf("locked(other client, bob) = %v, want 0", d)
+	}
+
+	now = now.Add(10 * time.Second)
+	if d := l.locked("192.0.2.1", "alice"); d != 0 {
+		t.Errorf("locked() = %v after the lockout, want 0", d)
+	}
+}
+
+func TestAuthLockout_Backoff(t *testing.T) {
+	now := time.Unix(1000, 0)
+	l := newTestLockout(&now)
+	cfg := LockoutConfig{MaxFailures: 2, Duration: 10 * time.Second, MaxDuration: 30 * time.Second}
+
+	want := []time.Duration{0, 10 * time.Second, 20 * time.Second, 30 * time.Second, 30 * time.Second}
+	for i, w := range want {
+		l.failure("192.0.2.1", "alice", cfg)
+		d := l.locked("192.0.2.1", "alice")
+		if d != w {
+			t.Errorf("failure %d: locked() = %v, want %v", i+1, d, w)
+		}
+		now = now.Add(d)
+	}
+}
+
+func TestAuthLockout_SuccessResets(t *testing.T) {
+	now := time.Unix(1000, 0)
+	l := newTestLockout(&now)
+	cfg := LockoutConfig{MaxFailures: 2, Duration: 10 * time.Second, MaxDuration: time.Minute}
+
+	l.failure("192.0.2.1", "alice", cfg)
+	l.success("192.0.2.1", "alice")
+	l.failure("192.0.2.1", "alice", cfg)
+	if d := l.locked("192.0.2.1", "alice"); d != 0 {
+		t.Errorf("locked() = %v, want the earlier failure forgotten after success", d)
+	}
+}
+
+func TestAuthLockout_FailuresExpire(t *testing.T) {
+	now := time.Unix(1000, 0)
+	l := newTestLockout(&now)
+	cfg := LockoutConfig{MaxFailures: 2, Duration: 10 * time.Second, MaxDuration: time.Minute}
+
+	l.failure("192.0.2.1", "alice", cfg)
+	now = now.Add(2 * time.Minute)
+	l.failure("192.0.2.1", "alice", cfg)
+	if d := l.locked("192.0.2.1", "alice"); d != 0 {
+		t.Errorf("locked() = %v, want failures older than MaxDuration forgotten", d)
+	}
+	if len(l.clients) != 1 || len(l.users) != 1 {
+		t.Errorf("records = %d clients, %d users, want 1 each", len(l.clients), len(l.users))
+	}
+
+	// Stale records are pruned.
+	now = now.Add(2 * time.Minute)
+	l.failure("198.51.100.7", "bob", cfg)
+	if _, ok := l.clients["192.0.2.1"]; ok {
+		t.Error("stale client record was not pruned")
+	}
+	if _, ok := l.users["alice"]; ok {
+		t.Error("stale user record was not pruned")
+	}
+}
+
+func TestAuthLockout_Disabled(t *testing.T) {
+	now := time.Unix(1000, 0)
+	l := newTestLockout(&now)
+
+	for i := 0; i < 10; i++ {
+		l.failure("192.0.2.1", "alice", LockoutConfig{})
+	}
+	if d := l.locked("192.0.2.1", "alice"); d != 0 {
+		t.Errorf("locked() = %v with MaxFailures 0, want 0", d)
+	}
+}
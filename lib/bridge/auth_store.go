@@ -2,10 +2,13 @@ package bridge
 
 import (
 	"errors"
+	"fmt"
 	"sort"
 	"strings"
 	"sync"
+	"time"
 
+	"github.com/go-i2p/logger"
 	"golang.org/x/crypto/bcrypt"
 )
 
@@ -15,51 +18,187 @@ var ErrUserNotFound = errors.New("user not found")
 // ErrEmptyUsername is returned when attempting to add a user with an empty username.
 var ErrEmptyUsername = errors.New("username cannot be empty")
 
+// ErrInvalidUsername is returned when a username contains ':' or a line break,
+// which cannot be stored in an htpasswd file.
+var ErrInvalidUsername = errors.New("username cannot contain ':' or line breaks")
+
+// ErrAuthFailed is returned by Authenticate for a wrong username or password.
+var ErrAuthFailed = errors.New("authentication failed")
+
+// ErrAuthLocked is returned, wrapped with the time left, by Authenticate
+// while the client or the user is locked out after repeated failures.
+var ErrAuthLocked = errors.New("too many failed authentication attempts")
+
 // AuthStore provides thread-safe authentication management.
 // It implements the handler.AuthManager interface to allow AUTH commands
 // to modify authentication configuration at runtime.
 //
 // Per SAM 3.2, AUTH commands allow runtime configuration of authentication
 // on subsequent connections. This store manages the credential database
-// and auth requirement flag.
+// and auth requirement flag. Passwords are only ever held as bcrypt hashes.
+// With an AuthBackend, every user change is saved before it takes effect.
 type AuthStore struct {
 	mu      sync.RWMutex
 	enabled bool
 	users   map[string]string
+
+	// backend persists users; nil keeps them in memory only.
+	backend AuthBackend
+
+	// lockoutConfig and lockout limit password guessing in Authenticate.
+	lockoutConfig LockoutConfig
+	lockout       *authLockout
+
+	// roles and policies decide what each user may do; see Policy.
+	roles    map[string]string
+	policies map[string]Policy
 }
 
 // NewAuthStore creates a new authentication store.
-// By default, authentication is disabled and no users are configured.
+// By default, authentication is disabled, no users are configured and
+// Authenticate uses DefaultLockoutConfig.
 func NewAuthStore() *AuthStore {
 	return &AuthStore{
-		enabled: false,
-		users:   make(map[string]string),
+		enabled:       false,
+		users:         make(map[string]string),
+		lockoutConfig: DefaultLockoutConfig(),
+		lockout:       newAuthLockout(),
 	}
 }
 
 // NewAuthStoreFromConfig creates an AuthStore initialized from an AuthConfig.
 // This allows the bridge server to use existing configuration.
 // Password values that are already bcrypt hashes (prefix "$2") are stored as-is;
-// plaintext passwords are hashed automatically.
+// plaintext passwords are hashed automatically. Users whose password cannot
+// be hashed are left out and logged; Config.Validate rejects them first.
 func NewAuthStoreFromConfig(cfg AuthConfig) *AuthStore {
-	users := make(map[string]string)
-	for k, v := range cfg.Users {
-		if isBcryptHash(v) {
-			users[k] = v
-		} else {
-			hash, err := bcrypt.GenerateFromPassword([]byte(v), bcrypt.DefaultCost)
-			if err != nil {
-				continue
-			}
-			users[k] = string(hash)
-		}
+	users, err := hashUsers(cfg.Users)
+	if err != nil {
+		log.WithFields(logger.Fields{"pkg": "bridge", "func": "NewAuthStoreFromConfig"}).WithError(err).Error("Leaving out auth users whose password cannot be hashed")
 	}
 	return &AuthStore{
-		enabled: cfg.Required,
-		users:   users,
+		enabled:       cfg.Required,
+		users:         users,
+		lockoutConfig: cfg.Lockout,
+		lockout:       newAuthLockout(),
+		roles:         copyRoles(cfg.Roles),
+		policies:      copyPolicies(cfg.Policies),
 	}
 }
 
+// NewAuthStoreWithBackend creates an AuthStore whose users are loaded from
+// and saved to backend. Users in cfg.Users that the backend does not have
+// are added; for users in both, the backend's password wins, since it
+// reflects later AUTH ADD commands. Plaintext passwords from either source
+// are hashed, and the backend is rewritten if anything was added or hashed.
+// As with NewAuthStoreFromConfig, authentication is enabled if cfg.Required
+// is set.
+func NewAuthStoreWithBackend(cfg AuthConfig, backend AuthBackend) (*AuthStore, error) {
+	users, err := loadBackendUsers(cfg, backend)
+	if err != nil {
+		return nil, err
+	}
+	return &AuthStore{
+		enabled:       cfg.Required,
+		users:         users,
+		backend:       backend,
+		lockoutConfig: cfg.Lockout,
+		lockout:       newAuthLockout(),
+		roles:         copyRoles(cfg.Roles),
+		policies:      copyPolicies(cfg.Policies),
+	}, nil
+}
+
+// loadBackendUsers merges cfg.Users into the users stored in backend,
+// migrating plaintext passwords to bcrypt and saving the result if it
+// differs from what was stored.
+func loadBackendUsers(cfg AuthConfig, backend AuthBackend) (map[string]string, error) {
+	stored, err := backend.LoadUsers()
+	if err != nil {
+		return nil, fmt.Errorf("load auth users: %w", err)
+	}
+
+	merged := make(map[string]string, len(stored)+len(cfg.Users))
+	for user, password := range cfg.Users {
+		merged[user] = password
+	}
+	for user, password := range stored {
+		merged[user] = password
+	}
+	users, err := hashUsers(merged)
+	if err != nil {
+		return nil, err
+	}
+
+	changed := len(users) != len(stored)
+	for user, hash := range users {
+		if stored[user] != hash {
+			changed = true
+			break
+		}
+	}
+	if changed {
+		if err := backend.SaveUsers(users); err != nil {
+			return nil, fmt.Errorf("save auth users: %w", err)
+		}
+		log.WithFields(logger.Fields{"pkg": "bridge", "func": "NewAuthStoreWithBackend", "users": len(users)}).Info("Migrated auth users to hashed storage")
+	}
+	return users, nil
+}
+
+// Replace swaps the whole authentication state for the one in cfg, as when
+// the configuration is reloaded. Without a backend in cfg, users added or
+// removed with AUTH commands since the store was created are discarded and
+// passwords are handled as in NewAuthStoreFromConfig. With one, users are
+// reloaded from it as in NewAuthStoreWithBackend. Failures already counted
+// towards a lockout are kept. On error the store is left unchanged.
+func (s *AuthStore) Replace(cfg AuthConfig) error {
+	var users map[string]string
+	if cfg.Backend != nil {
+		var err error
+		if users, err = loadBackendUsers(cfg, cfg.Backend); err != nil {
+			return err
+		}
+	} else {
+		var err error
+		if users, err = hashUsers(cfg.Users); err != nil {
+			return err
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.enabled = cfg.Required
+	s.users = users
+	s.backend = cfg.Backend
+	s.lockoutConfig = cfg.Lockout
+	s.roles = copyRoles(cfg.Roles)
+	s.policies = copyPolicies(cfg.Policies)
+	return nil
+}
+
+// hashUsers returns users with every plaintext password replaced by its
+// bcrypt hash. Values that are already bcrypt hashes are kept as-is. Users
+// whose password cannot be hashed, such as one longer than bcrypt's 72
+// bytes, are left out of the result and named in the error.
+func hashUsers(users map[string]string) (map[string]string, error) {
+	hashed := make(map[string]string, len(users))
+	var errs []error
+	for k, v := range users {
+		if isBcryptHash(v) {
+			hashed[k] = v
+			continue
+		}
+		hash, err := bcrypt.GenerateFromPassword([]byte(v), bcrypt.DefaultCost)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("hash password of user %q: %w", k, err))
+			continue
+		}
+		hashed[k] = string(hash)
+	}
+	return hashed, errors.Join(errs...)
+}
+
 // IsAuthEnabled returns true if authentication is currently required.
 // Implements handler.AuthManager interface.
 func (s *AuthStore) IsAuthEnabled() bool {
@@ -78,13 +217,19 @@ func (s *AuthStore) SetAuthEnabled(enabled bool) {
 }
 
 // AddUser adds or updates a user with the given password.
-// The password is hashed with bcrypt before storage (OWASP A07 compliance).
-// Returns ErrEmptyUsername if the username is empty.
+// The password is always hashed with bcrypt before storage (OWASP A07
+// compliance), even if it looks like a hash.
+// Returns ErrEmptyUsername if the username is empty, ErrInvalidUsername if it
+// contains ':' or a line break, or the backend's error if the change could
+// not be saved, in which case the store is unchanged.
 // Implements handler.AuthManager interface.
 func (s *AuthStore) AddUser(username, password string) error {
 	if username == "" {
 		return ErrEmptyUsername
 	}
+	if strings.ContainsAny(username, ":\r\n") {
+		return ErrInvalidUsername
+	}
 
 	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
 	if err != nil {
@@ -93,22 +238,53 @@ func (s *AuthStore) AddUser(username, password string) error {
 
 	s.mu.Lock()
 	defer s.mu.Unlock()
+	previous, existed := s.users[username]
 	s.users[username] = string(hash)
+	if err := s.save(); err != nil {
+		if existed {
+			s.users[username] = previous
+		} else {
+			delete(s.users, username)
+		}
+		return err
+	}
 	return nil
 }
 
 // RemoveUser removes a user from the authentication store.
-// Returns ErrUserNotFound if the user does not exist.
+// Returns ErrUserNotFound if the user does not exist, or the backend's error
+// if the change could not be saved, in which case the user is kept.
 // Implements handler.AuthManager interface.
 func (s *AuthStore) RemoveUser(username string) error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
-	if _, exists := s.users[username]; !exists {
+	previous, exists := s.users[username]
+	if !exists {
 		return ErrUserNotFound
 	}
 
 	delete(s.users, username)
+	if err := s.save(); err != nil {
+		s.users[username] = previous
+		return err
+	}
+	return nil
+}
+
+// save writes the users to the backend, if any. The caller must hold s.mu.
+func (s *AuthStore) save() error {
+	if s.backend == nil {
+		return nil
+	}
+	users := make(map[string]string, len(s.users))
+	for k, v := range s.users {
+		users[k] = v
+	}
+	if err := s.backend.SaveUsers(users); err != nil {
+		log.WithFields(logger.Fields{"pkg": "bridge", "func": "AuthStore.save"}).WithError(err).Error("Failed to save auth users")
+		return fmt.Errorf("save auth users: %w", err)
+	}
 	return nil
 }
 
@@ -141,18 +317,95 @@ func (s *AuthStore) ListUsers() []string {
 
 // CheckPassword verifies the password for a user.
 // Returns true if the user exists and the password matches.
-// Uses bcrypt comparison which is inherently constant-time.
+// Uses bcrypt comparison which is inherently constant-time. For an unknown
+// user the password is compared with a dummy hash, so that the time taken
+// does not reveal whether the user exists.
 // This method is used by the HELLO handler for authentication.
 func (s *AuthStore) CheckPassword(username, password string) bool {
 	s.mu.RLock()
-	defer s.mu.RUnlock()
 	storedHash, ok := s.users[username]
+	s.mu.RUnlock()
 	if !ok {
+		bcrypt.CompareHashAndPassword(dummyHash, []byte(password))
 		return false
 	}
 	return bcrypt.CompareHashAndPassword([]byte(storedHash), []byte(password)) == nil
 }
 
+// dummyHash is a bcrypt hash, at the cost used for stored passwords, that
+// CheckPassword compares passwords of unknown users with.
+var dummyHash = []byte("$2a$10$ga5Dz/lNZbi8XRHYKJ9wRuCnwifGPz1DphFzbBhdQ58j3WOl2Qo7G")
+
+// Authenticate checks a password presented by the client at remoteAddr,
+// with the brute-force protection described by LockoutConfig: failures are
+// counted per client IP and per username, and while either is locked out
+// the password is not checked at all. Returns nil if the password is right,
+// ErrAuthFailed if not, or an error wrapping ErrAuthLocked.
+// This method is used for HELLO USER/PASSWORD authentication.
+func (s *AuthStore) Authenticate(remoteAddr, username, password string) error {
+	client := clientHost(remoteAddr)
+	if d := s.lockout.locked(client, username); d > 0 {
+		return fmt.Errorf("%w; retry in %s", ErrAuthLocked, (d + time.Second - 1).Truncate(time.Second))
+	}
+
+	if !s.CheckPassword(username, password) {
+		s.mu.RLock()
+		cfg := s.lockoutConfig
+		s.mu.RUnlock()
+		s.lockout.failure(client, username, cfg)
+		return ErrAuthFailed
+	}
+	s.lockout.success(client, username)
+	return nil
+}
+
+// Role returns the role of username: its entry in AuthConfig.Roles, or
+// RoleUser. The user need not exist.
+func (s *AuthStore) Role(username string) string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if role, ok := s.roles[username]; ok {
+		return role
+	}
+	return RoleUser
+}
+
+// Policy returns the policy of username's role.
+func (s *AuthStore) Policy(username string) Policy {
+	role := s.Role(username)
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.rolePolicy(role)
+}
+
+// HasAdmin reports whether AuthConfig.Roles gives any user a role whose
+// policy allows AUTH commands. While one does, the server keeps AUTH
+// commands to those users even when authentication is disabled, so that
+// an anonymous client cannot add itself as a user and enable
+// authentication.
+func (s *AuthStore) HasAdmin() bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	for _, role := range s.roles {
+		if s.rolePolicy(role).Admin {
+			return true
+		}
+	}
+	return false
+}
+
+// rolePolicy returns the policy of role. The caller must hold s.mu.
+func (s *AuthStore) rolePolicy(role string) Policy {
+	if policy, ok := s.policies[role]; ok {
+		return policy
+	}
+	if role == RoleAdmin {
+		return AdminPolicy()
+	}
+	return DefaultUserPolicy()
+}
+
 // UserCount returns the number of registered users.
 func (s *AuthStore) UserCount() int {
 	s.mu.RLock()
@@ -175,9 +428,40 @@ func (s *AuthStore) ToConfig() AuthConfig {
 	return AuthConfig{
 		Required: s.enabled,
 		Users:    users,
+		Backend:  s.backend,
+		Lockout:  s.lockoutConfig,
+		Roles:    copyRoles(s.roles),
+		Policies: copyPolicies(s.policies),
+	}
+}
+
+// copyRoles returns a copy of roles, or nil if it is empty.
+func copyRoles(roles map[string]string) map[string]string {
+	if len(roles) == 0 {
+		return nil
 	}
+	copied := make(map[string]string, len(roles))
+	for user, role := range roles {
+		copied[user] = role
+	}
+	return copied
+}
+
+// copyPolicies returns a copy of policies, or nil if it is empty.
+func copyPolicies(policies map[string]Policy) map[string]Policy {
+	if len(policies) == 0 {
+		return nil
+	}
+	copied := make(map[string]Policy, len(policies))
+	for role, policy := range policies {
+		copied[role] = policy
+	}
+	return copied
 }
 
+// maxPasswordLength is the longest password bcrypt can hash, in bytes.
+const maxPasswordLength = 72
+
 // isBcryptHash detects whether a value is already a bcrypt hash.
 func isBcryptHash(s string) bool {
 	return strings.HasPrefix(s, "$2")
@@ -1,8 +1,13 @@
 package bridge
 
 import (
+	"errors"
+	"strings"
 	"sync"
 	"testing"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
 )
 
 func TestNewAuthStore(t *testing.T) {
@@ -239,6 +244,13 @@ func TestAuthStore_CheckPassword(t *testing.T) {
 	}
 }
 
+func TestAuthStore_DummyHash(t *testing.T) {
+	// Unknown users' passwords must take as long to check as known ones'.
+	if cost, err := bcrypt.Cost(dummyHash); err != nil || cost != bcrypt.DefaultCost {
+		t.Errorf("bcrypt.Cost(dummyHash) = %d, %v; want %d", cost, err, bcrypt.DefaultCost)
+	}
+}
+
 func TestAuthStore_CheckPassword_EmptyPassword(t *testing.T) {
 	store := NewAuthStore()
 	store.AddUser("testuser", "") // Empty password
@@ -252,6 +264,260 @@ func TestAuthStore_CheckPassword_EmptyPassword(t *testing.T) {
 	}
 }
 
+func TestAuthStore_Replace(t *testing.T) {
+	store := NewAuthStoreFromConfig(AuthConfig{
+		Required: false,
+		Users:    map[string]string{"old": "pass"},
+	})
+	store.AddUser("runtime", "pass")
+
+	if err := store.Replace(AuthConfig{
+		Required: true,
+		Users:    map[string]string{"alice": "secret"},
+	}); err != nil {
+		t.Fatalf("Replace() error = %v", err)
+	}
+
+	if !store.IsAuthEnabled() {
+		t.Error("IsAuthEnabled() = false after Replace with Required, want true")
+	}
+	if got := store.ListUsers(); len(got) != 1 || got[0] != "alice" {
+		t.Errorf("ListUsers() = %v, want [alice]", got)
+	}
+	if !store.CheckPassword("alice", "secret") {
+		t.Error("CheckPassword(alice) = false, want true")
+	}
+	if store.CheckPassword("runtime", "pass") {
+		t.Error("CheckPassword(runtime) = true, want users added at runtime discarded")
+	}
+}
+
+func TestAuthStore_AddUser_InvalidUsername(t *testing.T) {
+	store := NewAuthStore()
+
+	for _, name := range []string{"a:b", "a\nb", "a\rb"} {
+		if err := store.AddUser(name, "pass"); err != ErrInvalidUsername {
+			t.Errorf("AddUser(%q) error = %v, want ErrInvalidUsername", name, err)
+		}
+	}
+	if store.UserCount() != 0 {
+		t.Errorf("UserCount() = %d, want 0", store.UserCount())
+	}
+}
+
+func TestAuthStore_UnhashablePassword(t *testing.T) {
+	long := strings.Repeat("x", 73)
+
+	store := NewAuthStoreFromConfig(AuthConfig{Users: map[string]string{"alice": "secret", "bob": long}})
+	if !store.HasUser("alice") || store.HasUser("bob") {
+		t.Errorf("ListUsers() = %v, want only alice", store.ListUsers())
+	}
+
+	err := store.Replace(AuthConfig{Required: true, Users: map[string]string{"carol": long}})
+	if err == nil || !strings.Contains(err.Error(), `"carol"`) {
+		t.Errorf("Replace() error = %v, want one naming carol", err)
+	}
+	if store.IsAuthEnabled() || !store.HasUser("alice") {
+		t.Error("Replace() changed the store despite failing")
+	}
+
+	backend := &memoryBackend{users: map[string]string{"dave": long}}
+	if _, err := NewAuthStoreWithBackend(AuthConfig{}, backend); err == nil || !strings.Contains(err.Error(), `"dave"`) {
+		t.Errorf("NewAuthStoreWithBackend() error = %v, want one naming dave", err)
+	}
+}
+
+// memoryBackend is an AuthBackend that keeps users in memory and can be
+// made to fail.
+type memoryBackend struct {
+	users   map[string]string
+	saves   int
+	saveErr error
+}
+
+func (b *memoryBackend) LoadUsers() (map[string]string, error) {
+	users := make(map[string]string, len(b.users))
+	for k, v := range b.users {
+		users[k] = v
+	}
+	return users, nil
+}
+
+func (b *memoryBackend) SaveUsers(users map[string]string) error {
+	if b.saveErr != nil {
+		return b.saveErr
+	}
+	b.saves++
+	b.users = users
+	return nil
+}
+
+func TestNewAuthStoreWithBackend(t *testing.T) {
+	hash, _ := bcrypt.GenerateFromPassword([]byte("stored"), bcrypt.MinCost)
+	backend := &memoryBackend{users: map[string]string{
+		"alice": string(hash),
+		"bob":   "plain", // Written by hand; migrated on load
+	}}
+
+	store, err := NewAuthStoreWithBackend(AuthConfig{
+		Required: true,
+		Users:    map[string]string{"alice": "config", "carol": "fromconfig"},
+	}, backend)
+	if err != nil {
+		t.Fatalf("NewAuthStoreWithBackend() error = %v", err)
+	}
+
+	if !store.IsAuthEnabled() {
+		t.Error("IsAuthEnabled() = false, want true")
+	}
+	if !store.CheckPassword("alice", "stored") {
+		t.Error("CheckPassword(alice, stored) = false, want the backend's password to win")
+	}
+	if !store.CheckPassword("bob", "plain") {
+		t.Error("CheckPassword(bob) = false after migration, want true")
+	}
+	if !store.CheckPassword("carol", "fromconfig") {
+		t.Error("CheckPassword(carol) = false, want config users added")
+	}
+
+	if backend.saves != 1 {
+		t.Errorf("backend saved %d times, want 1 for the migration", backend.saves)
+	}
+	for user, stored := range backend.users {
+		if !isBcryptHash(stored) {
+			t.Errorf("backend user %q stored as %q, want a bcrypt hash", user, stored)
+		}
+	}
+
+	// Nothing to migrate the second time. Only Required turns auth on,
+	// whatever the backend holds.
+	restarted, err := NewAuthStoreWithBackend(AuthConfig{}, backend)
+	if err != nil {
+		t.Fatalf("NewAuthStoreWithBackend() error = %v", err)
+	}
+	if restarted.IsAuthEnabled() {
+		t.Error("IsAuthEnabled() = true without Required, want false")
+	}
+	if backend.saves != 1 {
+		t.Errorf("backend saved %d times, want no save when already migrated", backend.saves)
+	}
+}
+
+func TestAuthStore_BackendPersistsChanges(t *testing.T) {
+	backend := &memoryBackend{}
+	store, err := NewAuthStoreWithBackend(AuthConfig{Required: true}, backend)
+	if err != nil {
+		t.Fatalf("NewAuthStoreWithBackend() error = %v", err)
+	}
+
+	// A password that looks like a hash is still hashed.
+	if err := store.AddUser("alice", "$2a$10$notreallyahash"); err != nil {
+		t.Fatalf("AddUser() error = %v", err)
+	}
+	stored := backend.users["alice"]
+	if stored == "$2a$10$notreallyahash" || !isBcryptHash(stored) {
+		t.Errorf("backend stored %q, want a fresh bcrypt hash", stored)
+	}
+
+	if err := store.RemoveUser("alice"); err != nil {
+		t.Fatalf("RemoveUser() error = %v", err)
+	}
+	if _, ok := backend.users["alice"]; ok {
+		t.Error("backend still has alice after RemoveUser")
+	}
+}
+
+func TestAuthStore_BackendSaveFailure(t *testing.T) {
+	backend := &memoryBackend{}
+	store, err := NewAuthStoreWithBackend(AuthConfig{Users: map[string]string{"alice": "secret"}}, backend)
+	if err != nil {
+		t.Fatalf("NewAuthStoreWithBackend() error = %v", err)
+	}
+	backend.saveErr = errors.New("disk full")
+
+	if err := store.AddUser("bob", "pass"); !errors.Is(err, backend.saveErr) {
+		t.Errorf("AddUser() error = %v, want the backend error", err)
+	}
+	if store.HasUser("bob") {
+		t.Error("HasUser(bob) = true after a failed save, want the add rolled back")
+	}
+
+	if err := store.AddUser("alice", "changed"); err == nil {
+		t.Error("AddUser() = nil with a failing backend, want error")
+	}
+	if !store.CheckPassword("alice", "secret") {
+		t.Error("CheckPassword(alice, secret) = false, want the old password kept")
+	}
+
+	if err := store.RemoveUser("alice"); err == nil {
+		t.Error("RemoveUser() = nil with a failing backend, want error")
+	}
+	if !store.HasUser("alice") {
+		t.Error("HasUser(alice) = false after a failed save, want the user kept")
+	}
+}
+
+func TestAuthStore_ReplaceWithBackend(t *testing.T) {
+	backend := &memoryBackend{}
+	store, err := NewAuthStoreWithBackend(AuthConfig{}, backend)
+	if err != nil {
+		t.Fatalf("NewAuthStoreWithBackend() error = %v", err)
+	}
+	if err := store.AddUser("runtime", "pass"); err != nil {
+		t.Fatalf("AddUser() error = %v", err)
+	}
+
+	if err := store.Replace(AuthConfig{Backend: backend}); err != nil {
+		t.Fatalf("Replace() error = %v", err)
+	}
+	if store.IsAuthEnabled() {
+		t.Error("IsAuthEnabled() = true without Required, want false")
+	}
+	if !store.CheckPassword("runtime", "pass") {
+		t.Error("CheckPassword(runtime) = false, want users reloaded from the backend")
+	}
+}
+
+func TestAuthStore_Authenticate(t *testing.T) {
+	store := NewAuthStoreFromConfig(AuthConfig{
+		Required: true,
+		Users:    map[string]string{"alice": "secret"},
+		Lockout:  LockoutConfig{MaxFailures: 2, Duration: time.Minute, MaxDuration: time.Hour},
+	})
+
+	if err := store.Authenticate("192.0.2.1:5000", "alice", "secret"); err != nil {
+		t.Fatalf("Authenticate() error = %v, want nil", err)
+	}
+	for i := 0; i < 2; i++ {
+		if err := store.Authenticate("192.0.2.1:5000", "alice", "wrong"); err != ErrAuthFailed {
+			t.Fatalf("Authenticate() error = %v, want ErrAuthFailed", err)
+		}
+	}
+
+	// Locked out, even with the right password and from another port.
+	err := store.Authenticate("192.0.2.1:5001", "alice", "secret")
+	if !errors.Is(err, ErrAuthLocked) {
+		t.Fatalf("Authenticate() error = %v, want ErrAuthLocked", err)
+	}
+	if !strings.Contains(err.Error(), "retry in 1m0s") {
+		t.Errorf("Authenticate() error = %q, want the time left", err)
+	}
+
+	// Unknown users count towards the client's lockout too.
+	other := NewAuthStoreFromConfig(AuthConfig{
+		Users:   map[string]string{"alice": "secret"},
+		Lockout: LockoutConfig{MaxFailures: 2, Duration: time.Minute, MaxDuration: time.Hour},
+	})
+	other.Authenticate("198.51.100.7:1", "nobody", "x")
+	other.Authenticate("198.51.100.7:2", "someone", "x")
+	if err := other.Authenticate("198.51.100.7:3", "alice", "secret"); !errors.Is(err, ErrAuthLocked) {
+		t.Errorf("Authenticate() error = %v, want the client locked out", err)
+	}
+	if err := other.Authenticate("203.0.113.9:1", "alice", "secret"); err != nil {
+		t.Errorf("Authenticate() from another client error = %v, want nil", err)
+	}
+}
+
 func TestAuthStore_ToConfig(t *testing.T) {
 	store := NewAuthStore()
 	store.SetAuthEnabled(true)
@@ -0,0 +1,105 @@
+package bridge
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+
+	"github.com/go-i2p/logger"
+)
+
+// CertUserField selects the field of a verified TLS client certificate that
+// names its SAM user, for AuthConfig.CertUser.
+type CertUserField string
+
+const (
+	// CertUserNone disables client certificate authentication.
+	CertUserNone CertUserField = ""
+
+	// CertUserCommonName maps the subject common name (CN) to a user.
+	CertUserCommonName CertUserField = "cn"
+
+	// CertUserDNSName maps a DNS name from the subject alternative names.
+	CertUserDNSName CertUserField = "dns"
+
+	// CertUserEmail maps an email address from the subject alternative names.
+	CertUserEmail CertUserField = "email"
+
+	// CertUserURI maps a URI from the subject alternative names, such as a
+	// SPIFFE ID ("spiffe://example.org/ns/apps/sa/web").
+	CertUserURI CertUserField = "uri"
+)
+
+// valid reports whether f is one of the CertUser constants.
+func (f CertUserField) valid() bool {
+	switch f {
+	case CertUserNone, CertUserCommonName, CertUserDNSName, CertUserEmail, CertUserURI:
+		return true
+	}
+	return false
+}
+
+// certUserNames returns the candidate usernames in cert for field, in
+// certificate order.
+func certUserNames(cert *x509.Certificate, field CertUserField) []string {
+	switch field {
+	case CertUserCommonName:
+		if cert.Subject.CommonName != "" {
+			return []string{cert.Subject.CommonName}
+		}
+	case CertUserDNSName:
+		return cert.DNSNames
+	case CertUserEmail:
+		return cert.EmailAddresses
+	case CertUserURI:
+		names := make([]string, 0, len(cert.URIs))
+		for _, u := range cert.URIs {
+			names = append(names, u.String())
+		}
+		return names
+	}
+	return nil
+}
+
+// authenticateClientCert completes the TLS handshake of a control
+// connection and, if the client presented a certificate that verified
+// against TLSConfig.ClientCAs, marks c authenticated as the first
+// AuthStore user named by the Auth.CertUser field of the certificate.
+// The client can then send HELLO without USER and PASSWORD. Connections
+// that are not TLS, or whose certificate names no user, are left as they
+// are. An error means the handshake failed and the connection is unusable.
+func (s *Server) authenticateClientCert(c *Connection) error {
+	tlsConn, ok := c.Conn().(*tls.Conn)
+	if !ok {
+		return nil
+	}
+	cfg := s.Config()
+	if cfg.Auth.CertUser == CertUserNone {
+		return nil
+	}
+
+	ctx := context.Background()
+	if cfg.Timeouts.Handshake > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, cfg.Timeouts.Handshake)
+		defer cancel()
+	}
+	if err := tlsConn.HandshakeContext(ctx); err != nil {
+		return err
+	}
+
+	state := tlsConn.ConnectionState()
+	if len(state.VerifiedChains) == 0 {
+		return nil
+	}
+	cert := state.VerifiedChains[0][0]
+	for _, name := range certUserNames(cert, cfg.Auth.CertUser) {
+		if s.authStore.HasUser(name) {
+			c.SetAuthenticated(name)
+			log.WithFields(logger.Fields{"pkg": "bridge", "func": "Server.authenticateClientCert", "remote": c.RemoteAddr(), "user": name}).Info("Authenticated SAM client by certificate")
+			return nil
+		}
+	}
+	log.WithFields(logger.Fields{"pkg": "bridge", "func": "Server.authenticateClientCert", "remote": c.RemoteAddr(), "subject": cert.Subject.String(), "field": string(cfg.Auth.CertUser)}).Warn("Client certificate names no SAM user")
+	return nil
+}
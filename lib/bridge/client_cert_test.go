@@ -0,0 +1,195 @@
+package bridge
+
+import (
+	"bufio"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"math/big"
+	"net"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+// testCA issues certificates for TLS tests.
+type testCA struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+	pool *x509.CertPool
+}
+
+func newTestCA(t *testing.T) *testCA {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("GenerateKey() error = %v", err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "test CA"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("CreateCertificate() error = %v", err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatalf("ParseCertificate() error = %v", err)
+	}
+	pool := x509.NewCertPool()
+	pool.AddCert(cert)
+	return &testCA{cert: cert, key: key, pool: pool}
+}
+
+// issue returns a certificate signed by the CA, with tmpl filled in.
+func (ca *testCA) issue(t *testing.T, tmpl *x509.Certificate) tls.Certificate {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("GenerateKey() error = %v", err)
+	}
+	tmpl.SerialNumber = big.NewInt(time.Now().UnixNano())
+	tmpl.NotBefore = time.Now().Add(-time.Hour)
+	tmpl.NotAfter = time.Now().Add(time.Hour)
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, ca.cert, &key.PublicKey, ca.key)
+	if err != nil {
+		t.Fatalf("CreateCertificate() error = %v", err)
+	}
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key}
+}
+
+// startCertAuthServer starts a TLS server that maps the client certificate
+// CN to a user and requires authentication.
+func startCertAuthServer(t *testing.T, ca *testCA) string {
+	t.Helper()
+
+	serverCert := ca.issue(t, &x509.Certificate{
+		Subject:     pkix.Name{CommonName: "sam-bridge"},
+		IPAddresses: []net.IP{net.ParseIP("127.0.0.1")},
+		ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	})
+
+	config := DefaultConfig()
+	config.TLSConfig = &tls.Config{
+		Certificates: []tls.Certificate{serverCert},
+		ClientCAs:    ca.pool,
+		ClientAuth:   tls.VerifyClientCertIfGiven,
+	}
+	config.Auth.Required = true
+	config.Auth.Users = map[string]string{"web": "unused"}
+	config.Auth.CertUser = CertUserCommonName
+
+	_, addr := startTestServer(t, config, nil, func(_ *Server, ln net.Listener) net.Listener {
+		return tls.NewListener(ln, config.TLSConfig)
+	})
+	return addr
+}
+
+// helloAndCreate sends HELLO without credentials and SESSION CREATE over a
+// TLS connection, returning the SESSION CREATE reply.
+func helloAndCreate(t *testing.T, addr string, clientCerts []tls.Certificate, roots *x509.CertPool) string {
+	t.Helper()
+
+	conn, err := tls.Dial("tcp", addr, &tls.Config{RootCAs: roots, Certificates: clientCerts})
+	if err != nil {
+		t.Fatalf("tls.Dial() error = %v", err)
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(2 * time.Second))
+
+	reader := bufio.NewReader(conn)
+	conn.Write([]byte("HELLO VERSION MIN=3.0 MAX=3.3\n"))
+	if line, err := reader.ReadString('\n'); err != nil || !strings.Contains(line, "RESULT=OK") {
+		t.Fatalf("HELLO = %q, %v, want RESULT=OK", line, err)
+	}
+	conn.Write([]byte("SESSION CREATE STYLE=STREAM ID=test DESTINATION=TRANSIENT\n"))
+	line, err := reader.ReadString('\n')
+	if err != nil {
+		t.Fatalf("ReadString() error = %v", err)
+	}
+	return line
+}
+
+func TestServer_ClientCertAuthentication(t *testing.T) {
+	ca := newTestCA(t)
+	addr := startCertAuthServer(t, ca)
+	clientAuth := []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth}
+
+	web := ca.issue(t, &x509.Certificate{Subject: pkix.Name{CommonName: "web"}, ExtKeyUsage: clientAuth})
+	if line := helloAndCreate(t, addr, []tls.Certificate{web}, ca.pool); !strings.Contains(line, "RESULT=OK") {
+		t.Errorf("SESSION CREATE with a user's certificate = %q, want RESULT=OK", line)
+	}
+
+	unknown := ca.issue(t, &x509.Certificate{Subject: pkix.Name{CommonName: "other"}, ExtKeyUsage: clientAuth})
+	if line := helloAndCreate(t, addr, []tls.Certificate{unknown}, ca.pool); !strings.Contains(line, "authentication required") {
+		t.Errorf("SESSION CREATE with an unmapped certificate = %q, want authentication required", line)
+	}
+
+	if line := helloAndCreate(t, addr, nil, ca.pool); !strings.Contains(line, "authentication required") {
+		t.Errorf("SESSION CREATE without a certificate = %q, want authentication required", line)
+	}
+}
+
+func TestServer_ClientCertUntrusted(t *testing.T) {
+	ca := newTestCA(t)
+	addr := startCertAuthServer(t, ca)
+
+	// A certificate for a valid user name from another CA fails the handshake.
+	other := newTestCA(t)
+	forged := other.issue(t, &x509.Certificate{
+		Subject:     pkix.Name{CommonName: "web"},
+		ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	})
+
+	conn, err := tls.Dial("tcp", addr, &tls.Config{RootCAs: ca.pool, Certificates: []tls.Certificate{forged}})
+	if err != nil {
+		return // Rejected during the handshake
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(2 * time.Second))
+	conn.Write([]byte("HELLO VERSION MIN=3.0 MAX=3.3\n"))
+	if line, err := bufio.NewReader(conn).ReadString('\n'); err == nil {
+		t.Errorf("HELLO with an untrusted certificate = %q, want the connection closed", line)
+	}
+}
+
+func TestCertUserNames(t *testing.T) {
+	spiffe, _ := url.Parse("spiffe://example.org/ns/apps/sa/web")
+	cert := &x509.Certificate{
+		Subject:        pkix.Name{CommonName: "web"},
+		DNSNames:       []string{"web.apps.svc", "web"},
+		EmailAddresses: []string{"web@example.org"},
+		URIs:           []*url.URL{spiffe},
+	}
+
+	tests := []struct {
+		field CertUserField
+		want  []string
+	}{
+		{CertUserNone, nil},
+		{CertUserCommonName, []string{"web"}},
+		{CertUserDNSName, []string{"web.apps.svc", "web"}},
+		{CertUserEmail, []string{"web@example.org"}},
+		{CertUserURI, []string{"spiffe://example.org/ns/apps/sa/web"}},
+	}
+	for _, tt := range tests {
+		got := certUserNames(cert, tt.field)
+		if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+			t.Errorf("certUserNames(%q) = %v, want %v", tt.field, got, tt.want)
+		}
+	}
+
+	if got := certUserNames(&x509.Certificate{}, CertUserCommonName); len(got) != 0 {
+		t.Errorf("certUserNames() with no CN = %v, want none", got)
+	}
+}
@@ -0,0 +1,97 @@
+package bridge
+
+import (
+	"net"
+	"sync"
+)
+
+// clientLimiter tracks connections and sessions per client IP so that
+// LimitConfig.MaxConnectionsPerClient and MaxSessionsPerClient can be
+// enforced. A zero limit disables the corresponding check.
+//
+// Thread-safety: All methods are safe for concurrent use.
+type clientLimiter struct {
+	mu sync.Mutex
+
+	// connections counts open control connections by client IP.
+	connections map[string]int
+
+	// sessions counts sessions (including reservations for SESSION CREATE
+	// commands still in progress) by client IP.
+	sessions map[string]int
+}
+
+// newClientLimiter creates an empty clientLimiter.
+func newClientLimiter() *clientLimiter {
+	return &clientLimiter{
+		connections: make(map[string]int),
+		sessions:    make(map[string]int),
+	}
+}
+
+// acquireConnection records a new connection from ip.
+// Returns false without recording it if ip already has max connections.
+func (l *clientLimiter) acquireConnection(ip string, max int) bool {
+	return l.acquire(l.connections, ip, max)
+}
+
+// releaseConnection records that a connection from ip has closed.
+func (l *clientLimiter) releaseConnection(ip string) {
+	l.release(l.connections, ip)
+}
+
+// acquireSession reserves a session slot for ip.
+// Returns false without reserving it if ip already has max sessions.
+func (l *clientLimiter) acquireSession(ip string, max int) bool {
+	return l.acquire(l.sessions, ip, max)
+}
+
+// releaseSession frees a session slot held by ip.
+func (l *clientLimiter) releaseSession(ip string) {
+	l.release(l.sessions, ip)
+}
+
+// connectionCount returns the number of open connections from ip.
+func (l *clientLimiter) connectionCount(ip string) int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.connections[ip]
+}
+
+// sessionCount returns the number of sessions held by ip.
+func (l *clientLimiter) sessionCount(ip string) int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.sessions[ip]
+}
+
+func (l *clientLimiter) acquire(counts map[string]int, ip string, max int) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if max > 0 && counts[ip] >= max {
+		return false
+	}
+	counts[ip]++
+	return true
+}
+
+func (l *clientLimiter) release(counts map[string]int, ip string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if counts[ip] <= 1 {
+		delete(counts, ip)
+		return
+	}
+	counts[ip]--
+}
+
+// clientHost returns the host part of a remote address, which identifies
+// the client for per-client limits. Addresses without a port are returned
+// unchanged.
+func clientHost(addr string) string {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return addr
+	}
+	return host
+}
@@ -0,0 +1,74 @@
+package bridge
+
+import "testing"
+
+func TestClientLimiter_Connections(t *testing.T) {
+	l := newClientLimiter()
+
+	if !l.acquireConnection("10.0.0.1", 2) {
+		t.Fatal("first connection should be accepted")
+	}
+	if !l.acquireConnection("10.0.0.1", 2) {
+		t.Fatal("second connection should be accepted")
+	}
+	if l.acquireConnection("10.0.0.1", 2) {
+		t.Error("third connection should be rejected")
+	}
+	if !l.acquireConnection("10.0.0.2", 2) {
+		t.Error("other clients should not be affected")
+	}
+
+	l.releaseConnection("10.0.0.1")
+	if got := l.connectionCount("10.0.0.1"); got != 1 {
+		t.Errorf("connectionCount() = %d, want 1", got)
+	}
+	if !l.acquireConnection("10.0.0.1", 2) {
+		t.Error("connection should be accepted after release")
+	}
+}
+
+func TestClientLimiter_NoLimit(t *testing.T) {
+	l := newClientLimiter()
+
+	for i := 0; i < 100; i++ {
+		if !l.acquireSession("10.0.0.1", 0) {
+			t.Fatalf("acquireSession() with no limit rejected at %d", i)
+		}
+	}
+	if got := l.sessionCount("10.0.0.1"); got != 100 {
+		t.Errorf("sessionCount() = %d, want 100", got)
+	}
+}
+
+func TestClientLimiter_ReleaseRemovesEntry(t *testing.T) {
+	l := newClientLimiter()
+
+	l.acquireSession("10.0.0.1", 1)
+	l.releaseSession("10.0.0.1")
+	l.releaseSession("10.0.0.1") // Extra release must not go negative
+
+	if _, ok := l.sessions["10.0.0.1"]; ok {
+		t.Error("released client should be removed from the map")
+	}
+	if !l.acquireSession("10.0.0.1", 1) {
+		t.Error("session should be accepted after release")
+	}
+}
+
+func TestClientHost(t *testing.T) {
+	tests := []struct {
+		addr string
+		want string
+	}{
+		{"127.0.0.1:7656", "127.0.0.1"},
+		{"[::1]:7656", "::1"},
+		{"/run/sam.sock", "/run/sam.sock"},
+		{"", ""},
+	}
+
+	for _, tt := range tests {
+		if got := clientHost(tt.addr); got != tt.want {
+			t.Errorf("clientHost(%q) = %q, want %q", tt.addr, got, tt.want)
+		}
+	}
+}
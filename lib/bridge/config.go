@@ -5,7 +5,15 @@ package bridge
 
 import (
 	"crypto/tls"
+	"fmt"
+	"io"
+	"maps"
+	"os"
+	"slices"
+	"strings"
 	"time"
+
+	"github.com/go-i2p/go-sam-bridge/lib/session"
 )
 
 // Default configuration values per SAMv3.md specification.
@@ -34,20 +42,39 @@ const (
 	// Per SAM 3.2, PING/PONG is used for keepalive.
 	DefaultPongTimeout = 30 * time.Second
 
+	// DefaultStreamLinger is how long a stream keeps forwarding in one
+	// direction after the other has been closed.
+	DefaultStreamLinger = session.DefaultStreamLinger
+
 	// DefaultReadBufferSize is the default buffer size for reading commands.
 	DefaultReadBufferSize = 8192
 
 	// DefaultMaxLineLength is the maximum allowed command line length.
 	// This prevents memory exhaustion from malicious clients.
 	DefaultMaxLineLength = 65536
+
+	// DefaultAuthMaxFailures is the number of consecutive failed password
+	// checks from one client or for one user before it is locked out.
+	DefaultAuthMaxFailures = 5
+
+	// DefaultAuthLockout is the length of the first lockout.
+	DefaultAuthLockout = 30 * time.Second
+
+	// DefaultAuthMaxLockout caps the lockout length as it doubles.
+	DefaultAuthMaxLockout = 15 * time.Minute
 )
 
 // Config holds the SAM bridge server configuration.
 // All fields have sensible defaults that can be overridden.
 type Config struct {
-	// ListenAddr is the TCP address to listen on (e.g., ":7656", "127.0.0.1:7656").
+	// ListenAddr is the address to listen on: a TCP address (e.g., ":7656",
+	// "127.0.0.1:7656") or a Unix socket path (e.g., "unix:/run/sam-bridge/sam.sock").
 	ListenAddr string
 
+	// UnixSocket holds the socket file mode and owner used when ListenAddr
+	// is a "unix:" address.
+	UnixSocket UnixSocketConfig
+
 	// I2CPAddr is the I2CP router address for tunnel management.
 	I2CPAddr string
 
@@ -67,6 +94,30 @@ type Config struct {
 
 	// Limits holds connection limits and buffer sizes.
 	Limits LimitConfig
+
+	// Access holds the networks clients may or may not connect from.
+	Access AccessConfig
+
+	// Stream holds the files STREAM sessions may use on the bridge host.
+	Stream StreamConfig
+
+	// RateLimit limits how fast clients may send commands.
+	RateLimit RateLimitConfig
+
+	// ProxyProtocol accepts PROXY protocol headers from trusted proxies.
+	ProxyProtocol ProxyProtocolConfig
+
+	// AuditLog receives an AuditRecord for every command handled, as one
+	// JSON object per line; use NewAuditFile to append to a file. Nil
+	// disables the audit log. The writer is not closed by the server, and
+	// is kept across Reload.
+	AuditLog io.Writer
+
+	// TranscriptDir, if set, receives a transcript of the control lines of
+	// each connection, one file per connection, for debugging clients.
+	// Secret option values are redacted; stream payloads are not recorded.
+	// The directory is created if needed.
+	TranscriptDir string
 }
 
 // AuthConfig holds authentication settings per SAM 3.2.
@@ -77,7 +128,60 @@ type AuthConfig struct {
 
 	// Users maps usernames to passwords for authentication.
 	// Empty map with Required=false disables authentication.
+	// Values may be plaintext or bcrypt hashes; plaintext is hashed on load.
 	Users map[string]string
+
+	// Backend persists users added or removed with AUTH commands, e.g. a
+	// NewHtpasswdFile. If nil, users are kept in memory only. AUTH ENABLE
+	// is not persisted, so set Required with a backend to keep
+	// authentication on across restarts.
+	Backend AuthBackend
+
+	// Lockout limits password guessing by client IP and by username.
+	Lockout LockoutConfig
+
+	// CertUser lets a verified TLS client certificate authenticate the
+	// connection before HELLO: the named field of the certificate is
+	// mapped to an existing user, and HELLO then needs no USER/PASSWORD.
+	// Requires a TLSConfig whose ClientAuth verifies client certificates.
+	// CertUserNone (the default) disables this.
+	CertUser CertUserField
+
+	// Roles maps usernames to role names. Users not listed have RoleUser.
+	Roles map[string]string
+
+	// Policies defines what each role may do, by role name. RoleAdmin and
+	// RoleUser default to AdminPolicy and DefaultUserPolicy unless defined
+	// here; every other role in Roles must be defined.
+	Policies map[string]Policy
+}
+
+// LockoutConfig controls brute-force protection for password checks.
+// Failures are counted separately for each client IP and each username;
+// once either reaches MaxFailures, further attempts from that client or
+// for that user are refused without checking the password until the
+// lockout ends. Each failure after a lockout doubles its length, up to
+// MaxDuration. A successful login resets both counts, and counts are
+// forgotten once MaxDuration passes without a failure or lockout.
+type LockoutConfig struct {
+	// MaxFailures is the number of consecutive failures that triggers a
+	// lockout (0 = no lockout).
+	MaxFailures int
+
+	// Duration is the length of the first lockout.
+	Duration time.Duration
+
+	// MaxDuration caps the lockout length.
+	MaxDuration time.Duration
+}
+
+// DefaultLockoutConfig returns the default brute-force protection settings.
+func DefaultLockoutConfig() LockoutConfig {
+	return LockoutConfig{
+		MaxFailures: DefaultAuthMaxFailures,
+		Duration:    DefaultAuthLockout,
+		MaxDuration: DefaultAuthMaxLockout,
+	}
 }
 
 // TimeoutConfig holds timeout settings for connections.
@@ -97,6 +201,17 @@ type TimeoutConfig struct {
 	// Per SAM 3.2, PING/PONG is used for keepalive.
 	// If a PONG is not received within this duration, the connection may be closed.
 	PongTimeout time.Duration
+
+	// KeepaliveInterval is how often the bridge sends PING to clients that
+	// negotiated SAM 3.2 or later (0 = disabled). Clients that do not answer
+	// within PongTimeout are disconnected and their sessions released.
+	KeepaliveInterval time.Duration
+
+	// StreamLinger is how long a stream keeps forwarding data in one
+	// direction after the other direction has reached EOF. The side that
+	// stopped sending is half-closed, so its peer can still send a reply
+	// until then (0 = close both at once, without waiting for a reply).
+	StreamLinger time.Duration
 }
 
 // LimitConfig holds buffer and connection limits.
@@ -110,10 +225,56 @@ type LimitConfig struct {
 	// MaxConnections is the maximum number of concurrent connections (0 = no limit).
 	MaxConnections int
 
+	// MaxConnectionsPerClient is the maximum concurrent connections per client IP (0 = no limit).
+	MaxConnectionsPerClient int
+
 	// MaxSessionsPerClient is the maximum sessions per client IP (0 = no limit).
 	MaxSessionsPerClient int
 }
 
+// StreamConfig holds the files on the bridge host that STREAM sessions may
+// use. Clients cannot name files outside these directories.
+type StreamConfig struct {
+	// AccessListDir is the directory of the peer lists STREAM sessions may
+	// name with sam.accessListFile (see session.ParseAccessList). Empty
+	// refuses sam.accessListFile.
+	AccessListDir string
+
+	// ForwardSocketDir is the directory of the Unix sockets STREAM FORWARD
+	// may forward to with PATH (see session.ResolveForwardPath). Empty
+	// refuses PATH.
+	ForwardSocketDir string
+}
+
+// RateLimitConfig limits how fast clients may send commands, with a token
+// bucket for each control connection and one shared by all connections
+// from a client IP. Each command takes its cost in tokens from both
+// buckets; if either holds too few, the command is refused with I2P_ERROR
+// rather than queued. Buckets start full and refill at their Rate.
+type RateLimitConfig struct {
+	// PerConnection is the bucket of each control connection.
+	PerConnection TokenBucketConfig
+
+	// PerClient is the bucket shared by all connections from a client IP.
+	PerClient TokenBucketConfig
+
+	// Costs maps commands, each either a verb ("NAMING") or a verb and
+	// action ("NAMING LOOKUP"), to the tokens they take. An entry for the
+	// verb and action wins over one for the verb. Commands not listed cost
+	// 1; a cost of 0 exempts a command.
+	Costs map[string]int
+}
+
+// TokenBucketConfig configures one token bucket of RateLimitConfig.
+type TokenBucketConfig struct {
+	// Rate is the number of tokens added per second (0 = no limit).
+	Rate float64
+
+	// Burst is the size of the bucket: how many tokens can be spent at
+	// once after a quiet period.
+	Burst int
+}
+
 // DefaultConfig returns a Config with default values per SAMv3.md.
 func DefaultConfig() *Config {
 	return &Config{
@@ -124,46 +285,154 @@ func DefaultConfig() *Config {
 		Auth: AuthConfig{
 			Required: false,
 			Users:    make(map[string]string),
+			Lockout:  DefaultLockoutConfig(),
 		},
 		Timeouts: TimeoutConfig{
-			Handshake:   DefaultHandshakeTimeout,
-			Command:     DefaultCommandTimeout,
-			Idle:        0, // No idle timeout by default
-			PongTimeout: DefaultPongTimeout,
+			Handshake:         DefaultHandshakeTimeout,
+			Command:           DefaultCommandTimeout,
+			Idle:              0, // No idle timeout by default
+			PongTimeout:       DefaultPongTimeout,
+			KeepaliveInterval: 0, // No server-initiated PING by default
+			StreamLinger:      DefaultStreamLinger,
 		},
 		Limits: LimitConfig{
-			ReadBufferSize:       DefaultReadBufferSize,
-			MaxLineLength:        DefaultMaxLineLength,
-			MaxConnections:       0, // No limit
-			MaxSessionsPerClient: 0, // No limit
+			ReadBufferSize:          DefaultReadBufferSize,
+			MaxLineLength:           DefaultMaxLineLength,
+			MaxConnections:          0, // No limit
+			MaxConnectionsPerClient: 0, // No limit
+			MaxSessionsPerClient:    0, // No limit
+		},
+		RateLimit: RateLimitConfig{
+			Costs: DefaultCommandCosts(), // Buckets disabled by default
 		},
 	}
 }
 
-// Validate checks the configuration for errors and returns an error if invalid.
+// Validate checks the configuration for errors and returns the first
+// problem found, or nil if the configuration is valid.
 func (c *Config) Validate() error {
+	if errs := c.ValidateAll(); len(errs) > 0 {
+		return errs[0]
+	}
+	return nil
+}
+
+// ValidateAll checks the configuration and returns every problem found,
+// each as a *ConfigError, in a fixed order: map entries are checked in
+// order of their keys. It returns nil if the configuration is valid.
+func (c *Config) ValidateAll() []error {
+	var errs []error
 	if c.ListenAddr == "" {
-		return &ConfigError{Field: "ListenAddr", Message: "cannot be empty"}
+		errs = append(errs, &ConfigError{Field: "ListenAddr", Message: "cannot be empty"})
+	}
+	if path, ok := UnixSocketPath(c.ListenAddr); ok && path == "" {
+		errs = append(errs, &ConfigError{Field: "ListenAddr", Message: "unix socket path cannot be empty"})
+	}
+	if c.UnixSocket.Mode&^os.ModePerm != 0 {
+		errs = append(errs, &ConfigError{Field: "UnixSocket.Mode", Message: "must only contain permission bits"})
 	}
 	if c.I2CPAddr == "" {
-		return &ConfigError{Field: "I2CPAddr", Message: "cannot be empty"}
+		errs = append(errs, &ConfigError{Field: "I2CPAddr", Message: "cannot be empty"})
 	}
 	if c.DatagramPort < 0 || c.DatagramPort > 65535 {
-		return &ConfigError{Field: "DatagramPort", Message: "must be 0-65535"}
+		errs = append(errs, &ConfigError{Field: "DatagramPort", Message: "must be 0-65535"})
+	}
+	if c.Auth.Lockout.MaxFailures < 0 {
+		errs = append(errs, &ConfigError{Field: "Auth.Lockout.MaxFailures", Message: "cannot be negative"})
+	}
+	if c.Auth.Lockout.MaxFailures > 0 {
+		if c.Auth.Lockout.Duration <= 0 {
+			errs = append(errs, &ConfigError{Field: "Auth.Lockout.Duration", Message: "must be positive"})
+		}
+		if c.Auth.Lockout.MaxDuration < c.Auth.Lockout.Duration {
+			errs = append(errs, &ConfigError{Field: "Auth.Lockout.MaxDuration", Message: "cannot be less than Duration"})
+		}
+	}
+	if !c.Auth.CertUser.valid() {
+		errs = append(errs, &ConfigError{Field: "Auth.CertUser", Message: "must be one of cn, dns, email or uri"})
+	} else if c.Auth.CertUser != CertUserNone && (c.TLSConfig == nil || c.TLSConfig.ClientAuth < tls.VerifyClientCertIfGiven) {
+		errs = append(errs, &ConfigError{Field: "Auth.CertUser", Message: "requires a TLSConfig that verifies client certificates"})
+	}
+	for _, user := range slices.Sorted(maps.Keys(c.Auth.Users)) {
+		password := c.Auth.Users[user]
+		if !isBcryptHash(password) && len(password) > maxPasswordLength {
+			errs = append(errs, &ConfigError{Field: "Auth.Users[" + user + "]", Message: fmt.Sprintf("password cannot be longer than %d bytes", maxPasswordLength)})
+		}
+	}
+	for _, role := range slices.Sorted(maps.Keys(c.Auth.Policies)) {
+		if msg := c.Auth.Policies[role].validate(); msg != "" {
+			errs = append(errs, &ConfigError{Field: "Auth.Policies[" + role + "]", Message: msg})
+		}
+	}
+	for _, user := range slices.Sorted(maps.Keys(c.Auth.Roles)) {
+		role := c.Auth.Roles[user]
+		if _, ok := c.Auth.Policies[role]; !ok && role != RoleAdmin && role != RoleUser {
+			errs = append(errs, &ConfigError{Field: "Auth.Roles[" + user + "]", Message: "unknown role " + role})
+		}
 	}
 	if c.Timeouts.Handshake < 0 {
-		return &ConfigError{Field: "Timeouts.Handshake", Message: "cannot be negative"}
+		errs = append(errs, &ConfigError{Field: "Timeouts.Handshake", Message: "cannot be negative"})
 	}
 	if c.Timeouts.Command < 0 {
-		return &ConfigError{Field: "Timeouts.Command", Message: "cannot be negative"}
+		errs = append(errs, &ConfigError{Field: "Timeouts.Command", Message: "cannot be negative"})
+	}
+	if c.Timeouts.Idle < 0 {
+		errs = append(errs, &ConfigError{Field: "Timeouts.Idle", Message: "cannot be negative"})
+	}
+	if c.Timeouts.PongTimeout < 0 {
+		errs = append(errs, &ConfigError{Field: "Timeouts.PongTimeout", Message: "cannot be negative"})
+	}
+	if c.Timeouts.KeepaliveInterval < 0 {
+		errs = append(errs, &ConfigError{Field: "Timeouts.KeepaliveInterval", Message: "cannot be negative"})
+	}
+	if c.Timeouts.StreamLinger < 0 {
+		errs = append(errs, &ConfigError{Field: "Timeouts.StreamLinger", Message: "cannot be negative"})
 	}
 	if c.Limits.ReadBufferSize <= 0 {
-		return &ConfigError{Field: "Limits.ReadBufferSize", Message: "must be positive"}
+		errs = append(errs, &ConfigError{Field: "Limits.ReadBufferSize", Message: "must be positive"})
 	}
 	if c.Limits.MaxLineLength <= 0 {
-		return &ConfigError{Field: "Limits.MaxLineLength", Message: "must be positive"}
+		errs = append(errs, &ConfigError{Field: "Limits.MaxLineLength", Message: "must be positive"})
 	}
-	return nil
+	if c.Limits.MaxConnections < 0 {
+		errs = append(errs, &ConfigError{Field: "Limits.MaxConnections", Message: "cannot be negative"})
+	}
+	if c.Limits.MaxConnectionsPerClient < 0 {
+		errs = append(errs, &ConfigError{Field: "Limits.MaxConnectionsPerClient", Message: "cannot be negative"})
+	}
+	if c.Limits.MaxSessionsPerClient < 0 {
+		errs = append(errs, &ConfigError{Field: "Limits.MaxSessionsPerClient", Message: "cannot be negative"})
+	}
+	if msg := c.RateLimit.PerConnection.validate(); msg != "" {
+		errs = append(errs, &ConfigError{Field: "RateLimit.PerConnection", Message: msg})
+	}
+	if msg := c.RateLimit.PerClient.validate(); msg != "" {
+		errs = append(errs, &ConfigError{Field: "RateLimit.PerClient", Message: msg})
+	}
+	for _, command := range slices.Sorted(maps.Keys(c.RateLimit.Costs)) {
+		cost := c.RateLimit.Costs[command]
+		if strings.TrimSpace(command) == "" {
+			errs = append(errs, &ConfigError{Field: "RateLimit.Costs", Message: "cannot contain an empty command"})
+		} else if cost < 0 {
+			errs = append(errs, &ConfigError{Field: "RateLimit.Costs[" + command + "]", Message: "cannot be negative"})
+		}
+	}
+	for i, p := range c.Access.Allow {
+		if !p.IsValid() {
+			errs = append(errs, &ConfigError{Field: fmt.Sprintf("Access.Allow[%d]", i), Message: "invalid prefix"})
+		}
+	}
+	for i, p := range c.Access.Deny {
+		if !p.IsValid() {
+			errs = append(errs, &ConfigError{Field: fmt.Sprintf("Access.Deny[%d]", i), Message: "invalid prefix"})
+		}
+	}
+	for i, p := range c.ProxyProtocol.TrustedProxies {
+		if !p.IsValid() {
+			errs = append(errs, &ConfigError{Field: fmt.Sprintf("ProxyProtocol.TrustedProxies[%d]", i), Message: "invalid prefix"})
+		}
+	}
+	return errs
 }
 
 // WithListenAddr returns a copy of the config with the listen address set.
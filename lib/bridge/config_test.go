@@ -2,8 +2,13 @@ package bridge
 
 import (
 	"crypto/tls"
+	"net/netip"
+	"os"
+	"strings"
 	"testing"
 	"time"
+
+	"github.com/go-i2p/go-sam-bridge/lib/session"
 )
 
 func TestDefaultConfig(t *testing.T) {
@@ -59,6 +64,97 @@ func TestConfig_Validate(t *testing.T) {
 			wantErr:   true,
 			wantField: "ListenAddr",
 		},
+		{
+			name:    "unix socket listen address",
+			modify:  func(c *Config) { c.ListenAddr = "unix:/run/sam-bridge/sam.sock" },
+			wantErr: false,
+		},
+		{
+			name:      "empty unix socket path",
+			modify:    func(c *Config) { c.ListenAddr = "unix:" },
+			wantErr:   true,
+			wantField: "ListenAddr",
+		},
+		{
+			name:      "unix socket mode with type bits",
+			modify:    func(c *Config) { c.UnixSocket.Mode = os.ModeSetuid | 0o660 },
+			wantErr:   true,
+			wantField: "UnixSocket.Mode",
+		},
+		{
+			name:      "negative lockout failures",
+			modify:    func(c *Config) { c.Auth.Lockout.MaxFailures = -1 },
+			wantErr:   true,
+			wantField: "Auth.Lockout.MaxFailures",
+		},
+		{
+			name:      "zero lockout duration",
+			modify:    func(c *Config) { c.Auth.Lockout.Duration = 0 },
+			wantErr:   true,
+			wantField: "Auth.Lockout.Duration",
+		},
+		{
+			name:      "lockout max below duration",
+			modify:    func(c *Config) { c.Auth.Lockout.MaxDuration = time.Second },
+			wantErr:   true,
+			wantField: "Auth.Lockout.MaxDuration",
+		},
+		{
+			name:    "lockout disabled",
+			modify:  func(c *Config) { c.Auth.Lockout = LockoutConfig{} },
+			wantErr: false,
+		},
+		{
+			name:      "password too long for bcrypt",
+			modify:    func(c *Config) { c.Auth.Users = map[string]string{"alice": strings.Repeat("x", 73)} },
+			wantErr:   true,
+			wantField: "Auth.Users[alice]",
+		},
+		{
+			name:    "bcrypt hash as password",
+			modify:  func(c *Config) { c.Auth.Users = map[string]string{"alice": "$2a$10$" + strings.Repeat("x", 80)} },
+			wantErr: false,
+		},
+		{
+			name:      "unknown cert user field",
+			modify:    func(c *Config) { c.Auth.CertUser = "serial" },
+			wantErr:   true,
+			wantField: "Auth.CertUser",
+		},
+		{
+			name:      "cert user without client verification",
+			modify:    func(c *Config) { c.TLSConfig = &tls.Config{}; c.Auth.CertUser = CertUserCommonName },
+			wantErr:   true,
+			wantField: "Auth.CertUser",
+		},
+		{
+			name: "cert user with client verification",
+			modify: func(c *Config) {
+				c.TLSConfig = &tls.Config{ClientAuth: tls.VerifyClientCertIfGiven}
+				c.Auth.CertUser = CertUserURI
+			},
+			wantErr: false,
+		},
+		{
+			name:      "role without policy",
+			modify:    func(c *Config) { c.Auth.Roles = map[string]string{"web": "app"} },
+			wantErr:   true,
+			wantField: "Auth.Roles[web]",
+		},
+		{
+			name: "role with policy",
+			modify: func(c *Config) {
+				c.Auth.Roles = map[string]string{"web": "app", "ops": RoleAdmin}
+				c.Auth.Policies = map[string]Policy{"app": {Commands: []string{"STREAM"}}}
+			},
+			wantErr: false,
+		},
+		{
+			name:      "policy with unknown style",
+			modify:    func(c *Config) { c.Auth.Policies = map[string]Policy{"app": {Styles: []session.Style{"TCP"}}} },
+			wantErr:   true,
+			wantField: "Auth.Policies[app]",
+		},
 		{
 			name:      "empty I2CP address",
 			modify:    func(c *Config) { c.I2CPAddr = "" },
@@ -104,6 +200,24 @@ func TestConfig_Validate(t *testing.T) {
 			wantErr:   true,
 			wantField: "Timeouts.Command",
 		},
+		{
+			name:      "negative idle timeout",
+			modify:    func(c *Config) { c.Timeouts.Idle = -1 * time.Second },
+			wantErr:   true,
+			wantField: "Timeouts.Idle",
+		},
+		{
+			name:      "negative keepalive interval",
+			modify:    func(c *Config) { c.Timeouts.KeepaliveInterval = -1 * time.Second },
+			wantErr:   true,
+			wantField: "Timeouts.KeepaliveInterval",
+		},
+		{
+			name:      "negative stream linger",
+			modify:    func(c *Config) { c.Timeouts.StreamLinger = -1 * time.Second },
+			wantErr:   true,
+			wantField: "Timeouts.StreamLinger",
+		},
 		{
 			name:      "zero read buffer size",
 			modify:    func(c *Config) { c.Limits.ReadBufferSize = 0 },
@@ -122,6 +236,53 @@ func TestConfig_Validate(t *testing.T) {
 			wantErr:   true,
 			wantField: "Limits.MaxLineLength",
 		},
+		{
+			name:      "negative max connections per client",
+			modify:    func(c *Config) { c.Limits.MaxConnectionsPerClient = -1 },
+			wantErr:   true,
+			wantField: "Limits.MaxConnectionsPerClient",
+		},
+		{
+			name:      "negative max sessions per client",
+			modify:    func(c *Config) { c.Limits.MaxSessionsPerClient = -1 },
+			wantErr:   true,
+			wantField: "Limits.MaxSessionsPerClient",
+		},
+		{
+			name:      "rate limit without burst",
+			modify:    func(c *Config) { c.RateLimit.PerConnection = TokenBucketConfig{Rate: 10} },
+			wantErr:   true,
+			wantField: "RateLimit.PerConnection",
+		},
+		{
+			name:      "negative client rate",
+			modify:    func(c *Config) { c.RateLimit.PerClient = TokenBucketConfig{Rate: -1, Burst: 10} },
+			wantErr:   true,
+			wantField: "RateLimit.PerClient",
+		},
+		{
+			name:      "negative command cost",
+			modify:    func(c *Config) { c.RateLimit.Costs = map[string]int{"NAMING LOOKUP": -1} },
+			wantErr:   true,
+			wantField: "RateLimit.Costs[NAMING LOOKUP]",
+		},
+		{
+			name:    "access lists",
+			modify:  func(c *Config) { c.Access.Allow = []netip.Prefix{netip.MustParsePrefix("10.0.0.0/8")} },
+			wantErr: false,
+		},
+		{
+			name:      "invalid deny prefix",
+			modify:    func(c *Config) { c.Access.Deny = []netip.Prefix{netip.MustParsePrefix("10.0.0.0/8"), {}} },
+			wantErr:   true,
+			wantField: "Access.Deny[1]",
+		},
+		{
+			name:      "invalid trusted proxy",
+			modify:    func(c *Config) { c.ProxyProtocol.TrustedProxies = []netip.Prefix{{}} },
+			wantErr:   true,
+			wantField: "ProxyProtocol.TrustedProxies[0]",
+		},
 	}
 
 	for _, tt := range tests {
@@ -150,6 +311,55 @@ func TestConfig_Validate(t *testing.T) {
 	}
 }
 
+func TestConfig_ValidateAll(t *testing.T) {
+	if errs := DefaultConfig().ValidateAll(); errs != nil {
+		t.Errorf("ValidateAll() = %v, want nil", errs)
+	}
+
+	cfg := DefaultConfig()
+	cfg.ListenAddr = ""
+	cfg.DatagramPort = -1
+	cfg.Limits.MaxLineLength = 0
+
+	errs := cfg.ValidateAll()
+	wantFields := []string{"ListenAddr", "DatagramPort", "Limits.MaxLineLength"}
+	if len(errs) != len(wantFields) {
+		t.Fatalf("ValidateAll() returned %d errors, want %d: %v", len(errs), len(wantFields), errs)
+	}
+	for i, err := range errs {
+		cfgErr, ok := err.(*ConfigError)
+		if !ok {
+			t.Fatalf("errs[%d] type = %T, want *ConfigError", i, err)
+		}
+		if cfgErr.Field != wantFields[i] {
+			t.Errorf("errs[%d].Field = %q, want %q", i, cfgErr.Field, wantFields[i])
+		}
+	}
+
+	if err := cfg.Validate(); err != errs[0] && err.Error() != errs[0].Error() {
+		t.Errorf("Validate() = %v, want first error %v", err, errs[0])
+	}
+}
+
+func TestConfig_ValidateAll_MapOrder(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Auth.Roles = map[string]string{"carol": "c", "alice": "a", "bob": "b"}
+	cfg.RateLimit.Costs = map[string]int{"STREAM": -1, "DEST": -1}
+
+	wantFields := []string{"Auth.Roles[alice]", "Auth.Roles[bob]", "Auth.Roles[carol]", "RateLimit.Costs[DEST]", "RateLimit.Costs[STREAM]"}
+	for run := 0; run < 5; run++ {
+		errs := cfg.ValidateAll()
+		if len(errs) != len(wantFields) {
+			t.Fatalf("ValidateAll() returned %d errors, want %d: %v", len(errs), len(wantFields), errs)
+		}
+		for i, err := range errs {
+			if field := err.(*ConfigError).Field; field != wantFields[i] {
+				t.Errorf("run %d: errs[%d].Field = %q, want %q", run, i, field, wantFields[i])
+			}
+		}
+	}
+}
+
 func TestConfig_WithListenAddr(t *testing.T) {
 	cfg := DefaultConfig()
 	newCfg := cfg.WithListenAddr("127.0.0.1:8080")
@@ -3,10 +3,15 @@ package bridge
 import (
 	"bufio"
 	"net"
+	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
+// nextConnectionID allocates Connection IDs.
+var nextConnectionID atomic.Uint64
+
 // ConnectionState represents the current state of a client connection.
 type ConnectionState int
 
@@ -23,6 +28,10 @@ const (
 	// StateSessionBound indicates a session has been created and bound.
 	StateSessionBound
 
+	// StateStreaming indicates the socket carries stream data after
+	// STREAM CONNECT or STREAM ACCEPT and no longer accepts commands.
+	StateStreaming
+
 	// StateClosed indicates the connection has been closed.
 	StateClosed
 )
@@ -38,6 +47,8 @@ func (s ConnectionState) String() string {
 		return "READY"
 	case StateSessionBound:
 		return "SESSION_BOUND"
+	case StateStreaming:
+		return "STREAMING"
 	case StateClosed:
 		return "CLOSED"
 	default:
@@ -51,6 +62,9 @@ func (s ConnectionState) String() string {
 type Connection struct {
 	mu sync.RWMutex
 
+	// id uniquely identifies the connection within the process.
+	id uint64
+
 	// conn is the underlying network connection.
 	conn net.Conn
 
@@ -84,6 +98,25 @@ type Connection struct {
 	// pendingPing tracks an outstanding PING awaiting PONG response.
 	// Nil when no PING is pending.
 	pendingPing *PendingPing
+
+	// busy is set while a command handler runs; see SetBusy.
+	busy bool
+
+	// forwards holds the STREAM FORWARD listeners created on this connection.
+	forwards []net.Listener
+
+	// commandTokens is the connection's RateLimitConfig.PerConnection
+	// bucket. It is guarded by the server's rateLimiter, not mu.
+	commandTokens tokenBucket
+
+	// transcript records the control lines of the connection, if enabled.
+	transcript *Transcript
+}
+
+// activeConnCounter is implemented by STREAM FORWARD listeners that report
+// how many forwarded connections are still open.
+type activeConnCounter interface {
+	ActiveConns() int
 }
 
 // PendingPing tracks an outstanding PING command awaiting PONG.
@@ -100,6 +133,7 @@ type PendingPing struct {
 func NewConnection(conn net.Conn, bufferSize int) *Connection {
 	now := time.Now()
 	return &Connection{
+		id:           nextConnectionID.Add(1),
 		conn:         conn,
 		reader:       bufio.NewReaderSize(conn, bufferSize),
 		state:        StateNew,
@@ -109,6 +143,11 @@ func NewConnection(conn net.Conn, bufferSize int) *Connection {
 	}
 }
 
+// ID returns the connection's process-unique identifier.
+func (c *Connection) ID() uint64 {
+	return c.id
+}
+
 // Conn returns the underlying net.Conn.
 func (c *Connection) Conn() net.Conn {
 	c.mu.RLock()
@@ -198,6 +237,36 @@ func (c *Connection) UnbindSession() {
 	}
 }
 
+// SetStreaming marks the connection as a stream data socket.
+func (c *Connection) SetStreaming() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.state != StateClosed {
+		c.state = StateStreaming
+	}
+}
+
+// SetForwards records the STREAM FORWARD listeners created on this connection.
+func (c *Connection) SetForwards(listeners []net.Listener) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.forwards = append(c.forwards[:0], listeners...)
+}
+
+// ActiveForwards returns the number of open connections accepted by this
+// connection's STREAM FORWARD listeners.
+func (c *Connection) ActiveForwards() int {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	n := 0
+	for _, l := range c.forwards {
+		if counter, ok := l.(activeConnCounter); ok {
+			n += counter.ActiveConns()
+		}
+	}
+	return n
+}
+
 // CreatedAt returns when the connection was established.
 func (c *Connection) CreatedAt() time.Time {
 	c.mu.RLock()
@@ -226,6 +295,12 @@ func (c *Connection) RemoteAddr() string {
 	return c.remoteAddr
 }
 
+// ClientIP returns the host part of the client's remote address.
+// It identifies the client for per-client limits.
+func (c *Connection) ClientIP() string {
+	return clientHost(c.RemoteAddr())
+}
+
 // IdleDuration returns how long the connection has been idle.
 func (c *Connection) IdleDuration() time.Duration {
 	c.mu.RLock()
@@ -248,9 +323,30 @@ func (c *Connection) Close() error {
 		return nil
 	}
 	c.state = StateClosed
+	if c.transcript != nil {
+		_ = c.transcript.Close()
+	}
 	return c.conn.Close()
 }
 
+// SetTranscript records the connection's control lines in t until the
+// connection closes or starts carrying stream data.
+func (c *Connection) SetTranscript(t *Transcript) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.transcript = t
+}
+
+// RecordLine adds a line received from the client to the transcript, if
+// one is being recorded.
+func (c *Connection) RecordLine(line string) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if c.transcript != nil && c.state != StateStreaming {
+		c.transcript.Record(TranscriptIn, line)
+	}
+}
+
 // IsClosed returns true if the connection is closed.
 func (c *Connection) IsClosed() bool {
 	c.mu.RLock()
@@ -272,10 +368,18 @@ func (c *Connection) SetWriteDeadline(t time.Time) error {
 	return c.conn.SetWriteDeadline(t)
 }
 
-// Write writes data to the underlying connection.
+// Write writes data to the underlying connection. The lines written are
+// recorded in the transcript, if any.
 func (c *Connection) Write(data []byte) (int, error) {
 	c.mu.RLock()
 	defer c.mu.RUnlock()
+	if c.transcript != nil && c.state != StateStreaming {
+		for _, line := range strings.SplitAfter(string(data), "\n") {
+			if line != "" {
+				c.transcript.Record(TranscriptOut, line)
+			}
+		}
+	}
 	return c.conn.Write(data)
 }
 
@@ -316,6 +420,30 @@ func (c *Connection) ClearPendingPing() {
 	c.pendingPing = nil
 }
 
+// SetBusy marks whether a command handler is running on the connection.
+// While it is, such as during a STREAM ACCEPT waiting for a peer, the
+// command loop cannot read the client's PONG, so keepalive neither sends
+// PING nor enforces the PONG and idle timeouts. Clearing it counts as
+// activity and restarts the deadline of a PING sent before the handler ran.
+func (c *Connection) SetBusy(busy bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.busy = busy
+	if !busy {
+		c.lastActivity = time.Now()
+		if c.pendingPing != nil {
+			c.pendingPing.SentAt = c.lastActivity
+		}
+	}
+}
+
+// IsBusy reports whether a command handler is running on the connection.
+func (c *Connection) IsBusy() bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.busy
+}
+
 // IsPongOverdue returns true if a PING is pending and the timeout has elapsed.
 // timeout should be the configured PongTimeout duration.
 func (c *Connection) IsPongOverdue(timeout time.Duration) bool {
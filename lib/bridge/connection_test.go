@@ -58,6 +58,7 @@ func TestConnectionState_String(t *testing.T) {
 		{StateHandshaking, "HANDSHAKING"},
 		{StateReady, "READY"},
 		{StateSessionBound, "SESSION_BOUND"},
+		{StateStreaming, "STREAMING"},
 		{StateClosed, "CLOSED"},
 		{ConnectionState(99), "UNKNOWN"},
 	}
@@ -413,3 +414,38 @@ func TestConnection_IsPongOverdue(t *testing.T) {
 		t.Error("expected not overdue with longer timeout")
 	}
 }
+
+// countingListener is a net.Listener that reports a fixed number of
+// active forwarded connections.
+type countingListener struct {
+	net.Listener
+	active int
+}
+
+func (l *countingListener) ActiveConns() int { return l.active }
+
+func TestConnection_ActiveForwards(t *testing.T) {
+	c := NewConnection(newMockConn(), 1024)
+	if got := c.ActiveForwards(); got != 0 {
+		t.Errorf("ActiveForwards() = %d with no forwards, want 0", got)
+	}
+
+	c.SetForwards([]net.Listener{&countingListener{active: 2}, &countingListener{active: 1}})
+	if got := c.ActiveForwards(); got != 3 {
+		t.Errorf("ActiveForwards() = %d, want 3", got)
+	}
+}
+
+func TestConnection_SetStreaming(t *testing.T) {
+	c := NewConnection(newMockConn(), 1024)
+	c.SetStreaming()
+	if c.State() != StateStreaming {
+		t.Errorf("State() = %v, want %v", c.State(), StateStreaming)
+	}
+
+	c.Close()
+	c.SetStreaming()
+	if c.State() != StateClosed {
+		t.Errorf("State() = %v after Close, want %v", c.State(), StateClosed)
+	}
+}
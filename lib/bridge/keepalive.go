@@ -0,0 +1,129 @@
+package bridge
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"time"
+
+	"github.com/go-i2p/logger"
+
+	"github.com/go-i2p/go-sam-bridge/lib/protocol"
+)
+
+// keepalive runs for the lifetime of a control connection's command loop.
+// It sends PING at Timeouts.KeepaliveInterval once the client has negotiated
+// SAM 3.2 or later, and closes the connection if a PONG is overdue or the
+// client has been idle for longer than Timeouts.Idle. Nothing is checked
+// while a command handler runs (see Connection.SetBusy). Closing the connection
+// unblocks the command loop, whose cleanup releases the bound session.
+// Timeouts changed by Reload are picked up on the next tick, but a
+// connection opened while both keepalive and the idle timeout were off is
+// not checked.
+//
+// keepalive returns when stop is closed or the connection has been closed.
+func (s *Server) keepalive(c *Connection, stop <-chan struct{}) {
+	timeouts := s.Config().Timeouts
+	tick := keepaliveTick(timeouts)
+	if tick <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(tick)
+	defer ticker.Stop()
+
+	var lastPing time.Time
+	for {
+		select {
+		case <-stop:
+			return
+		case <-s.done:
+			return
+		case <-ticker.C:
+		}
+
+		if c.IsClosed() {
+			return
+		}
+
+		if current := s.Config().Timeouts; current != timeouts {
+			timeouts = current
+			if tick = keepaliveTick(timeouts); tick <= 0 {
+				return
+			}
+			ticker.Reset(tick)
+		}
+
+		if c.IsBusy() {
+			continue
+		}
+
+		if timeouts.Idle > 0 && c.IdleDuration() > timeouts.Idle {
+			log.WithFields(logger.Fields{"pkg": "bridge", "func": "Server.keepalive", "remote": c.RemoteAddr(), "session": c.SessionID(), "idle": c.IdleDuration()}).Info("Closing idle SAM connection")
+			s.sendIdleTimeoutError(c)
+			c.Close()
+			return
+		}
+
+		if c.IsPongOverdue(timeouts.PongTimeout) {
+			log.WithFields(logger.Fields{"pkg": "bridge", "func": "Server.keepalive", "remote": c.RemoteAddr(), "session": c.SessionID()}).Info("Closing SAM connection: PONG not received")
+			s.sendPongTimeoutError(c)
+			c.Close()
+			return
+		}
+
+		if timeouts.KeepaliveInterval <= 0 || c.GetPendingPing() != nil {
+			continue
+		}
+		if !protocol.VersionSupportsPing(c.Version()) || time.Since(lastPing) < timeouts.KeepaliveInterval {
+			continue
+		}
+
+		lastPing = time.Now()
+		if err := s.SendPing(c, newPingNonce()); err != nil {
+			log.WithFields(logger.Fields{"pkg": "bridge", "func": "Server.keepalive", "remote": c.RemoteAddr()}).WithError(err).Debug("Failed to send keepalive PING")
+			c.Close()
+			return
+		}
+	}
+}
+
+// keepaliveTick returns how often keepalive checks a connection: the
+// shortest of the configured keepalive interval, PONG timeout and idle
+// timeout. Returns 0 if neither keepalive nor idle enforcement is enabled.
+func keepaliveTick(timeouts TimeoutConfig) time.Duration {
+	var tick time.Duration
+	shorter := func(d time.Duration) {
+		if d > 0 && (tick == 0 || d < tick) {
+			tick = d
+		}
+	}
+
+	if timeouts.KeepaliveInterval > 0 {
+		shorter(timeouts.KeepaliveInterval)
+		shorter(timeouts.PongTimeout)
+	}
+	shorter(timeouts.Idle)
+	return tick
+}
+
+// newPingNonce returns random text for a keepalive PING.
+// The client echoes it back in its PONG.
+func newPingNonce() string {
+	buf := make([]byte, 8)
+	if _, err := rand.Read(buf); err != nil {
+		return time.Now().Format("20060102150405.000000000")
+	}
+	return hex.EncodeToString(buf)
+}
+
+// sendIdleTimeoutError sends an idle timeout error response before the
+// connection is closed for inactivity.
+func (s *Server) sendIdleTimeoutError(c *Connection) {
+	response := protocol.NewResponse("SESSION").
+		WithAction("STATUS").
+		WithResult("I2P_ERROR").
+		WithMessage("connection timeout: idle")
+
+	// Best effort - ignore write errors since we're closing anyway
+	_ = s.sendResponse(c, response)
+}
@@ -0,0 +1,221 @@
+package bridge
+
+import (
+	"bufio"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-i2p/go-sam-bridge/lib/handler"
+	"github.com/go-i2p/go-sam-bridge/lib/protocol"
+)
+
+func TestKeepaliveTick(t *testing.T) {
+	tests := []struct {
+		name     string
+		timeouts TimeoutConfig
+		want     time.Duration
+	}{
+		{
+			name:     "disabled",
+			timeouts: TimeoutConfig{PongTimeout: 30 * time.Second},
+			want:     0,
+		},
+		{
+			name:     "keepalive only",
+			timeouts: TimeoutConfig{KeepaliveInterval: time.Minute, PongTimeout: 30 * time.Second},
+			want:     30 * time.Second,
+		},
+		{
+			name:     "idle only ignores pong timeout",
+			timeouts: TimeoutConfig{Idle: 5 * time.Minute, PongTimeout: 30 * time.Second},
+			want:     5 * time.Minute,
+		},
+		{
+			name:     "shortest wins",
+			timeouts: TimeoutConfig{KeepaliveInterval: 10 * time.Second, Idle: time.Minute, PongTimeout: 30 * time.Second},
+			want:     10 * time.Second,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := keepaliveTick(tt.timeouts); got != tt.want {
+				t.Errorf("keepaliveTick() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewPingNonce(t *testing.T) {
+	a, b := newPingNonce(), newPingNonce()
+	if a == "" || strings.ContainsAny(a, " \n") {
+		t.Errorf("newPingNonce() = %q, want single token", a)
+	}
+	if a == b {
+		t.Error("newPingNonce() returned the same nonce twice")
+	}
+}
+
+// startKeepaliveServer starts a server with HELLO and SESSION CREATE
+// handlers and returns a connected client that has completed HELLO.
+func startKeepaliveServer(t *testing.T, config *Config, version string) (*Server, net.Conn, *bufio.Reader) {
+	t.Helper()
+
+	hello := func(ctx *handler.Context, cmd *protocol.Command) (*protocol.Response, error) {
+		return protocol.NewResponse("HELLO").
+			WithAction("REPLY").
+			WithResult("OK").
+			WithVersion(version), nil
+	}
+	server, addr := startTestServer(t, config, map[string]handler.HandlerFunc{"HELLO": hello}, nil)
+
+	conn, err := net.Dial("tcp", addr)
+	if err != nil {
+		t.Fatalf("net.Dial() error = %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+
+	reader := bufio.NewReader(conn)
+	conn.Write([]byte("HELLO VERSION MIN=3.0 MAX=" + version + "\n"))
+	if _, err := reader.ReadString('\n'); err != nil {
+		t.Fatalf("ReadString() error = %v", err)
+	}
+	return server, conn, reader
+}
+
+func TestServer_KeepalivePing(t *testing.T) {
+	config := DefaultConfig()
+	config.Timeouts.KeepaliveInterval = 50 * time.Millisecond
+	config.Timeouts.PongTimeout = time.Second
+
+	_, conn, reader := startKeepaliveServer(t, config, "3.3")
+
+	conn.SetReadDeadline(time.Now().Add(time.Second))
+	line, err := reader.ReadString('\n')
+	if err != nil {
+		t.Fatalf("ReadString() error = %v", err)
+	}
+	if !strings.HasPrefix(line, "PING ") {
+		t.Fatalf("got %q, want PING <nonce>", line)
+	}
+
+	// Answer the PING; the bridge should keep pinging rather than disconnect.
+	conn.Write([]byte("PONG " + strings.TrimPrefix(strings.TrimSpace(line), "PING ") + "\n"))
+
+	conn.SetReadDeadline(time.Now().Add(time.Second))
+	line, err = reader.ReadString('\n')
+	if err != nil {
+		t.Fatalf("ReadString() error = %v", err)
+	}
+	if !strings.HasPrefix(line, "PING ") {
+		t.Errorf("got %q after PONG, want another PING", line)
+	}
+}
+
+func TestServer_KeepalivePongTimeout(t *testing.T) {
+	config := DefaultConfig()
+	config.Timeouts.KeepaliveInterval = 50 * time.Millisecond
+	config.Timeouts.PongTimeout = 100 * time.Millisecond
+
+	server, conn, reader := startKeepaliveServer(t, config, "3.3")
+
+	conn.Write([]byte("SESSION CREATE STYLE=STREAM ID=keepalive DESTINATION=TRANSIENT\n"))
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			t.Fatalf("ReadString() error = %v", err)
+		}
+		if strings.HasPrefix(line, "PING") {
+			continue
+		}
+		if !strings.Contains(line, "RESULT=OK") {
+			t.Fatalf("SESSION CREATE = %q, want RESULT=OK", line)
+		}
+		break
+	}
+
+	// Never answer PING; the connection must be closed with an error.
+	conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	var lines []string
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			break
+		}
+		lines = append(lines, line)
+	}
+	if len(lines) == 0 || !strings.Contains(lines[len(lines)-1], "PONG not received") {
+		t.Errorf("lines = %q, want PONG timeout error before close", lines)
+	}
+
+	deadline := time.Now().Add(time.Second)
+	for server.clients.sessionCount("127.0.0.1") != 0 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	if got := server.clients.sessionCount("127.0.0.1"); got != 0 {
+		t.Errorf("sessionCount() = %d after PONG timeout, want 0", got)
+	}
+}
+
+func TestServer_KeepalivePausedDuringCommand(t *testing.T) {
+	config := DefaultConfig()
+	config.Timeouts.KeepaliveInterval = 50 * time.Millisecond
+	config.Timeouts.PongTimeout = 100 * time.Millisecond
+	config.Timeouts.Idle = 100 * time.Millisecond
+
+	server, conn, reader := startKeepaliveServer(t, config, "3.3")
+	server.Router().RegisterFunc("STREAM ACCEPT", func(ctx *handler.Context, cmd *protocol.Command) (*protocol.Response, error) {
+		// Wait for a peer for several keepalive intervals.
+		time.Sleep(400 * time.Millisecond)
+		return protocol.NewResponse("STREAM").
+			WithAction("STATUS").
+			WithResult("OK"), nil
+	})
+
+	conn.Write([]byte("STREAM ACCEPT ID=keepalive\n"))
+	conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	line, err := reader.ReadString('\n')
+	if err != nil {
+		t.Fatalf("ReadString() error = %v, want the STREAM STATUS reply", err)
+	}
+	if !strings.HasPrefix(line, "STREAM STATUS") || !strings.Contains(line, "RESULT=OK") {
+		t.Errorf("got %q, want STREAM STATUS RESULT=OK with no PING or timeout before it", line)
+	}
+}
+
+func TestServer_KeepaliveSkipsOldVersions(t *testing.T) {
+	config := DefaultConfig()
+	config.Timeouts.KeepaliveInterval = 20 * time.Millisecond
+	config.Timeouts.PongTimeout = 50 * time.Millisecond
+
+	_, conn, reader := startKeepaliveServer(t, config, "3.1")
+
+	// SAM 3.1 clients do not understand PING and must not be disconnected.
+	conn.SetReadDeadline(time.Now().Add(200 * time.Millisecond))
+	if line, err := reader.ReadString('\n'); err == nil {
+		t.Errorf("got %q, want no PING for SAM 3.1 client", line)
+	} else if netErr, ok := err.(net.Error); !ok || !netErr.Timeout() {
+		t.Errorf("ReadString() error = %v, want timeout (connection should stay open)", err)
+	}
+}
+
+func TestServer_IdleTimeout(t *testing.T) {
+	config := DefaultConfig()
+	config.Timeouts.Idle = 100 * time.Millisecond
+
+	_, conn, reader := startKeepaliveServer(t, config, "3.3")
+
+	conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	line, err := reader.ReadString('\n')
+	if err != nil {
+		t.Fatalf("ReadString() error = %v", err)
+	}
+	if !strings.Contains(line, "connection timeout: idle") {
+		t.Errorf("got %q, want idle timeout error", line)
+	}
+	if _, err := reader.ReadString('\n'); err == nil {
+		t.Error("expected connection to be closed after idle timeout")
+	}
+}
@@ -0,0 +1,84 @@
+package bridge
+
+import (
+	"strings"
+	"time"
+
+	"github.com/go-i2p/go-sam-bridge/lib/metrics"
+	"github.com/go-i2p/go-sam-bridge/lib/protocol"
+)
+
+// Label values are restricted to SAM protocol tokens so that clients cannot
+// create unbounded metric series by sending arbitrary verbs or actions.
+var (
+	metricVerbs = tokenSet(
+		protocol.VerbHello, protocol.VerbSession, protocol.VerbStream,
+		protocol.VerbDatagram, protocol.VerbDatagram2, protocol.VerbDatagram3,
+		protocol.VerbRaw, protocol.VerbDest, protocol.VerbNaming,
+		protocol.VerbPing, protocol.VerbPong, protocol.VerbAuth,
+		protocol.VerbQuit, protocol.VerbStop, protocol.VerbExit, protocol.VerbHelp,
+	)
+	metricActions = tokenSet(
+		protocol.ActionVersion, protocol.ActionReply, protocol.ActionStatus,
+		protocol.ActionCreate, protocol.ActionAdd, protocol.ActionRemove,
+		protocol.ActionList, protocol.ActionConnect, protocol.ActionAccept,
+		protocol.ActionForward, protocol.ActionSend, protocol.ActionReceived,
+		protocol.ActionGenerate, protocol.ActionLookup, protocol.ActionEnable,
+		protocol.ActionDisable,
+	)
+	metricResults = tokenSet(
+		protocol.ResultOK, protocol.ResultAlreadyAccepting, protocol.ResultCantReachPeer,
+		protocol.ResultDuplicatedDest, protocol.ResultDuplicatedID, protocol.ResultI2PError,
+		protocol.ResultInvalidKey, protocol.ResultInvalidID, protocol.ResultKeyNotFound,
+		protocol.ResultPeerNotFound, protocol.ResultTimeout, protocol.ResultNoVersion,
+		protocol.ResultLeasesetNotFound,
+	)
+)
+
+func tokenSet(tokens ...string) map[string]struct{} {
+	set := make(map[string]struct{}, len(tokens))
+	for _, t := range tokens {
+		set[t] = struct{}{}
+	}
+	return set
+}
+
+// metricLabel returns value upper-cased if it is in known, "" if it is empty,
+// and other otherwise.
+func metricLabel(value string, known map[string]struct{}, other string) string {
+	if value == "" {
+		return ""
+	}
+	value = strings.ToUpper(value)
+	if _, ok := known[value]; ok {
+		return value
+	}
+	return other
+}
+
+// recordCommand records the outcome and latency of a dispatched command.
+// err is the internal error returned by the handler, if any.
+func recordCommand(cmd *protocol.Command, response *protocol.Response, err error, elapsed time.Duration) {
+	verb := metricLabel(cmd.Verb, metricVerbs, "UNKNOWN")
+	action := ""
+	if verb != "UNKNOWN" {
+		action = metricLabel(cmd.Action, metricActions, "OTHER")
+	}
+
+	var result string
+	switch {
+	case err != nil:
+		result = "ERROR"
+	case response == nil:
+		result = "NONE"
+	default:
+		result = metricLabel(getOptionValue(response.Options, "RESULT"), metricResults, "OTHER")
+	}
+
+	metrics.Commands.Inc(verb, action, result)
+	metrics.CommandDuration.Observe(elapsed.Seconds(), verb, action)
+
+	if verb == protocol.VerbStream && (action == protocol.ActionConnect || action == protocol.ActionAccept) {
+		metrics.StreamResults.Inc(action, result)
+	}
+}
@@ -0,0 +1,65 @@
+package bridge
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/go-i2p/go-sam-bridge/lib/metrics"
+	"github.com/go-i2p/go-sam-bridge/lib/protocol"
+)
+
+func TestMetricLabel(t *testing.T) {
+	tests := []struct {
+		value string
+		want  string
+	}{
+		{"", ""},
+		{"STREAM", "STREAM"},
+		{"stream", "STREAM"},
+		{"BOGUS", "OTHER"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			if got := metricLabel(tt.value, metricVerbs, "OTHER"); got != tt.want {
+				t.Errorf("metricLabel(%q) = %q, want %q", tt.value, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRecordCommand(t *testing.T) {
+	connect := &protocol.Command{Verb: "STREAM", Action: "CONNECT"}
+	failed := protocol.NewResponse("STREAM").WithAction("STATUS").WithResult("CANT_REACH_PEER")
+
+	commandsBefore := metrics.Commands.Value("STREAM", "CONNECT", "CANT_REACH_PEER")
+	streamBefore := metrics.StreamResults.Value("CONNECT", "CANT_REACH_PEER")
+	durationBefore := metrics.CommandDuration.Count("STREAM", "CONNECT")
+
+	recordCommand(connect, failed, nil, 10*time.Millisecond)
+
+	if got := metrics.Commands.Value("STREAM", "CONNECT", "CANT_REACH_PEER") - commandsBefore; got != 1 {
+		t.Errorf("sam_commands_total delta = %v, want 1", got)
+	}
+	if got := metrics.StreamResults.Value("CONNECT", "CANT_REACH_PEER") - streamBefore; got != 1 {
+		t.Errorf("sam_stream_results_total delta = %v, want 1", got)
+	}
+	if got := metrics.CommandDuration.Count("STREAM", "CONNECT") - durationBefore; got != 1 {
+		t.Errorf("sam_command_duration_seconds count delta = %d, want 1", got)
+	}
+}
+
+func TestRecordCommand_BoundsLabels(t *testing.T) {
+	unknown := &protocol.Command{Verb: "XYZZY", Action: "PLUGH"}
+	before := metrics.Commands.Value("UNKNOWN", "", "ERROR")
+
+	recordCommand(unknown, nil, errors.New("boom"), time.Millisecond)
+
+	if got := metrics.Commands.Value("UNKNOWN", "", "ERROR") - before; got != 1 {
+		t.Errorf("unknown command delta = %v, want 1", got)
+	}
+	if got := metrics.Commands.Value("XYZZY", "PLUGH", "ERROR"); got != 0 {
+		t.Errorf("client-supplied verb created a series: %v", got)
+	}
+}
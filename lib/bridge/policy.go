@@ -0,0 +1,143 @@
+package bridge
+
+import (
+	"strings"
+
+	"github.com/go-i2p/go-sam-bridge/lib/protocol"
+	"github.com/go-i2p/go-sam-bridge/lib/session"
+)
+
+// Built-in role names for AuthConfig.Roles.
+const (
+	// RoleAdmin is the role allowed to run AUTH commands. Unless redefined
+	// in AuthConfig.Policies, its policy is AdminPolicy.
+	RoleAdmin = "admin"
+
+	// RoleUser is the role of users not listed in AuthConfig.Roles. Unless
+	// redefined in AuthConfig.Policies, its policy is DefaultUserPolicy.
+	RoleUser = "user"
+)
+
+// Policy lists what the users of a role may do once authenticated. It is
+// enforced by the server only while authentication is enabled, since
+// until then there is no user to apply it to, except that AUTH commands
+// are kept to admins whenever a user has an Admin role (see
+// AuthStore.HasAdmin). HELLO, PING, QUIT, STOP, EXIT and HELP are always
+// allowed.
+type Policy struct {
+	// Commands lists the commands the role may run, each either a verb
+	// ("NAMING") or a verb and action ("STREAM CONNECT"). Nil allows all.
+	// AUTH commands are governed by Admin instead.
+	Commands []string
+
+	// Styles lists the session styles SESSION CREATE and SESSION ADD may
+	// use. Nil allows all. PRIMARY also allows its alias MASTER.
+	Styles []session.Style
+
+	// PersistentKeys allows SESSION CREATE with a DESTINATION private key,
+	// rather than only TRANSIENT.
+	PersistentKeys bool
+
+	// Forward allows STREAM FORWARD.
+	Forward bool
+
+	// Admin allows AUTH commands (ENABLE, DISABLE, ADD, REMOVE, LIST).
+	Admin bool
+}
+
+// AdminPolicy returns the default policy of RoleAdmin: everything.
+func AdminPolicy() Policy {
+	return Policy{PersistentKeys: true, Forward: true, Admin: true}
+}
+
+// DefaultUserPolicy returns the default policy of RoleUser: everything
+// except AUTH commands.
+func DefaultUserPolicy() Policy {
+	return Policy{PersistentKeys: true, Forward: true}
+}
+
+// policyExempt holds the verbs every connection may use.
+var policyExempt = map[string]bool{
+	"HELLO": true,
+	"PING":  true,
+	"QUIT":  true,
+	"STOP":  true,
+	"EXIT":  true,
+	"HELP":  true,
+}
+
+// validate returns a description of the first invalid entry, or "".
+func (p Policy) validate() string {
+	for _, c := range p.Commands {
+		if strings.TrimSpace(c) == "" {
+			return "Commands cannot contain an empty entry"
+		}
+	}
+	for _, style := range p.Styles {
+		if !session.Style(strings.ToUpper(string(style))).IsValid() {
+			return "unknown session style " + string(style)
+		}
+	}
+	return ""
+}
+
+// check returns why p forbids cmd, or "" if it is allowed.
+func (p Policy) check(cmd *protocol.Command) string {
+	verb := strings.ToUpper(cmd.Verb)
+	action := strings.ToUpper(cmd.Action)
+
+	switch {
+	case policyExempt[verb]:
+		return ""
+	case isAuthCommand(cmd):
+		if !p.Admin {
+			return "AUTH commands require the admin role"
+		}
+		return ""
+	case !p.allowsCommand(verb, action):
+		return strings.TrimSpace(verb+" "+action) + " not permitted"
+	}
+
+	if isNewSessionCommand(cmd) {
+		if style := session.Style(strings.ToUpper(cmd.Get("STYLE"))); !p.allowsStyle(style) {
+			return "session style " + string(style) + " not permitted"
+		}
+		if action == "CREATE" && !p.PersistentKeys {
+			if dest := cmd.Get("DESTINATION"); dest != "" && !strings.EqualFold(dest, "TRANSIENT") {
+				return "persistent destination keys not permitted"
+			}
+		}
+	}
+	if verb == "STREAM" && action == "FORWARD" && !p.Forward {
+		return "STREAM FORWARD not permitted"
+	}
+	return ""
+}
+
+// allowsCommand reports whether Commands lists the verb or verb and action.
+func (p Policy) allowsCommand(verb, action string) bool {
+	if p.Commands == nil {
+		return true
+	}
+	for _, c := range p.Commands {
+		allowedVerb, allowedAction, _ := strings.Cut(strings.ToUpper(strings.TrimSpace(c)), " ")
+		if allowedVerb == verb && (allowedAction == "" || strings.TrimSpace(allowedAction) == action) {
+			return true
+		}
+	}
+	return false
+}
+
+// allowsStyle reports whether Styles lists style.
+func (p Policy) allowsStyle(style session.Style) bool {
+	if p.Styles == nil {
+		return true
+	}
+	for _, allowed := range p.Styles {
+		allowed = session.Style(strings.ToUpper(string(allowed)))
+		if allowed == style || (allowed.IsPrimary() && style.IsPrimary()) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,134 @@
+package bridge
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/go-i2p/go-sam-bridge/lib/protocol"
+	"github.com/go-i2p/go-sam-bridge/lib/session"
+)
+
+func TestPolicy_Check(t *testing.T) {
+	restricted := Policy{
+		Commands: []string{"SESSION", "STREAM CONNECT", "naming lookup"},
+		Styles:   []session.Style{session.StyleStream, session.StylePrimary},
+	}
+
+	tests := []struct {
+		name       string
+		policy     Policy
+		cmd        *protocol.Command
+		wantReason string // substring; empty means allowed
+	}{
+		{"user runs streams", DefaultUserPolicy(), &protocol.Command{Verb: "STREAM", Action: "CONNECT"}, ""},
+		{"user cannot AUTH", DefaultUserPolicy(), &protocol.Command{Verb: "AUTH", Action: "DISABLE"}, "admin role"},
+		{"admin can AUTH", AdminPolicy(), &protocol.Command{Verb: "AUTH", Action: "ADD"}, ""},
+		{"exempt verbs", Policy{Commands: []string{}}, &protocol.Command{Verb: "PING"}, ""},
+		{"listed verb", restricted, &protocol.Command{Verb: "SESSION", Action: "REMOVE"}, ""},
+		{"listed verb and action", restricted, &protocol.Command{Verb: "STREAM", Action: "CONNECT"}, ""},
+		{"case-insensitive entry", restricted, &protocol.Command{Verb: "NAMING", Action: "LOOKUP"}, ""},
+		{"unlisted action", restricted, &protocol.Command{Verb: "STREAM", Action: "ACCEPT"}, "STREAM ACCEPT not permitted"},
+		{"unlisted verb", restricted, &protocol.Command{Verb: "DEST", Action: "GENERATE"}, "DEST GENERATE not permitted"},
+		{
+			"allowed style",
+			restricted,
+			&protocol.Command{Verb: "SESSION", Action: "CREATE", Options: map[string]string{"STYLE": "STREAM", "DESTINATION": "TRANSIENT"}},
+			"",
+		},
+		{
+			"MASTER alias of PRIMARY",
+			restricted,
+			&protocol.Command{Verb: "SESSION", Action: "CREATE", Options: map[string]string{"STYLE": "MASTER", "DESTINATION": "TRANSIENT"}},
+			"",
+		},
+		{
+			"forbidden style",
+			restricted,
+			&protocol.Command{Verb: "SESSION", Action: "ADD", Options: map[string]string{"STYLE": "RAW"}},
+			"session style RAW not permitted",
+		},
+		{
+			"persistent keys forbidden",
+			restricted,
+			&protocol.Command{Verb: "SESSION", Action: "CREATE", Options: map[string]string{"STYLE": "STREAM", "DESTINATION": "AAAAprivatekey"}},
+			"persistent destination keys",
+		},
+		{
+			"persistent keys allowed",
+			DefaultUserPolicy(),
+			&protocol.Command{Verb: "SESSION", Action: "CREATE", Options: map[string]string{"STYLE": "STREAM", "DESTINATION": "AAAAprivatekey"}},
+			"",
+		},
+		{"forward forbidden", Policy{}, &protocol.Command{Verb: "STREAM", Action: "FORWARD"}, "STREAM FORWARD not permitted"},
+		{"forward allowed", DefaultUserPolicy(), &protocol.Command{Verb: "STREAM", Action: "FORWARD"}, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reason := tt.policy.check(tt.cmd)
+			if tt.wantReason == "" && reason != "" {
+				t.Errorf("check() = %q, want allowed", reason)
+			}
+			if tt.wantReason != "" && !strings.Contains(reason, tt.wantReason) {
+				t.Errorf("check() = %q, want it to contain %q", reason, tt.wantReason)
+			}
+		})
+	}
+}
+
+func TestPolicy_Validate(t *testing.T) {
+	if msg := (Policy{Commands: []string{"SESSION"}, Styles: []session.Style{"stream"}}).validate(); msg != "" {
+		t.Errorf("validate() = %q, want valid", msg)
+	}
+	if msg := (Policy{Commands: []string{" "}}).validate(); msg == "" {
+		t.Error("validate() with an empty command = \"\", want error")
+	}
+	if msg := (Policy{Styles: []session.Style{"TCP"}}).validate(); !strings.Contains(msg, "TCP") {
+		t.Errorf("validate() = %q, want it to name the unknown style", msg)
+	}
+}
+
+func TestAuthStore_Policy(t *testing.T) {
+	app := Policy{Commands: []string{"STREAM"}}
+	store := NewAuthStoreFromConfig(AuthConfig{
+		Roles:    map[string]string{"ops": RoleAdmin, "web": "app"},
+		Policies: map[string]Policy{"app": app},
+	})
+
+	if got := store.Role("ops"); got != RoleAdmin {
+		t.Errorf("Role(ops) = %q, want %q", got, RoleAdmin)
+	}
+	if got := store.Role("someone"); got != RoleUser {
+		t.Errorf("Role(someone) = %q, want %q", got, RoleUser)
+	}
+	if !store.Policy("ops").Admin {
+		t.Error("Policy(ops).Admin = false, want the built-in admin policy")
+	}
+	if p := store.Policy("someone"); p.Admin || !p.Forward {
+		t.Errorf("Policy(someone) = %+v, want DefaultUserPolicy", p)
+	}
+	if p := store.Policy("web"); len(p.Commands) != 1 || p.Commands[0] != "STREAM" {
+		t.Errorf("Policy(web) = %+v, want the app policy", p)
+	}
+	if !store.HasAdmin() {
+		t.Error("HasAdmin() = false with ops as admin")
+	}
+
+	// Replace swaps the roles.
+	if err := store.Replace(AuthConfig{}); err != nil {
+		t.Fatalf("Replace() error = %v", err)
+	}
+	if got := store.Role("ops"); got != RoleUser {
+		t.Errorf("Role(ops) after Replace = %q, want %q", got, RoleUser)
+	}
+	if store.HasAdmin() {
+		t.Error("HasAdmin() = true without roles")
+	}
+	store.Replace(AuthConfig{
+		Roles:    map[string]string{"web": "app"},
+		Policies: map[string]Policy{"app": {Admin: true}},
+	})
+	if !store.HasAdmin() {
+		t.Error("HasAdmin() = false with a custom role allowing AUTH commands")
+	}
+}
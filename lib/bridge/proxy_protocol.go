@@ -0,0 +1,310 @@
+package bridge
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/netip"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-i2p/logger"
+
+	"github.com/go-i2p/go-sam-bridge/lib/session"
+)
+
+// ProxyProtocolConfig enables the HAProxy PROXY protocol on the control
+// listener, so that a load balancer in front of the bridge can pass on the
+// address of each client. Connections from TrustedProxies must start with
+// a version 1 or 2 PROXY header, whose source address then replaces the
+// proxy's as the connection's remote address for limits, access lists,
+// handlers and logging. Connections from other addresses are served as
+// direct clients; a PROXY header from them is not parsed.
+type ProxyProtocolConfig struct {
+	// TrustedProxies lists the networks of the proxies allowed to send
+	// PROXY headers. Empty disables the PROXY protocol.
+	TrustedProxies []netip.Prefix
+}
+
+// trusts reports whether the peer at addr is a trusted proxy.
+func (p ProxyProtocolConfig) trusts(addr net.Addr) bool {
+	ip, ok := addrIP(addr)
+	if !ok {
+		return false
+	}
+	for _, prefix := range p.TrustedProxies {
+		if prefix.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// PROXY protocol header limits and markers, per the HAProxy specification.
+const (
+	// proxyV1MaxLength is the longest version 1 header, CRLF included.
+	proxyV1MaxLength = 107
+
+	// proxyV2HeaderLength is the length of the fixed part of a version 2
+	// header: signature, version and command, family and length.
+	proxyV2HeaderLength = 16
+
+	proxyV2CommandLocal = 0x0
+	proxyV2CommandProxy = 0x1
+
+	proxyV2FamilyInet  = 0x1
+	proxyV2FamilyInet6 = 0x2
+)
+
+// proxyV1Prefix starts every version 1 header.
+var proxyV1Prefix = []byte("PROXY ")
+
+// proxyV2Signature starts every version 2 header.
+var proxyV2Signature = []byte("\r\n\r\n\x00\r\nQUIT\n")
+
+// proxyConn is a connection from a trusted proxy whose PROXY header has
+// been read. It reports the client's address as its remote address.
+type proxyConn struct {
+	net.Conn
+
+	// reader holds any bytes read past the header.
+	reader *bufio.Reader
+
+	// remote is the client address from the header, or the proxy's
+	// address for LOCAL and UNKNOWN headers.
+	remote net.Addr
+}
+
+// Read reads from the data that followed the PROXY header.
+func (c *proxyConn) Read(b []byte) (int, error) {
+	return c.reader.Read(b)
+}
+
+// RemoteAddr returns the client address given by the PROXY header.
+func (c *proxyConn) RemoteAddr() net.Addr {
+	return c.remote
+}
+
+// CloseWrite closes the write side of the proxy connection, so that
+// forwarded streams can be half-closed through it.
+func (c *proxyConn) CloseWrite() error {
+	return session.CloseWrite(c.Conn)
+}
+
+// readProxyHeader reads a version 1 or 2 PROXY header from conn and
+// returns the connection to use in its place.
+func readProxyHeader(conn net.Conn) (net.Conn, error) {
+	// Only peek as far as the shortest header needs, so that a complete
+	// "PROXY UNKNOWN\r\n" with nothing after it is not left waiting for
+	// more bytes.
+	reader := bufio.NewReader(conn)
+	prefix, err := reader.Peek(len(proxyV1Prefix))
+	if err != nil {
+		return nil, fmt.Errorf("reading PROXY header: %w", err)
+	}
+
+	var remote net.Addr
+	switch {
+	case bytes.Equal(prefix, proxyV1Prefix):
+		remote, err = readProxyV1(reader)
+	case bytes.HasPrefix(proxyV2Signature, prefix):
+		var sig []byte
+		if sig, err = reader.Peek(len(proxyV2Signature)); err != nil {
+			return nil, fmt.Errorf("reading PROXY header: %w", err)
+		}
+		if !bytes.Equal(sig, proxyV2Signature) {
+			return nil, errors.New("missing PROXY header")
+		}
+		remote, err = readProxyV2(reader)
+	default:
+		err = errors.New("missing PROXY header")
+	}
+	if err != nil {
+		return nil, err
+	}
+	if remote == nil {
+		remote = conn.RemoteAddr()
+	}
+	return &proxyConn{Conn: conn, reader: reader, remote: remote}, nil
+}
+
+// readProxyV1 reads a version 1 header such as
+// "PROXY TCP4 192.0.2.1 198.51.100.1 56324 7656\r\n". It returns a nil
+// address for "PROXY UNKNOWN".
+func readProxyV1(reader *bufio.Reader) (net.Addr, error) {
+	var line []byte
+	for !bytes.HasSuffix(line, []byte("\r\n")) {
+		if len(line) >= proxyV1MaxLength {
+			return nil, errors.New("PROXY v1 header too long")
+		}
+		b, err := reader.ReadByte()
+		if err != nil {
+			return nil, fmt.Errorf("reading PROXY v1 header: %w", err)
+		}
+		line = append(line, b)
+	}
+
+	fields := strings.Split(strings.TrimSuffix(string(line), "\r\n"), " ")
+	if len(fields) >= 2 && fields[1] == "UNKNOWN" {
+		return nil, nil
+	}
+	if len(fields) != 6 || (fields[1] != "TCP4" && fields[1] != "TCP6") {
+		return nil, fmt.Errorf("malformed PROXY v1 header %q", line)
+	}
+	ip, err := netip.ParseAddr(fields[2])
+	if err != nil || ip.Is4() != (fields[1] == "TCP4") {
+		return nil, fmt.Errorf("invalid PROXY v1 source address %q", fields[2])
+	}
+	port, err := strconv.ParseUint(fields[4], 10, 16)
+	if err != nil {
+		return nil, fmt.Errorf("invalid PROXY v1 source port %q", fields[4])
+	}
+	return net.TCPAddrFromAddrPort(netip.AddrPortFrom(ip, uint16(port))), nil
+}
+
+// readProxyV2 reads a binary version 2 header. It returns a nil address
+// for LOCAL commands, such as proxy health checks, and for address
+// families other than IPv4 and IPv6.
+func readProxyV2(reader *bufio.Reader) (net.Addr, error) {
+	header := make([]byte, proxyV2HeaderLength)
+	if _, err := io.ReadFull(reader, header); err != nil {
+		return nil, fmt.Errorf("reading PROXY v2 header: %w", err)
+	}
+	if version := header[12] >> 4; version != 2 {
+		return nil, fmt.Errorf("unsupported PROXY version %d", version)
+	}
+	command := header[12] & 0x0f
+	family := header[13] >> 4
+
+	// The addresses are followed by optional TLVs, which are skipped.
+	body := make([]byte, binary.BigEndian.Uint16(header[14:16]))
+	if _, err := io.ReadFull(reader, body); err != nil {
+		return nil, fmt.Errorf("reading PROXY v2 addresses: %w", err)
+	}
+
+	switch command {
+	case proxyV2CommandLocal:
+		return nil, nil
+	case proxyV2CommandProxy:
+	default:
+		return nil, fmt.Errorf("unsupported PROXY v2 command %d", command)
+	}
+
+	var ipLen int
+	switch family {
+	case proxyV2FamilyInet:
+		ipLen = 4
+	case proxyV2FamilyInet6:
+		ipLen = 16
+	default:
+		return nil, nil
+	}
+	// Source address, destination address, source port, destination port.
+	if len(body) < 2*ipLen+4 {
+		return nil, errors.New("PROXY v2 address block too short")
+	}
+	ip, _ := netip.AddrFromSlice(body[:ipLen])
+	port := binary.BigEndian.Uint16(body[2*ipLen:])
+	return net.TCPAddrFromAddrPort(netip.AddrPortFrom(ip, port)), nil
+}
+
+// proxyListener reads the PROXY headers of connections from trusted
+// proxies before Accept returns them. Headers are read in a goroutine per
+// connection, so a proxy that is slow to send one does not hold up other
+// clients. Connections whose header is missing or malformed are closed.
+type proxyListener struct {
+	net.Listener
+	server *Server
+
+	start sync.Once
+	conns chan net.Conn
+	err   error
+	done  chan struct{}
+}
+
+// ProxyListener wraps ln so that connections from the trusted proxies of
+// the server's ProxyProtocol settings have their PROXY header read, and
+// report the client address as their remote address. ListenAndServe does
+// this itself; use it with Serve and a listener of your own. For TLS, ln
+// must be the plain TCP listener beneath tls.NewListener, since proxies
+// send the header before the TLS handshake.
+func (s *Server) ProxyListener(ln net.Listener) net.Listener {
+	return &proxyListener{
+		Listener: ln,
+		server:   s,
+		conns:    make(chan net.Conn),
+		done:     make(chan struct{}),
+	}
+}
+
+// Accept returns the next connection, with its PROXY header read if it
+// came from a trusted proxy.
+func (l *proxyListener) Accept() (net.Conn, error) {
+	l.start.Do(func() { go l.acceptLoop() })
+	select {
+	case conn := <-l.conns:
+		return conn, nil
+	case <-l.done:
+		return nil, l.err
+	}
+}
+
+// acceptLoop accepts connections from the underlying listener until it
+// fails, handing them to Accept.
+func (l *proxyListener) acceptLoop() {
+	for {
+		conn, err := l.Listener.Accept()
+		if err != nil {
+			var netErr net.Error
+			if errors.As(err, &netErr) && netErr.Timeout() {
+				continue
+			}
+			l.err = err
+			close(l.done)
+			return
+		}
+		if !l.server.Config().ProxyProtocol.trusts(conn.RemoteAddr()) {
+			l.deliver(conn)
+			continue
+		}
+		go l.readHeader(conn)
+	}
+}
+
+// readHeader reads the PROXY header of a connection from a trusted proxy
+// within the handshake timeout and delivers the connection.
+func (l *proxyListener) readHeader(conn net.Conn) {
+	timeout := l.server.Config().Timeouts.Handshake
+	if timeout <= 0 {
+		timeout = DefaultHandshakeTimeout
+	}
+	if err := conn.SetReadDeadline(time.Now().Add(timeout)); err != nil {
+		conn.Close()
+		return
+	}
+	proxied, err := readProxyHeader(conn)
+	if err == nil {
+		err = conn.SetReadDeadline(time.Time{})
+	}
+	if err != nil {
+		log.WithFields(logger.Fields{"pkg": "bridge", "func": "proxyListener.readHeader", "proxy": conn.RemoteAddr().String()}).WithError(err).Warn("Rejecting connection: invalid PROXY protocol header")
+		conn.Close()
+		return
+	}
+	l.deliver(proxied)
+}
+
+// deliver hands conn to Accept, or closes it if the listener has stopped.
+func (l *proxyListener) deliver(conn net.Conn) {
+	select {
+	case l.conns <- conn:
+	case <-l.done:
+		conn.Close()
+	}
+}
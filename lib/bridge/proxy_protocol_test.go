@@ -0,0 +1,190 @@
+package bridge
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"io"
+	"net"
+	"net/netip"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-i2p/go-sam-bridge/lib/handler"
+	"github.com/go-i2p/go-sam-bridge/lib/protocol"
+)
+
+// bufferConn is a net.Conn that reads from a fixed buffer.
+type bufferConn struct {
+	net.Conn
+	r io.Reader
+}
+
+func (c *bufferConn) Read(b []byte) (int, error) { return c.r.Read(b) }
+func (c *bufferConn) RemoteAddr() net.Addr {
+	return &net.TCPAddr{IP: net.ParseIP("127.0.0.1"), Port: 50000}
+}
+
+// proxyV2Header builds a version 2 header with the given command, family
+// and address block.
+func proxyV2Header(command, family byte, body []byte) []byte {
+	header := append([]byte(nil), proxyV2Signature...)
+	header = append(header, 0x20|command, family<<4|0x1)
+	header = binary.BigEndian.AppendUint16(header, uint16(len(body)))
+	return append(header, body...)
+}
+
+func TestReadProxyHeader(t *testing.T) {
+	ipv4 := []byte{192, 0, 2, 7, 127, 0, 0, 1, 0x9c, 0x40, 0x1d, 0xe8}
+	ipv6 := append(append(netip.MustParseAddr("2001:db8::7").AsSlice(), netip.MustParseAddr("::1").AsSlice()...), 0x9c, 0x40, 0x1d, 0xe8)
+	withTLV := append(append([]byte(nil), ipv4...), 0x04, 0x00, 0x02, 'h', 'i')
+
+	tests := []struct {
+		name   string
+		header []byte
+		want   string
+	}{
+		{"v1 TCP4", []byte("PROXY TCP4 192.0.2.7 127.0.0.1 40000 7656\r\n"), "192.0.2.7:40000"},
+		{"v1 TCP6", []byte("PROXY TCP6 2001:db8::7 ::1 40000 7656\r\n"), "[2001:db8::7]:40000"},
+		{"v1 UNKNOWN", []byte("PROXY UNKNOWN\r\n"), "127.0.0.1:50000"},
+		{"v2 IPv4", proxyV2Header(proxyV2CommandProxy, proxyV2FamilyInet, ipv4), "192.0.2.7:40000"},
+		{"v2 IPv6", proxyV2Header(proxyV2CommandProxy, proxyV2FamilyInet6, ipv6), "[2001:db8::7]:40000"},
+		{"v2 TLVs", proxyV2Header(proxyV2CommandProxy, proxyV2FamilyInet, withTLV), "192.0.2.7:40000"},
+		{"v2 LOCAL", proxyV2Header(proxyV2CommandLocal, 0, nil), "127.0.0.1:50000"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data := append(append([]byte(nil), tt.header...), "HELLO VERSION\n"...)
+			conn, err := readProxyHeader(&bufferConn{r: bytes.NewReader(data)})
+			if err != nil {
+				t.Fatalf("readProxyHeader() error = %v", err)
+			}
+			if got := conn.RemoteAddr().String(); got != tt.want {
+				t.Errorf("RemoteAddr() = %s, want %s", got, tt.want)
+			}
+			rest, _ := io.ReadAll(conn)
+			if string(rest) != "HELLO VERSION\n" {
+				t.Errorf("data after header = %q, want %q", rest, "HELLO VERSION\n")
+			}
+		})
+	}
+}
+
+// TestReadProxyHeader_NoData checks that a header is read without waiting
+// for data after it, even one shorter than the version 2 signature.
+func TestReadProxyHeader_NoData(t *testing.T) {
+	for _, header := range []string{
+		"PROXY UNKNOWN\r\n",
+		"PROXY TCP4 192.0.2.7 127.0.0.1 40000 7656\r\n",
+		string(proxyV2Header(proxyV2CommandLocal, 0, nil)),
+	} {
+		client, server := net.Pipe()
+		server.SetReadDeadline(time.Now().Add(2 * time.Second))
+		go client.Write([]byte(header))
+
+		if _, err := readProxyHeader(server); err != nil {
+			t.Errorf("readProxyHeader(%q) with no data after it: error = %v", header, err)
+		}
+		client.Close()
+		server.Close()
+	}
+}
+
+func TestReadProxyHeader_Invalid(t *testing.T) {
+	tests := []struct {
+		name   string
+		header string
+	}{
+		{"no header", "HELLO VERSION MIN=3.0 MAX=3.3\n"},
+		{"v1 too long", "PROXY TCP4 " + strings.Repeat("1", 100) + "\r\n"},
+		{"v1 bad address", "PROXY TCP4 example.org 127.0.0.1 40000 7656\r\n"},
+		{"v1 family mismatch", "PROXY TCP4 2001:db8::7 ::1 40000 7656\r\n"},
+		{"v1 bad port", "PROXY TCP4 192.0.2.7 127.0.0.1 99999 7656\r\n"},
+		{"v1 missing fields", "PROXY TCP4 192.0.2.7\r\n"},
+		{"v2 short addresses", string(proxyV2Header(proxyV2CommandProxy, proxyV2FamilyInet, []byte{192, 0, 2, 7}))},
+		{"v2 bad command", string(proxyV2Header(0x7, proxyV2FamilyInet, make([]byte, 12)))},
+		{"truncated", "PROXY TCP4 192.0.2.7"},
+		{"v2 bad signature", "\r\n\r\n\x00\r\nQUIT!" + strings.Repeat("\x00", 8)},
+	}
+	for _, tt := range tests {
+		if _, err := readProxyHeader(&bufferConn{r: strings.NewReader(tt.header)}); err == nil {
+			t.Errorf("readProxyHeader(%s) error = nil, want error", tt.name)
+		}
+	}
+}
+
+func TestProxyProtocolConfig_Trusts(t *testing.T) {
+	p := ProxyProtocolConfig{TrustedProxies: []netip.Prefix{netip.MustParsePrefix("10.0.0.0/8")}}
+	if !p.trusts(&net.TCPAddr{IP: net.ParseIP("10.1.2.3")}) {
+		t.Error("trusts(10.1.2.3) = false, want true")
+	}
+	if p.trusts(&net.TCPAddr{IP: net.ParseIP("192.0.2.1")}) {
+		t.Error("trusts(192.0.2.1) = true, want false")
+	}
+	if p.trusts(&net.UnixAddr{Name: "/run/sam.sock", Net: "unix"}) {
+		t.Error("trusts(unix socket) = true, want false")
+	}
+	if (ProxyProtocolConfig{}).trusts(&net.TCPAddr{IP: net.ParseIP("10.1.2.3")}) {
+		t.Error("empty ProxyProtocolConfig trusts a proxy, want none")
+	}
+}
+
+// startProxyServer starts a server that trusts loopback as a PROXY
+// protocol proxy and denies clients in 198.51.100.0/24.
+func startProxyServer(t *testing.T) (*Server, string) {
+	t.Helper()
+
+	config := DefaultConfig()
+	config.ProxyProtocol.TrustedProxies = []netip.Prefix{netip.MustParsePrefix("127.0.0.0/8")}
+	config.Access.Deny = []netip.Prefix{netip.MustParsePrefix("198.51.100.0/24")}
+
+	hello := func(ctx *handler.Context, cmd *protocol.Command) (*protocol.Response, error) {
+		return protocol.NewResponse("HELLO").
+			WithAction("REPLY").
+			WithResult("OK").
+			WithMessage(ctx.RemoteAddr()).
+			WithVersion("3.3"), nil
+	}
+	return startTestServer(t, config, map[string]handler.HandlerFunc{"HELLO": hello}, (*Server).ProxyListener)
+}
+
+// proxyHello sends a PROXY header and HELLO, returning the HELLO reply or
+// the read error.
+func proxyHello(t *testing.T, addr, header string) (string, error) {
+	t.Helper()
+	conn, err := net.Dial("tcp", addr)
+	if err != nil {
+		t.Fatalf("net.Dial() error = %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	conn.SetDeadline(time.Now().Add(2 * time.Second))
+	conn.Write([]byte(header + "HELLO VERSION MIN=3.0 MAX=3.3\n"))
+	return bufio.NewReader(conn).ReadString('\n')
+}
+
+func TestServer_ProxyProtocol(t *testing.T) {
+	server, addr := startProxyServer(t)
+
+	line, err := proxyHello(t, addr, "PROXY TCP4 192.0.2.7 127.0.0.1 40000 7656\r\n")
+	if err != nil {
+		t.Fatalf("HELLO through a proxy: error = %v", err)
+	}
+	if !strings.Contains(line, "192.0.2.7:40000") {
+		t.Errorf("HELLO reply = %q, want the client address in the handler context", line)
+	}
+	conns := server.Connections()
+	if len(conns) != 1 || conns[0].RemoteAddr != "192.0.2.7:40000" {
+		t.Errorf("Connections() = %+v, want one from 192.0.2.7:40000", conns)
+	}
+
+	// Access lists apply to the client address, not the proxy's.
+	if line, err := proxyHello(t, addr, "PROXY TCP4 198.51.100.7 127.0.0.1 40000 7656\r\n"); err == nil {
+		t.Errorf("HELLO from a denied client through a proxy = %q, want the connection closed", line)
+	}
+
+	// A trusted proxy must send a header.
+	if line, err := proxyHello(t, addr, ""); err == nil {
+		t.Errorf("HELLO from a trusted proxy without a header = %q, want the connection closed", line)
+	}
+}
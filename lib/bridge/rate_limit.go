@@ -0,0 +1,189 @@
+package bridge
+
+import (
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-i2p/go-sam-bridge/lib/protocol"
+)
+
+// rateLimitPruneInterval is how often refilled client buckets are dropped.
+const rateLimitPruneInterval = time.Minute
+
+// Rate limit scopes, reported when a command is refused.
+const (
+	rateLimitConnection = "connection"
+	rateLimitClient     = "client"
+)
+
+// DefaultCommandCosts returns the default RateLimitConfig.Costs: commands
+// that build tunnels, generate keys or query the network cost more than
+// the 1 token of other commands.
+func DefaultCommandCosts() map[string]int {
+	return map[string]int{
+		"SESSION CREATE": 10,
+		"SESSION ADD":    10,
+		"DEST GENERATE":  5,
+		"NAMING LOOKUP":  5,
+	}
+}
+
+// enabled reports whether the bucket limits anything.
+func (b TokenBucketConfig) enabled() bool {
+	return b.Rate > 0
+}
+
+// validate returns a description of the first invalid setting, or "".
+func (b TokenBucketConfig) validate() string {
+	switch {
+	case b.Rate < 0:
+		return "Rate cannot be negative"
+	case b.Burst < 0:
+		return "Burst cannot be negative"
+	case b.Rate > 0 && b.Burst == 0:
+		return "Burst must be positive when Rate is set"
+	}
+	return ""
+}
+
+// cost returns the tokens cmd takes. An entry for the verb and action
+// takes precedence over one for the verb alone.
+func (r RateLimitConfig) cost(cmd *protocol.Command) int {
+	verb := strings.ToUpper(cmd.Verb)
+	action := strings.ToUpper(cmd.Action)
+
+	verbCost, verbListed := 0, false
+	for key, c := range r.Costs {
+		costVerb, costAction, _ := strings.Cut(strings.ToUpper(strings.TrimSpace(key)), " ")
+		costAction = strings.TrimSpace(costAction)
+		switch {
+		case costVerb != verb:
+		case costAction == "":
+			verbCost, verbListed = c, true
+		case costAction == action:
+			return c
+		}
+	}
+	if verbListed {
+		return verbCost
+	}
+	return 1
+}
+
+// tokenBucket holds the tokens left in one bucket. The zero value is a
+// full bucket.
+type tokenBucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// refill adds the tokens earned since the bucket was last used, up to
+// cfg.Burst.
+func (b *tokenBucket) refill(now time.Time, cfg TokenBucketConfig) {
+	if b.last.IsZero() {
+		b.tokens = float64(cfg.Burst)
+	} else {
+		b.tokens = min(float64(cfg.Burst), b.tokens+now.Sub(b.last).Seconds()*cfg.Rate)
+	}
+	b.last = now
+}
+
+// full reports whether the bucket would be full at now.
+func (b *tokenBucket) full(now time.Time, cfg TokenBucketConfig) bool {
+	return b.tokens+now.Sub(b.last).Seconds()*cfg.Rate >= float64(cfg.Burst)
+}
+
+// rateLimiter applies RateLimitConfig to commands. The bucket of each
+// connection is kept on the Connection; the buckets of client IPs are kept
+// here and dropped once they have refilled.
+//
+// Thread-safety: All methods are safe for concurrent use.
+type rateLimiter struct {
+	mu sync.Mutex
+
+	// now returns the current time; replaced in tests.
+	now func() time.Time
+
+	clients map[string]*tokenBucket
+
+	lastPrune time.Time
+}
+
+// newRateLimiter creates a rateLimiter with every bucket full.
+func newRateLimiter() *rateLimiter {
+	return &rateLimiter{
+		now:     time.Now,
+		clients: make(map[string]*tokenBucket),
+	}
+}
+
+// allow takes cost tokens from the connection bucket conn and from the
+// bucket of client under cfg. If either holds too few, it takes none and
+// returns the scope of the limit that refused the command; otherwise it
+// returns "". A command costing more than a bucket's Burst runs once the
+// bucket is full and leaves it in debt.
+func (l *rateLimiter) allow(conn *tokenBucket, client string, cost int, cfg RateLimitConfig) string {
+	if cost <= 0 || (!cfg.PerConnection.enabled() && !cfg.PerClient.enabled()) {
+		return ""
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	l.prune(now, cfg.PerClient)
+
+	if cfg.PerConnection.enabled() {
+		conn.refill(now, cfg.PerConnection)
+		if conn.tokens < float64(min(cost, cfg.PerConnection.Burst)) {
+			return rateLimitConnection
+		}
+	}
+	if cfg.PerClient.enabled() {
+		clientBucket := l.clients[client]
+		if clientBucket == nil {
+			clientBucket = &tokenBucket{}
+			l.clients[client] = clientBucket
+		}
+		clientBucket.refill(now, cfg.PerClient)
+		if clientBucket.tokens < float64(min(cost, cfg.PerClient.Burst)) {
+			return rateLimitClient
+		}
+		clientBucket.tokens -= float64(cost)
+	}
+	if cfg.PerConnection.enabled() {
+		conn.tokens -= float64(cost)
+	}
+	return ""
+}
+
+// prune drops client buckets that have refilled, at most once per prune
+// interval, so that clients that have gone away do not grow the map.
+func (l *rateLimiter) prune(now time.Time, cfg TokenBucketConfig) {
+	if now.Sub(l.lastPrune) < rateLimitPruneInterval {
+		return
+	}
+	l.lastPrune = now
+	for client, b := range l.clients {
+		if !cfg.enabled() || b.full(now, cfg) {
+			delete(l.clients, client)
+		}
+	}
+}
+
+// rateLimitedResponse returns the I2P_ERROR reply to a command refused by
+// the rate limits, with the action clients expect for its verb.
+func rateLimitedResponse(cmd *protocol.Command) *protocol.Response {
+	verb := strings.ToUpper(cmd.Verb)
+	response := protocol.NewResponse(verb).
+		WithResult(protocol.ResultI2PError).
+		WithMessage("rate limit exceeded")
+	switch verb {
+	case protocol.VerbHello, protocol.VerbDest, protocol.VerbNaming, protocol.VerbAuth:
+		response.WithAction(protocol.ActionReply)
+	case protocol.VerbSession, protocol.VerbStream:
+		response.WithAction(protocol.ActionStatus)
+	}
+	return response
+}
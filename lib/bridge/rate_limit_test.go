@@ -0,0 +1,229 @@
+package bridge
+
+import (
+	"bufio"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-i2p/go-sam-bridge/lib/handler"
+	"github.com/go-i2p/go-sam-bridge/lib/protocol"
+)
+
+// newTestRateLimiter returns a rateLimiter whose clock is *now.
+func newTestRateLimiter(now *time.Time) *rateLimiter {
+	l := newRateLimiter()
+	l.now = func() time.Time { return *now }
+	return l
+}
+
+func TestRateLimitConfig_Cost(t *testing.T) {
+	cfg := RateLimitConfig{Costs: map[string]int{
+		"SESSION CREATE": 10,
+		"naming":         3,
+		"NAMING LOOKUP":  5,
+		"PING":           0,
+	}}
+
+	tests := []struct {
+		verb, action string
+		want         int
+	}{
+		{"SESSION", "CREATE", 10},
+		{"session", "create", 10},
+		{"SESSION", "REMOVE", 1},
+		{"NAMING", "LOOKUP", 5},
+		{"NAMING", "OTHER", 3},
+		{"PING", "", 0},
+		{"STREAM", "CONNECT", 1},
+	}
+	for _, tt := range tests {
+		cmd := &protocol.Command{Verb: tt.verb, Action: tt.action}
+		if got := cfg.cost(cmd); got != tt.want {
+			t.Errorf("cost(%s %s) = %d, want %d", tt.verb, tt.action, got, tt.want)
+		}
+	}
+}
+
+func TestRateLimiter_PerConnection(t *testing.T) {
+	now := time.Unix(1000, 0)
+	l := newTestRateLimiter(&now)
+	cfg := RateLimitConfig{PerConnection: TokenBucketConfig{Rate: 1, Burst: 3}}
+
+	var conn tokenBucket
+	for i := 0; i < 3; i++ {
+		if scope := l.allow(&conn, "192.0.2.1", 1, cfg); scope != "" {
+			t.Fatalf("command %d refused by %s limit, want allowed", i+1, scope)
+		}
+	}
+	if scope := l.allow(&conn, "192.0.2.1", 1, cfg); scope != rateLimitConnection {
+		t.Errorf("command over Burst: scope = %q, want %q", scope, rateLimitConnection)
+	}
+
+	// Other connections have their own bucket.
+	var other tokenBucket
+	if scope := l.allow(&other, "192.0.2.1", 1, cfg); scope != "" {
+		t.Errorf("other connection refused by %s limit, want allowed", scope)
+	}
+
+	now = now.Add(time.Second)
+	if scope := l.allow(&conn, "192.0.2.1", 1, cfg); scope != "" {
+		t.Errorf("command after refill refused by %s limit, want allowed", scope)
+	}
+	if scope := l.allow(&conn, "192.0.2.1", 1, cfg); scope == "" {
+		t.Error("second command after 1 token refill allowed, want refused")
+	}
+}
+
+func TestRateLimiter_PerClient(t *testing.T) {
+	now := time.Unix(1000, 0)
+	l := newTestRateLimiter(&now)
+	cfg := RateLimitConfig{
+		PerConnection: TokenBucketConfig{Rate: 1, Burst: 2},
+		PerClient:     TokenBucketConfig{Rate: 1, Burst: 3},
+	}
+
+	var conn1, conn2 tokenBucket
+	for _, conn := range []*tokenBucket{&conn1, &conn1, &conn2} {
+		if scope := l.allow(conn, "192.0.2.1", 1, cfg); scope != "" {
+			t.Fatalf("command refused by %s limit, want allowed", scope)
+		}
+	}
+	if scope := l.allow(&conn2, "192.0.2.1", 1, cfg); scope != rateLimitClient {
+		t.Errorf("command over client Burst: scope = %q, want %q", scope, rateLimitClient)
+	}
+	// A refused command takes no tokens from the connection bucket.
+	if conn2.tokens != 1 {
+		t.Errorf("connection tokens = %v after refusal, want 1", conn2.tokens)
+	}
+	var conn3 tokenBucket
+	if scope := l.allow(&conn3, "198.51.100.7", 1, cfg); scope != "" {
+		t.Errorf("other client refused by %s limit, want allowed", scope)
+	}
+}
+
+func TestRateLimiter_Costs(t *testing.T) {
+	now := time.Unix(1000, 0)
+	l := newTestRateLimiter(&now)
+	cfg := RateLimitConfig{PerConnection: TokenBucketConfig{Rate: 1, Burst: 5}}
+
+	var conn tokenBucket
+	if scope := l.allow(&conn, "192.0.2.1", 0, cfg); scope != "" || conn.tokens != 0 || !conn.last.IsZero() {
+		t.Errorf("zero cost command: scope %q, bucket %+v, want allowed and untouched", scope, conn)
+	}
+
+	// A command costing more than Burst needs a full bucket and leaves it in debt.
+	if scope := l.allow(&conn, "192.0.2.1", 10, cfg); scope != "" {
+		t.Fatalf("command costing more than Burst refused by %s limit, want allowed with a full bucket", scope)
+	}
+	now = now.Add(5 * time.Second)
+	if scope := l.allow(&conn, "192.0.2.1", 1, cfg); scope == "" {
+		t.Error("command while in debt allowed, want refused")
+	}
+}
+
+func TestRateLimiter_Disabled(t *testing.T) {
+	now := time.Unix(1000, 0)
+	l := newTestRateLimiter(&now)
+
+	var conn tokenBucket
+	for i := 0; i < 100; i++ {
+		if scope := l.allow(&conn, "192.0.2.1", 10, RateLimitConfig{}); scope != "" {
+			t.Fatalf("command refused by %s limit with no limits configured", scope)
+		}
+	}
+	if len(l.clients) != 0 {
+		t.Errorf("client buckets = %d, want none", len(l.clients))
+	}
+}
+
+func TestRateLimiter_Prune(t *testing.T) {
+	now := time.Unix(1000, 0)
+	l := newTestRateLimiter(&now)
+	cfg := RateLimitConfig{PerClient: TokenBucketConfig{Rate: 1, Burst: 10}}
+
+	var conn tokenBucket
+	l.allow(&conn, "192.0.2.1", 10, cfg)
+	now = now.Add(2 * time.Minute)
+	l.allow(&conn, "198.51.100.7", 1, cfg)
+	if _, ok := l.clients["192.0.2.1"]; ok {
+		t.Error("refilled client bucket was not pruned")
+	}
+	if _, ok := l.clients["198.51.100.7"]; !ok {
+		t.Error("client bucket in use was pruned")
+	}
+}
+
+func TestRateLimitedResponse(t *testing.T) {
+	tests := []struct {
+		verb string
+		want string
+	}{
+		{"NAMING", "NAMING REPLY RESULT=I2P_ERROR"},
+		{"session", "SESSION STATUS RESULT=I2P_ERROR"},
+		{"DEST", "DEST REPLY RESULT=I2P_ERROR"},
+		{"PING", "PING RESULT=I2P_ERROR"},
+	}
+	for _, tt := range tests {
+		got := rateLimitedResponse(&protocol.Command{Verb: tt.verb}).String()
+		if !strings.HasPrefix(got, tt.want) || !strings.Contains(got, "rate limit exceeded") {
+			t.Errorf("rateLimitedResponse(%s) = %q, want %q and the rate limit message", tt.verb, got, tt.want)
+		}
+	}
+}
+
+func TestServer_RateLimit(t *testing.T) {
+	config := DefaultConfig()
+	config.RateLimit.PerConnection = TokenBucketConfig{Rate: 0.001, Burst: 6}
+
+	server, err := NewServer(config, newMockRegistry())
+	if err != nil {
+		t.Fatalf("NewServer() error = %v", err)
+	}
+	server.Router().RegisterFunc("HELLO", func(ctx *handler.Context, cmd *protocol.Command) (*protocol.Response, error) {
+		return protocol.NewResponse("HELLO").
+			WithAction("REPLY").
+			WithResult("OK").
+			WithVersion("3.3"), nil
+	})
+	server.Router().RegisterFunc("NAMING LOOKUP", func(ctx *handler.Context, cmd *protocol.Command) (*protocol.Response, error) {
+		return protocol.NamingReplyOK(cmd.Get("NAME"), "dest"), nil
+	})
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("net.Listen() error = %v", err)
+	}
+	go server.Serve(listener)
+	defer server.Close()
+
+	conn, err := net.Dial("tcp", listener.Addr().String())
+	if err != nil {
+		t.Fatalf("net.Dial() error = %v", err)
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(2 * time.Second))
+	reader := bufio.NewReader(conn)
+
+	send := func(line string) string {
+		conn.Write([]byte(line + "\n"))
+		reply, err := reader.ReadString('\n')
+		if err != nil {
+			t.Fatalf("ReadString() error = %v", err)
+		}
+		return reply
+	}
+
+	// HELLO costs 1 and NAMING LOOKUP 5, which empties the bucket.
+	if line := send("HELLO VERSION MIN=3.0 MAX=3.3"); !strings.Contains(line, "RESULT=OK") {
+		t.Fatalf("HELLO = %q, want RESULT=OK", line)
+	}
+	if line := send("NAMING LOOKUP NAME=test.i2p"); !strings.Contains(line, "RESULT=OK") {
+		t.Fatalf("first NAMING LOOKUP = %q, want RESULT=OK", line)
+	}
+	line := send("NAMING LOOKUP NAME=test.i2p")
+	if !strings.HasPrefix(line, "NAMING REPLY RESULT=I2P_ERROR") || !strings.Contains(line, "rate limit exceeded") {
+		t.Errorf("second NAMING LOOKUP = %q, want a rate limit I2P_ERROR", line)
+	}
+}
@@ -0,0 +1,68 @@
+package bridge
+
+import (
+	"github.com/go-i2p/logger"
+)
+
+// Reload applies the authentication, timeout, limit, access, stream, rate
+// limit, PROXY protocol and transcript settings of cfg to the running server.
+// Existing connections and sessions are kept:
+//
+//   - Auth replaces the AuthStore's users, enablement and lockout
+//     settings; failures already counted are kept. Without an
+//     Auth.Backend this discards changes made with AUTH commands; with one,
+//     users are reloaded from it. Connections that already authenticated
+//     stay authenticated.
+//   - Timeouts apply to existing connections from their next command or
+//     keepalive tick. StreamLinger applies to new connections and STREAM
+//     FORWARD commands.
+//   - Limits apply to new connections and sessions. Clients already above a
+//     lowered limit are not disconnected.
+//   - Access applies to new connections and to each datagram on the UDP
+//     port. Clients connected from a newly denied network stay connected.
+//   - Stream applies to sessions created on new connections, and
+//     ForwardSocketDir to STREAM FORWARD on new connections.
+//   - RateLimit applies from each connection's next command. Tokens left in
+//     existing buckets are kept, up to the new Burst.
+//   - ProxyProtocol applies to new connections on the listener created by
+//     ListenAndServe or wrapped with ProxyListener.
+//   - TranscriptDir applies to new connections, so transcripts can be
+//     turned on to debug a client without a restart.
+//
+// The other fields of cfg, such as ListenAddr and TLSConfig, are ignored;
+// they take effect only when the server is restarted. Reload returns an
+// error and changes nothing if the resulting configuration is invalid or the
+// auth backend cannot be loaded.
+func (s *Server) Reload(cfg *Config) error {
+	s.reloadMu.Lock()
+	defer s.reloadMu.Unlock()
+
+	next := *s.Config()
+	next.Auth = cfg.Auth
+	next.Timeouts = cfg.Timeouts
+	next.Limits = cfg.Limits
+	next.Access = cfg.Access
+	next.Stream = cfg.Stream
+	next.RateLimit = cfg.RateLimit
+	next.ProxyProtocol = cfg.ProxyProtocol
+	next.TranscriptDir = cfg.TranscriptDir
+	if err := next.Validate(); err != nil {
+		log.WithFields(logger.Fields{"pkg": "bridge", "func": "Server.Reload"}).WithError(err).Warn("Rejected invalid configuration reload")
+		return err
+	}
+
+	if err := s.authStore.Replace(next.Auth); err != nil {
+		log.WithFields(logger.Fields{"pkg": "bridge", "func": "Server.Reload"}).WithError(err).Warn("Rejected configuration reload")
+		return err
+	}
+	s.config.Store(&next)
+
+	log.WithFields(logger.Fields{
+		"pkg":            "bridge",
+		"func":           "Server.Reload",
+		"authRequired":   next.Auth.Required,
+		"users":          s.authStore.UserCount(),
+		"maxConnections": next.Limits.MaxConnections,
+	}).Info("Configuration reloaded")
+	return nil
+}
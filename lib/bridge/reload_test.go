@@ -0,0 +1,118 @@
+package bridge
+
+import (
+	"bufio"
+	"net"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestServer_Reload(t *testing.T) {
+	server, err := NewServer(DefaultConfig(), newMockRegistry())
+	if err != nil {
+		t.Fatalf("NewServer() error = %v", err)
+	}
+
+	cfg := DefaultConfig()
+	cfg.ListenAddr = "127.0.0.1:9999"
+	cfg.Auth = AuthConfig{Required: true, Users: map[string]string{"alice": "secret"}}
+	cfg.Timeouts.Command = 5 * time.Second
+	cfg.Limits.MaxConnections = 5
+	cfg.Stream = StreamConfig{AccessListDir: "/etc/sam-bridge/peers", ForwardSocketDir: "/run/sam-bridge"}
+
+	if err := server.Reload(cfg); err != nil {
+		t.Fatalf("Reload() error = %v", err)
+	}
+
+	got := server.Config()
+	if got.ListenAddr != DefaultListenAddr {
+		t.Errorf("ListenAddr = %q, want it unchanged by Reload", got.ListenAddr)
+	}
+	if got.Timeouts.Command != 5*time.Second {
+		t.Errorf("Timeouts.Command = %v, want 5s", got.Timeouts.Command)
+	}
+	if got.Limits.MaxConnections != 5 {
+		t.Errorf("Limits.MaxConnections = %d, want 5", got.Limits.MaxConnections)
+	}
+	if got.Stream != (StreamConfig{AccessListDir: "/etc/sam-bridge/peers", ForwardSocketDir: "/run/sam-bridge"}) {
+		t.Errorf("Stream = %+v, want the directories", got.Stream)
+	}
+	if !server.AuthStore().IsAuthEnabled() {
+		t.Error("IsAuthEnabled() = false after Reload, want true")
+	}
+	if !server.AuthStore().CheckPassword("alice", "secret") {
+		t.Error("CheckPassword(alice) = false after Reload, want true")
+	}
+}
+
+func TestServer_ReloadInvalid(t *testing.T) {
+	server, err := NewServer(DefaultConfig(), newMockRegistry())
+	if err != nil {
+		t.Fatalf("NewServer() error = %v", err)
+	}
+	before := server.Config()
+
+	cfg := DefaultConfig()
+	cfg.Auth = AuthConfig{Required: true, Users: map[string]string{"alice": "secret"}}
+	cfg.Limits.MaxLineLength = 0
+
+	err = server.Reload(cfg)
+	cfgErr, ok := err.(*ConfigError)
+	if !ok || cfgErr.Field != "Limits.MaxLineLength" {
+		t.Fatalf("Reload() error = %v, want ConfigError for Limits.MaxLineLength", err)
+	}
+	if server.Config() != before {
+		t.Error("Config() changed after a rejected Reload")
+	}
+	if server.AuthStore().IsAuthEnabled() {
+		t.Error("IsAuthEnabled() = true after a rejected Reload, want false")
+	}
+}
+
+func TestServer_ReloadKeepsConnections(t *testing.T) {
+	server, addr, _ := startStreamServer(t)
+	existing, existingReader := dialHello(t, addr)
+
+	cfg := DefaultConfig()
+	cfg.Auth = AuthConfig{Required: true, Users: map[string]string{"alice": "secret"}}
+	if err := server.Reload(cfg); err != nil {
+		t.Fatalf("Reload() error = %v", err)
+	}
+
+	sessionCreate := func(conn net.Conn, reader *bufio.Reader) string {
+		t.Helper()
+		conn.Write([]byte("SESSION CREATE STYLE=STREAM ID=test DESTINATION=TRANSIENT\n"))
+		conn.SetReadDeadline(time.Now().Add(time.Second))
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			t.Fatalf("ReadString() error = %v", err)
+		}
+		return line
+	}
+
+	// A connection from before the reload keeps working.
+	if line := sessionCreate(existing, existingReader); !strings.Contains(line, "RESULT=OK") {
+		t.Errorf("existing connection SESSION CREATE = %q, want RESULT=OK", line)
+	}
+
+	// New connections must now authenticate.
+	anon, anonReader := dialHello(t, addr)
+	if line := sessionCreate(anon, anonReader); !strings.Contains(line, "authentication required") {
+		t.Errorf("unauthenticated SESSION CREATE = %q, want authentication required", line)
+	}
+
+	conn, err := net.Dial("tcp", addr)
+	if err != nil {
+		t.Fatalf("net.Dial() error = %v", err)
+	}
+	defer conn.Close()
+	reader := bufio.NewReader(conn)
+	conn.Write([]byte("HELLO VERSION MIN=3.0 MAX=3.3 USER=alice PASSWORD=secret\n"))
+	if _, err := reader.ReadString('\n'); err != nil {
+		t.Fatalf("ReadString() error = %v", err)
+	}
+	if line := sessionCreate(conn, reader); !strings.Contains(line, "RESULT=OK") {
+		t.Errorf("authenticated SESSION CREATE = %q, want RESULT=OK", line)
+	}
+}
@@ -7,6 +7,7 @@ import (
 	"fmt"
 	"io"
 	"net"
+	"sort"
 	"strings"
 	"sync"
 	"sync/atomic"
@@ -16,6 +17,7 @@ import (
 
 	"github.com/go-i2p/go-sam-bridge/lib/datagram"
 	"github.com/go-i2p/go-sam-bridge/lib/handler"
+	"github.com/go-i2p/go-sam-bridge/lib/metrics"
 	"github.com/go-i2p/go-sam-bridge/lib/protocol"
 	"github.com/go-i2p/go-sam-bridge/lib/session"
 )
@@ -23,7 +25,9 @@ import (
 // Server is the SAM bridge server that accepts client connections
 // and processes SAM protocol commands.
 type Server struct {
-	config    *Config
+	// config is replaced, never modified, by Reload.
+	config    atomic.Pointer[Config]
+	reloadMu  sync.Mutex
 	listener  net.Listener
 	router    *handler.Router
 	registry  session.Registry
@@ -38,6 +42,20 @@ type Server struct {
 	connections map[*Connection]struct{}
 	closed      atomic.Bool
 
+	// draining is set by Shutdown: the listener is closed and new
+	// sessions are rejected while active streams finish.
+	draining atomic.Bool
+
+	// clients tracks connections and sessions per client IP for
+	// LimitConfig.MaxConnectionsPerClient and MaxSessionsPerClient.
+	clients *clientLimiter
+
+	// rateLimit holds the per-client command buckets of RateLimitConfig.
+	rateLimit *rateLimiter
+
+	// auditLog serializes writes to Config.AuditLog.
+	auditLog auditLog
+
 	// done is closed when the server shuts down.
 	done chan struct{}
 }
@@ -49,18 +67,28 @@ func NewServer(config *Config, registry session.Registry) (*Server, error) {
 		return nil, err
 	}
 
-	// Initialize AuthStore from config
+	// Initialize AuthStore from config, loading persisted users if configured
 	authStore := NewAuthStoreFromConfig(config.Auth)
+	if config.Auth.Backend != nil {
+		var err error
+		if authStore, err = NewAuthStoreWithBackend(config.Auth, config.Auth.Backend); err != nil {
+			log.WithFields(logger.Fields{"pkg": "bridge", "func": "NewServer"}).WithError(err).Error("Failed to load auth users")
+			return nil, err
+		}
+	}
 
-	return &Server{
-		config:      config,
+	s := &Server{
 		registry:    registry,
 		router:      handler.NewRouter(),
 		parser:      protocol.NewParser(),
 		authStore:   authStore,
 		connections: make(map[*Connection]struct{}),
+		clients:     newClientLimiter(),
+		rateLimit:   newRateLimiter(),
 		done:        make(chan struct{}),
-	}, nil
+	}
+	s.config.Store(config)
+	return s, nil
 }
 
 // Router returns the command router for handler registration.
@@ -73,9 +101,10 @@ func (s *Server) Registry() session.Registry {
 	return s.registry
 }
 
-// Config returns the server configuration.
+// Config returns the server configuration. After Reload, it returns the
+// reloaded configuration.
 func (s *Server) Config() *Config {
-	return s.config
+	return s.config.Load()
 }
 
 // AuthStore returns the authentication store for handler registration.
@@ -85,38 +114,54 @@ func (s *Server) AuthStore() *AuthStore {
 }
 
 // ListenAndServe starts listening on the configured address and serves clients.
+// ListenAddr may be a TCP address or a "unix:" socket path.
 // This method blocks until the server is closed.
 func (s *Server) ListenAndServe() error {
-	log.WithFields(logger.Fields{"pkg": "bridge", "func": "Server.ListenAndServe", "addr": s.config.ListenAddr}).Debug("Starting SAM bridge server")
+	cfg := s.Config()
+	log.WithFields(logger.Fields{"pkg": "bridge", "func": "Server.ListenAndServe", "addr": cfg.ListenAddr}).Debug("Starting SAM bridge server")
 	// Start UDP datagram listener if enabled
-	if s.config.DatagramPort > 0 {
+	if cfg.DatagramPort > 0 {
 		if err := s.startUDPListener(); err != nil {
 			log.WithFields(logger.Fields{"pkg": "bridge", "func": "Server.ListenAndServe"}).WithError(err).Error("Failed to start UDP listener")
 			return fmt.Errorf("failed to start UDP listener: %w", err)
 		}
 	}
 
-	listener, err := net.Listen("tcp", s.config.ListenAddr)
+	listener, err := s.listen()
 	if err != nil {
-		s.stopUDPListener() // Clean up UDP if TCP fails
-		log.WithFields(logger.Fields{"pkg": "bridge", "func": "Server.ListenAndServe", "addr": s.config.ListenAddr}).WithError(err).Error("Failed to bind TCP listener")
+		s.stopUDPListener() // Clean up UDP if the control listener fails
+		log.WithFields(logger.Fields{"pkg": "bridge", "func": "Server.ListenAndServe", "addr": cfg.ListenAddr}).WithError(err).Error("Failed to bind control listener")
 		return err
 	}
-	log.WithFields(logger.Fields{"pkg": "bridge", "func": "Server.ListenAndServe", "addr": s.config.ListenAddr}).Info("SAM bridge TCP listener started")
+	log.WithFields(logger.Fields{"pkg": "bridge", "func": "Server.ListenAndServe", "addr": cfg.ListenAddr}).Info("SAM bridge listener started")
+
+	// Read PROXY headers from trusted proxies, beneath TLS
+	listener = s.ProxyListener(listener)
 
 	// Wrap with TLS if configured
-	if s.config.TLSConfig != nil {
-		listener = tls.NewListener(listener, s.config.TLSConfig)
+	if cfg.TLSConfig != nil {
+		listener = tls.NewListener(listener, cfg.TLSConfig)
 	}
 
 	return s.Serve(listener)
 }
 
+// listen opens the control listener for ListenAddr, which is either a TCP
+// address or a "unix:" socket path.
+func (s *Server) listen() (net.Listener, error) {
+	cfg := s.Config()
+	if path, ok := UnixSocketPath(cfg.ListenAddr); ok {
+		return ListenUnix(path, cfg.UnixSocket)
+	}
+	return net.Listen("tcp", cfg.ListenAddr)
+}
+
 // startUDPListener initializes and starts the UDP datagram listener.
 // Per SAM specification, UDP port 7655 accepts datagrams for sending.
 func (s *Server) startUDPListener() error {
-	addr := fmt.Sprintf(":%d", s.config.DatagramPort)
+	addr := fmt.Sprintf(":%d", s.Config().DatagramPort)
 	s.udpListener = datagram.NewUDPListener(addr, s.registry)
+	s.udpListener.SetAccessFilter(s.AllowsPeer)
 	return s.udpListener.Start()
 }
 
@@ -138,8 +183,8 @@ func (s *Server) Serve(listener net.Listener) error {
 	for {
 		conn, err := listener.Accept()
 		if err != nil {
-			if s.closed.Load() {
-				return nil // Server was closed
+			if s.closed.Load() || s.draining.Load() {
+				return nil // Server was closed or is shutting down
 			}
 			// Check if it's a temporary error
 			var netErr net.Error
@@ -149,32 +194,49 @@ func (s *Server) Serve(listener net.Listener) error {
 			return err
 		}
 
+		// Check the access lists before anything else about the client
+		if !s.AllowsPeer(conn.RemoteAddr()) {
+			log.WithFields(logger.Fields{"pkg": "bridge", "func": "Server.Serve", "remote": conn.RemoteAddr().String()}).Warn("Rejecting connection: client address not allowed")
+			conn.Close()
+			continue
+		}
+
 		// Check connection limits
 		if !s.canAccept() {
 			conn.Close()
 			continue
 		}
 
+		// Check per-client connection limit; released in handleConnection
+		ip := clientHost(conn.RemoteAddr().String())
+		if limit := s.Config().Limits.MaxConnectionsPerClient; !s.clients.acquireConnection(ip, limit) {
+			log.WithFields(logger.Fields{"pkg": "bridge", "func": "Server.Serve", "client": ip, "limit": limit}).Warn("Rejecting connection: per-client connection limit reached")
+			conn.Close()
+			continue
+		}
+
 		go s.handleConnection(conn)
 	}
 }
 
 // canAccept returns true if the server can accept a new connection.
 func (s *Server) canAccept() bool {
-	if s.config.Limits.MaxConnections == 0 {
+	limit := s.Config().Limits.MaxConnections
+	if limit == 0 {
 		return true
 	}
 
 	s.mu.Lock()
 	defer s.mu.Unlock()
-	return len(s.connections) < s.config.Limits.MaxConnections
+	return len(s.connections) < limit
 }
 
 // handleConnection processes a single client connection.
 func (s *Server) handleConnection(conn net.Conn) {
-	c := NewConnection(conn, s.config.Limits.ReadBufferSize)
+	c := NewConnection(conn, s.Config().Limits.ReadBufferSize)
 	remoteAddr := conn.RemoteAddr().String()
 	log.WithFields(logger.Fields{"pkg": "bridge", "func": "Server.handleConnection", "remote": remoteAddr}).Debug("New SAM client connection")
+	s.startTranscript(c)
 
 	s.mu.Lock()
 	s.connections[c] = struct{}{}
@@ -186,6 +248,10 @@ func (s *Server) handleConnection(conn net.Conn) {
 		s.mu.Lock()
 		delete(s.connections, c)
 		s.mu.Unlock()
+		if c.SessionID() != "" {
+			s.clients.releaseSession(c.ClientIP())
+		}
+		s.clients.releaseConnection(c.ClientIP())
 		if ctx != nil {
 			ctx.CloseForwardListeners()
 			// Per SAMv3.md: "The session is terminated when the socket is disconnected."
@@ -200,6 +266,20 @@ func (s *Server) handleConnection(conn net.Conn) {
 	}()
 
 	ctx = handler.NewContext(conn, s.registry)
+	ctx.StreamLinger = s.Config().Timeouts.StreamLinger
+	ctx.AccessListDir = s.Config().Stream.AccessListDir
+	ctx.ForwardSocketDir = s.Config().Stream.ForwardSocketDir
+
+	if err := s.authenticateClientCert(c); err != nil {
+		log.WithFields(logger.Fields{"pkg": "bridge", "func": "Server.handleConnection", "remote": remoteAddr}).WithError(err).Debug("TLS handshake failed")
+		return
+	}
+
+	// Keepalive PING and idle timeout enforcement
+	keepaliveDone := make(chan struct{})
+	stopKeepalive := sync.OnceFunc(func() { close(keepaliveDone) })
+	defer stopKeepalive()
+	go s.keepalive(c, keepaliveDone)
 
 	// Command loop
 	for {
@@ -208,7 +288,7 @@ func (s *Server) handleConnection(conn net.Conn) {
 		}
 
 		// Check for PONG timeout
-		if c.IsPongOverdue(s.config.Timeouts.PongTimeout) {
+		if c.IsPongOverdue(s.Config().Timeouts.PongTimeout) {
 			s.sendPongTimeoutError(c)
 			return
 		}
@@ -222,14 +302,24 @@ func (s *Server) handleConnection(conn net.Conn) {
 			continue // Parse error, already handled
 		}
 
-		// Handle PONG responses
+		// Handle PONG responses. A PONG answers our keepalive and does not
+		// count as client activity for the idle timeout.
 		if strings.EqualFold(cmd.Verb, "PONG") {
 			c.ClearPendingPing()
 			continue
 		}
+		c.UpdateActivity()
 
-		// Process command and send response
-		if shouldReturn := s.processCommand(ctx, c, cmd); shouldReturn {
+		// Process command and send response. Keepalive pauses while the
+		// handler runs, as the client's PONG cannot be read until it returns.
+		c.SetBusy(true)
+		shouldReturn = s.processCommand(ctx, c, cmd)
+		c.SetBusy(false)
+		if shouldReturn {
+			if done := ctx.ForwardingDone(); done != nil {
+				stopKeepalive()
+				s.waitForStream(c, done)
+			}
 			return
 		}
 
@@ -238,6 +328,22 @@ func (s *Server) handleConnection(conn net.Conn) {
 	}
 }
 
+// waitForStream keeps a connection open while it carries stream data after
+// STREAM CONNECT or STREAM ACCEPT. It returns when forwarding ends or the
+// server is closed; the caller then releases the connection.
+func (s *Server) waitForStream(c *Connection, done <-chan struct{}) {
+	c.SetStreaming()
+	// The command timeout no longer applies to the data pipe.
+	if err := c.SetReadDeadline(time.Time{}); err != nil {
+		return
+	}
+
+	select {
+	case <-done:
+	case <-s.done:
+	}
+}
+
 // readAndParseCommand reads a line and parses it as a SAM command.
 // Returns (cmd, shouldReturn). If shouldReturn is true, caller should return.
 // If cmd is nil and shouldReturn is false, there was a parse error that was handled.
@@ -258,8 +364,7 @@ func (s *Server) readAndParseCommand(c *Connection) (*protocol.Command, bool) {
 		}
 		return nil, true
 	}
-
-	c.UpdateActivity()
+	c.RecordLine(line)
 
 	// Parse command
 	cmd, err := s.parser.Parse(line)
@@ -273,7 +378,11 @@ func (s *Server) readAndParseCommand(c *Connection) (*protocol.Command, bool) {
 // processCommand dispatches the command and sends the response.
 // Returns true if the connection should be closed.
 func (s *Server) processCommand(ctx *handler.Context, c *Connection, cmd *protocol.Command) bool {
+	start := time.Now()
 	response, err := s.dispatchCommand(ctx, c, cmd)
+	elapsed := time.Since(start)
+	recordCommand(cmd, response, err, elapsed)
+	s.audit(c, cmd, response, err, start, elapsed)
 	if err != nil {
 		return true // Internal error, close connection
 	}
@@ -300,6 +409,10 @@ func (s *Server) syncContextState(ctx *handler.Context, c *Connection) {
 	}
 	if c.IsAuthenticated() {
 		ctx.Authenticated = true
+		ctx.User = c.Username()
+	}
+	if len(ctx.ForwardListeners) > 0 {
+		c.SetForwards(ctx.ForwardListeners)
 	}
 }
 
@@ -309,9 +422,9 @@ func (s *Server) getDeadline(c *Connection) time.Time {
 
 	switch c.State() {
 	case StateNew, StateHandshaking:
-		timeout = s.config.Timeouts.Handshake
+		timeout = s.Config().Timeouts.Handshake
 	default:
-		timeout = s.config.Timeouts.Command
+		timeout = s.Config().Timeouts.Command
 	}
 
 	if timeout > 0 {
@@ -323,7 +436,7 @@ func (s *Server) getDeadline(c *Connection) time.Time {
 // readLine reads a single line from the reader, enforcing max line length.
 func (s *Server) readLine(reader *bufio.Reader) (string, error) {
 	var line strings.Builder
-	maxLen := s.config.Limits.MaxLineLength
+	maxLen := s.Config().Limits.MaxLineLength
 
 	for {
 		part, isPrefix, err := reader.ReadLine()
@@ -352,6 +465,17 @@ func (s *Server) dispatchCommand(
 	cmd *protocol.Command,
 ) (*protocol.Response, error) {
 	log.WithFields(logger.Fields{"pkg": "bridge", "func": "Server.dispatchCommand", "cmd": cmd.Verb + " " + cmd.Action}).Debug("Dispatching SAM command")
+
+	// Refuse commands over the rate limits before doing any work for them.
+	// Logged at debug level, since a client looping on a command would
+	// otherwise flood the log.
+	rateLimit := s.Config().RateLimit
+	if scope := s.rateLimit.allow(&c.commandTokens, c.ClientIP(), rateLimit.cost(cmd), rateLimit); scope != "" {
+		log.WithFields(logger.Fields{"pkg": "bridge", "func": "Server.dispatchCommand", "client": c.ClientIP(), "cmd": cmd.Verb + " " + cmd.Action, "limit": scope}).Debug("Command refused: rate limit exceeded")
+		metrics.CommandsThrottled.Inc(scope)
+		return rateLimitedResponse(cmd), nil
+	}
+
 	// Check handshake state
 	if !ctx.HandshakeComplete && !isHandshakeCommand(cmd) {
 		return protocol.NewResponse("HELLO").
@@ -360,13 +484,37 @@ func (s *Server) dispatchCommand(
 			WithMessage("handshake not complete"), nil
 	}
 
+	// Check HELLO credentials before the handler replies, so that wrong
+	// passwords and locked-out clients are refused.
+	var authUser string
+	if isHandshakeCommand(cmd) && !ctx.HandshakeComplete {
+		user, response := s.authenticateHello(c, cmd)
+		if response != nil {
+			return response, nil
+		}
+		authUser = user
+	}
+
 	// Check authentication if required (use AuthStore for runtime state)
-	if s.authStore.IsAuthEnabled() && !ctx.Authenticated && !isAuthCommand(cmd) {
+	if s.authStore.IsAuthEnabled() && !ctx.Authenticated && !isHandshakeCommand(cmd) {
 		return protocol.NewResponse(cmd.Verb).
 			WithResult("I2P_ERROR").
 			WithMessage("authentication required"), nil
 	}
 
+	// Apply the user's role policy. Connections that completed HELLO before
+	// authentication was enabled have no user and get RoleUser's policy.
+	// AUTH commands stay restricted while authentication is disabled if an
+	// admin is configured.
+	if s.authStore.IsAuthEnabled() || (isAuthCommand(cmd) && s.authStore.HasAdmin()) {
+		if reason := s.authStore.Policy(c.Username()).check(cmd); reason != "" {
+			log.WithFields(logger.Fields{"pkg": "bridge", "func": "Server.dispatchCommand", "user": c.Username(), "cmd": cmd.Verb + " " + cmd.Action, "reason": reason}).Warn("Command denied by policy")
+			return protocol.NewResponse(cmd.Verb).
+				WithResult("I2P_ERROR").
+				WithMessage("permission denied: " + reason), nil
+		}
+	}
+
 	// Route to handler
 	h := s.router.Route(cmd)
 	if h == nil {
@@ -375,22 +523,74 @@ func (s *Server) dispatchCommand(
 			WithMessage("unknown command"), nil
 	}
 
+	// Refuse new sessions while Shutdown drains existing ones.
+	if s.draining.Load() && isNewSessionCommand(cmd) {
+		return protocol.NewResponse(protocol.VerbSession).
+			WithAction(protocol.ActionStatus).
+			WithResult(protocol.ResultI2PError).
+			WithMessage("bridge is shutting down"), nil
+	}
+
+	// Reserve a per-client session slot before SESSION CREATE runs. The slot
+	// is kept if the session is bound and released with the connection.
+	reserved := false
+	if isSessionCreateCommand(cmd) && c.SessionID() == "" {
+		if limit := s.Config().Limits.MaxSessionsPerClient; !s.clients.acquireSession(c.ClientIP(), limit) {
+			log.WithFields(logger.Fields{"pkg": "bridge", "func": "Server.dispatchCommand", "client": c.ClientIP(), "limit": limit}).Warn("Rejecting SESSION CREATE: per-client session limit reached")
+			return protocol.NewResponse(protocol.VerbSession).
+				WithAction(protocol.ActionStatus).
+				WithResult(protocol.ResultI2PError).
+				WithMessage("session limit reached for this client"), nil
+		}
+		reserved = true
+	}
+
 	response, err := h.Handle(ctx, cmd)
 	if err != nil {
+		if reserved {
+			s.clients.releaseSession(c.ClientIP())
+		}
 		return nil, err
 	}
 
 	// Update connection state based on command success
-	s.updateConnectionState(c, cmd, response)
+	s.updateConnectionState(c, cmd, response, authUser)
+
+	if reserved && c.SessionID() == "" {
+		s.clients.releaseSession(c.ClientIP())
+	}
 
 	return response, nil
 }
 
+// authenticateHello checks the USER and PASSWORD of a HELLO command while
+// authentication is enabled, or an admin is configured so that one can
+// run AUTH commands while it is disabled. It returns the authenticated username, which
+// is empty if no credentials were given, or a HELLO REPLY refusing the
+// connection if they are wrong or the client is locked out.
+func (s *Server) authenticateHello(c *Connection, cmd *protocol.Command) (string, *protocol.Response) {
+	user := cmd.Get("USER")
+	if user == "" || !(s.authStore.IsAuthEnabled() || s.authStore.HasAdmin()) {
+		return "", nil
+	}
+
+	if err := s.authStore.Authenticate(c.RemoteAddr(), user, cmd.Get("PASSWORD")); err != nil {
+		log.WithFields(logger.Fields{"pkg": "bridge", "func": "Server.authenticateHello", "client": c.ClientIP(), "user": user}).WithError(err).Warn("HELLO authentication failed")
+		return "", protocol.NewResponse(protocol.VerbHello).
+			WithAction(protocol.ActionReply).
+			WithResult(protocol.ResultI2PError).
+			WithMessage(err.Error())
+	}
+	return user, nil
+}
+
 // updateConnectionState updates connection state after successful commands.
+// authUser is the username authenticated by a HELLO command, if any.
 func (s *Server) updateConnectionState(
 	c *Connection,
 	cmd *protocol.Command,
 	response *protocol.Response,
+	authUser string,
 ) {
 	if response == nil {
 		return
@@ -412,17 +612,24 @@ func (s *Server) updateConnectionState(
 			c.SetVersion(version)
 			c.SetState(StateReady)
 
-			// Handle authentication from HELLO
-			if user := cmd.Get("USER"); user != "" {
-				if s.config.CheckPassword(user, cmd.Get("PASSWORD")) {
-					c.SetAuthenticated(user)
-				}
+			// Handle authentication from HELLO. A connection that completes
+			// HELLO while authentication is off stays usable if a later
+			// AUTH ENABLE or Reload turns it on.
+			if authUser != "" {
+				c.SetAuthenticated(authUser)
+			} else if !s.authStore.IsAuthEnabled() {
+				c.SetAuthenticated("")
 			}
 		}
 
 	case verb == "SESSION" && action == "CREATE":
-		// Session was created, bind it to connection
-		if id := getOptionValue(response.Options, "ID"); id != "" {
+		// Session was created, bind it to connection. SESSION STATUS does
+		// not echo the ID, so fall back to the one the client requested.
+		id := getOptionValue(response.Options, "ID")
+		if id == "" {
+			id = cmd.Get("ID")
+		}
+		if id != "" {
 			c.BindSession(id)
 		}
 	}
@@ -450,12 +657,22 @@ func isHandshakeCommand(cmd *protocol.Command) bool {
 	return strings.EqualFold(cmd.Verb, "HELLO")
 }
 
-// isAuthCommand returns true if the command is related to authentication.
-// Per SAM 3.2, HELLO (with USER/PASSWORD) and AUTH commands can be used
-// before authentication is established.
+// isSessionCreateCommand returns true if the command is SESSION CREATE.
+func isSessionCreateCommand(cmd *protocol.Command) bool {
+	return strings.EqualFold(cmd.Verb, "SESSION") && strings.EqualFold(cmd.Action, "CREATE")
+}
+
+// isNewSessionCommand returns true for SESSION CREATE and SESSION ADD,
+// the commands that create sessions or subsessions.
+func isNewSessionCommand(cmd *protocol.Command) bool {
+	return strings.EqualFold(cmd.Verb, "SESSION") &&
+		(strings.EqualFold(cmd.Action, "CREATE") || strings.EqualFold(cmd.Action, "ADD"))
+}
+
+// isAuthCommand returns true for the AUTH commands, which manage users.
+// While authentication is enabled they need the admin role; see Policy.
 func isAuthCommand(cmd *protocol.Command) bool {
-	verb := strings.ToUpper(cmd.Verb)
-	return verb == "HELLO" || verb == "AUTH"
+	return strings.EqualFold(cmd.Verb, "AUTH")
 }
 
 // sendParseError sends a protocol error response for parse failures.
@@ -537,7 +754,8 @@ func (s *Server) sendResponse(c *Connection, response *protocol.Response) error
 	return nil
 }
 
-// Close gracefully shuts down the server.
+// Close immediately shuts down the server, closing the listener and every
+// connection. Use Shutdown to let active streams finish first.
 func (s *Server) Close() error {
 	if s.closed.Swap(true) {
 		return nil // Already closed
@@ -576,6 +794,82 @@ func (s *Server) ConnectionCount() int {
 	return len(s.connections)
 }
 
+// ConnectionInfo is a point-in-time snapshot of a control connection,
+// used by management interfaces.
+type ConnectionInfo struct {
+	ID         uint64
+	RemoteAddr string
+	State      ConnectionState
+	Version    string
+	Username   string
+	SessionID  string
+	Age        time.Duration
+	Idle       time.Duration
+}
+
+// Connections returns a snapshot of all open control connections,
+// ordered by connection ID.
+func (s *Server) Connections() []ConnectionInfo {
+	s.mu.Lock()
+	connections := make([]*Connection, 0, len(s.connections))
+	for c := range s.connections {
+		connections = append(connections, c)
+	}
+	s.mu.Unlock()
+
+	infos := make([]ConnectionInfo, 0, len(connections))
+	for _, c := range connections {
+		infos = append(infos, ConnectionInfo{
+			ID:         c.ID(),
+			RemoteAddr: c.RemoteAddr(),
+			State:      c.State(),
+			Version:    c.Version(),
+			Username:   c.Username(),
+			SessionID:  c.SessionID(),
+			Age:        c.Age(),
+			Idle:       c.IdleDuration(),
+		})
+	}
+	sort.Slice(infos, func(i, j int) bool { return infos[i].ID < infos[j].ID })
+	return infos
+}
+
+// CloseConnection closes the control connection with the given ID.
+// Its bound session is closed and unregistered as for a client disconnect.
+// Returns false if no such connection is open.
+func (s *Server) CloseConnection(id uint64) bool {
+	return s.closeConnectionWhere(func(c *Connection) bool { return c.ID() == id })
+}
+
+// CloseSessionConnection closes the control connection bound to sessionID,
+// which terminates the session per SAMv3.md.
+// Returns false if no open connection is bound to the session.
+func (s *Server) CloseSessionConnection(sessionID string) bool {
+	if sessionID == "" {
+		return false
+	}
+	return s.closeConnectionWhere(func(c *Connection) bool { return c.SessionID() == sessionID })
+}
+
+func (s *Server) closeConnectionWhere(match func(*Connection) bool) bool {
+	s.mu.Lock()
+	var target *Connection
+	for c := range s.connections {
+		if match(c) {
+			target = c
+			break
+		}
+	}
+	s.mu.Unlock()
+
+	if target == nil {
+		return false
+	}
+	log.WithFields(logger.Fields{"pkg": "bridge", "func": "Server.closeConnectionWhere", "remote": target.RemoteAddr(), "session": target.SessionID()}).Info("Closing SAM connection on request")
+	target.Close()
+	return true
+}
+
 // Addr returns the listener address, or empty string if not listening.
 func (s *Server) Addr() string {
 	s.mu.Lock()
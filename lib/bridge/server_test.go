@@ -101,6 +101,46 @@ func (r *mockRegistry) MostRecentByStyle(style session.Style) session.Session {
 	return nil
 }
 
+// startTestServer starts a server with config on a loopback listener and
+// returns it with the listener's address. It answers HELLO with version 3.3
+// and SESSION CREATE with RESULT=OK; handlers adds commands or replaces
+// those stubs. If wrap is not nil, the server accepts connections through
+// the listener it returns.
+func startTestServer(t *testing.T, config *Config, handlers map[string]handler.HandlerFunc, wrap func(*Server, net.Listener) net.Listener) (*Server, string) {
+	t.Helper()
+
+	server, err := NewServer(config, newMockRegistry())
+	if err != nil {
+		t.Fatalf("NewServer() error = %v", err)
+	}
+	server.Router().RegisterFunc("HELLO", func(ctx *handler.Context, cmd *protocol.Command) (*protocol.Response, error) {
+		return protocol.NewResponse("HELLO").
+			WithAction("REPLY").
+			WithResult("OK").
+			WithVersion("3.3"), nil
+	})
+	server.Router().RegisterFunc("SESSION CREATE", func(ctx *handler.Context, cmd *protocol.Command) (*protocol.Response, error) {
+		return protocol.NewResponse("SESSION").
+			WithAction("STATUS").
+			WithResult("OK"), nil
+	})
+	for key, fn := range handlers {
+		server.Router().RegisterFunc(key, fn)
+	}
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("net.Listen() error = %v", err)
+	}
+	served := net.Listener(listener)
+	if wrap != nil {
+		served = wrap(server, listener)
+	}
+	go server.Serve(served)
+	t.Cleanup(func() { server.Close() })
+	return server, listener.Addr().String()
+}
+
 func TestNewServer(t *testing.T) {
 	registry := newMockRegistry()
 	config := DefaultConfig()
@@ -358,6 +398,196 @@ func TestServer_Authentication(t *testing.T) {
 	}
 }
 
+func TestServer_AuthenticationLockout(t *testing.T) {
+	config := DefaultConfig()
+	config.Auth.Required = true
+	config.Auth.Users = map[string]string{"admin": "secret"}
+	config.Auth.Lockout = LockoutConfig{MaxFailures: 2, Duration: time.Minute, MaxDuration: time.Hour}
+
+	server, err := NewServer(config, newMockRegistry())
+	if err != nil {
+		t.Fatalf("NewServer() error = %v", err)
+	}
+	server.Router().RegisterFunc("HELLO", func(ctx *handler.Context, cmd *protocol.Command) (*protocol.Response, error) {
+		return protocol.NewResponse("HELLO").
+			WithAction("REPLY").
+			WithResult("OK").
+			WithVersion("3.3"), nil
+	})
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("net.Listen() error = %v", err)
+	}
+	go server.Serve(listener)
+	defer server.Close()
+
+	hello := func(password string) string {
+		t.Helper()
+		conn, err := net.Dial("tcp", listener.Addr().String())
+		if err != nil {
+			t.Fatalf("net.Dial() error = %v", err)
+		}
+		defer conn.Close()
+		conn.Write([]byte("HELLO VERSION MIN=3.0 MAX=3.3 USER=admin PASSWORD=" + password + "\n"))
+		line, err := bufio.NewReader(conn).ReadString('\n')
+		if err != nil {
+			t.Fatalf("ReadString() error = %v", err)
+		}
+		return line
+	}
+
+	for i := 0; i < 2; i++ {
+		if line := hello("guess"); !strings.Contains(line, "I2P_ERROR") || !strings.Contains(line, "authentication failed") {
+			t.Errorf("HELLO with a wrong password = %q, want authentication failed", line)
+		}
+	}
+	if line := hello("secret"); !strings.Contains(line, "I2P_ERROR") || !strings.Contains(line, "too many failed") {
+		t.Errorf("HELLO while locked out = %q, want too many failed attempts", line)
+	}
+}
+
+func TestServer_Policies(t *testing.T) {
+	config := DefaultConfig()
+	config.Auth.Required = true
+	config.Auth.Users = map[string]string{"ops": "opspass", "web": "webpass"}
+	config.Auth.Roles = map[string]string{"ops": RoleAdmin}
+
+	server, err := NewServer(config, newMockRegistry())
+	if err != nil {
+		t.Fatalf("NewServer() error = %v", err)
+	}
+	ok := func(verb string) handler.HandlerFunc {
+		return func(ctx *handler.Context, cmd *protocol.Command) (*protocol.Response, error) {
+			return protocol.NewResponse(verb).WithAction("REPLY").WithResult("OK").WithVersion("3.3"), nil
+		}
+	}
+	server.Router().RegisterFunc("HELLO", ok("HELLO"))
+	server.Router().RegisterFunc("AUTH DISABLE", ok("AUTH"))
+	server.Router().RegisterFunc("STREAM FORWARD", ok("STREAM"))
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("net.Listen() error = %v", err)
+	}
+	go server.Serve(listener)
+	defer server.Close()
+
+	run := func(hello, command string) string {
+		t.Helper()
+		conn, err := net.Dial("tcp", listener.Addr().String())
+		if err != nil {
+			t.Fatalf("net.Dial() error = %v", err)
+		}
+		defer conn.Close()
+		reader := bufio.NewReader(conn)
+		conn.Write([]byte(hello + "\n"))
+		if line, err := reader.ReadString('\n'); err != nil || !strings.Contains(line, "RESULT=OK") {
+			t.Fatalf("%s = %q, %v, want RESULT=OK", hello, line, err)
+		}
+		conn.Write([]byte(command + "\n"))
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			t.Fatalf("ReadString() error = %v", err)
+		}
+		return line
+	}
+
+	const (
+		anonymous = "HELLO VERSION MIN=3.0 MAX=3.3"
+		asOps     = "HELLO VERSION MIN=3.0 MAX=3.3 USER=ops PASSWORD=opspass"
+		asWeb     = "HELLO VERSION MIN=3.0 MAX=3.3 USER=web PASSWORD=webpass"
+	)
+	if line := run(anonymous, "AUTH DISABLE"); !strings.Contains(line, "authentication required") {
+		t.Errorf("unauthenticated AUTH DISABLE = %q, want authentication required", line)
+	}
+	if line := run(asWeb, "AUTH DISABLE"); !strings.Contains(line, "permission denied") {
+		t.Errorf("AUTH DISABLE as a user = %q, want permission denied", line)
+	}
+	if line := run(asOps, "AUTH DISABLE"); !strings.Contains(line, "RESULT=OK") {
+		t.Errorf("AUTH DISABLE as admin = %q, want RESULT=OK", line)
+	}
+	if line := run(asWeb, "STREAM FORWARD ID=s PORT=8080"); !strings.Contains(line, "RESULT=OK") {
+		t.Errorf("STREAM FORWARD as a user = %q, want RESULT=OK under the default policy", line)
+	}
+}
+
+func TestServer_AuthCommandsWhileDisabled(t *testing.T) {
+	config := DefaultConfig()
+	config.Auth.Users = map[string]string{"ops": "opspass", "web": "webpass"}
+	config.Auth.Roles = map[string]string{"ops": RoleAdmin}
+
+	server, err := NewServer(config, newMockRegistry())
+	if err != nil {
+		t.Fatalf("NewServer() error = %v", err)
+	}
+	if server.AuthStore().IsAuthEnabled() {
+		t.Fatal("IsAuthEnabled() = true, want authentication disabled")
+	}
+	ok := func(verb string) handler.HandlerFunc {
+		return func(ctx *handler.Context, cmd *protocol.Command) (*protocol.Response, error) {
+			return protocol.NewResponse(verb).WithAction("REPLY").WithResult("OK").WithVersion("3.3"), nil
+		}
+	}
+	server.Router().RegisterFunc("HELLO", ok("HELLO"))
+	server.Router().RegisterFunc("AUTH ADD", ok("AUTH"))
+	server.Router().RegisterFunc("AUTH ENABLE", ok("AUTH"))
+	server.Router().RegisterFunc("NAMING LOOKUP", ok("NAMING"))
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("net.Listen() error = %v", err)
+	}
+	go server.Serve(listener)
+	defer server.Close()
+
+	run := func(hello, command string) string {
+		t.Helper()
+		conn, err := net.Dial("tcp", listener.Addr().String())
+		if err != nil {
+			t.Fatalf("net.Dial() error = %v", err)
+		}
+		defer conn.Close()
+		reader := bufio.NewReader(conn)
+		conn.Write([]byte(hello + "\n"))
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			t.Fatalf("ReadString() error = %v", err)
+		}
+		if !strings.Contains(line, "RESULT=OK") {
+			return line
+		}
+		conn.Write([]byte(command + "\n"))
+		if line, err = reader.ReadString('\n'); err != nil {
+			t.Fatalf("ReadString() error = %v", err)
+		}
+		return line
+	}
+
+	const (
+		anonymous = "HELLO VERSION MIN=3.0 MAX=3.3"
+		asOps     = "HELLO VERSION MIN=3.0 MAX=3.3 USER=ops PASSWORD=opspass"
+		asWeb     = "HELLO VERSION MIN=3.0 MAX=3.3 USER=web PASSWORD=webpass"
+	)
+	for _, command := range []string{"AUTH ADD USER=ops PASSWORD=mine", "AUTH ENABLE"} {
+		if line := run(anonymous, command); !strings.Contains(line, "permission denied") {
+			t.Errorf("anonymous %s = %q, want permission denied", command, line)
+		}
+		if line := run(asWeb, command); !strings.Contains(line, "permission denied") {
+			t.Errorf("%s as a user = %q, want permission denied", command, line)
+		}
+		if line := run(asOps, command); !strings.Contains(line, "RESULT=OK") {
+			t.Errorf("%s as admin = %q, want RESULT=OK", command, line)
+		}
+	}
+	if line := run("HELLO VERSION MIN=3.0 MAX=3.3 USER=ops PASSWORD=guess", "AUTH ENABLE"); !strings.Contains(line, "authentication failed") {
+		t.Errorf("HELLO with a wrong admin password = %q, want authentication failed", line)
+	}
+	if line := run(anonymous, "NAMING LOOKUP NAME=ME"); !strings.Contains(line, "RESULT=OK") {
+		t.Errorf("anonymous NAMING LOOKUP = %q, want RESULT=OK while authentication is disabled", line)
+	}
+}
+
 func TestServer_MaxConnections(t *testing.T) {
 	registry := newMockRegistry()
 	config := DefaultConfig()
@@ -415,6 +645,128 @@ func TestServer_MaxConnections(t *testing.T) {
 	}
 }
 
+func TestServer_MaxConnectionsPerClient(t *testing.T) {
+	registry := newMockRegistry()
+	config := DefaultConfig()
+	config.Limits.MaxConnectionsPerClient = 1
+	config.Timeouts.Handshake = time.Second
+
+	server, err := NewServer(config, registry)
+	if err != nil {
+		t.Fatalf("NewServer() error = %v", err)
+	}
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("net.Listen() error = %v", err)
+	}
+
+	go server.Serve(listener)
+	defer server.Close()
+
+	conn1, err := net.Dial("tcp", listener.Addr().String())
+	if err != nil {
+		t.Fatalf("first net.Dial() error = %v", err)
+	}
+	defer conn1.Close()
+
+	// Give server time to register the connection
+	time.Sleep(20 * time.Millisecond)
+
+	// Second connection from the same IP should be closed immediately
+	conn2, err := net.Dial("tcp", listener.Addr().String())
+	if err != nil {
+		return // Connection rejected as expected
+	}
+	defer conn2.Close()
+
+	conn2.SetReadDeadline(time.Now().Add(200 * time.Millisecond))
+	buf := make([]byte, 1)
+	if _, err := conn2.Read(buf); err == nil {
+		t.Error("second connection from same client should have been closed")
+	}
+
+	if got := server.clients.connectionCount("127.0.0.1"); got != 1 {
+		t.Errorf("connectionCount() = %d, want 1", got)
+	}
+}
+
+func TestServer_MaxSessionsPerClient(t *testing.T) {
+	registry := newMockRegistry()
+	config := DefaultConfig()
+	config.Limits.MaxSessionsPerClient = 1
+
+	server, err := NewServer(config, registry)
+	if err != nil {
+		t.Fatalf("NewServer() error = %v", err)
+	}
+
+	server.Router().RegisterFunc("HELLO", func(ctx *handler.Context, cmd *protocol.Command) (*protocol.Response, error) {
+		return protocol.NewResponse("HELLO").
+			WithAction("REPLY").
+			WithResult("OK").
+			WithVersion("3.3"), nil
+	})
+	server.Router().RegisterFunc("SESSION CREATE", func(ctx *handler.Context, cmd *protocol.Command) (*protocol.Response, error) {
+		return protocol.NewResponse("SESSION").
+			WithAction("STATUS").
+			WithResult("OK"), nil
+	})
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("net.Listen() error = %v", err)
+	}
+
+	go server.Serve(listener)
+	defer server.Close()
+
+	createSession := func(conn net.Conn, reader *bufio.Reader, id string) string {
+		conn.Write([]byte("SESSION CREATE STYLE=STREAM ID=" + id + " DESTINATION=TRANSIENT\n"))
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			t.Fatalf("ReadString() error = %v", err)
+		}
+		return line
+	}
+
+	dial := func() (net.Conn, *bufio.Reader) {
+		conn, err := net.Dial("tcp", listener.Addr().String())
+		if err != nil {
+			t.Fatalf("net.Dial() error = %v", err)
+		}
+		reader := bufio.NewReader(conn)
+		conn.Write([]byte("HELLO VERSION MIN=3.0 MAX=3.3\n"))
+		if _, err := reader.ReadString('\n'); err != nil {
+			t.Fatalf("ReadString() error = %v", err)
+		}
+		return conn, reader
+	}
+
+	conn1, reader1 := dial()
+	if line := createSession(conn1, reader1, "first"); !strings.Contains(line, "RESULT=OK") {
+		t.Fatalf("first SESSION CREATE = %q, want RESULT=OK", line)
+	}
+
+	conn2, reader2 := dial()
+	defer conn2.Close()
+	line := createSession(conn2, reader2, "second")
+	if !strings.Contains(line, "SESSION STATUS RESULT=I2P_ERROR") || !strings.Contains(line, "session limit") {
+		t.Errorf("second SESSION CREATE = %q, want session limit I2P_ERROR", line)
+	}
+
+	// Closing the first connection frees its session slot
+	conn1.Close()
+	deadline := time.Now().Add(time.Second)
+	for server.clients.sessionCount("127.0.0.1") != 0 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	if line := createSession(conn2, reader2, "third"); !strings.Contains(line, "RESULT=OK") {
+		t.Errorf("SESSION CREATE after release = %q, want RESULT=OK", line)
+	}
+}
+
 func TestGetOptionValue(t *testing.T) {
 	tests := []struct {
 		name    string
@@ -543,8 +895,9 @@ func TestIsAuthCommand(t *testing.T) {
 		verb string
 		want bool
 	}{
-		{"HELLO", true},
-		{"hello", true},
+		{"AUTH", true},
+		{"auth", true},
+		{"HELLO", false},
 		{"SESSION", false},
 	}
 
@@ -793,3 +1146,35 @@ func TestSendPing(t *testing.T) {
 		})
 	}
 }
+
+func TestServer_ConnectionsAndClose(t *testing.T) {
+	server, conn, reader := startKeepaliveServer(t, DefaultConfig(), "3.3")
+
+	conn.Write([]byte("SESSION CREATE STYLE=STREAM ID=admin DESTINATION=TRANSIENT\n"))
+	if _, err := reader.ReadString('\n'); err != nil {
+		t.Fatalf("ReadString() error = %v", err)
+	}
+
+	infos := server.Connections()
+	if len(infos) != 1 {
+		t.Fatalf("Connections() = %d entries, want 1", len(infos))
+	}
+	if infos[0].Version != "3.3" || infos[0].RemoteAddr != conn.LocalAddr().String() {
+		t.Errorf("Connections()[0] = %+v", infos[0])
+	}
+
+	if server.CloseConnection(infos[0].ID + 1) {
+		t.Error("CloseConnection() with unknown ID = true, want false")
+	}
+	if server.CloseSessionConnection("missing") {
+		t.Error("CloseSessionConnection() with unknown session = true, want false")
+	}
+	if !server.CloseConnection(infos[0].ID) {
+		t.Fatal("CloseConnection() = false, want true")
+	}
+
+	conn.SetReadDeadline(time.Now().Add(time.Second))
+	if _, err := reader.ReadString('\n'); err == nil {
+		t.Error("expected connection to be closed")
+	}
+}
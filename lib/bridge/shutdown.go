@@ -0,0 +1,79 @@
+package bridge
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-i2p/logger"
+)
+
+// shutdownPollInterval is how often Shutdown checks for active streams.
+const shutdownPollInterval = 100 * time.Millisecond
+
+// Shutdown gracefully shuts down the server. It closes the listener so no
+// new connections are accepted and rejects SESSION CREATE and SESSION ADD
+// with "bridge is shutting down". Stream data connections (after STREAM
+// CONNECT or STREAM ACCEPT) and connections accepted by STREAM FORWARD are
+// left to finish; once none remain, Shutdown calls Close.
+//
+// If ctx is done first, the remaining connections are closed and
+// ctx.Err() is returned. Control connections, and with them their
+// sessions, stay open until the drain ends because closing a session
+// would tear down its streams.
+func (s *Server) Shutdown(ctx context.Context) error {
+	if s.closed.Load() {
+		return nil
+	}
+
+	if !s.draining.Swap(true) {
+		log.WithFields(logger.Fields{"pkg": "bridge", "func": "Server.Shutdown", "streams": s.ActiveStreams()}).Info("Draining SAM bridge")
+
+		s.mu.Lock()
+		listener := s.listener
+		s.mu.Unlock()
+		if listener != nil {
+			listener.Close()
+		}
+	}
+
+	ticker := time.NewTicker(shutdownPollInterval)
+	defer ticker.Stop()
+
+	for {
+		if s.ActiveStreams() == 0 {
+			return s.Close()
+		}
+		select {
+		case <-ctx.Done():
+			log.WithFields(logger.Fields{"pkg": "bridge", "func": "Server.Shutdown", "streams": s.ActiveStreams()}).Warn("Shutdown deadline reached, closing active streams")
+			s.Close()
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// Draining returns true once Shutdown has been called.
+func (s *Server) Draining() bool {
+	return s.draining.Load()
+}
+
+// ActiveStreams returns the number of stream data connections plus the
+// number of open connections accepted by STREAM FORWARD.
+func (s *Server) ActiveStreams() int {
+	s.mu.Lock()
+	connections := make([]*Connection, 0, len(s.connections))
+	for c := range s.connections {
+		connections = append(connections, c)
+	}
+	s.mu.Unlock()
+
+	n := 0
+	for _, c := range connections {
+		if c.State() == StateStreaming {
+			n++
+		}
+		n += c.ActiveForwards()
+	}
+	return n
+}
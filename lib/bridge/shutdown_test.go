@@ -0,0 +1,206 @@
+package bridge
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"io"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-i2p/go-sam-bridge/lib/handler"
+	"github.com/go-i2p/go-sam-bridge/lib/protocol"
+)
+
+// startStreamServer starts a server whose STREAM CONNECT handler pipes the
+// client socket to an in-memory peer. Each accepted STREAM CONNECT sends its
+// peer end on the returned channel.
+func startStreamServer(t *testing.T) (*Server, string, <-chan net.Conn) {
+	t.Helper()
+
+	peers := make(chan net.Conn, 4)
+	connect := func(ctx *handler.Context, cmd *protocol.Command) (*protocol.Response, error) {
+		i2pSide, peer := net.Pipe()
+		peers <- peer
+		ctx.SetStreamConn(i2pSide)
+		ctx.StartForwarding()
+		return protocol.NewResponse("STREAM").
+			WithAction("STATUS").
+			WithResult("OK"), nil
+	}
+	server, addr := startTestServer(t, DefaultConfig(), map[string]handler.HandlerFunc{"STREAM CONNECT": connect}, nil)
+	return server, addr, peers
+}
+
+// dialHello connects to addr and completes HELLO.
+func dialHello(t *testing.T, addr string) (net.Conn, *bufio.Reader) {
+	t.Helper()
+
+	conn, err := net.Dial("tcp", addr)
+	if err != nil {
+		t.Fatalf("net.Dial() error = %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+
+	reader := bufio.NewReader(conn)
+	conn.Write([]byte("HELLO VERSION MIN=3.0 MAX=3.3\n"))
+	if _, err := reader.ReadString('\n'); err != nil {
+		t.Fatalf("ReadString() error = %v", err)
+	}
+	return conn, reader
+}
+
+// openStream issues STREAM CONNECT on a new connection and returns the
+// client socket and the I2P peer it is piped to.
+func openStream(t *testing.T, addr string, peers <-chan net.Conn) (net.Conn, net.Conn) {
+	t.Helper()
+
+	conn, reader := dialHello(t, addr)
+	conn.Write([]byte("STREAM CONNECT ID=test DESTINATION=peer\n"))
+	line, err := reader.ReadString('\n')
+	if err != nil {
+		t.Fatalf("ReadString() error = %v", err)
+	}
+	if !strings.Contains(line, "RESULT=OK") {
+		t.Fatalf("STREAM CONNECT = %q, want RESULT=OK", line)
+	}
+
+	peer := <-peers
+	t.Cleanup(func() { peer.Close() })
+	return conn, peer
+}
+
+// waitForStreams waits until server reports want active streams.
+func waitForStreams(t *testing.T, server *Server, want int) {
+	t.Helper()
+
+	deadline := time.Now().Add(time.Second)
+	for server.ActiveStreams() != want && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	if got := server.ActiveStreams(); got != want {
+		t.Fatalf("ActiveStreams() = %d, want %d", got, want)
+	}
+}
+
+func TestServer_StreamStaysOpen(t *testing.T) {
+	server, addr, peers := startStreamServer(t)
+	conn, peer := openStream(t, addr, peers)
+
+	// Data must flow after the command loop has handed the socket over.
+	waitForStreams(t, server, 1)
+	go conn.Write([]byte("hello"))
+
+	buf := make([]byte, 5)
+	peer.SetReadDeadline(time.Now().Add(time.Second))
+	if _, err := io.ReadFull(peer, buf); err != nil {
+		t.Fatalf("peer read error = %v", err)
+	}
+	if string(buf) != "hello" {
+		t.Errorf("peer read %q, want %q", buf, "hello")
+	}
+
+	peer.Close()
+	waitForStreams(t, server, 0)
+}
+
+func TestServer_ShutdownDrainsStreams(t *testing.T) {
+	server, addr, peers := startStreamServer(t)
+	_, peer := openStream(t, addr, peers)
+	control, controlReader := dialHello(t, addr)
+	waitForStreams(t, server, 1)
+
+	shutdownErr := make(chan error, 1)
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		shutdownErr <- server.Shutdown(ctx)
+	}()
+
+	deadline := time.Now().Add(time.Second)
+	for !server.Draining() && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	// New sessions are refused while draining.
+	control.Write([]byte("SESSION CREATE STYLE=STREAM ID=late DESTINATION=TRANSIENT\n"))
+	line, err := controlReader.ReadString('\n')
+	if err != nil {
+		t.Fatalf("ReadString() error = %v", err)
+	}
+	if !strings.Contains(line, "RESULT=I2P_ERROR") || !strings.Contains(line, "shutting down") {
+		t.Errorf("SESSION CREATE while draining = %q, want shutting down error", line)
+	}
+
+	// New connections are refused.
+	if conn, err := net.DialTimeout("tcp", addr, 100*time.Millisecond); err == nil {
+		conn.Close()
+		t.Error("net.Dial() succeeded while draining, want error")
+	}
+
+	select {
+	case err := <-shutdownErr:
+		t.Fatalf("Shutdown() returned %v with a stream still active", err)
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	peer.Close()
+	select {
+	case err := <-shutdownErr:
+		if err != nil {
+			t.Errorf("Shutdown() error = %v, want nil", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("Shutdown() did not return after the stream finished")
+	}
+
+	// Remaining control connections are closed once drained.
+	control.SetReadDeadline(time.Now().Add(time.Second))
+	if _, err := controlReader.ReadString('\n'); err == nil {
+		t.Error("expected control connection to be closed after Shutdown")
+	}
+}
+
+func TestServer_ShutdownDeadline(t *testing.T) {
+	server, addr, peers := startStreamServer(t)
+	conn, _ := openStream(t, addr, peers)
+	waitForStreams(t, server, 1)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	if err := server.Shutdown(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Shutdown() error = %v, want context.DeadlineExceeded", err)
+	}
+
+	conn.SetReadDeadline(time.Now().Add(time.Second))
+	buf := make([]byte, 1)
+	if _, err := conn.Read(buf); err == nil {
+		t.Error("expected stream to be closed after the shutdown deadline")
+	}
+}
+
+func TestServer_ShutdownIdle(t *testing.T) {
+	server, addr, _ := startStreamServer(t)
+	dialHello(t, addr)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	start := time.Now()
+	if err := server.Shutdown(ctx); err != nil {
+		t.Errorf("Shutdown() error = %v, want nil", err)
+	}
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Errorf("Shutdown() took %v with no active streams", elapsed)
+	}
+	deadline := time.Now().Add(time.Second)
+	for server.ConnectionCount() != 0 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	if got := server.ConnectionCount(); got != 0 {
+		t.Errorf("ConnectionCount() = %d after Shutdown, want 0", got)
+	}
+}
@@ -0,0 +1,175 @@
+package bridge
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-i2p/logger"
+)
+
+// Transcript directions.
+const (
+	// TranscriptIn marks a line received from the client.
+	TranscriptIn = "in"
+
+	// TranscriptOut marks a line sent to the client.
+	TranscriptOut = "out"
+)
+
+// transcriptFileMode is the file mode of a transcript file. Transcripts
+// are redacted, but still show what a client did.
+const transcriptFileMode os.FileMode = 0o600
+
+// TranscriptEntry is one control line of a transcript.
+type TranscriptEntry struct {
+	Time time.Time `json:"time"`
+
+	// Dir is TranscriptIn or TranscriptOut.
+	Dir string `json:"dir"`
+
+	// Line is the line without its newline, with secret option values
+	// replaced by Redacted.
+	Line string `json:"line"`
+}
+
+// Transcript records the control lines of one connection as JSON lines of
+// TranscriptEntry. Stream and datagram payloads are not recorded.
+//
+// Thread-safety: All methods are safe for concurrent use.
+type Transcript struct {
+	mu sync.Mutex
+	w  io.Writer
+
+	// err is the first write error; nothing more is recorded after it.
+	err error
+}
+
+// NewTranscript returns a Transcript writing to w. Close closes w if it
+// is an io.Closer.
+func NewTranscript(w io.Writer) *Transcript {
+	return &Transcript{w: w}
+}
+
+// Record writes a line in direction dir, with secrets redacted.
+func (t *Transcript) Record(dir, line string) {
+	entry := TranscriptEntry{
+		Time: time.Now().UTC(),
+		Dir:  dir,
+		Line: redactLine(strings.TrimRight(line, "\r\n")),
+	}
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.err == nil {
+		_, t.err = t.w.Write(append(data, '\n'))
+	}
+}
+
+// Close closes the underlying writer, if it is an io.Closer.
+func (t *Transcript) Close() error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.err == nil {
+		t.err = errors.New("transcript closed")
+	}
+	if closer, ok := t.w.(io.Closer); ok {
+		return closer.Close()
+	}
+	return nil
+}
+
+// ReadTranscript reads the entries of a transcript written by Transcript.
+func ReadTranscript(r io.Reader) ([]TranscriptEntry, error) {
+	var entries []TranscriptEntry
+	dec := json.NewDecoder(r)
+	for {
+		var entry TranscriptEntry
+		err := dec.Decode(&entry)
+		if errors.Is(err, io.EOF) {
+			return entries, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("transcript entry %d: %w", len(entries)+1, err)
+		}
+		if entry.Dir != TranscriptIn && entry.Dir != TranscriptOut {
+			return nil, fmt.Errorf("transcript entry %d: unknown direction %q", len(entries)+1, entry.Dir)
+		}
+		entries = append(entries, entry)
+	}
+}
+
+// redactLine replaces the values of secret KEY=value options in a SAM
+// line with Redacted, leaving the rest of the line as it was sent.
+func redactLine(line string) string {
+	var b strings.Builder
+	for i := 0; i < len(line); {
+		if line[i] == ' ' || line[i] == '\t' {
+			b.WriteByte(line[i])
+			i++
+			continue
+		}
+		end := tokenEnd(line, i)
+		token := line[i:end]
+		if key, value, ok := strings.Cut(token, "="); ok && key != "" {
+			if len(value) >= 2 && value[0] == '"' && value[len(value)-1] == '"' {
+				value = value[1 : len(value)-1]
+			}
+			if redactOption(key, value) == Redacted {
+				token = key + "=" + Redacted
+			}
+		}
+		b.WriteString(token)
+		i = end
+	}
+	return b.String()
+}
+
+// tokenEnd returns the end of the token starting at i: the next space or
+// tab outside double quotes.
+func tokenEnd(line string, i int) int {
+	quoted := false
+	for ; i < len(line); i++ {
+		switch c := line[i]; {
+		case c == '\\' && quoted:
+			i++
+		case c == '"':
+			quoted = !quoted
+		case (c == ' ' || c == '\t') && !quoted:
+			return i
+		}
+	}
+	return len(line)
+}
+
+// startTranscript records c in a new file in Config.TranscriptDir, if set.
+// Failing to create the file is logged and the connection served anyway.
+func (s *Server) startTranscript(c *Connection) {
+	dir := s.Config().TranscriptDir
+	if dir == "" {
+		return
+	}
+
+	name := fmt.Sprintf("%s-%d.jsonl", time.Now().UTC().Format("20060102T150405Z"), c.ID())
+	err := os.MkdirAll(dir, 0o700)
+	var file *os.File
+	if err == nil {
+		file, err = os.OpenFile(filepath.Join(dir, name), os.O_WRONLY|os.O_CREATE|os.O_EXCL, transcriptFileMode)
+	}
+	if err != nil {
+		log.WithFields(logger.Fields{"pkg": "bridge", "func": "Server.startTranscript", "dir": dir}).WithError(err).Warn("Failed to create transcript file")
+		return
+	}
+	log.WithFields(logger.Fields{"pkg": "bridge", "func": "Server.startTranscript", "remote": c.RemoteAddr(), "file": file.Name()}).Debug("Recording connection transcript")
+	c.SetTranscript(NewTranscript(file))
+}
@@ -0,0 +1,147 @@
+package bridge
+
+import (
+	"bufio"
+	"bytes"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-i2p/go-sam-bridge/lib/handler"
+	"github.com/go-i2p/go-sam-bridge/lib/protocol"
+)
+
+func TestRedactLine(t *testing.T) {
+	tests := []struct {
+		line, want string
+	}{
+		{"HELLO VERSION MIN=3.0 MAX=3.3", "HELLO VERSION MIN=3.0 MAX=3.3"},
+		{"HELLO VERSION USER=alice PASSWORD=hunter2", "HELLO VERSION USER=alice PASSWORD=[REDACTED]"},
+		{`HELLO VERSION USER=alice PASSWORD="two words"`, "HELLO VERSION USER=alice PASSWORD=[REDACTED]"},
+		{`AUTH ADD USER=bob PASSWORD="a \"quoted\" pass" ROLE=admin`, "AUTH ADD USER=bob PASSWORD=[REDACTED] ROLE=admin"},
+		{"SESSION CREATE STYLE=STREAM ID=a DESTINATION=AAAAkeys", "SESSION CREATE STYLE=STREAM ID=a DESTINATION=[REDACTED]"},
+		{"SESSION CREATE STYLE=STREAM ID=a DESTINATION=TRANSIENT", "SESSION CREATE STYLE=STREAM ID=a DESTINATION=TRANSIENT"},
+		{"SESSION STATUS RESULT=OK DESTINATION=AAAAkeys", "SESSION STATUS RESULT=OK DESTINATION=[REDACTED]"},
+		{"DEST REPLY PUB=AAAApub PRIV=AAAApriv", "DEST REPLY PUB=AAAApub PRIV=[REDACTED]"},
+		{"SESSION CREATE ID=a OFFLINE_SIGNATURE=sig i2cp.leaseSetPrivateKey=key", "SESSION CREATE ID=a OFFLINE_SIGNATURE=[REDACTED] i2cp.leaseSetPrivateKey=[REDACTED]"},
+		{"NAMING REPLY RESULT=OK NAME=test.i2p VALUE=AAAApub", "NAMING REPLY RESULT=OK NAME=test.i2p VALUE=AAAApub"},
+		{`HELLO REPLY RESULT=I2P_ERROR MESSAGE="bad  input"`, `HELLO REPLY RESULT=I2P_ERROR MESSAGE="bad  input"`},
+		{"PING  some text", "PING  some text"},
+	}
+	for _, tt := range tests {
+		if got := redactLine(tt.line); got != tt.want {
+			t.Errorf("redactLine(%q) = %q, want %q", tt.line, got, tt.want)
+		}
+	}
+}
+
+func TestTranscript_RecordAndRead(t *testing.T) {
+	var buf bytes.Buffer
+	tr := NewTranscript(&buf)
+	tr.Record(TranscriptIn, "HELLO VERSION USER=alice PASSWORD=hunter2\n")
+	tr.Record(TranscriptOut, "HELLO REPLY RESULT=OK VERSION=3.3\n")
+	tr.Close()
+	tr.Record(TranscriptIn, "QUIT\n")
+
+	if strings.Contains(buf.String(), "hunter2") {
+		t.Fatalf("transcript contains the password:\n%s", buf.String())
+	}
+
+	entries, err := ReadTranscript(&buf)
+	if err != nil {
+		t.Fatalf("ReadTranscript() error = %v", err)
+	}
+	if len(entries) != 2 {
+		t.Fatalf("ReadTranscript() = %d entries, want 2 (none after Close)", len(entries))
+	}
+	if entries[0].Dir != TranscriptIn || entries[0].Line != "HELLO VERSION USER=alice PASSWORD=[REDACTED]" {
+		t.Errorf("entries[0] = %+v", entries[0])
+	}
+	if entries[1].Dir != TranscriptOut || entries[1].Line != "HELLO REPLY RESULT=OK VERSION=3.3" {
+		t.Errorf("entries[1] = %+v", entries[1])
+	}
+	if entries[0].Time.IsZero() {
+		t.Error("entries[0].Time is zero")
+	}
+}
+
+func TestReadTranscript_Invalid(t *testing.T) {
+	for _, input := range []string{
+		`{"dir":"sideways","line":"PING"}`,
+		`{"dir":"in","line":`,
+	} {
+		if _, err := ReadTranscript(strings.NewReader(input)); err == nil {
+			t.Errorf("ReadTranscript(%q) error = nil, want an error", input)
+		}
+	}
+}
+
+func TestServer_Transcript(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "transcripts")
+	config := DefaultConfig()
+	config.TranscriptDir = dir
+
+	server, err := NewServer(config, newMockRegistry())
+	if err != nil {
+		t.Fatalf("NewServer() error = %v", err)
+	}
+	server.Router().RegisterFunc("HELLO", func(ctx *handler.Context, cmd *protocol.Command) (*protocol.Response, error) {
+		return protocol.NewResponse("HELLO").
+			WithAction("REPLY").
+			WithResult("OK").
+			WithVersion("3.3"), nil
+	})
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("net.Listen() error = %v", err)
+	}
+	go server.Serve(listener)
+	defer server.Close()
+
+	conn, err := net.Dial("tcp", listener.Addr().String())
+	if err != nil {
+		t.Fatalf("net.Dial() error = %v", err)
+	}
+	conn.SetDeadline(time.Now().Add(2 * time.Second))
+	conn.Write([]byte("HELLO VERSION MIN=3.0 MAX=3.3 USER=alice PASSWORD=hunter2\n"))
+	if _, err := bufio.NewReader(conn).ReadString('\n'); err != nil {
+		t.Fatalf("ReadString() error = %v", err)
+	}
+	conn.Close()
+
+	// Lines are written to the file as they are recorded, so the reply is
+	// already in it.
+	files, _ := filepath.Glob(filepath.Join(dir, "*.jsonl"))
+	if len(files) != 1 {
+		t.Fatalf("transcript files = %v, want 1", files)
+	}
+
+	f, err := os.Open(files[0])
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	defer f.Close()
+	entries, err := ReadTranscript(f)
+	if err != nil {
+		t.Fatalf("ReadTranscript() error = %v", err)
+	}
+	want := []TranscriptEntry{
+		{Dir: TranscriptIn, Line: "HELLO VERSION MIN=3.0 MAX=3.3 USER=alice PASSWORD=[REDACTED]"},
+		{Dir: TranscriptOut, Line: "HELLO REPLY RESULT=OK VERSION=3.3"},
+	}
+	if len(entries) != len(want) {
+		t.Fatalf("transcript = %+v, want %d entries", entries, len(want))
+	}
+	for i, w := range want {
+		if entries[i].Dir != w.Dir || entries[i].Line != w.Line {
+			t.Errorf("entries[%d] = %s %q, want %s %q", i, entries[i].Dir, entries[i].Line, w.Dir, w.Line)
+		}
+	}
+	if info, err := f.Stat(); err == nil && info.Mode().Perm() != transcriptFileMode {
+		t.Errorf("file mode = %v, want %v", info.Mode().Perm(), transcriptFileMode)
+	}
+}
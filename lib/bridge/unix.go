@@ -0,0 +1,152 @@
+package bridge
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"os/user"
+	"strconv"
+	"strings"
+
+	"github.com/go-i2p/logger"
+)
+
+// UnixPrefix marks a listen address as a Unix domain socket path,
+// e.g. "unix:/run/sam-bridge/sam.sock".
+const UnixPrefix = "unix:"
+
+// DefaultUnixSocketMode is the file mode applied to SAM control sockets:
+// read/write for the owner and group only.
+const DefaultUnixSocketMode os.FileMode = 0o660
+
+// UnixSocketConfig holds settings for a Unix domain socket listener.
+// Access to the SAM port is then controlled by filesystem permissions.
+// All Unix socket clients share a single entry for per-client limits.
+type UnixSocketConfig struct {
+	// Mode is the permission bits applied to the socket file (0 = DefaultUnixSocketMode).
+	Mode os.FileMode
+
+	// Owner optionally changes the socket's owner and group, as "user",
+	// "user:group" or ":group". Names and numeric IDs are both accepted.
+	Owner string
+}
+
+// UnixSocketPath returns the socket path of a "unix:" address and true,
+// or "" and false if addr is not a Unix socket address.
+func UnixSocketPath(addr string) (string, bool) {
+	if !strings.HasPrefix(addr, UnixPrefix) {
+		return "", false
+	}
+	return addr[len(UnixPrefix):], true
+}
+
+// ListenUnix creates a Unix domain socket listener at path and applies the
+// configured mode and owner. A stale socket file left by a previous run is
+// removed first; the socket file is removed again when the listener is closed.
+func ListenUnix(path string, cfg UnixSocketConfig) (net.Listener, error) {
+	if path == "" {
+		return nil, fmt.Errorf("empty unix socket path")
+	}
+
+	uid, gid, err := lookupOwner(cfg.Owner)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := RemoveStaleSocket(path); err != nil {
+		return nil, err
+	}
+
+	ln, err := net.Listen("unix", path)
+	if err != nil {
+		return nil, err
+	}
+
+	mode := cfg.Mode
+	if mode == 0 {
+		mode = DefaultUnixSocketMode
+	}
+	if err := os.Chmod(path, mode); err != nil {
+		ln.Close()
+		return nil, fmt.Errorf("chmod %s: %w", path, err)
+	}
+	if uid != -1 || gid != -1 {
+		if err := os.Chown(path, uid, gid); err != nil {
+			ln.Close()
+			return nil, fmt.Errorf("chown %s: %w", path, err)
+		}
+	}
+
+	log.WithFields(logger.Fields{"pkg": "bridge", "func": "ListenUnix", "path": path, "mode": fmt.Sprintf("%o", mode), "owner": cfg.Owner}).Debug("Unix socket listener created")
+	return ln, nil
+}
+
+// RemoveStaleSocket removes path if it is a Unix socket nobody is listening
+// on. It returns an error if path is another kind of file or the socket is
+// still in use, and nil if path does not exist.
+func RemoveStaleSocket(path string) error {
+	info, err := os.Lstat(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	if info.Mode()&os.ModeSocket == 0 {
+		return fmt.Errorf("%s exists and is not a socket", path)
+	}
+	if conn, err := net.Dial("unix", path); err == nil {
+		conn.Close()
+		return fmt.Errorf("%s is in use", path)
+	}
+
+	log.WithFields(logger.Fields{"pkg": "bridge", "func": "RemoveStaleSocket", "path": path}).Info("Removing stale unix socket")
+	return os.Remove(path)
+}
+
+// lookupOwner resolves a "user[:group]" owner spec to numeric IDs.
+// -1 is returned for any part that is not set, which os.Chown leaves unchanged.
+func lookupOwner(owner string) (uid, gid int, err error) {
+	uid, gid = -1, -1
+	if owner == "" {
+		return uid, gid, nil
+	}
+
+	userName, groupName, _ := strings.Cut(owner, ":")
+	if userName != "" {
+		if uid, err = lookupID(userName, func(name string) (string, error) {
+			u, err := user.Lookup(name)
+			if err != nil {
+				return "", err
+			}
+			return u.Uid, nil
+		}); err != nil {
+			return -1, -1, fmt.Errorf("unix socket owner %q: %w", userName, err)
+		}
+	}
+	if groupName != "" {
+		if gid, err = lookupID(groupName, func(name string) (string, error) {
+			g, err := user.LookupGroup(name)
+			if err != nil {
+				return "", err
+			}
+			return g.Gid, nil
+		}); err != nil {
+			return -1, -1, fmt.Errorf("unix socket group %q: %w", groupName, err)
+		}
+	}
+	return uid, gid, nil
+}
+
+// lookupID returns name as a number if it is numeric, otherwise the
+// numeric ID that lookup resolves it to.
+func lookupID(name string, lookup func(string) (string, error)) (int, error) {
+	if id, err := strconv.Atoi(name); err == nil {
+		return id, nil
+	}
+	id, err := lookup(name)
+	if err != nil {
+		return -1, err
+	}
+	return strconv.Atoi(id)
+}
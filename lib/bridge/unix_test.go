@@ -0,0 +1,204 @@
+package bridge
+
+import (
+	"bufio"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-i2p/go-sam-bridge/lib/handler"
+	"github.com/go-i2p/go-sam-bridge/lib/protocol"
+)
+
+func TestUnixSocketPath(t *testing.T) {
+	tests := []struct {
+		addr     string
+		wantPath string
+		wantOK   bool
+	}{
+		{"unix:/run/sam.sock", "/run/sam.sock", true},
+		{"unix:", "", true},
+		{":7656", "", false},
+		{"127.0.0.1:7656", "", false},
+	}
+
+	for _, tt := range tests {
+		path, ok := UnixSocketPath(tt.addr)
+		if path != tt.wantPath || ok != tt.wantOK {
+			t.Errorf("UnixSocketPath(%q) = (%q, %v), want (%q, %v)", tt.addr, path, ok, tt.wantPath, tt.wantOK)
+		}
+	}
+}
+
+func TestListenUnix(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "sam.sock")
+
+	ln, err := ListenUnix(path, UnixSocketConfig{Mode: 0o600})
+	if err != nil {
+		t.Fatalf("ListenUnix() error = %v", err)
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatalf("Stat() error = %v", err)
+	}
+	if perm := info.Mode().Perm(); perm != 0o600 {
+		t.Errorf("socket mode = %o, want 600", perm)
+	}
+
+	if ln2, err := ListenUnix(path, UnixSocketConfig{}); err == nil {
+		ln2.Close()
+		t.Error("ListenUnix() on socket in use succeeded, want error")
+	}
+
+	ln.Close()
+	if _, err := os.Lstat(path); !os.IsNotExist(err) {
+		t.Errorf("socket file still exists after Close(): %v", err)
+	}
+}
+
+func TestListenUnix_DefaultMode(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "sam.sock")
+
+	ln, err := ListenUnix(path, UnixSocketConfig{})
+	if err != nil {
+		t.Fatalf("ListenUnix() error = %v", err)
+	}
+	defer ln.Close()
+
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatalf("Stat() error = %v", err)
+	}
+	if perm := info.Mode().Perm(); perm != DefaultUnixSocketMode {
+		t.Errorf("socket mode = %o, want %o", perm, DefaultUnixSocketMode)
+	}
+}
+
+func TestListenUnix_Owner(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "sam.sock")
+
+	// Changing to our own uid/gid is always permitted.
+	owner := strconv.Itoa(os.Getuid()) + ":" + strconv.Itoa(os.Getgid())
+	ln, err := ListenUnix(path, UnixSocketConfig{Owner: owner})
+	if err != nil {
+		t.Fatalf("ListenUnix() error = %v", err)
+	}
+	ln.Close()
+
+	if _, err := ListenUnix(path, UnixSocketConfig{Owner: "no-such-user-sam-bridge"}); err == nil {
+		t.Error("ListenUnix() with unknown owner succeeded, want error")
+	}
+}
+
+func TestRemoveStaleSocket(t *testing.T) {
+	dir := t.TempDir()
+
+	// Missing file is not an error.
+	if err := RemoveStaleSocket(filepath.Join(dir, "missing.sock")); err != nil {
+		t.Errorf("RemoveStaleSocket(missing) error = %v", err)
+	}
+
+	// Regular files are never removed.
+	file := filepath.Join(dir, "file")
+	if err := os.WriteFile(file, nil, 0o600); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+	if err := RemoveStaleSocket(file); err == nil {
+		t.Error("RemoveStaleSocket(regular file) succeeded, want error")
+	}
+
+	// A socket nobody listens on is removed.
+	stale := filepath.Join(dir, "stale.sock")
+	ln, err := net.Listen("unix", stale)
+	if err != nil {
+		t.Fatalf("net.Listen() error = %v", err)
+	}
+	ln.(*net.UnixListener).SetUnlinkOnClose(false)
+	ln.Close()
+
+	if err := RemoveStaleSocket(stale); err != nil {
+		t.Errorf("RemoveStaleSocket(stale) error = %v", err)
+	}
+	if _, err := os.Lstat(stale); !os.IsNotExist(err) {
+		t.Errorf("stale socket still exists: %v", err)
+	}
+}
+
+func TestLookupOwner(t *testing.T) {
+	tests := []struct {
+		owner   string
+		wantUID int
+		wantGID int
+	}{
+		{"", -1, -1},
+		{"1000", 1000, -1},
+		{"1000:1001", 1000, 1001},
+		{":1001", -1, 1001},
+	}
+
+	for _, tt := range tests {
+		uid, gid, err := lookupOwner(tt.owner)
+		if err != nil {
+			t.Errorf("lookupOwner(%q) error = %v", tt.owner, err)
+			continue
+		}
+		if uid != tt.wantUID || gid != tt.wantGID {
+			t.Errorf("lookupOwner(%q) = (%d, %d), want (%d, %d)", tt.owner, uid, gid, tt.wantUID, tt.wantGID)
+		}
+	}
+}
+
+func TestServer_ListenAndServeUnix(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "sam.sock")
+
+	config := DefaultConfig()
+	config.ListenAddr = UnixPrefix + path
+	config.DatagramPort = 0
+
+	server, err := NewServer(config, newMockRegistry())
+	if err != nil {
+		t.Fatalf("NewServer() error = %v", err)
+	}
+	server.Router().RegisterFunc("HELLO", func(ctx *handler.Context, cmd *protocol.Command) (*protocol.Response, error) {
+		return protocol.NewResponse("HELLO").
+			WithAction("REPLY").
+			WithResult("OK").
+			WithVersion("3.3"), nil
+	})
+
+	errCh := make(chan error, 1)
+	go func() { errCh <- server.ListenAndServe() }()
+
+	var conn net.Conn
+	for deadline := time.Now().Add(time.Second); time.Now().Before(deadline); time.Sleep(10 * time.Millisecond) {
+		if conn, err = net.Dial("unix", path); err == nil {
+			break
+		}
+	}
+	if err != nil {
+		t.Fatalf("net.Dial() error = %v", err)
+	}
+	defer conn.Close()
+
+	conn.Write([]byte("HELLO VERSION MIN=3.0 MAX=3.3\n"))
+	line, err := bufio.NewReader(conn).ReadString('\n')
+	if err != nil {
+		t.Fatalf("ReadString() error = %v", err)
+	}
+	if !strings.Contains(line, "RESULT=OK") {
+		t.Errorf("HELLO reply = %q, want RESULT=OK", line)
+	}
+
+	server.Close()
+	if err := <-errCh; err != nil {
+		t.Errorf("ListenAndServe() error = %v", err)
+	}
+	if _, err := os.Lstat(path); !os.IsNotExist(err) {
+		t.Errorf("socket file still exists after Close(): %v", err)
+	}
+}
@@ -16,9 +16,14 @@ import (
 	"strings"
 	"sync"
 
+	"github.com/go-i2p/go-sam-bridge/lib/metrics"
 	"github.com/go-i2p/go-sam-bridge/lib/session"
+	"github.com/go-i2p/logger"
 )
 
+// unknownStyle labels dropped datagrams that could not be matched to a session.
+const unknownStyle = "UNKNOWN"
+
 // Common errors for UDP datagram handling
 var (
 	ErrInvalidDatagram  = errors.New("invalid datagram format")
@@ -107,6 +112,10 @@ type UDPListener struct {
 
 	// onDatagram is called for each valid datagram received (for testing/metrics).
 	onDatagram func(header *DatagramHeader, payload []byte, from net.Addr)
+
+	// allow reports whether datagrams from an address are accepted.
+	// Nil accepts all; see SetAccessFilter.
+	allow func(from net.Addr) bool
 }
 
 // NewUDPListener creates a new UDP listener for SAM datagrams.
@@ -126,6 +135,16 @@ func NewUDPListener(addr string, registry session.Registry) *UDPListener {
 	}
 }
 
+// SetAccessFilter sets a function that decides, for each datagram, whether
+// its source address may use the UDP port, such as bridge.Server.AllowsPeer.
+// Datagrams it rejects are dropped before their header is parsed. Must be
+// called before Start.
+func (l *UDPListener) SetAccessFilter(allow func(from net.Addr) bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.allow = allow
+}
+
 // Start begins listening for UDP datagrams.
 // This method is non-blocking and starts a goroutine to handle incoming datagrams.
 func (l *UDPListener) Start() error {
@@ -166,9 +185,15 @@ func (l *UDPListener) receiveLoop() {
 		}
 
 		data, addr := l.readDatagram(buf)
-		if data != nil {
-			l.handleDatagram(data, addr)
+		if data == nil {
+			continue
 		}
+		if l.allow != nil && !l.allow(addr) {
+			log.WithFields(logger.Fields{"pkg": "datagram", "func": "UDPListener.receiveLoop", "from": addr}).Debug("Dropping datagram: client address not allowed")
+			metrics.DatagramsDropped.Inc(unknownStyle, metrics.DropDenied)
+			continue
+		}
+		l.handleDatagram(data, addr)
 	}
 }
 
@@ -204,6 +229,7 @@ func (l *UDPListener) handleDatagram(data []byte, from net.Addr) {
 	header, payload, err := ParseDatagramHeader(data)
 	if err != nil {
 		// Invalid datagram - silently drop per SAM behavior
+		metrics.DatagramsDropped.Inc(unknownStyle, metrics.DropInvalid)
 		return
 	}
 
@@ -211,6 +237,15 @@ func (l *UDPListener) handleDatagram(data []byte, from net.Addr) {
 	sess := l.registry.Get(header.Nickname)
 	if sess == nil {
 		// Session not found - silently drop
+		metrics.DatagramsDropped.Inc(unknownStyle, metrics.DropUnknownSession)
+		return
+	}
+
+	// Logged at debug level, like denied addresses, since anyone who can
+	// reach the port could otherwise flood the log.
+	if !allowedSource(sess, from) {
+		log.WithFields(logger.Fields{"pkg": "datagram", "func": "UDPListener.handleDatagram", "sessionID": header.Nickname, "from": from}).Debug("Dropping datagram from a source not bound to the session")
+		metrics.DatagramsDropped.Inc(string(sess.Style()), metrics.DropForbidden)
 		return
 	}
 
@@ -223,6 +258,59 @@ func (l *UDPListener) handleDatagram(data []byte, from net.Addr) {
 	}
 }
 
+// allowedSource reports whether a datagram from may be sent through sess.
+// The header names the session but carries no credentials, so, as in Java
+// I2P, datagrams are only accepted from the client that created it: the
+// session's UDPSource if set, and otherwise the host of its control
+// connection, or loopback if that is a unix socket.
+//
+// Users sharing a host share its address, so a session created by an
+// authenticated user is also bound to a source port: UDP_SOURCE_PORT if
+// given, and otherwise the port of the first datagram it accepts.
+func allowedSource(sess session.Session, from net.Addr) bool {
+	src, ok := from.(*net.UDPAddr)
+	if !ok {
+		return false
+	}
+
+	var bound *net.UDPAddr
+	if s, ok := sess.(interface{ UDPSource() *net.UDPAddr }); ok {
+		bound = s.UDPSource()
+	}
+	if !allowedHost(sess, bound, src) {
+		return false
+	}
+	if bound != nil && bound.Port != 0 {
+		return bound.Port == src.Port
+	}
+	if session.OwnerOf(sess) == "" {
+		return true
+	}
+	b, ok := sess.(interface{ BindUDPSourcePort(int) int })
+	return ok && b.BindUDPSourcePort(src.Port) == src.Port
+}
+
+// allowedHost reports whether src is on the host sess accepts datagrams
+// from: bound's IP if set, and otherwise the host of its control
+// connection, or loopback if that is a unix socket.
+func allowedHost(sess session.Session, bound, src *net.UDPAddr) bool {
+	if bound != nil && bound.IP != nil {
+		return bound.IP.Equal(src.IP)
+	}
+
+	conn := sess.ControlConn()
+	if conn == nil {
+		return false
+	}
+	switch ctrl := conn.RemoteAddr().(type) {
+	case *net.TCPAddr:
+		return ctrl.IP.Equal(src.IP)
+	case *net.UnixAddr:
+		return src.IP.IsLoopback()
+	}
+	return false
+}
+
 // routeToSession routes the datagram to the appropriate session type.
 func (l *UDPListener) routeToSession(sess session.Session, header *DatagramHeader, payload []byte) {
 	switch sess.Style() {
@@ -232,6 +320,7 @@ func (l *UDPListener) routeToSession(sess session.Session, header *DatagramHeade
 		l.routeToDatagramSession(sess, header, payload)
 	default:
 		// Session style doesn't support datagrams - drop
+		metrics.DatagramsDropped.Inc(string(sess.Style()), metrics.DropInvalid)
 	}
 }
 
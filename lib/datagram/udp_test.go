@@ -23,6 +23,8 @@ type mockSession struct {
 	status      session.Status
 	dest        *session.Destination
 	controlConn net.Conn
+	owner       string
+	udpSource   *net.UDPAddr
 	closed      bool
 	mu          sync.Mutex
 }
@@ -41,6 +43,20 @@ func (m *mockSession) Style() session.Style              { return m.style }
 func (m *mockSession) Status() session.Status            { return m.status }
 func (m *mockSession) Destination() *session.Destination { return m.dest }
 func (m *mockSession) ControlConn() net.Conn             { return m.controlConn }
+func (m *mockSession) Owner() string                     { return m.owner }
+func (m *mockSession) UDPSource() *net.UDPAddr           { return m.udpSource }
+
+func (m *mockSession) BindUDPSourcePort(port int) int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.udpSource == nil {
+		m.udpSource = &net.UDPAddr{}
+	}
+	if m.udpSource.Port == 0 {
+		m.udpSource.Port = port
+	}
+	return m.udpSource.Port
+}
 
 func (m *mockSession) Close() error {
 	m.mu.Lock()
@@ -393,6 +409,121 @@ func TestUDPListenerSendReceive(t *testing.T) {
 	time.Sleep(50 * time.Millisecond)
 }
 
+func TestUDPListenerAccessFilter(t *testing.T) {
+	listener := NewUDPListener("127.0.0.1:0", newMockSessionRegistry())
+	checked := make(chan net.Addr, 1)
+	listener.SetAccessFilter(func(from net.Addr) bool {
+		checked <- from
+		return false
+	})
+	if err := listener.Start(); err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+	defer listener.Close()
+
+	conn, err := net.Dial("udp", listener.Addr().String())
+	if err != nil {
+		t.Fatalf("Failed to dial: %v", err)
+	}
+	defer conn.Close()
+	if _, err := conn.Write([]byte("3.0 testnick dest~\nHello")); err != nil {
+		t.Fatalf("Failed to write: %v", err)
+	}
+
+	select {
+	case from := <-checked:
+		if from.String() != conn.LocalAddr().String() {
+			t.Errorf("filter checked %v, want %v", from, conn.LocalAddr())
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("access filter was not called")
+	}
+}
+
+// remoteConn is a net.Conn that reports a fixed remote address.
+type remoteConn struct {
+	net.Conn
+	remote net.Addr
+}
+
+func (c *remoteConn) RemoteAddr() net.Addr { return c.remote }
+
+func TestAllowedSource(t *testing.T) {
+	tcpCtrl := &remoteConn{remote: &net.TCPAddr{IP: net.ParseIP("192.0.2.10"), Port: 40000}}
+	unixCtrl := &remoteConn{remote: &net.UnixAddr{Name: "@", Net: "unix"}}
+	local := &net.UDPAddr{IP: net.ParseIP("127.0.0.1"), Port: 5000}
+	client := &net.UDPAddr{IP: net.ParseIP("192.0.2.10"), Port: 5000}
+	other := &net.UDPAddr{IP: net.ParseIP("192.0.2.99"), Port: 5000}
+
+	tests := []struct {
+		name   string
+		ctrl   net.Conn
+		source *net.UDPAddr
+		from   net.Addr
+		want   bool
+	}{
+		{"control connection host", tcpCtrl, nil, client, true},
+		{"other host", tcpCtrl, nil, other, false},
+		{"unix control socket, loopback", unixCtrl, nil, local, true},
+		{"unix control socket, remote", unixCtrl, nil, client, false},
+		{"no control connection", nil, nil, client, false},
+		{"source host", tcpCtrl, &net.UDPAddr{IP: other.IP}, other, true},
+		{"source host, control connection host", tcpCtrl, &net.UDPAddr{IP: other.IP}, client, false},
+		{"source port", tcpCtrl, &net.UDPAddr{Port: 5000}, client, true},
+		{"source port, other port", tcpCtrl, &net.UDPAddr{Port: 6000}, client, false},
+		{"source port, other host", tcpCtrl, &net.UDPAddr{Port: 5000}, other, false},
+		{"source host and port", nil, &net.UDPAddr{IP: other.IP, Port: 5000}, other, true},
+		{"not UDP", tcpCtrl, nil, &net.TCPAddr{IP: client.IP, Port: 5000}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sess := newMockSession("nick", session.StyleRaw)
+			sess.controlConn = tt.ctrl
+			sess.udpSource = tt.source
+			if got := allowedSource(sess, tt.from); got != tt.want {
+				t.Errorf("allowedSource() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAllowedSource_SameHostUsers(t *testing.T) {
+	ctrl := &remoteConn{remote: &net.TCPAddr{IP: net.ParseIP("127.0.0.1"), Port: 40000}}
+	alice := &net.UDPAddr{IP: net.ParseIP("127.0.0.1"), Port: 5000}
+	bob := &net.UDPAddr{IP: net.ParseIP("127.0.0.1"), Port: 6000}
+
+	// alice's session is bound to the port of her first datagram, so bob
+	// cannot send through it from the same host.
+	sess := newMockSession("alice-nick", session.StyleDatagram)
+	sess.controlConn = ctrl
+	sess.owner = "alice"
+	if !allowedSource(sess, alice) {
+		t.Fatal("allowedSource() = false for the owner's first datagram")
+	}
+	if allowedSource(sess, bob) {
+		t.Error("allowedSource() = true for another user on the same host")
+	}
+	if !allowedSource(sess, alice) {
+		t.Error("allowedSource() = false for the owner's bound port")
+	}
+
+	// With UDP_SOURCE_PORT the port is bound before any datagram.
+	sess = newMockSession("alice-nick", session.StyleDatagram)
+	sess.controlConn = ctrl
+	sess.owner = "alice"
+	sess.udpSource = &net.UDPAddr{Port: alice.Port}
+	if allowedSource(sess, bob) {
+		t.Error("allowedSource() = true for a port other than UDP_SOURCE_PORT")
+	}
+
+	// Without authentication any port on the host is still allowed.
+	sess = newMockSession("shared", session.StyleDatagram)
+	sess.controlConn = ctrl
+	if !allowedSource(sess, alice) || !allowedSource(sess, bob) {
+		t.Error("allowedSource() bound an unowned session to one port")
+	}
+}
+
 // TestUDPListenerConcurrency tests concurrent operations.
 func TestUDPListenerConcurrency(t *testing.T) {
 	registry := newMockSessionRegistry()
@@ -0,0 +1,71 @@
+package embedding
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/go-i2p/logger"
+
+	"github.com/go-i2p/go-sam-bridge/lib/admin"
+)
+
+// startAdminListener serves the management API on AdminAddr if configured.
+func (b *Bridge) startAdminListener() error {
+	if b.config.AdminAddr == "" {
+		return nil
+	}
+
+	ln, err := admin.Listen(b.config.AdminAddr)
+	if err != nil {
+		return err
+	}
+
+	b.adminServer = &http.Server{
+		Handler:           admin.NewHandler(b.server, b.deps.Registry),
+		ReadHeaderTimeout: metricsReadHeaderTimeout,
+	}
+	b.adminListener = ln
+
+	go func() {
+		if err := b.adminServer.Serve(ln); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			b.deps.Logger.WithFields(logger.Fields{"pkg": "embedding", "func": "Bridge.startAdminListener"}).WithError(err).Warn("Admin listener stopped")
+		}
+	}()
+
+	b.deps.Logger.WithFields(logger.Fields{"pkg": "embedding", "func": "Bridge.startAdminListener", "addr": ln.Addr().String()}).Info("Admin API listener started")
+	return nil
+}
+
+// stopAdminListener shuts down the admin listener if running.
+func (b *Bridge) stopAdminListener(ctx context.Context) {
+	b.mu.Lock()
+	srv := b.takeAdminServer()
+	b.mu.Unlock()
+
+	if srv == nil {
+		return
+	}
+	if err := srv.Shutdown(ctx); err != nil {
+		b.deps.Logger.WithFields(logger.Fields{"pkg": "embedding", "func": "Bridge.stopAdminListener"}).WithError(err).Warn("Error closing admin listener")
+	}
+}
+
+// takeAdminServer detaches and returns the admin server, or nil if none is
+// running. The caller must hold b.mu.
+func (b *Bridge) takeAdminServer() *http.Server {
+	srv := b.adminServer
+	b.adminServer = nil
+	return srv
+}
+
+// AdminAddr returns the address the admin API listener is bound to,
+// or an empty string if the admin API is disabled or the bridge is not running.
+func (b *Bridge) AdminAddr() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.adminListener == nil || b.adminServer == nil {
+		return ""
+	}
+	return b.adminListener.Addr().String()
+}
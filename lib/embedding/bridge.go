@@ -3,6 +3,8 @@ package embedding
 import (
 	"context"
 	"fmt"
+	"net"
+	"net/http"
 	"sync"
 	"sync/atomic"
 	"time"
@@ -42,6 +44,14 @@ type Bridge struct {
 	embeddedRouter embedded.EmbeddedRouter
 	udpListener    *datagram.UDPListener
 
+	// metricsServer serves Prometheus metrics when Config.MetricsAddr is set.
+	metricsServer   *http.Server
+	metricsListener net.Listener
+
+	// adminServer serves the management API when Config.AdminAddr is set.
+	adminServer   *http.Server
+	adminListener net.Listener
+
 	mu       sync.Mutex
 	running  atomic.Bool
 	done     chan struct{}
@@ -79,6 +89,7 @@ func New(opts ...Option) (*Bridge, error) {
 	if cfg.DatagramPort > 0 {
 		udpAddr := fmt.Sprintf(":%d", cfg.DatagramPort)
 		udpListener = datagram.NewUDPListener(udpAddr, deps.Registry)
+		udpListener.SetAccessFilter(server.AllowsPeer)
 	}
 
 	return &Bridge{
@@ -171,7 +182,25 @@ func (b *Bridge) Start(ctx context.Context) error {
 	runCtx, cancel := context.WithCancel(ctx)
 	b.cancelFn = cancel
 
+	if err := b.startMetricsListener(); err != nil {
+		b.cleanupStartupResources()
+		return fmt.Errorf("failed to start metrics listener: %w", err)
+	}
+
+	if err := b.startAdminListener(); err != nil {
+		if srv := b.takeMetricsServer(); srv != nil {
+			_ = srv.Close()
+		}
+		b.cleanupStartupResources()
+		return fmt.Errorf("failed to start admin listener: %w", err)
+	}
+
 	if err := b.startTCPServer(); err != nil {
+		for _, srv := range []*http.Server{b.takeMetricsServer(), b.takeAdminServer()} {
+			if srv != nil {
+				_ = srv.Close()
+			}
+		}
 		b.cleanupStartupResources()
 		return err
 	}
@@ -246,7 +275,7 @@ func (b *Bridge) startTCPServer() error {
 func (b *Bridge) runServer(startErrCh chan<- error) {
 	var err error
 	if b.config.Listener != nil {
-		err = b.server.Serve(b.config.Listener)
+		err = b.server.Serve(b.server.ProxyListener(b.config.Listener))
 	} else {
 		err = b.server.ListenAndServe()
 	}
@@ -276,18 +305,22 @@ func (b *Bridge) cleanupStartupResources() {
 func (b *Bridge) watchContext(ctx context.Context) {
 	go func() {
 		<-ctx.Done()
-		b.Stop(context.Background())
+		stopCtx, cancel := context.WithTimeout(context.Background(), DefaultShutdownTimeout)
+		defer cancel()
+		b.Stop(stopCtx)
 	}()
 }
 
-// Stop gracefully shuts down the bridge.
-// The context can be used to set a timeout for shutdown operations.
+// Stop gracefully shuts down the bridge. New connections and new sessions
+// are refused while active streams and STREAM FORWARD connections finish;
+// when ctx is done, whatever remains is closed and ctx.Err() is returned.
 // Stop is safe to call concurrently; only the first call performs cleanup.
 func (b *Bridge) Stop(ctx context.Context) error {
 	if !b.running.Load() {
 		return nil // Already stopped
 	}
 
+	var stopErr error
 	b.stopOnce.Do(func() {
 		b.deps.Logger.WithFields(logger.Fields{"pkg": "embedding", "func": "Bridge.Stop"}).Info("Stopping SAM bridge...")
 
@@ -298,9 +331,10 @@ func (b *Bridge) Stop(ctx context.Context) error {
 			b.cancelFn()
 		}
 
-		// Close the server
-		if err := b.server.Close(); err != nil {
-			b.deps.Logger.WithFields(logger.Fields{"pkg": "embedding", "func": "Bridge.Stop"}).WithError(err).Warn("Error closing server")
+		// Drain active streams, then close the server
+		if err := b.server.Shutdown(ctx); err != nil {
+			stopErr = err
+			b.deps.Logger.WithFields(logger.Fields{"pkg": "embedding", "func": "Bridge.Stop"}).WithError(err).Warn("Server did not drain before the shutdown deadline")
 		}
 
 		// Close all sessions
@@ -308,6 +342,10 @@ func (b *Bridge) Stop(ctx context.Context) error {
 			b.deps.Logger.WithFields(logger.Fields{"pkg": "embedding", "func": "Bridge.Stop"}).WithError(err).Warn("Error closing sessions")
 		}
 
+		b.stopMetricsListener(ctx)
+		b.stopAdminListener(ctx)
+		b.closeAuditFile()
+
 		// Close UDP listener
 		if b.udpListener != nil {
 			if err := b.udpListener.Close(); err != nil {
@@ -328,9 +366,95 @@ func (b *Bridge) Stop(ctx context.Context) error {
 		}
 	})
 
+	return stopErr
+}
+
+// Reload applies a new configuration to the bridge without touching
+// existing connections or sessions. As with New, opts are applied to a
+// default configuration, so pass the complete set of options.
+//
+// Authentication users, roles, policies, enablement and lockouts, access
+// lists, timeouts, limits and the transcript directory take effect
+// immediately (see bridge.Server.Reload). A WithAuditFile file is closed
+// and reopened on the next command, so that it can be rotated. Other
+// settings, such as the listen address, TLS or the metrics and admin
+// listeners, are only used on restart; a warning is logged for each that
+// changed. If the configuration is invalid, Reload returns an error and the
+// bridge keeps running as before.
+func (b *Bridge) Reload(opts ...Option) error {
+	cfg, err := buildConfig(opts)
+	if err != nil {
+		return err
+	}
+
+	bridgeConfig := cfg.toBridgeConfig()
+	if err := b.server.Reload(bridgeConfig); err != nil {
+		return err
+	}
+	if b.server.AuthStore().IsAuthEnabled() {
+		// AUTH commands are only registered at startup if auth was enabled then.
+		RegisterAuthHandlers(b.server.Router(), b.server.AuthStore(), b.deps)
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for _, field := range restartRequired(b.config, cfg) {
+		b.deps.Logger.WithFields(logger.Fields{"pkg": "embedding", "func": "Bridge.Reload", "field": field}).Warn("Setting changed; restart the bridge to apply it")
+	}
+	b.config.AuthUsers = cfg.AuthUsers
+	b.config.AuthBackend = cfg.AuthBackend
+	b.config.AuthLockout = cfg.AuthLockout
+	b.config.AuthRoles = cfg.AuthRoles
+	b.config.AuthPolicies = cfg.AuthPolicies
+	b.config.AllowedNetworks = cfg.AllowedNetworks
+	b.config.DeniedNetworks = cfg.DeniedNetworks
+	b.config.AccessListDir = cfg.AccessListDir
+	b.config.ForwardSocketDir = cfg.ForwardSocketDir
+	b.config.RateLimit = cfg.RateLimit
+	b.config.TrustedProxies = cfg.TrustedProxies
+	b.config.TranscriptDir = cfg.TranscriptDir
+	// The server keeps its audit log; closing the file lets it be rotated.
+	b.closeAuditFile()
+	b.config.ClientCertUser = cfg.ClientCertUser
+	b.config.Timeouts = cfg.Timeouts
+	b.config.Limits = cfg.Limits
+	b.config.KeepaliveInterval = cfg.KeepaliveInterval
+	b.config.IdleTimeout = cfg.IdleTimeout
 	return nil
 }
 
+// closeAuditFile closes the file of WithAuditFile, if used. The server
+// reopens it on its next record.
+func (b *Bridge) closeAuditFile() {
+	if f, ok := b.config.AuditLog.(*bridge.AuditFile); ok {
+		if err := f.Close(); err != nil {
+			b.deps.Logger.WithFields(logger.Fields{"pkg": "embedding", "func": "Bridge.closeAuditFile", "path": f.Path()}).WithError(err).Warn("Error closing audit log")
+		}
+	}
+}
+
+// restartRequired returns the names of settings that differ between old and
+// next but cannot be changed by Reload.
+func restartRequired(old, next *Config) []string {
+	var fields []string
+	check := func(name string, changed bool) {
+		if changed {
+			fields = append(fields, name)
+		}
+	}
+	check("ListenAddr", old.ListenAddr != next.ListenAddr)
+	check("UnixSocketMode", old.UnixSocketMode != next.UnixSocketMode)
+	check("UnixSocketOwner", old.UnixSocketOwner != next.UnixSocketOwner)
+	check("I2CPAddr", old.I2CPAddr != next.I2CPAddr)
+	check("DatagramPort", old.DatagramPort != next.DatagramPort)
+	check("TLSConfig", (old.TLSConfig == nil) != (next.TLSConfig == nil))
+	check("MetricsAddr", old.MetricsAddr != next.MetricsAddr)
+	check("AdminAddr", old.AdminAddr != next.AdminAddr)
+	check("AuditLog", (old.AuditLog == nil) != (next.AuditLog == nil))
+	return fields
+}
+
 // Wait blocks until the bridge has stopped.
 // Returns any error that caused the shutdown.
 func (b *Bridge) Wait() error {
@@ -6,6 +6,8 @@ import (
 	"testing"
 	"time"
 
+	"github.com/go-i2p/go-sam-bridge/lib/bridge"
+	"github.com/go-i2p/go-sam-bridge/lib/protocol"
 	"github.com/go-i2p/go-sam-bridge/lib/session"
 )
 
@@ -286,3 +288,67 @@ func TestBridgeWithUDPListener(t *testing.T) {
 		t.Errorf("Stop() error = %v", err)
 	}
 }
+
+func TestBridgeReload(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to create test listener: %v", err)
+	}
+	defer ln.Close()
+
+	b, err := New(WithListener(ln), WithI2CPProvider(&mockI2CPProvider{}))
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	limits := bridge.DefaultConfig().Limits
+	limits.MaxConnections = 3
+	err = b.Reload(
+		WithListener(ln),
+		WithI2CPProvider(&mockI2CPProvider{}),
+		WithAuth(map[string]string{"alice": "secret"}),
+		WithLimits(limits),
+	)
+	if err != nil {
+		t.Fatalf("Reload() error = %v", err)
+	}
+
+	if !b.Server().AuthStore().IsAuthEnabled() {
+		t.Error("IsAuthEnabled() = false after Reload with users, want true")
+	}
+	if got := b.Server().Config().Limits.MaxConnections; got != 3 {
+		t.Errorf("Limits.MaxConnections = %d, want 3", got)
+	}
+	if b.Server().Router().Route(&protocol.Command{Verb: "AUTH", Action: "LIST"}) == nil {
+		t.Error("AUTH LIST not routed after Reload enabled authentication")
+	}
+	if len(b.Config().AuthUsers) != 1 {
+		t.Errorf("Config().AuthUsers = %v, want alice", b.Config().AuthUsers)
+	}
+
+	// An invalid configuration is rejected and changes nothing.
+	limits.MaxLineLength = 0
+	if err := b.Reload(WithListener(ln), WithI2CPProvider(&mockI2CPProvider{}), WithLimits(limits)); err == nil {
+		t.Error("Reload() with invalid limits = nil, want error")
+	}
+	if !b.Server().AuthStore().IsAuthEnabled() {
+		t.Error("IsAuthEnabled() = false after a rejected Reload, want true")
+	}
+}
+
+func TestRestartRequired(t *testing.T) {
+	old := DefaultConfig()
+	next := DefaultConfig()
+	next.AuthUsers = map[string]string{"alice": "secret"}
+	next.KeepaliveInterval = time.Minute
+	if got := restartRequired(old, next); len(got) != 0 {
+		t.Errorf("restartRequired() = %v, want none for reloadable settings", got)
+	}
+
+	next.ListenAddr = "127.0.0.1:17656"
+	next.MetricsAddr = "127.0.0.1:7660"
+	got := restartRequired(old, next)
+	if len(got) != 2 || got[0] != "ListenAddr" || got[1] != "MetricsAddr" {
+		t.Errorf("restartRequired() = %v, want [ListenAddr MetricsAddr]", got)
+	}
+}
@@ -4,7 +4,10 @@ package embedding
 
 import (
 	"crypto/tls"
+	"io"
 	"net"
+	"net/netip"
+	"os"
 	"time"
 
 	"github.com/go-i2p/go-sam-bridge/lib/bridge"
@@ -27,6 +30,10 @@ const (
 
 	// DefaultEmbeddedRouterTimeout is the maximum time to wait for the embedded router.
 	DefaultEmbeddedRouterTimeout = 60 * time.Second
+
+	// DefaultShutdownTimeout bounds how long the bridge drains active
+	// streams when it stops because its Start context was cancelled.
+	DefaultShutdownTimeout = 30 * time.Second
 )
 
 // HandlerRegistrarFunc is a function that registers handlers with a router.
@@ -36,9 +43,18 @@ type HandlerRegistrarFunc func(router *handler.Router, deps *Dependencies)
 // Config holds the complete configuration for an embedded SAM bridge.
 // It extends bridge.Config with I2CP and embedding-specific settings.
 type Config struct {
-	// ListenAddr is the SAM TCP listen address (default ":7656").
+	// ListenAddr is the SAM listen address (default ":7656"): a TCP address
+	// or a "unix:" socket path such as "unix:/run/sam-bridge/sam.sock".
 	ListenAddr string
 
+	// UnixSocketMode is the file mode of a "unix:" listen socket
+	// (default bridge.DefaultUnixSocketMode, 0660).
+	UnixSocketMode os.FileMode
+
+	// UnixSocketOwner optionally sets the owner of a "unix:" listen socket,
+	// as "user", "user:group" or ":group".
+	UnixSocketOwner string
+
 	// I2CPAddr is the I2CP router address (default "127.0.0.1:7654").
 	I2CPAddr string
 
@@ -58,6 +74,63 @@ type Config struct {
 	// Empty map disables authentication.
 	AuthUsers map[string]string
 
+	// AuthBackend persists users added or removed with AUTH commands,
+	// e.g. bridge.NewHtpasswdFile. If nil, they are lost on restart.
+	// Setting it makes authentication required.
+	AuthBackend bridge.AuthBackend
+
+	// ClientCertUser lets a verified TLS client certificate authenticate
+	// the connection as the AuthUsers user named by this certificate field
+	// (see bridge.AuthConfig.CertUser). TLSConfig must verify client
+	// certificates, e.g. with ClientAuth: tls.VerifyClientCertIfGiven.
+	ClientCertUser bridge.CertUserField
+
+	// AuthLockout overrides the brute-force protection for HELLO
+	// authentication. If nil, bridge.DefaultLockoutConfig is used.
+	AuthLockout *bridge.LockoutConfig
+
+	// AuthRoles maps usernames to roles. Users not listed have
+	// bridge.RoleUser; only bridge.RoleAdmin may run AUTH commands unless
+	// AuthPolicies says otherwise.
+	AuthRoles map[string]string
+
+	// AuthPolicies defines or overrides the policy of each role
+	// (see bridge.AuthConfig.Policies).
+	AuthPolicies map[string]bridge.Policy
+
+	// AllowedNetworks, if not empty, limits the SAM control and UDP ports
+	// to clients in these networks (see bridge.AccessConfig).
+	AllowedNetworks []netip.Prefix
+
+	// DeniedNetworks lists networks whose clients are refused, even if
+	// AllowedNetworks contains them.
+	DeniedNetworks []netip.Prefix
+
+	// AccessListDir is the directory of the peer lists STREAM sessions may
+	// name with sam.accessListFile. Empty refuses sam.accessListFile.
+	AccessListDir string
+
+	// ForwardSocketDir is the directory of the Unix sockets STREAM FORWARD
+	// may forward to with PATH. Empty refuses PATH.
+	ForwardSocketDir string
+
+	// RateLimit limits how fast clients may send commands. If nil, commands
+	// are not rate limited.
+	RateLimit *bridge.RateLimitConfig
+
+	// TrustedProxies lists the networks of load balancers that send a
+	// PROXY protocol header with each client's address
+	// (see bridge.ProxyProtocolConfig). Empty disables the PROXY protocol.
+	TrustedProxies []netip.Prefix
+
+	// AuditLog receives a JSON line for each command handled, with secrets
+	// redacted (see bridge.AuditRecord). Nil disables the audit log.
+	AuditLog io.Writer
+
+	// TranscriptDir, if set, receives a redacted transcript of each
+	// connection's control lines (see bridge.Config.TranscriptDir).
+	TranscriptDir string
+
 	// Listener is a custom net.Listener for the SAM server.
 	// If nil, the bridge creates its own listener on ListenAddr.
 	Listener net.Listener
@@ -96,6 +169,33 @@ type Config struct {
 	// EmbeddedRouterTimeout is the maximum time to wait for the embedded router to become ready.
 	// Default is 60 seconds.
 	EmbeddedRouterTimeout time.Duration
+
+	// Timeouts overrides the bridge's connection timeouts.
+	// If nil, bridge.DefaultConfig timeouts are used.
+	Timeouts *bridge.TimeoutConfig
+
+	// Limits overrides the bridge's buffer sizes and connection limits,
+	// including the maximum command line length.
+	// If nil, bridge.DefaultConfig limits are used.
+	Limits *bridge.LimitConfig
+
+	// KeepaliveInterval is how often the bridge sends PING to SAM 3.2+ clients.
+	// Clients that do not answer with PONG are disconnected. Zero leaves
+	// Timeouts.KeepaliveInterval unchanged (keepalive is off by default).
+	KeepaliveInterval time.Duration
+
+	// IdleTimeout closes control connections that send no command for this long.
+	// Zero leaves Timeouts.Idle unchanged (no idle timeout by default).
+	IdleTimeout time.Duration
+
+	// MetricsAddr is the TCP address of an HTTP listener serving Prometheus
+	// metrics at /metrics (e.g. "127.0.0.1:7660"). Empty disables metrics.
+	MetricsAddr string
+
+	// AdminAddr is the address of the local HTTP/JSON management API: a
+	// loopback TCP address (e.g. "127.0.0.1:7661") or a "unix:" socket path.
+	// Empty disables the admin API.
+	AdminAddr string
 }
 
 // DefaultConfig returns a Config with sensible defaults.
@@ -123,6 +223,30 @@ func (c *Config) Validate() error {
 	return nil
 }
 
+// ValidateAll checks the configuration, including the settings passed
+// through to bridge.Config, and returns every problem found. It returns nil
+// if the configuration is valid.
+func (c *Config) ValidateAll() []error {
+	var errs []error
+	if c.ListenAddr == "" && c.Listener == nil {
+		errs = append(errs, ErrMissingListenAddr)
+	}
+	if c.I2CPAddr == "" && c.I2CPProvider == nil {
+		errs = append(errs, ErrMissingI2CPAddr)
+	}
+
+	// Empty addresses were checked above, where a custom Listener or
+	// I2CPProvider may stand in for them.
+	cfg := c.toBridgeConfig()
+	if cfg.ListenAddr == "" {
+		cfg.ListenAddr = DefaultListenAddr
+	}
+	if cfg.I2CPAddr == "" {
+		cfg.I2CPAddr = DefaultI2CPAddr
+	}
+	return append(errs, cfg.ValidateAll()...)
+}
+
 // toBridgeConfig converts embedding.Config to bridge.Config.
 func (c *Config) toBridgeConfig() *bridge.Config {
 	// Start with default bridge config to get proper defaults
@@ -130,9 +254,23 @@ func (c *Config) toBridgeConfig() *bridge.Config {
 
 	// Override with embedding config values
 	cfg.ListenAddr = c.ListenAddr
+	cfg.UnixSocket.Mode = c.UnixSocketMode
+	cfg.UnixSocket.Owner = c.UnixSocketOwner
 	cfg.I2CPAddr = c.I2CPAddr
 	cfg.DatagramPort = c.DatagramPort
 	cfg.TLSConfig = c.TLSConfig
+	if c.Timeouts != nil {
+		cfg.Timeouts = *c.Timeouts
+	}
+	if c.Limits != nil {
+		cfg.Limits = *c.Limits
+	}
+	if c.KeepaliveInterval != 0 {
+		cfg.Timeouts.KeepaliveInterval = c.KeepaliveInterval
+	}
+	if c.IdleTimeout != 0 {
+		cfg.Timeouts.Idle = c.IdleTimeout
+	}
 
 	// Copy auth users if any
 	if len(c.AuthUsers) > 0 {
@@ -142,6 +280,28 @@ func (c *Config) toBridgeConfig() *bridge.Config {
 			cfg.Auth.Users[k] = v
 		}
 	}
+	// Persisted users are only useful with authentication on, and AUTH
+	// ENABLE does not survive a restart.
+	if c.AuthBackend != nil {
+		cfg.Auth.Required = true
+	}
+	cfg.Auth.Backend = c.AuthBackend
+	cfg.Auth.CertUser = c.ClientCertUser
+	cfg.Auth.Roles = c.AuthRoles
+	cfg.Auth.Policies = c.AuthPolicies
+	if c.AuthLockout != nil {
+		cfg.Auth.Lockout = *c.AuthLockout
+	}
+	cfg.Access.Allow = c.AllowedNetworks
+	cfg.Access.Deny = c.DeniedNetworks
+	cfg.Stream.AccessListDir = c.AccessListDir
+	cfg.Stream.ForwardSocketDir = c.ForwardSocketDir
+	if c.RateLimit != nil {
+		cfg.RateLimit = *c.RateLimit
+	}
+	cfg.ProxyProtocol.TrustedProxies = c.TrustedProxies
+	cfg.AuditLog = c.AuditLog
+	cfg.TranscriptDir = c.TranscriptDir
 
 	return cfg
 }
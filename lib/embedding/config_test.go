@@ -1,7 +1,11 @@
 package embedding
 
 import (
+	"errors"
 	"testing"
+	"time"
+
+	"github.com/go-i2p/go-sam-bridge/lib/bridge"
 )
 
 func TestDefaultConfig(t *testing.T) {
@@ -87,9 +91,11 @@ func TestConfigValidate(t *testing.T) {
 
 func TestConfigToBridgeConfig(t *testing.T) {
 	cfg := &Config{
-		ListenAddr:   ":8000",
-		I2CPAddr:     "10.0.0.1:7654",
-		DatagramPort: 8001,
+		ListenAddr:        ":8000",
+		I2CPAddr:          "10.0.0.1:7654",
+		DatagramPort:      8001,
+		KeepaliveInterval: 30 * time.Second,
+		IdleTimeout:       10 * time.Minute,
 		AuthUsers: map[string]string{
 			"user1": "pass1",
 			"user2": "pass2",
@@ -110,6 +116,14 @@ func TestConfigToBridgeConfig(t *testing.T) {
 		t.Errorf("DatagramPort = %d, want %d", bridgeCfg.DatagramPort, cfg.DatagramPort)
 	}
 
+	if bridgeCfg.Timeouts.KeepaliveInterval != cfg.KeepaliveInterval {
+		t.Errorf("Timeouts.KeepaliveInterval = %v, want %v", bridgeCfg.Timeouts.KeepaliveInterval, cfg.KeepaliveInterval)
+	}
+
+	if bridgeCfg.Timeouts.Idle != cfg.IdleTimeout {
+		t.Errorf("Timeouts.Idle = %v, want %v", bridgeCfg.Timeouts.Idle, cfg.IdleTimeout)
+	}
+
 	if !bridgeCfg.Auth.Required {
 		t.Error("Auth.Required should be true when users are configured")
 	}
@@ -118,3 +132,77 @@ func TestConfigToBridgeConfig(t *testing.T) {
 		t.Errorf("Auth.Users length = %d, want 2", len(bridgeCfg.Auth.Users))
 	}
 }
+
+func TestConfigToBridgeConfig_TimeoutsAndLimits(t *testing.T) {
+	timeouts := bridge.TimeoutConfig{
+		Handshake:         5 * time.Second,
+		Command:           20 * time.Second,
+		Idle:              time.Minute,
+		PongTimeout:       10 * time.Second,
+		KeepaliveInterval: 15 * time.Second,
+	}
+	limits := bridge.LimitConfig{
+		ReadBufferSize: 4096,
+		MaxLineLength:  1024,
+		MaxConnections: 50,
+	}
+
+	cfg := DefaultConfig()
+	WithTimeouts(timeouts)(cfg)
+	WithLimits(limits)(cfg)
+
+	bridgeCfg := cfg.toBridgeConfig()
+	if bridgeCfg.Timeouts != timeouts {
+		t.Errorf("Timeouts = %+v, want %+v", bridgeCfg.Timeouts, timeouts)
+	}
+	if bridgeCfg.Limits != limits {
+		t.Errorf("Limits = %+v, want %+v", bridgeCfg.Limits, limits)
+	}
+
+	// The dedicated keepalive and idle options take precedence.
+	WithKeepalive(time.Minute)(cfg)
+	WithIdleTimeout(time.Hour)(cfg)
+	bridgeCfg = cfg.toBridgeConfig()
+	if bridgeCfg.Timeouts.KeepaliveInterval != time.Minute {
+		t.Errorf("Timeouts.KeepaliveInterval = %v, want %v", bridgeCfg.Timeouts.KeepaliveInterval, time.Minute)
+	}
+	if bridgeCfg.Timeouts.Idle != time.Hour {
+		t.Errorf("Timeouts.Idle = %v, want %v", bridgeCfg.Timeouts.Idle, time.Hour)
+	}
+	if bridgeCfg.Timeouts.Handshake != timeouts.Handshake {
+		t.Errorf("Timeouts.Handshake = %v, want %v", bridgeCfg.Timeouts.Handshake, timeouts.Handshake)
+	}
+}
+
+func TestConfigValidateAll(t *testing.T) {
+	if errs := DefaultConfig().ValidateAll(); errs != nil {
+		t.Errorf("ValidateAll() = %v, want nil", errs)
+	}
+
+	// A custom listener stands in for an empty ListenAddr.
+	cfg := DefaultConfig()
+	cfg.ListenAddr = ""
+	cfg.Listener = &mockListener{}
+	if errs := cfg.ValidateAll(); errs != nil {
+		t.Errorf("ValidateAll() with Listener = %v, want nil", errs)
+	}
+
+	cfg = DefaultConfig()
+	cfg.I2CPAddr = ""
+	cfg.DatagramPort = 70000
+	cfg.Limits = &bridge.LimitConfig{ReadBufferSize: 4096, MaxLineLength: 0}
+
+	errs := cfg.ValidateAll()
+	if len(errs) != 3 {
+		t.Fatalf("ValidateAll() returned %d errors, want 3: %v", len(errs), errs)
+	}
+	if !errors.Is(errs[0], ErrMissingI2CPAddr) {
+		t.Errorf("errs[0] = %v, want %v", errs[0], ErrMissingI2CPAddr)
+	}
+	for i, field := range []string{"DatagramPort", "Limits.MaxLineLength"} {
+		cfgErr, ok := errs[i+1].(*bridge.ConfigError)
+		if !ok || cfgErr.Field != field {
+			t.Errorf("errs[%d] = %v, want a ConfigError for %s", i+1, errs[i+1], field)
+		}
+	}
+}
@@ -41,7 +41,7 @@
 //
 // # Available Options
 //
-//   - WithListenAddr: Set SAM TCP listen address (default ":7656")
+//   - WithListenAddr: Set SAM listen address, TCP or "unix:/path" (default ":7656")
 //   - WithI2CPAddr: Set I2CP router address (default "127.0.0.1:7654")
 //   - WithDatagramPort: Set UDP datagram port (default 7655)
 //   - WithListener: Provide custom net.Listener
@@ -50,9 +50,21 @@
 //   - WithLogger: Provide custom *logger.Logger (github.com/go-i2p/logger)
 //   - WithTLS: Enable TLS with custom config
 //   - WithAuth: Set SAM authentication users
+//   - WithAuthFile, WithAuthBackend: Persist users added with AUTH ADD
+//   - WithAuthLockout: Tune lockouts after failed HELLO logins
+//   - WithUserRoles, WithRolePolicy: Restrict what each user may do
+//   - WithAllowedNetworks, WithDeniedNetworks: Restrict client addresses
+//   - WithAccessListDir: Allow peer list files for STREAM sessions
+//   - WithForwardSocketDir: Allow STREAM FORWARD to Unix sockets
+//   - WithRateLimit: Limit how fast clients may send commands
+//   - WithTrustedProxies: Accept PROXY protocol headers from load balancers
+//   - WithAuditLog, WithAuditFile: Record each command in a JSON-lines audit log
+//   - WithTranscriptDir: Record each connection's control lines for debugging
+//   - WithClientCertAuth: Authenticate TLS clients by certificate
 //   - WithI2CPCredentials: Set I2CP authentication
 //   - WithHandlerRegistrar: Custom handler registration
 //   - WithDebug: Enable debug logging
+//   - WithTimeouts, WithLimits: Override bridge timeouts and limits
 //
 // # Custom Handlers
 //
@@ -75,12 +87,15 @@
 // The Bridge implements the Lifecycle interface:
 //
 //   - Start(ctx): Begin serving (non-blocking)
-//   - Stop(ctx): Graceful shutdown
+//   - Stop(ctx): Graceful shutdown; active streams may finish until ctx is done
 //   - Wait(): Block until stopped
 //   - Running(): Check if bridge is active
 //
 // Context cancellation in Start() triggers automatic shutdown.
 //
+// Reload(opts...) applies new auth users, timeouts and limits to a running
+// bridge without dropping connections or sessions.
+//
 // # Thread Safety
 //
 // Bridge methods are safe for concurrent use. The bridge uses atomic operations
@@ -151,24 +151,38 @@ func createStreamManagerCallback(
 
 		switch sess.Style() {
 		case session.StyleStream:
-			wireStreamManager(deps, i2cpSess, sess.ID(), connector, acceptor, forwarder)
+			wireStreamManager(deps, i2cpSess, sess, connector, acceptor, forwarder)
 		case session.StyleDatagram, session.StyleRaw, session.StyleDatagram2, session.StyleDatagram3:
 			wireDatagramConn(deps, i2cpSess, sess)
 		case session.StylePrimary, session.StyleMaster:
 			wirePrimarySession(deps, i2cpSess, sess, connector, acceptor, forwarder)
 		}
+
+		// Rebuild transports on the new go-i2cp session after a router reconnect.
+		watchReconnect(deps, sess, i2cpSess, func() {
+			switch sess.Style() {
+			case session.StyleStream:
+				wireStreamManager(deps, i2cpSess, sess, connector, acceptor, forwarder)
+			case session.StyleDatagram, session.StyleRaw, session.StyleDatagram2, session.StyleDatagram3:
+				wireDatagramConn(deps, i2cpSess, sess)
+			case session.StylePrimary, session.StyleMaster:
+				rewirePrimarySubsessions(deps, i2cpSess, sess, connector, acceptor, forwarder)
+			}
+		})
 	}
 }
 
-// wireStreamManager creates and registers a StreamManager for a STREAM session.
+// wireStreamManager creates and registers a StreamManager for a STREAM session,
+// applying the session's i2p.streaming.* options to it.
 func wireStreamManager(
 	deps *Dependencies,
 	i2cpSess *i2cp.I2CPSession,
-	sessionID string,
+	sess session.Session,
 	connector *handler.StreamingConnector,
 	acceptor *handler.StreamingAcceptor,
 	forwarder *handler.StreamingForwarder,
 ) {
+	sessionID := sess.ID()
 	underlyingSession := i2cpSess.Session()
 	underlyingClient := deps.I2CPClient.I2CPClient()
 	if underlyingSession == nil || underlyingClient == nil {
@@ -187,9 +201,10 @@ func wireStreamManager(
 		deps.Logger.WithFields(logger.Fields{"pkg": "embedding", "func": "wireStreamManager", "sessionID": sessionID}).WithError(err).Warn("Failed to create StreamManager adapter")
 		return
 	}
+	adapter.SetOptions(session.StreamingOptionsOf(sess))
 
 	connector.RegisterManager(sessionID, adapter)
-	if err := acceptor.RegisterManager(sessionID, adapter); err != nil {
+	if err := acceptor.RegisterManager(sessionID, adapter, session.StreamingOptionsOf(sess).MaxMessageSize); err != nil {
 		deps.Logger.WithFields(logger.Fields{"pkg": "embedding", "func": "wireStreamManager", "sessionID": sessionID}).WithError(err).Warn("Failed to register acceptor StreamManager")
 	}
 	forwarder.RegisterManager(sessionID, adapter)
@@ -241,18 +256,52 @@ func wirePrimarySession(
 	}
 
 	primary.SetSubsessionCreatedCallback(func(sub session.Session, _ *session.PrimarySessionImpl) {
-		switch sub.Style() {
-		case session.StyleStream:
-			wireStreamManager(deps, i2cpSess, sub.ID(), connector, acceptor, forwarder)
-		case session.StyleDatagram, session.StyleRaw, session.StyleDatagram2, session.StyleDatagram3:
-			wireDatagramConn(deps, i2cpSess, sub)
-		}
+		wireSubsession(deps, i2cpSess, sub, connector, acceptor, forwarder)
 		deps.Logger.WithFields(logger.Fields{"pkg": "embedding", "func": "wirePrimarySession.subsessionCallback", "subsessionID": sub.ID(), "style": sub.Style()}).Debug("Wired transport for PRIMARY subsession")
 	})
 
 	deps.Logger.WithFields(logger.Fields{"pkg": "embedding", "func": "wirePrimarySession", "sessionID": sess.ID()}).Debug("Wired PRIMARY session with subsession callback")
 }
 
+// rewirePrimarySubsessions rebuilds the transport of every existing subsession
+// of a PRIMARY session, e.g. after its I2CP session was re-established.
+func rewirePrimarySubsessions(
+	deps *Dependencies,
+	i2cpSess *i2cp.I2CPSession,
+	sess session.Session,
+	connector *handler.StreamingConnector,
+	acceptor *handler.StreamingAcceptor,
+	forwarder *handler.StreamingForwarder,
+) {
+	primary, ok := sess.(*session.PrimarySessionImpl)
+	if !ok {
+		return
+	}
+
+	for _, id := range primary.Subsessions() {
+		if sub := primary.Subsession(id); sub != nil {
+			wireSubsession(deps, i2cpSess, sub, connector, acceptor, forwarder)
+		}
+	}
+}
+
+// wireSubsession wires the transport for a single PRIMARY subsession.
+func wireSubsession(
+	deps *Dependencies,
+	i2cpSess *i2cp.I2CPSession,
+	sub session.Session,
+	connector *handler.StreamingConnector,
+	acceptor *handler.StreamingAcceptor,
+	forwarder *handler.StreamingForwarder,
+) {
+	switch sub.Style() {
+	case session.StyleStream:
+		wireStreamManager(deps, i2cpSess, sub, connector, acceptor, forwarder)
+	case session.StyleDatagram, session.StyleRaw, session.StyleDatagram2, session.StyleDatagram3:
+		wireDatagramConn(deps, i2cpSess, sub)
+	}
+}
+
 // datagramProtocolForStyle returns the I2CP protocol number for the given SAM session style.
 func datagramProtocolForStyle(style session.Style) uint8 {
 	switch style {
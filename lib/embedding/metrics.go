@@ -0,0 +1,125 @@
+package embedding
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/go-i2p/logger"
+
+	"github.com/go-i2p/go-sam-bridge/lib/metrics"
+	"github.com/go-i2p/go-sam-bridge/lib/session"
+)
+
+// MetricsPath is the HTTP path the metrics listener serves.
+const MetricsPath = "/metrics"
+
+// metricsReadHeaderTimeout bounds how long a scrape may take to send its headers.
+const metricsReadHeaderTimeout = 10 * time.Second
+
+// registerBridgeMetrics registers the gauges that are read from the bridge
+// at scrape time: open control connections and sessions by style and status.
+func (b *Bridge) registerBridgeMetrics() {
+	metrics.Default.GaugeFunc("sam_control_connections",
+		"Open SAM control connections.",
+		nil,
+		func() []metrics.Sample {
+			return []metrics.Sample{{Value: float64(b.server.ConnectionCount())}}
+		})
+
+	metrics.Default.GaugeFunc("sam_sessions",
+		"Registered SAM sessions, by style and status.",
+		[]string{"style", "status"},
+		func() []metrics.Sample {
+			return sessionSamples(b.deps.Registry)
+		})
+}
+
+// sessionSamples counts the sessions in registry by style and status.
+func sessionSamples(registry session.Registry) []metrics.Sample {
+	type key struct{ style, status string }
+	counts := make(map[key]int)
+	for _, id := range registry.All() {
+		sess := registry.Get(id)
+		if sess == nil {
+			continue // closed since All() was called
+		}
+		counts[key{string(sess.Style()), sess.Status().String()}]++
+	}
+
+	samples := make([]metrics.Sample, 0, len(counts))
+	for k, n := range counts {
+		samples = append(samples, metrics.Sample{LabelValues: []string{k.style, k.status}, Value: float64(n)})
+	}
+	sort.Slice(samples, func(i, j int) bool {
+		if samples[i].LabelValues[0] != samples[j].LabelValues[0] {
+			return samples[i].LabelValues[0] < samples[j].LabelValues[0]
+		}
+		return samples[i].LabelValues[1] < samples[j].LabelValues[1]
+	})
+	return samples
+}
+
+// startMetricsListener serves metrics.Default on MetricsAddr if configured.
+func (b *Bridge) startMetricsListener() error {
+	if b.config.MetricsAddr == "" {
+		return nil
+	}
+
+	ln, err := net.Listen("tcp", b.config.MetricsAddr)
+	if err != nil {
+		return err
+	}
+
+	b.registerBridgeMetrics()
+
+	mux := http.NewServeMux()
+	mux.Handle(MetricsPath, metrics.Default.Handler())
+	b.metricsServer = &http.Server{Handler: mux, ReadHeaderTimeout: metricsReadHeaderTimeout}
+	b.metricsListener = ln
+
+	go func() {
+		if err := b.metricsServer.Serve(ln); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			b.deps.Logger.WithFields(logger.Fields{"pkg": "embedding", "func": "Bridge.startMetricsListener"}).WithError(err).Warn("Metrics listener stopped")
+		}
+	}()
+
+	b.deps.Logger.WithFields(logger.Fields{"pkg": "embedding", "func": "Bridge.startMetricsListener", "addr": ln.Addr().String()}).Info("Metrics listener started")
+	return nil
+}
+
+// stopMetricsListener shuts down the metrics listener if running.
+func (b *Bridge) stopMetricsListener(ctx context.Context) {
+	b.mu.Lock()
+	srv := b.takeMetricsServer()
+	b.mu.Unlock()
+
+	if srv == nil {
+		return
+	}
+	if err := srv.Shutdown(ctx); err != nil {
+		b.deps.Logger.WithFields(logger.Fields{"pkg": "embedding", "func": "Bridge.stopMetricsListener"}).WithError(err).Warn("Error closing metrics listener")
+	}
+}
+
+// takeMetricsServer detaches and returns the metrics server, or nil if none
+// is running. The caller must hold b.mu.
+func (b *Bridge) takeMetricsServer() *http.Server {
+	srv := b.metricsServer
+	b.metricsServer = nil
+	return srv
+}
+
+// MetricsAddr returns the address the metrics listener is bound to,
+// or an empty string if metrics are disabled or the bridge is not running.
+func (b *Bridge) MetricsAddr() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.metricsListener == nil || b.metricsServer == nil {
+		return ""
+	}
+	return b.metricsListener.Addr().String()
+}
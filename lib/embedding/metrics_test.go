@@ -0,0 +1,87 @@
+package embedding
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/go-i2p/go-sam-bridge/lib/session"
+)
+
+func TestSessionSamples(t *testing.T) {
+	registry := session.NewRegistry()
+	for _, s := range []*session.BaseSession{
+		session.NewBaseSession("stream-1", session.StyleStream, nil, nil, nil),
+		session.NewBaseSession("stream-2", session.StyleStream, nil, nil, nil),
+		session.NewBaseSession("raw-1", session.StyleRaw, nil, nil, nil),
+	} {
+		if s.Style() == session.StyleStream {
+			s.Activate()
+		}
+		if err := registry.Register(s); err != nil {
+			t.Fatalf("Register() error = %v", err)
+		}
+	}
+
+	samples := sessionSamples(registry)
+	if len(samples) != 2 {
+		t.Fatalf("sessionSamples() returned %d samples, want 2: %+v", len(samples), samples)
+	}
+
+	raw, stream := samples[0], samples[1]
+	if raw.LabelValues[0] != "RAW" || raw.LabelValues[1] != "CREATING" || raw.Value != 1 {
+		t.Errorf("RAW sample = %+v, want RAW/CREATING = 1", raw)
+	}
+	if stream.LabelValues[0] != "STREAM" || stream.LabelValues[1] != "ACTIVE" || stream.Value != 2 {
+		t.Errorf("STREAM sample = %+v, want STREAM/ACTIVE = 2", stream)
+	}
+}
+
+func TestBridgeMetricsListener(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to create test listener: %v", err)
+	}
+
+	bridge, err := New(
+		WithListener(ln),
+		WithI2CPProvider(&mockI2CPProvider{}),
+		WithDatagramPort(0),
+		WithMetricsAddr("127.0.0.1:0"),
+	)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	if err := bridge.Start(context.Background()); err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+
+	addr := bridge.MetricsAddr()
+	if addr == "" {
+		t.Fatal("MetricsAddr() is empty after Start()")
+	}
+
+	resp, err := http.Get("http://" + addr + MetricsPath)
+	if err != nil {
+		t.Fatalf("GET %s error = %v", MetricsPath, err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+
+	for _, want := range []string{"sam_control_connections 0\n", "# TYPE sam_sessions gauge\n", "# TYPE sam_commands_total counter\n"} {
+		if !strings.Contains(string(body), want) {
+			t.Errorf("metrics output missing %q", want)
+		}
+	}
+
+	if err := bridge.Stop(context.Background()); err != nil {
+		t.Errorf("Stop() error = %v", err)
+	}
+	if got := bridge.MetricsAddr(); got != "" {
+		t.Errorf("MetricsAddr() = %q after Stop(), want empty", got)
+	}
+}
@@ -2,9 +2,13 @@ package embedding
 
 import (
 	"crypto/tls"
+	"io"
 	"net"
+	"net/netip"
+	"os"
 	"time"
 
+	"github.com/go-i2p/go-sam-bridge/lib/bridge"
 	"github.com/go-i2p/go-sam-bridge/lib/handler"
 	"github.com/go-i2p/go-sam-bridge/lib/i2cp"
 	"github.com/go-i2p/go-sam-bridge/lib/session"
@@ -14,14 +18,30 @@ import (
 // Option is a functional option for configuring the Bridge.
 type Option func(*Config)
 
-// WithListenAddr sets the SAM TCP listen address.
-// Default is ":7656" per SAMv3.md.
+// WithListenAddr sets the SAM listen address: a TCP address or a
+// "unix:" socket path. Default is ":7656" per SAMv3.md.
 func WithListenAddr(addr string) Option {
 	return func(c *Config) {
 		c.ListenAddr = addr
 	}
 }
 
+// WithUnixSocketMode sets the file mode of a "unix:" listen socket.
+// Default is 0660.
+func WithUnixSocketMode(mode os.FileMode) Option {
+	return func(c *Config) {
+		c.UnixSocketMode = mode
+	}
+}
+
+// WithUnixSocketOwner sets the owner of a "unix:" listen socket,
+// as "user", "user:group" or ":group".
+func WithUnixSocketOwner(owner string) Option {
+	return func(c *Config) {
+		c.UnixSocketOwner = owner
+	}
+}
+
 // WithI2CPAddr sets the I2CP router address.
 // Default is "127.0.0.1:7654" per I2CP spec.
 func WithI2CPAddr(addr string) Option {
@@ -108,6 +128,146 @@ func WithAuth(users map[string]string) Option {
 	}
 }
 
+// WithAuthBackend persists SAM authentication users in backend, so that
+// users added with AUTH ADD survive a restart. Users from WithAuth are
+// merged into the backend when the bridge starts. As with WithAuth,
+// authentication is required.
+func WithAuthBackend(backend bridge.AuthBackend) Option {
+	return func(c *Config) {
+		c.AuthBackend = backend
+	}
+}
+
+// WithAuthFile persists SAM authentication users in an htpasswd-style file
+// at path, as written by "htpasswd -B". See bridge.HtpasswdFile.
+func WithAuthFile(path string) Option {
+	return WithAuthBackend(bridge.NewHtpasswdFile(path))
+}
+
+// WithClientCertAuth authenticates TLS clients by certificate: the given
+// field of a verified client certificate is mapped to a SAM user, and HELLO
+// then needs no USER/PASSWORD. Use with WithTLS and a tls.Config whose
+// ClientAuth verifies client certificates.
+func WithClientCertAuth(field bridge.CertUserField) Option {
+	return func(c *Config) {
+		c.ClientCertUser = field
+	}
+}
+
+// WithAuthLockout sets how failed HELLO logins lock out the client IP and
+// the username. A zero MaxFailures disables lockouts.
+func WithAuthLockout(lockout bridge.LockoutConfig) Option {
+	return func(c *Config) {
+		c.AuthLockout = &lockout
+	}
+}
+
+// WithUserRoles assigns roles to SAM authentication users, e.g.
+// {"ops": bridge.RoleAdmin}. Users not listed have bridge.RoleUser.
+func WithUserRoles(roles map[string]string) Option {
+	return func(c *Config) {
+		c.AuthRoles = make(map[string]string, len(roles))
+		for k, v := range roles {
+			c.AuthRoles[k] = v
+		}
+	}
+}
+
+// WithRolePolicy sets the policy of a role, defining the role if it is
+// not bridge.RoleAdmin or bridge.RoleUser.
+func WithRolePolicy(role string, policy bridge.Policy) Option {
+	return func(c *Config) {
+		if c.AuthPolicies == nil {
+			c.AuthPolicies = make(map[string]bridge.Policy)
+		}
+		c.AuthPolicies[role] = policy
+	}
+}
+
+// WithAllowedNetworks limits the SAM control and UDP ports to clients in
+// the given networks, e.g. netip.MustParsePrefix("172.17.0.0/16").
+// Loopback is not implied; include it if local clients need access.
+func WithAllowedNetworks(prefixes ...netip.Prefix) Option {
+	return func(c *Config) {
+		c.AllowedNetworks = append([]netip.Prefix(nil), prefixes...)
+	}
+}
+
+// WithDeniedNetworks refuses clients in the given networks on the SAM
+// control and UDP ports, even if WithAllowedNetworks allows them.
+func WithDeniedNetworks(prefixes ...netip.Prefix) Option {
+	return func(c *Config) {
+		c.DeniedNetworks = append([]netip.Prefix(nil), prefixes...)
+	}
+}
+
+// WithAccessListDir lets STREAM sessions restrict the peers that may
+// connect to them with a list file in dir, named by the sam.accessListFile
+// option of SESSION CREATE. Files hold one or more b32 addresses or
+// destination hashes per line.
+func WithAccessListDir(dir string) Option {
+	return func(c *Config) {
+		c.AccessListDir = dir
+	}
+}
+
+// WithForwardSocketDir lets STREAM FORWARD forward to the Unix sockets in
+// dir with the PATH option, instead of HOST and PORT. PATH may be a name
+// within dir or an absolute path inside it; other paths are refused.
+func WithForwardSocketDir(dir string) Option {
+	return func(c *Config) {
+		c.ForwardSocketDir = dir
+	}
+}
+
+// WithRateLimit limits how fast clients may send commands, with token
+// buckets per connection and per client IP. Commands over the limit are
+// refused with I2P_ERROR. Start from bridge.DefaultCommandCosts for Costs
+// to keep the default weights of expensive commands.
+func WithRateLimit(limits bridge.RateLimitConfig) Option {
+	return func(c *Config) {
+		c.RateLimit = &limits
+	}
+}
+
+// WithTrustedProxies accepts HAProxy PROXY protocol headers (version 1 or
+// 2) from load balancers in the given networks, so that limits, access
+// lists and logs see each client's own address. Connections from these
+// networks must send a header. A WithListener listener must then not
+// terminate TLS itself, since proxies send the header before the handshake.
+func WithTrustedProxies(prefixes ...netip.Prefix) Option {
+	return func(c *Config) {
+		c.TrustedProxies = append([]netip.Prefix(nil), prefixes...)
+	}
+}
+
+// WithAuditLog writes a JSON line to w for each command handled: time,
+// client address, user, command, session, result and latency. Passwords,
+// private keys and offline signing material are redacted. Writes are
+// serialized, so w need not be safe for concurrent use.
+func WithAuditLog(w io.Writer) Option {
+	return func(c *Config) {
+		c.AuditLog = w
+	}
+}
+
+// WithAuditFile appends the audit log of WithAuditLog to the file at path,
+// created with mode 0600 if needed. The file is closed by Stop, and by
+// Reload so that a rotated file is replaced. See bridge.AuditFile.
+func WithAuditFile(path string) Option {
+	return WithAuditLog(bridge.NewAuditFile(path))
+}
+
+// WithTranscriptDir records the control lines of each connection in a
+// file in dir, to debug client interoperability. Secrets are redacted and
+// stream payloads are not recorded. Transcripts can be replayed with
+// "sam-bridge replay".
+func WithTranscriptDir(dir string) Option {
+	return func(c *Config) {
+		c.TranscriptDir = dir
+	}
+}
+
 // WithI2CPCredentials sets I2CP authentication credentials.
 func WithI2CPCredentials(username, password string) Option {
 	return func(c *Config) {
@@ -139,3 +299,52 @@ func WithEmbeddedRouterTimeout(timeout time.Duration) Option {
 		c.EmbeddedRouterTimeout = timeout
 	}
 }
+
+// WithTimeouts sets the bridge's connection timeouts. WithKeepalive and
+// WithIdleTimeout, if also given, take precedence for their fields.
+func WithTimeouts(timeouts bridge.TimeoutConfig) Option {
+	return func(c *Config) {
+		c.Timeouts = &timeouts
+	}
+}
+
+// WithLimits sets the bridge's buffer sizes and connection limits,
+// including the maximum command line length.
+func WithLimits(limits bridge.LimitConfig) Option {
+	return func(c *Config) {
+		c.Limits = &limits
+	}
+}
+
+// WithKeepalive enables server-initiated PING at the given interval.
+// SAM 3.2+ clients that do not answer with PONG are disconnected and their
+// sessions released.
+func WithKeepalive(interval time.Duration) Option {
+	return func(c *Config) {
+		c.KeepaliveInterval = interval
+	}
+}
+
+// WithIdleTimeout closes control connections that send no command for the
+// given duration, releasing their sessions.
+func WithIdleTimeout(timeout time.Duration) Option {
+	return func(c *Config) {
+		c.IdleTimeout = timeout
+	}
+}
+
+// WithMetricsAddr enables the Prometheus metrics listener on addr.
+// Metrics are served at MetricsPath in the Prometheus text format.
+func WithMetricsAddr(addr string) Option {
+	return func(c *Config) {
+		c.MetricsAddr = addr
+	}
+}
+
+// WithAdminAddr enables the local management API on addr, which must be a
+// loopback TCP address or a "unix:" socket path. See package admin.
+func WithAdminAddr(addr string) Option {
+	return func(c *Config) {
+		c.AdminAddr = addr
+	}
+}
@@ -1,11 +1,16 @@
 package embedding
 
 import (
+	"bytes"
 	"crypto/tls"
 	"net"
+	"net/netip"
 	"testing"
+	"time"
 
+	"github.com/go-i2p/go-sam-bridge/lib/bridge"
 	"github.com/go-i2p/go-sam-bridge/lib/handler"
+	"github.com/go-i2p/go-sam-bridge/lib/session"
 	"github.com/go-i2p/logger"
 )
 
@@ -18,6 +23,24 @@ func TestWithListenAddr(t *testing.T) {
 	}
 }
 
+func TestWithUnixSocket(t *testing.T) {
+	cfg := DefaultConfig()
+	WithListenAddr("unix:/run/sam-bridge/sam.sock")(cfg)
+	WithUnixSocketMode(0o600)(cfg)
+	WithUnixSocketOwner("i2p:sam")(cfg)
+
+	bcfg := cfg.toBridgeConfig()
+	if bcfg.ListenAddr != "unix:/run/sam-bridge/sam.sock" {
+		t.Errorf("ListenAddr = %q, want unix socket address", bcfg.ListenAddr)
+	}
+	if bcfg.UnixSocket.Mode != 0o600 {
+		t.Errorf("UnixSocket.Mode = %o, want 600", bcfg.UnixSocket.Mode)
+	}
+	if bcfg.UnixSocket.Owner != "i2p:sam" {
+		t.Errorf("UnixSocket.Owner = %q, want %q", bcfg.UnixSocket.Owner, "i2p:sam")
+	}
+}
+
 func TestWithI2CPAddr(t *testing.T) {
 	cfg := DefaultConfig()
 	WithI2CPAddr("10.0.0.1:7654")(cfg)
@@ -109,6 +132,176 @@ func TestWithAuth(t *testing.T) {
 	}
 }
 
+func TestWithAuthFile(t *testing.T) {
+	cfg := DefaultConfig()
+	WithAuthFile("/var/lib/sam-bridge/users")(cfg)
+
+	f, ok := cfg.AuthBackend.(*bridge.HtpasswdFile)
+	if !ok {
+		t.Fatalf("AuthBackend = %T, want *bridge.HtpasswdFile", cfg.AuthBackend)
+	}
+	if f.Path() != "/var/lib/sam-bridge/users" {
+		t.Errorf("Path() = %q, want %q", f.Path(), "/var/lib/sam-bridge/users")
+	}
+
+	bc := cfg.toBridgeConfig()
+	if bc.Auth.Backend != cfg.AuthBackend {
+		t.Error("toBridgeConfig() did not pass the auth backend through")
+	}
+	if !bc.Auth.Required {
+		t.Error("Auth.Required = false with an auth file, want true")
+	}
+}
+
+func TestWithAuthLockout(t *testing.T) {
+	cfg := DefaultConfig()
+	if bc := cfg.toBridgeConfig(); bc.Auth.Lockout != bridge.DefaultLockoutConfig() {
+		t.Errorf("default Auth.Lockout = %+v, want bridge.DefaultLockoutConfig()", bc.Auth.Lockout)
+	}
+
+	lockout := bridge.LockoutConfig{MaxFailures: 3, Duration: time.Minute, MaxDuration: time.Hour}
+	WithAuthLockout(lockout)(cfg)
+	if bc := cfg.toBridgeConfig(); bc.Auth.Lockout != lockout {
+		t.Errorf("Auth.Lockout = %+v, want %+v", bc.Auth.Lockout, lockout)
+	}
+}
+
+func TestWithRolePolicy(t *testing.T) {
+	cfg := DefaultConfig()
+	WithAuth(map[string]string{"ops": "secret", "web": "secret"})(cfg)
+	WithUserRoles(map[string]string{"ops": bridge.RoleAdmin, "web": "web"})(cfg)
+	WithRolePolicy("web", bridge.Policy{Commands: []string{"SESSION", "STREAM"}, Styles: []session.Style{session.StyleStream}})(cfg)
+
+	if errs := cfg.ValidateAll(); len(errs) != 0 {
+		t.Fatalf("ValidateAll() = %v, want no errors", errs)
+	}
+	bc := cfg.toBridgeConfig()
+	if bc.Auth.Roles["ops"] != bridge.RoleAdmin || bc.Auth.Roles["web"] != "web" {
+		t.Errorf("Auth.Roles = %v, want ops=admin web=web", bc.Auth.Roles)
+	}
+	if p, ok := bc.Auth.Policies["web"]; !ok || len(p.Commands) != 2 {
+		t.Errorf("Auth.Policies[web] = %+v, %v, want the web policy", p, ok)
+	}
+
+	WithUserRoles(map[string]string{"web": "undefined"})(cfg)
+	if errs := cfg.ValidateAll(); len(errs) == 0 {
+		t.Error("ValidateAll() with an undefined role = no errors, want one")
+	}
+}
+
+func TestWithAllowedNetworks(t *testing.T) {
+	cfg := DefaultConfig()
+	allow := netip.MustParsePrefix("172.17.0.0/16")
+	deny := netip.MustParsePrefix("172.17.0.99/32")
+	WithAllowedNetworks(allow)(cfg)
+	WithDeniedNetworks(deny)(cfg)
+
+	bc := cfg.toBridgeConfig()
+	if len(bc.Access.Allow) != 1 || bc.Access.Allow[0] != allow {
+		t.Errorf("Access.Allow = %v, want [%v]", bc.Access.Allow, allow)
+	}
+	if len(bc.Access.Deny) != 1 || bc.Access.Deny[0] != deny {
+		t.Errorf("Access.Deny = %v, want [%v]", bc.Access.Deny, deny)
+	}
+}
+
+func TestWithRateLimit(t *testing.T) {
+	cfg := DefaultConfig()
+	if bc := cfg.toBridgeConfig(); bc.RateLimit.PerConnection.Rate != 0 || bc.RateLimit.PerClient.Rate != 0 {
+		t.Errorf("default RateLimit = %+v, want no limits", bc.RateLimit)
+	}
+
+	limits := bridge.RateLimitConfig{
+		PerConnection: bridge.TokenBucketConfig{Rate: 5, Burst: 20},
+		PerClient:     bridge.TokenBucketConfig{Rate: 20, Burst: 100},
+		Costs:         bridge.DefaultCommandCosts(),
+	}
+	WithRateLimit(limits)(cfg)
+	bc := cfg.toBridgeConfig()
+	if bc.RateLimit.PerConnection != limits.PerConnection || bc.RateLimit.PerClient != limits.PerClient {
+		t.Errorf("RateLimit = %+v, want %+v", bc.RateLimit, limits)
+	}
+	if bc.RateLimit.Costs["SESSION CREATE"] != 10 {
+		t.Errorf("RateLimit.Costs = %v, want the default costs", bc.RateLimit.Costs)
+	}
+}
+
+func TestWithTrustedProxies(t *testing.T) {
+	cfg := DefaultConfig()
+	proxy := netip.MustParsePrefix("10.0.0.0/8")
+	WithTrustedProxies(proxy)(cfg)
+
+	bc := cfg.toBridgeConfig()
+	if len(bc.ProxyProtocol.TrustedProxies) != 1 || bc.ProxyProtocol.TrustedProxies[0] != proxy {
+		t.Errorf("ProxyProtocol.TrustedProxies = %v, want [%v]", bc.ProxyProtocol.TrustedProxies, proxy)
+	}
+}
+
+func TestWithAuditLog(t *testing.T) {
+	cfg := DefaultConfig()
+	var buf bytes.Buffer
+	WithAuditLog(&buf)(cfg)
+
+	if bc := cfg.toBridgeConfig(); bc.AuditLog != &buf {
+		t.Errorf("AuditLog = %v, want the writer", bc.AuditLog)
+	}
+
+	WithAuditFile("/var/log/sam-bridge/audit.log")(cfg)
+	f, ok := cfg.AuditLog.(*bridge.AuditFile)
+	if !ok || f.Path() != "/var/log/sam-bridge/audit.log" {
+		t.Errorf("AuditLog = %v, want an AuditFile for the path", cfg.AuditLog)
+	}
+}
+
+func TestWithTranscriptDir(t *testing.T) {
+	cfg := DefaultConfig()
+	WithTranscriptDir("/var/lib/sam-bridge/transcripts")(cfg)
+
+	if bc := cfg.toBridgeConfig(); bc.TranscriptDir != "/var/lib/sam-bridge/transcripts" {
+		t.Errorf("TranscriptDir = %q, want the directory", bc.TranscriptDir)
+	}
+}
+
+func TestWithAccessListDir(t *testing.T) {
+	cfg := DefaultConfig()
+	WithAccessListDir("/etc/sam-bridge/peers")(cfg)
+
+	if bc := cfg.toBridgeConfig(); bc.Stream.AccessListDir != "/etc/sam-bridge/peers" {
+		t.Errorf("Stream.AccessListDir = %q, want the directory", bc.Stream.AccessListDir)
+	}
+}
+
+func TestWithForwardSocketDir(t *testing.T) {
+	cfg := DefaultConfig()
+	WithForwardSocketDir("/run/sam-bridge")(cfg)
+
+	if bc := cfg.toBridgeConfig(); bc.Stream.ForwardSocketDir != "/run/sam-bridge" {
+		t.Errorf("Stream.ForwardSocketDir = %q, want the directory", bc.Stream.ForwardSocketDir)
+	}
+}
+
+func TestWithClientCertAuth(t *testing.T) {
+	cfg := DefaultConfig()
+	WithTLS(&tls.Config{ClientAuth: tls.VerifyClientCertIfGiven})(cfg)
+	WithClientCertAuth(bridge.CertUserURI)(cfg)
+
+	if cfg.ClientCertUser != bridge.CertUserURI {
+		t.Errorf("ClientCertUser = %q, want %q", cfg.ClientCertUser, bridge.CertUserURI)
+	}
+	if bc := cfg.toBridgeConfig(); bc.Auth.CertUser != bridge.CertUserURI {
+		t.Errorf("Auth.CertUser = %q, want %q", bc.Auth.CertUser, bridge.CertUserURI)
+	}
+	if errs := cfg.ValidateAll(); len(errs) != 0 {
+		t.Errorf("ValidateAll() = %v, want no errors", errs)
+	}
+
+	// Certificates cannot be checked without client verification.
+	WithTLS(&tls.Config{})(cfg)
+	if errs := cfg.ValidateAll(); len(errs) != 1 {
+		t.Errorf("ValidateAll() = %v without client certificate verification, want one error", errs)
+	}
+}
+
 func TestWithI2CPCredentials(t *testing.T) {
 	cfg := DefaultConfig()
 	WithI2CPCredentials("user", "pass")(cfg)
@@ -151,6 +344,42 @@ func TestWithHandlerRegistrar(t *testing.T) {
 	}
 }
 
+func TestWithKeepalive(t *testing.T) {
+	cfg := DefaultConfig()
+	WithKeepalive(30 * time.Second)(cfg)
+
+	if cfg.KeepaliveInterval != 30*time.Second {
+		t.Errorf("KeepaliveInterval = %v, want %v", cfg.KeepaliveInterval, 30*time.Second)
+	}
+}
+
+func TestWithIdleTimeout(t *testing.T) {
+	cfg := DefaultConfig()
+	WithIdleTimeout(10 * time.Minute)(cfg)
+
+	if cfg.IdleTimeout != 10*time.Minute {
+		t.Errorf("IdleTimeout = %v, want %v", cfg.IdleTimeout, 10*time.Minute)
+	}
+}
+
+func TestWithMetricsAddr(t *testing.T) {
+	cfg := DefaultConfig()
+	WithMetricsAddr("127.0.0.1:7660")(cfg)
+
+	if cfg.MetricsAddr != "127.0.0.1:7660" {
+		t.Errorf("MetricsAddr = %q, want %q", cfg.MetricsAddr, "127.0.0.1:7660")
+	}
+}
+
+func TestWithAdminAddr(t *testing.T) {
+	cfg := DefaultConfig()
+	WithAdminAddr("unix:/tmp/sam-admin.sock")(cfg)
+
+	if cfg.AdminAddr != "unix:/tmp/sam-admin.sock" {
+		t.Errorf("AdminAddr = %q, want %q", cfg.AdminAddr, "unix:/tmp/sam-admin.sock")
+	}
+}
+
 // mockListener implements net.Listener for testing.
 type mockListener struct{}
 
@@ -0,0 +1,61 @@
+package embedding
+
+import (
+	"github.com/go-i2p/logger"
+
+	"github.com/go-i2p/go-sam-bridge/lib/i2cp"
+	"github.com/go-i2p/go-sam-bridge/lib/protocol"
+	"github.com/go-i2p/go-sam-bridge/lib/session"
+)
+
+// watchReconnect keeps a SAM session usable across I2P router restarts.
+// When the I2CP client loses the router, the SAM client receives a
+// SESSION STATUS notice on its control socket. Once the I2CP session is
+// re-established with the same destination, rewire rebuilds the transports
+// bound to the old go-i2cp session and the client is told the session is
+// back. If the session is abandoned, the control socket is closed after the
+// notice, which ends the SAM session per SAMv3.md.
+func watchReconnect(deps *Dependencies, sess session.Session, i2cpSess *i2cp.I2CPSession, rewire func()) {
+	callbacks := &i2cp.SessionCallbacks{}
+	if existing := i2cpSess.Callbacks(); existing != nil {
+		copied := *existing
+		callbacks = &copied
+	}
+
+	callbacks.OnSuspended = func(err error) {
+		deps.Logger.WithFields(logger.Fields{"pkg": "embedding", "func": "watchReconnect", "sessionID": sess.ID()}).WithError(err).Warn("I2CP session suspended, waiting for router reconnect")
+		notifySessionStatus(deps, sess, protocol.ResultI2PError, "I2P router connection lost, reconnecting")
+	}
+	callbacks.OnReestablished = func() {
+		rewire()
+		deps.Logger.WithFields(logger.Fields{"pkg": "embedding", "func": "watchReconnect", "sessionID": sess.ID()}).Info("I2CP session re-established, transports rewired")
+		notifySessionStatus(deps, sess, protocol.ResultOK, "session re-established after I2P router reconnect")
+	}
+	callbacks.OnAbandoned = func(err error) {
+		deps.Logger.WithFields(logger.Fields{"pkg": "embedding", "func": "watchReconnect", "sessionID": sess.ID()}).WithError(err).Error("I2CP session abandoned, closing SAM session")
+		notifySessionStatus(deps, sess, protocol.ResultI2PError, "session lost: "+err.Error())
+		if conn := sess.ControlConn(); conn != nil {
+			conn.Close()
+		}
+	}
+
+	i2cpSess.SetCallbacks(callbacks)
+}
+
+// notifySessionStatus writes an unsolicited SESSION STATUS line for sess to
+// its control socket.
+func notifySessionStatus(deps *Dependencies, sess session.Session, result, message string) {
+	conn := sess.ControlConn()
+	if conn == nil {
+		return
+	}
+
+	resp := protocol.NewResponse(protocol.VerbSession).
+		WithAction(protocol.ActionStatus).
+		WithResult(result).
+		WithOption("ID", sess.ID()).
+		WithMessage(message)
+	if _, err := conn.Write(resp.Bytes()); err != nil {
+		deps.Logger.WithFields(logger.Fields{"pkg": "embedding", "func": "notifySessionStatus", "sessionID": sess.ID()}).WithError(err).Debug("Failed to notify SAM client of session status")
+	}
+}
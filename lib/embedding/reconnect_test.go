@@ -0,0 +1,86 @@
+package embedding
+
+import (
+	"bufio"
+	"errors"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-i2p/logger"
+
+	"github.com/go-i2p/go-sam-bridge/lib/i2cp"
+	"github.com/go-i2p/go-sam-bridge/lib/session"
+)
+
+func TestWatchReconnect(t *testing.T) {
+	server, client := net.Pipe()
+	defer client.Close()
+
+	deps := &Dependencies{Logger: logger.GetGoI2PLogger()}
+	sess := session.NewBaseSession("test-session", session.StyleStream, nil, server, nil)
+	i2cpSess := &i2cp.I2CPSession{}
+
+	rewired := false
+	watchReconnect(deps, sess, i2cpSess, func() { rewired = true })
+
+	callbacks := i2cpSess.Callbacks()
+	if callbacks == nil || callbacks.OnSuspended == nil || callbacks.OnReestablished == nil || callbacks.OnAbandoned == nil {
+		t.Fatal("watchReconnect did not install reconnect callbacks")
+	}
+
+	reader := bufio.NewReader(client)
+	readLine := func() string {
+		client.SetReadDeadline(time.Now().Add(time.Second))
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			t.Fatalf("ReadString() error = %v", err)
+		}
+		return line
+	}
+
+	go callbacks.OnSuspended(errors.New("router restart"))
+	if line := readLine(); !strings.HasPrefix(line, "SESSION STATUS RESULT=I2P_ERROR ID=test-session") {
+		t.Errorf("suspend notice = %q", line)
+	}
+
+	go callbacks.OnReestablished()
+	if line := readLine(); !strings.HasPrefix(line, "SESSION STATUS RESULT=OK ID=test-session") {
+		t.Errorf("re-established notice = %q", line)
+	}
+	if !rewired {
+		t.Error("expected transports to be rewired after re-establishment")
+	}
+
+	go callbacks.OnAbandoned(errors.New("gave up"))
+	if line := readLine(); !strings.Contains(line, "gave up") {
+		t.Errorf("abandon notice = %q", line)
+	}
+	if _, err := reader.ReadString('\n'); err == nil {
+		t.Error("expected control socket to be closed after abandonment")
+	}
+}
+
+func TestWatchReconnect_PreservesCallbacks(t *testing.T) {
+	deps := &Dependencies{Logger: logger.GetGoI2PLogger()}
+	sess := session.NewBaseSession("test-session", session.StyleStream, nil, nil, nil)
+	i2cpSess := &i2cp.I2CPSession{}
+
+	destroyed := false
+	i2cpSess.SetCallbacks(&i2cp.SessionCallbacks{OnDestroyed: func() { destroyed = true }})
+
+	watchReconnect(deps, sess, i2cpSess, func() {})
+
+	callbacks := i2cpSess.Callbacks()
+	if callbacks.OnDestroyed == nil {
+		t.Fatal("existing callbacks were dropped")
+	}
+	callbacks.OnDestroyed()
+	if !destroyed {
+		t.Error("existing OnDestroyed callback was not preserved")
+	}
+
+	// Notifying a session without a control socket must not panic.
+	callbacks.OnSuspended(nil)
+}
@@ -128,7 +128,8 @@ func (h *DatagramHandler) handleSend(ctx *Context, cmd *protocol.Command) (*prot
 }
 
 // lookupDatagramSession finds the appropriate DATAGRAM session for sending.
-// Per SAMv3.md, tries bound session first, then most recently created.
+// Per SAMv3.md, tries bound session first, then the most recently created
+// one owned by the same user.
 func (h *DatagramHandler) lookupDatagramSession(ctx *Context) (session.DatagramSession, *protocol.Response) {
 	var dgSess session.DatagramSession
 	var ok bool
@@ -144,7 +145,7 @@ func (h *DatagramHandler) lookupDatagramSession(ctx *Context) (session.DatagramS
 
 	// If bound session is not the target style, try most recently created
 	if !ok && ctx.Registry != nil {
-		if mostRecent := ctx.Registry.MostRecentByStyle(h.style); mostRecent != nil {
+		if mostRecent := session.MostRecentOwned(ctx.Registry, h.style, ctx.User); mostRecent != nil {
 			dgSess, ok = mostRecent.(session.DatagramSession)
 		}
 	}
@@ -5,8 +5,8 @@ package handler
 
 import (
 	"context"
-	"io"
 	"net"
+	"time"
 
 	"github.com/go-i2p/go-sam-bridge/lib/protocol"
 	"github.com/go-i2p/go-sam-bridge/lib/session"
@@ -57,6 +57,11 @@ type Context struct {
 	// Always true if authentication is disabled on the bridge.
 	Authenticated bool
 
+	// User is the SAM user the client authenticated as, or empty if it
+	// did not. Sessions created on this connection are owned by User, and
+	// other users' sessions cannot be used from it.
+	User string
+
 	// HandshakeComplete indicates if HELLO has been received.
 	HandshakeComplete bool
 
@@ -66,14 +71,30 @@ type Context struct {
 	// ForwardListeners holds listeners created by STREAM FORWARD.
 	// Closed when the SAM connection is torn down to stop all forwarding loops.
 	ForwardListeners []net.Listener
+
+	// StreamLinger is how long ForwardData keeps forwarding in one direction
+	// after the other has reached EOF (0 = close both at once).
+	StreamLinger time.Duration
+
+	// AccessListDir is the directory sam.accessListFile names a file in.
+	// Empty refuses sam.accessListFile.
+	AccessListDir string
+
+	// ForwardSocketDir is the directory STREAM FORWARD PATH may name a Unix
+	// socket in. Empty refuses PATH.
+	ForwardSocketDir string
+
+	// forwardDone is closed when forwarding started by StartForwarding ends.
+	forwardDone chan struct{}
 }
 
 // NewContext creates a new handler context with the given connection.
 func NewContext(conn net.Conn, registry session.Registry) *Context {
 	return &Context{
-		Conn:     conn,
-		Registry: registry,
-		Ctx:      context.Background(),
+		Conn:         conn,
+		Registry:     registry,
+		Ctx:          context.Background(),
+		StreamLinger: session.DefaultStreamLinger,
 	}
 }
 
@@ -146,43 +167,34 @@ func (c *Context) StartForwarding() {
 	if c.StreamConn == nil || c.Conn == nil {
 		return
 	}
-	go c.ForwardData(c.StreamConn)
+	done := make(chan struct{})
+	c.forwardDone = done
+	go func(streamConn net.Conn) {
+		defer close(done)
+		_ = c.ForwardData(streamConn)
+	}(c.StreamConn)
+}
+
+// ForwardingDone returns a channel that is closed when the forwarding
+// started by StartForwarding has finished, or nil if forwarding was
+// never started on this connection.
+func (c *Context) ForwardingDone() <-chan struct{} {
+	if c.forwardDone == nil {
+		return nil
+	}
+	return c.forwardDone
 }
 
 // ForwardData performs bidirectional data forwarding between the control
 // socket (Conn) and the I2P stream connection (i2pConn).
-// This function runs until either connection is closed or encounters an error.
+// This function runs until both directions are done. When one side stops
+// sending, the other side's write half is closed and the remaining
+// direction drains for up to StreamLinger; see session.Splice.
 func (c *Context) ForwardData(i2pConn net.Conn) error {
 	if c.Conn == nil {
 		return nil
 	}
-
-	// Use a WaitGroup to wait for both copy directions
-	done := make(chan error, 2)
-
-	// Forward: control socket -> I2P stream
-	go func() {
-		_, err := io.Copy(i2pConn, c.Conn)
-		done <- err
-	}()
-
-	// Forward: I2P stream -> control socket
-	go func() {
-		_, err := io.Copy(c.Conn, i2pConn)
-		done <- err
-	}()
-
-	// Wait for either direction to complete (connection closed)
-	err := <-done
-
-	// Close both connections to unblock the other goroutine
-	c.Conn.Close()
-	i2pConn.Close()
-
-	// Wait for the second goroutine
-	<-done
-
-	return err
+	return session.Splice(context.Background(), c.Conn, i2pConn, c.StreamLinger)
 }
 
 // StartDatagramReceiver starts a goroutine that reads from the session's
@@ -158,3 +158,35 @@ func TestHandlerFunc(t *testing.T) {
 		t.Error("HandlerFunc was not called")
 	}
 }
+
+func TestContext_ForwardingDone(t *testing.T) {
+	client, bridgeSide := net.Pipe()
+	i2pSide, peer := net.Pipe()
+	defer client.Close()
+	defer peer.Close()
+
+	ctx := NewContext(bridgeSide, nil)
+	if ctx.ForwardingDone() != nil {
+		t.Error("ForwardingDone() should be nil before StartForwarding")
+	}
+
+	ctx.SetStreamConn(i2pSide)
+	ctx.StartForwarding()
+	done := ctx.ForwardingDone()
+	if done == nil {
+		t.Fatal("ForwardingDone() = nil after StartForwarding")
+	}
+
+	select {
+	case <-done:
+		t.Fatal("forwarding finished while both ends are open")
+	case <-time.After(20 * time.Millisecond):
+	}
+
+	peer.Close()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("ForwardingDone() not closed after the I2P side closed")
+	}
+}
@@ -119,7 +119,8 @@ func (h *RawHandler) handleSend(ctx *Context, cmd *protocol.Command) (*protocol.
 }
 
 // lookupRawSession finds the appropriate RAW session for sending.
-// Per SAMv3.md, tries bound session first, then most recently created.
+// Per SAMv3.md, tries bound session first, then the most recently created
+// one owned by the same user.
 func (h *RawHandler) lookupRawSession(ctx *Context) (session.RawSession, *protocol.Response) {
 	var rawSess session.RawSession
 	var ok bool
@@ -135,7 +136,7 @@ func (h *RawHandler) lookupRawSession(ctx *Context) (session.RawSession, *protoc
 
 	// If bound session is not RAW style, try most recently created
 	if !ok && ctx.Registry != nil {
-		if mostRecent := ctx.Registry.MostRecentByStyle(session.StyleRaw); mostRecent != nil {
+		if mostRecent := session.MostRecentOwned(ctx.Registry, session.StyleRaw, ctx.User); mostRecent != nil {
 			rawSess, ok = mostRecent.(session.RawSession)
 		}
 	}
@@ -5,6 +5,7 @@ import (
 	"context"
 	"fmt"
 	"net"
+	"net/netip"
 	"time"
 
 	"github.com/go-i2p/logger"
@@ -116,11 +117,28 @@ func (h *SessionHandler) handleCreate(ctx *Context, cmd *protocol.Command) (*pro
 		return sessionError(err.Error()), nil
 	}
 
+	// Parse the inbound stream access list, reading any list file from the
+	// bridge's access list directory
+	if config.AccessList, err = session.ParseAccessList(cmd.Options, ctx.AccessListDir); err != nil {
+		return sessionError(err.Error()), nil
+	}
+
 	// Create the session based on style
 	newSession, err := h.createSession(id, style, dest, ctx.Conn, config, cmd)
 	if err != nil {
 		return sessionError(err.Error()), nil
 	}
+	if o, ok := newSession.(interface{ SetOwner(string) }); ok {
+		o.SetOwner(ctx.User)
+	}
+	src, err := udpSource(style, cmd)
+	if err != nil {
+		newSession.Close()
+		return sessionError(err.Error()), nil
+	}
+	if s, ok := newSession.(interface{ SetUDPSource(*net.UDPAddr) }); ok && src != nil {
+		s.SetUDPSource(src)
+	}
 
 	// Setup I2CP session and wait for tunnels
 	i2cpHandle, resp := h.setupI2CPSession(ctx, id, config, newSession)
@@ -370,6 +388,44 @@ func (h *SessionHandler) createSession(
 	}
 }
 
+// udpSource returns the address that datagrams for a new DATAGRAM, RAW,
+// DATAGRAM2 or DATAGRAM3 session must come from on the SAM UDP port, from
+// its UDP_SOURCE_HOST and UDP_SOURCE_PORT options. HOST and PORT only set
+// where datagrams are forwarded to. It returns nil if neither option is
+// given, leaving the session bound to the host of its control connection.
+// Without UDP_SOURCE_HOST only the port is bound; without UDP_SOURCE_PORT
+// the UDP listener decides which ports on the host are allowed.
+// UDP_SOURCE_HOST must be an IP address, so that SESSION CREATE never
+// waits on a DNS lookup.
+func udpSource(style session.Style, cmd *protocol.Command) (*net.UDPAddr, error) {
+	switch style {
+	case session.StyleRaw, session.StyleDatagram, session.StyleDatagram2, session.StyleDatagram3:
+	default:
+		return nil, nil
+	}
+
+	host, portStr := cmd.Get("UDP_SOURCE_HOST"), cmd.Get("UDP_SOURCE_PORT")
+	if host == "" && portStr == "" {
+		return nil, nil
+	}
+	src := &net.UDPAddr{}
+	if portStr != "" {
+		port, err := protocol.ValidatePortString(portStr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid UDP_SOURCE_PORT: %w", err)
+		}
+		src.Port = port
+	}
+	if host != "" {
+		ip, err := netip.ParseAddr(host)
+		if err != nil {
+			return nil, fmt.Errorf("invalid UDP_SOURCE_HOST: must be an IP address")
+		}
+		src.IP = net.IP(ip.Unmap().AsSlice())
+	}
+	return src, nil
+}
+
 // createStreamSession creates a StreamSessionImpl for STYLE=STREAM.
 // Handles STREAM-specific options: FORWARD/HOST/PORT for forwarding mode.
 //
@@ -39,6 +39,11 @@ func (h *SessionHandler) parseConfig(cmd *protocol.Command, style session.Style)
 		return nil, err
 	}
 
+	// Parse i2p.streaming.* options, which are also passed through
+	if err := h.parseStreamingOptions(cmd, config); err != nil {
+		return nil, err
+	}
+
 	// Collect unparsed I2CP options for passthrough
 	h.collectI2CPOptions(cmd, config, parsedOptions)
 
@@ -135,7 +140,20 @@ func (h *SessionHandler) parseConfigUDPOptions(cmd *protocol.Command, config *se
 	return nil
 }
 
-// collectI2CPOptions gathers unparsed i2cp.* and streaming.* options for I2CP passthrough.
+// parseStreamingOptions validates the i2p.streaming.* options and stores
+// them for the session's streams. Per Java I2P they are session options,
+// so collectI2CPOptions also passes them through to I2CP.
+func (h *SessionHandler) parseStreamingOptions(cmd *protocol.Command, config *session.SessionConfig) error {
+	opts, err := session.ParseStreamingOptions(cmd.Options)
+	if err != nil {
+		return err
+	}
+	config.Streaming = opts
+	return nil
+}
+
+// collectI2CPOptions gathers unparsed i2cp.*, streaming.* and i2p.streaming.*
+// options for I2CP passthrough.
 func (h *SessionHandler) collectI2CPOptions(cmd *protocol.Command, config *session.SessionConfig, parsed map[string]bool) {
 	for key, value := range cmd.Options {
 		if parsed[key] {
@@ -167,6 +185,7 @@ func isStandardSAMOption(key string) bool {
 	switch key {
 	case "STYLE", "ID", "DESTINATION", "SIGNATURE_TYPE",
 		"PORT", "HOST", "SILENT", "SSL",
+		"UDP_SOURCE_HOST", "UDP_SOURCE_PORT",
 		"LISTEN_PORT", "LISTEN_PROTOCOL",
 		"SEND_TAGS", "TAG_THRESHOLD", "EXPIRES", "SEND_LEASESET":
 		return true
@@ -176,11 +195,12 @@ func isStandardSAMOption(key string) bool {
 }
 
 // isI2CPOption returns true if the option should be passed through to I2CP.
-// This includes i2cp.*, streaming.*, inbound.*, outbound.*, and sam.* options
-// per SAMv3.md specification.
+// This includes i2cp.*, streaming.*, i2p.streaming.*, inbound.*, outbound.*,
+// and sam.* options per SAMv3.md specification.
 func isI2CPOption(key string) bool {
 	return strings.HasPrefix(key, "i2cp.") ||
 		strings.HasPrefix(key, "streaming.") ||
+		strings.HasPrefix(key, session.StreamingOptionPrefix) ||
 		strings.HasPrefix(key, "inbound.") ||
 		strings.HasPrefix(key, "outbound.") ||
 		strings.HasPrefix(key, "sam.")
@@ -353,12 +373,18 @@ func validateStyleOptions(style session.Style, cmd *protocol.Command) error {
 		if cmd.Get("HOST") != "" {
 			return fmt.Errorf("HOST is invalid for STYLE=STREAM")
 		}
+		for _, opt := range []string{"UDP_SOURCE_HOST", "UDP_SOURCE_PORT"} {
+			if cmd.Get(opt) != "" {
+				return fmt.Errorf("%s is invalid for STYLE=STREAM", opt)
+			}
+		}
 
 	case session.StylePrimary, session.StyleMaster:
 		// Per SAM spec: These options only apply to subsessions, not PRIMARY
 		disallowed := []string{
 			"PORT", "HOST", "FROM_PORT", "TO_PORT",
 			"PROTOCOL", "LISTEN_PORT", "LISTEN_PROTOCOL", "HEADER",
+			"UDP_SOURCE_HOST", "UDP_SOURCE_PORT",
 		}
 		for _, opt := range disallowed {
 			if cmd.Get(opt) != "" {
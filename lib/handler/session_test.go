@@ -4,6 +4,7 @@ import (
 	"errors"
 	"strings"
 	"testing"
+	"time"
 
 	commondest "github.com/go-i2p/common/destination"
 	"github.com/go-i2p/go-sam-bridge/lib/protocol"
@@ -128,6 +129,43 @@ func TestSessionHandler_Handle(t *testing.T) {
 			wantResult:    protocol.ResultOK,
 			wantSession:   true,
 		},
+		{
+			name: "STREAM session with access list",
+			command: &protocol.Command{
+				Verb:   "SESSION",
+				Action: "CREATE",
+				Options: map[string]string{
+					"STYLE":                 "STREAM",
+					"ID":                    "test-session-acl",
+					"DESTINATION":           "TRANSIENT",
+					"i2cp.enableAccessList": "true",
+					"i2cp.accessList":       "aeaqcaibaeaqcaibaeaqcaibaeaqcaibaeaqcaibaeaqcaibaeaq.b32.i2p",
+				},
+			},
+			manager:       successManager,
+			registry:      newMockRegistry(),
+			handshakeDone: true,
+			wantResult:    protocol.ResultOK,
+			wantSession:   true,
+		},
+		{
+			name: "access list file without list directory",
+			command: &protocol.Command{
+				Verb:   "SESSION",
+				Action: "CREATE",
+				Options: map[string]string{
+					"STYLE":                 "STREAM",
+					"ID":                    "test-session-acl",
+					"DESTINATION":           "TRANSIENT",
+					"i2cp.enableAccessList": "true",
+					"sam.accessListFile":    "peers",
+				},
+			},
+			manager:       successManager,
+			registry:      newMockRegistry(),
+			handshakeDone: true,
+			wantResult:    protocol.ResultI2PError,
+		},
 		{
 			name: "missing handshake",
 			command: &protocol.Command{
@@ -954,6 +992,32 @@ func TestSessionHandler_ParseConfig(t *testing.T) {
 					c.I2CPOptions["streaming.initialAckDelay"] == "500"
 			},
 		},
+		{
+			name: "i2p.streaming options parsed and passed through",
+			options: map[string]string{
+				"i2p.streaming.maxMessageSize":    "1200",
+				"i2p.streaming.connectTimeout":    "30000",
+				"i2p.streaming.maxConnsPerMinute": "5",
+				"i2p.streaming.answerPings":       "false",
+			},
+			style: session.StyleStream,
+			check: func(c *session.SessionConfig) bool {
+				return c.Streaming.MaxMessageSize == 1200 &&
+					c.Streaming.ConnectTimeout == 30*time.Second &&
+					c.Streaming.MaxConnsPerMinute == 5 &&
+					!c.Streaming.AnswerPings &&
+					c.I2CPOptions["i2p.streaming.maxMessageSize"] == "1200"
+			},
+		},
+		{
+			name: "i2p.streaming option invalid",
+			options: map[string]string{
+				"i2p.streaming.maxWindowSize": "500",
+			},
+			style:     session.StyleStream,
+			wantErr:   true,
+			errSubstr: "i2p.streaming.maxWindowSize",
+		},
 		{
 			name: "sam.udp options explicit parsing",
 			options: map[string]string{
@@ -1086,6 +1150,44 @@ func TestContainsWhitespace(t *testing.T) {
 	}
 }
 
+func TestUDPSource(t *testing.T) {
+	tests := []struct {
+		name    string
+		style   session.Style
+		options map[string]string
+		want    string // "" means nil
+		wantErr bool
+	}{
+		{"no options", session.StyleDatagram, nil, "", false},
+		{"forwarding HOST and PORT", session.StyleDatagram, map[string]string{"HOST": "192.0.2.1", "PORT": "5000"}, "", false},
+		{"stream", session.StyleStream, map[string]string{"UDP_SOURCE_HOST": "192.0.2.1", "UDP_SOURCE_PORT": "5000"}, "", false},
+		{"host and port", session.StyleDatagram, map[string]string{"UDP_SOURCE_HOST": "192.0.2.1", "UDP_SOURCE_PORT": "5000", "HOST": "192.0.2.2", "PORT": "6000"}, "192.0.2.1:5000", false},
+		{"port only", session.StyleRaw, map[string]string{"UDP_SOURCE_PORT": "5000"}, ":5000", false},
+		{"host only", session.StyleDatagram3, map[string]string{"UDP_SOURCE_HOST": "::1"}, "[::1]:0", false},
+		{"invalid port", session.StyleDatagram2, map[string]string{"UDP_SOURCE_PORT": "70000"}, "", true},
+		{"host name", session.StyleDatagram, map[string]string{"UDP_SOURCE_HOST": "localhost"}, "", true},
+		{"IPv4-mapped host", session.StyleDatagram, map[string]string{"UDP_SOURCE_HOST": "::ffff:192.0.2.1"}, "192.0.2.1:0", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := udpSource(tt.style, &protocol.Command{Verb: "SESSION", Action: "CREATE", Options: tt.options})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("udpSource() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.want == "" {
+				if got != nil {
+					t.Errorf("udpSource() = %v, want nil", got)
+				}
+				return
+			}
+			if got == nil || got.String() != tt.want {
+				t.Errorf("udpSource() = %v, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
 func TestIsStandardSAMOption(t *testing.T) {
 	tests := []struct {
 		key  string
@@ -1097,6 +1199,8 @@ func TestIsStandardSAMOption(t *testing.T) {
 		{"SIGNATURE_TYPE", true},
 		{"PORT", true},
 		{"HOST", true},
+		{"UDP_SOURCE_HOST", true},
+		{"UDP_SOURCE_PORT", true},
 		{"SILENT", true},
 		{"SSL", true},
 		{"LISTEN_PORT", true},
@@ -1136,6 +1240,9 @@ func TestIsI2CPOption(t *testing.T) {
 		{"streaming.maxConnsPerMinute", true},
 		{"streaming.initialAckDelay", true},
 		{"streaming.maxWindowSize", true},
+		// i2p.streaming.* options
+		{"i2p.streaming.maxWindowSize", true},
+		{"i2p.streaming.profile", true},
 		// inbound.* options
 		{"inbound.quantity", true},
 		{"inbound.length", true},
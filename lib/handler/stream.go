@@ -56,9 +56,10 @@ type AcceptInfo struct {
 // StreamForwarder sets up forwarding for incoming connections.
 // Implementations handle the forwarding lifecycle.
 type StreamForwarder interface {
-	// Forward sets up forwarding to the specified host:port.
+	// Forward sets up forwarding to opts.Host:opts.Port, or to the Unix
+	// socket opts.Path if it is set.
 	// Returns a Listener that can be closed to stop forwarding.
-	Forward(sess session.Session, host string, port int, ssl bool) (net.Listener, error)
+	Forward(sess session.Session, opts session.ForwardOptions) (net.Listener, error)
 }
 
 // NewStreamHandler creates a new STREAM command handler.
@@ -309,7 +310,12 @@ func (h *StreamHandler) buildAcceptResponse(info *AcceptInfo) (*protocol.Respons
 
 // handleForward processes STREAM FORWARD command.
 // Request: STREAM FORWARD ID=$nickname PORT=$port [HOST=$host] [SILENT={true,false}] [SSL={true,false}]
+// Request: STREAM FORWARD ID=$nickname PATH=$path [HOST=$host] [SILENT={true,false}] [SSL={true,false}]
 // Response: STREAM STATUS RESULT=OK (always sent, even if SILENT=true)
+//
+// PATH is a go-sam-bridge extension forwarding to a Unix socket within
+// ctx.ForwardSocketDir instead of HOST:PORT; HOST then only names the TLS
+// server for SSL=true.
 func (h *StreamHandler) handleForward(ctx *Context, cmd *protocol.Command) (*protocol.Response, error) {
 	// Parse required parameters
 	id := cmd.Get("ID")
@@ -317,15 +323,35 @@ func (h *StreamHandler) handleForward(ctx *Context, cmd *protocol.Command) (*pro
 		return streamInvalidID("missing ID"), nil
 	}
 
-	portStr := cmd.Get("PORT")
-	if portStr == "" {
-		return streamError("missing PORT"), nil
+	opts := session.ForwardOptions{
+		Host:       cmd.Get("HOST"),
+		Silent:     parseBool(cmd.Get("SILENT"), false),
+		SSLEnabled: parseBool(cmd.Get("SSL"), false),
 	}
 
-	// Validate port (SAM 3.0+)
-	port, err := protocol.ValidatePortString(portStr)
-	if err != nil {
-		return streamError(fmt.Sprintf("invalid PORT: %v", err)), nil
+	if cmd.Has("PATH") {
+		path, err := session.ResolveForwardPath(cmd.Get("PATH"), ctx.ForwardSocketDir)
+		if err != nil {
+			return streamError(err.Error()), nil
+		}
+		opts.Path = path
+	} else {
+		portStr := cmd.Get("PORT")
+		if portStr == "" {
+			return streamError("missing PORT"), nil
+		}
+
+		// Validate port (SAM 3.0+)
+		port, err := protocol.ValidatePortString(portStr)
+		if err != nil {
+			return streamError(fmt.Sprintf("invalid PORT: %v", err)), nil
+		}
+		opts.Port = port
+
+		if opts.Host == "" {
+			// Default to client's IP address
+			opts.Host = extractHost(ctx.RemoteAddr())
+		}
 	}
 
 	// Lookup session
@@ -339,25 +365,28 @@ func (h *StreamHandler) handleForward(ctx *Context, cmd *protocol.Command) (*pro
 		return streamError("session is not STREAM style"), nil
 	}
 
-	// Parse optional parameters
-	host := cmd.Get("HOST")
-	if host == "" {
-		// Default to client's IP address
-		host = extractHost(ctx.RemoteAddr())
-	}
-
-	ssl := parseBool(cmd.Get("SSL"), false)
-
 	// Set up forwarding
 	if h.Forwarder == nil {
 		return streamError("forwarder not available"), nil
 	}
 
-	listener, err := h.Forwarder.Forward(sess, host, port, ssl)
+	// Forwarded connections linger like this connection's streams.
+	opts.Linger = ctx.StreamLinger
+
+	listener, err := h.Forwarder.Forward(sess, opts)
 	if err != nil {
 		return streamError(err.Error()), nil
 	}
 
+	// Record the target on the session so management tools can report it.
+	if opts.Path != "" {
+		if fp, ok := sess.(interface{ SetForwardPath(string) }); ok {
+			fp.SetForwardPath(opts.Path)
+		}
+	} else if fc, ok := sess.(interface{ SetForwardConfig(string, int) error }); ok {
+		_ = fc.SetForwardConfig(opts.Host, opts.Port)
+	}
+
 	// Store the listener so it can be closed when the SAM connection ends,
 	// preventing goroutine and file-descriptor leaks.
 	ctx.AddForwardListener(listener)
@@ -370,7 +399,10 @@ func (h *StreamHandler) handleForward(ctx *Context, cmd *protocol.Command) (*pro
 // This function will:
 // 1. Check if the ID matches the bound session on this connection
 // 2. Check if the bound session is a PRIMARY and the ID matches a subsession
-// 3. Look up in the global registry
+// 3. Look up in the global registry, among the sessions owned by ctx.User
+//
+// Another user's session is reported as not found, so that its ID cannot
+// be probed or used from this connection.
 func (h *StreamHandler) lookupSession(ctx *Context, id string) session.Session {
 	// First check if session is bound to this connection
 	if ctx.Session != nil {
@@ -388,11 +420,15 @@ func (h *StreamHandler) lookupSession(ctx *Context, id string) session.Session {
 	}
 
 	// Otherwise lookup in registry
-	if ctx.Registry != nil {
-		return ctx.Registry.Get(id)
+	if ctx.Registry == nil {
+		return nil
 	}
-
-	return nil
+	sess := ctx.Registry.Get(id)
+	if sess != nil && session.OwnerOf(sess) != ctx.User {
+		log.WithFields(logger.Fields{"pkg": "handler", "func": "StreamHandler.lookupSession", "sessionID": id, "user": ctx.User, "owner": session.OwnerOf(sess)}).Warn("Refusing access to another user's session")
+		return nil
+	}
+	return sess
 }
 
 // connectError returns an appropriate error response for connection failures.
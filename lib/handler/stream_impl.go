@@ -7,15 +7,16 @@ package handler
 import (
 	"context"
 	"crypto/tls"
+	"errors"
 	"fmt"
-	"io"
 	"net"
-	"strconv"
 	"sync"
+	"sync/atomic"
 	"time"
 
+	"github.com/go-i2p/logger"
+
 	"github.com/go-i2p/go-sam-bridge/lib/session"
-	gostreaming "github.com/go-i2p/go-streaming"
 )
 
 // StreamingConnector implements StreamConnector using go-streaming.
@@ -26,7 +27,8 @@ import (
 //
 // Integration with go-streaming:
 //   - Uses streaming.StreamManager for I2CP session bridging
-//   - Uses streaming.Dial() for connection establishment
+//   - Uses streaming.Dial() for connection establishment, with the
+//     session's i2p.streaming.connectTimeout and maxMessageSize
 //   - Returns net.Conn representing bidirectional stream
 type StreamingConnector struct {
 	mu sync.RWMutex
@@ -34,12 +36,6 @@ type StreamingConnector struct {
 	// manager is the go-streaming StreamManager for I2CP integration.
 	// This is set per-session when the session is created with I2CP integration.
 	managers map[string]StreamManager
-
-	// connectTimeout is the timeout for connection establishment.
-	connectTimeout time.Duration
-
-	// defaultMTU is the default MTU for stream connections.
-	defaultMTU int
 }
 
 // StreamManager is an interface representing go-streaming's StreamManager.
@@ -64,9 +60,7 @@ type StreamManager interface {
 // NewStreamingConnector creates a new StreamingConnector.
 func NewStreamingConnector() *StreamingConnector {
 	return &StreamingConnector{
-		managers:       make(map[string]StreamManager),
-		connectTimeout: 60 * time.Second,
-		defaultMTU:     1730, // Default per I2P streaming spec
+		managers: make(map[string]StreamManager),
 	}
 }
 
@@ -100,8 +94,10 @@ func (c *StreamingConnector) Connect(sess session.Session, dest string, fromPort
 		return nil, fmt.Errorf("no stream manager registered for session %s", sess.ID())
 	}
 
-	// Create timeout context
-	ctx, cancel := context.WithTimeout(context.Background(), c.connectTimeout)
+	// The session's i2p.streaming.connectTimeout bounds the lookup and the
+	// handshake, and its i2p.streaming.maxMessageSize is the stream MTU.
+	opts := session.StreamingOptionsOf(sess)
+	ctx, cancel := context.WithTimeout(context.Background(), opts.ConnectTimeout)
 	defer cancel()
 
 	// Resolve destination if needed (hostname or B32)
@@ -117,13 +113,32 @@ func (c *StreamingConnector) Connect(sess session.Session, dest string, fromPort
 		resolvedDest = dest
 	}
 
-	// Dial the destination
-	conn, err := manager.Dial(resolvedDest, uint16(toPort), c.defaultMTU)
-	if err != nil {
-		return nil, fmt.Errorf("stream connect failed: %w", err)
+	// Dial the destination. Dial takes no context, so a stream that
+	// connects after the timeout is closed when it arrives.
+	type dialResult struct {
+		conn net.Conn
+		err  error
 	}
+	dialed := make(chan dialResult, 1)
+	go func() {
+		conn, err := manager.Dial(resolvedDest, uint16(toPort), opts.MaxMessageSize)
+		dialed <- dialResult{conn, err}
+	}()
 
-	return conn, nil
+	select {
+	case r := <-dialed:
+		if r.err != nil {
+			return nil, fmt.Errorf("stream connect failed: %w", r.err)
+		}
+		return r.conn, nil
+	case <-ctx.Done():
+		go func() {
+			if r := <-dialed; r.conn != nil {
+				r.conn.Close()
+			}
+		}()
+		return nil, fmt.Errorf("stream connect failed: timed out after %v", opts.ConnectTimeout)
+	}
 }
 
 // isHostnameOrB32 checks if the destination needs resolution.
@@ -151,9 +166,6 @@ type StreamingAcceptor struct {
 
 	// defaultPort is the default listening port.
 	defaultPort uint16
-
-	// defaultMTU is the default MTU for listeners.
-	defaultMTU int
 }
 
 // NewStreamingAcceptor creates a new StreamingAcceptor.
@@ -162,19 +174,25 @@ func NewStreamingAcceptor() *StreamingAcceptor {
 		listeners:   make(map[string]net.Listener),
 		managers:    make(map[string]StreamManager),
 		defaultPort: 0, // Use session's destination port
-		defaultMTU:  1730,
 	}
 }
 
-// RegisterManager registers a StreamManager for a session.
-func (a *StreamingAcceptor) RegisterManager(sessionID string, manager StreamManager) error {
+// RegisterManager registers a StreamManager for a session and listens on
+// it with the given MTU, the session's i2p.streaming.maxMessageSize.
+func (a *StreamingAcceptor) RegisterManager(sessionID string, manager StreamManager, mtu int) error {
 	a.mu.Lock()
 	defer a.mu.Unlock()
 
 	a.managers[sessionID] = manager
 
+	// Re-registering (e.g. after an I2CP reconnect) replaces the listener.
+	if old, ok := a.listeners[sessionID]; ok {
+		old.Close()
+		delete(a.listeners, sessionID)
+	}
+
 	// Create listener for the session
-	listener, err := manager.Listen(a.defaultPort, a.defaultMTU)
+	listener, err := manager.Listen(a.defaultPort, mtu)
 	if err != nil {
 		return fmt.Errorf("failed to create listener: %w", err)
 	}
@@ -220,27 +238,20 @@ func (a *StreamingAcceptor) Accept(sess session.Session) (net.Conn, *AcceptInfo,
 		}
 	}
 
-	conn, err = listener.Accept()
+	// Streams from peers the session's access list does not allow, or
+	// over its inbound limits, are reset here, before the client sees them.
+	conn, err = session.AcceptAllowed(listener, session.AccessListOf(sess), session.InboundThrottleOf(sess))
 	if err != nil {
 		return nil, nil, fmt.Errorf("accept failed: %w", err)
 	}
 
 	// Extract connection info
-	info := &AcceptInfo{
-		Destination: "",
-		FromPort:    0,
-		ToPort:      0,
-	}
+	info := &AcceptInfo{}
 
 	// Prefer peer Base64 destination when go-streaming exposes it.
 	// Fall back to string form for non-I2P address types used in tests/mocks.
-	if remoteAddr := conn.RemoteAddr(); remoteAddr != nil {
-		if b64, ok := gostreaming.PeerDestinationBase64(remoteAddr); ok && b64 != "" {
-			info.Destination = b64
-		} else {
-			info.Destination = remoteAddr.String()
-		}
-	}
+	info.Destination = session.PeerDestination(conn.RemoteAddr())
+	info.FromPort, info.ToPort = session.StreamPorts(conn)
 
 	return conn, info, nil
 }
@@ -269,9 +280,21 @@ type forwardState struct {
 	listener        net.Listener
 	targetHost      string
 	targetPort      int
+	targetPath      string
 	ssl             bool
+	silent          bool
+	mtu             int
 	tlsClientConfig *tls.Config
+	linger          time.Duration
 	cancel          context.CancelFunc
+
+	// acl and throttle are the session's access list and inbound limits,
+	// applied before forwarding.
+	acl      *session.AccessList
+	throttle *session.InboundThrottle
+
+	// active counts forwarded connections that are still open.
+	active atomic.Int32
 }
 
 // NewStreamingForwarder creates a new StreamingForwarder.
@@ -283,10 +306,31 @@ func NewStreamingForwarder() *StreamingForwarder {
 }
 
 // RegisterManager registers a StreamManager for a session.
+// An active forward for the session is moved onto the new manager, so
+// forwarding survives the session being re-established after an I2CP reconnect.
 func (f *StreamingForwarder) RegisterManager(sessionID string, manager StreamManager) {
 	f.mu.Lock()
 	defer f.mu.Unlock()
 	f.managers[sessionID] = manager
+
+	state, ok := f.forwarders[sessionID]
+	if !ok {
+		return
+	}
+	state.cancel()
+	state.listener.Close()
+
+	listener, err := manager.Listen(0, state.mtu)
+	if err != nil {
+		log.WithFields(logger.Fields{"pkg": "handler", "func": "StreamingForwarder.RegisterManager", "sessionID": sessionID}).WithError(err).Warn("Failed to restart STREAM FORWARD on new stream manager")
+		delete(f.forwarders, sessionID)
+		return
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	state.listener = listener
+	state.cancel = cancel
+	go f.forwardLoop(ctx, state, listener)
 }
 
 // SetTLSClientConfig sets the TLS configuration for SSL STREAM FORWARD connections.
@@ -312,10 +356,12 @@ func (f *StreamingForwarder) UnregisterManager(sessionID string) {
 }
 
 // Forward implements StreamForwarder.Forward.
-// Sets up forwarding from I2P to a local host:port.
+// Sets up forwarding from I2P to a local host:port or Unix socket.
 //
 // Per SAMv3.md: When SSL=true, the connection to the local host uses TLS.
-func (f *StreamingForwarder) Forward(sess session.Session, host string, port int, ssl bool) (net.Listener, error) {
+// Unless SILENT=true, each connection's data is preceded by a line giving
+// the peer's destination. Forwarded connections linger for opts.Linger.
+func (f *StreamingForwarder) Forward(sess session.Session, opts session.ForwardOptions) (net.Listener, error) {
 	f.mu.Lock()
 	defer f.mu.Unlock()
 
@@ -329,8 +375,9 @@ func (f *StreamingForwarder) Forward(sess session.Session, host string, port int
 		return nil, fmt.Errorf("no stream manager for session %s", sess.ID())
 	}
 
-	// Create I2P listener
-	listener, err := manager.Listen(0, 1730)
+	// Create I2P listener with the session's i2p.streaming.maxMessageSize
+	mtu := session.StreamingOptionsOf(sess).MaxMessageSize
+	listener, err := manager.Listen(0, mtu)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create I2P listener: %w", err)
 	}
@@ -339,22 +386,66 @@ func (f *StreamingForwarder) Forward(sess session.Session, host string, port int
 
 	state := &forwardState{
 		listener:        listener,
-		targetHost:      host,
-		targetPort:      port,
-		ssl:             ssl,
+		targetHost:      opts.Host,
+		targetPort:      opts.Port,
+		targetPath:      opts.Path,
+		ssl:             opts.SSLEnabled,
+		silent:          opts.Silent,
+		mtu:             mtu,
 		tlsClientConfig: f.tlsClientConfig,
+		linger:          opts.Linger,
+		acl:             session.AccessListOf(sess),
+		throttle:        session.InboundThrottleOf(sess),
 		cancel:          cancel,
 	}
 	f.forwarders[sess.ID()] = state
 
 	// Start forwarding goroutine
-	go f.forwardLoop(ctx, state)
+	go f.forwardLoop(ctx, state, listener)
+
+	return &forwardHandle{forwarder: f, sessionID: sess.ID(), state: state}, nil
+}
 
-	return listener, nil
+// forwardHandle is the net.Listener returned by Forward. Closing it stops
+// the forward even after RegisterManager has replaced the I2P listener.
+type forwardHandle struct {
+	forwarder *StreamingForwarder
+	sessionID string
+	state     *forwardState
 }
 
-// forwardLoop accepts connections and forwards them.
-func (f *StreamingForwarder) forwardLoop(ctx context.Context, state *forwardState) {
+// Accept is not supported; connections are consumed by the forward loop.
+func (h *forwardHandle) Accept() (net.Conn, error) {
+	return nil, fmt.Errorf("forward listener for session %s does not accept directly", h.sessionID)
+}
+
+// Close stops the forward and closes the current I2P listener.
+func (h *forwardHandle) Close() error {
+	f := h.forwarder
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.forwarders[h.sessionID] == h.state {
+		delete(f.forwarders, h.sessionID)
+	}
+	h.state.cancel()
+	return h.state.listener.Close()
+}
+
+// ActiveConns returns the number of forwarded connections still open.
+func (h *forwardHandle) ActiveConns() int {
+	return int(h.state.active.Load())
+}
+
+// Addr returns the address of the current I2P listener.
+func (h *forwardHandle) Addr() net.Addr {
+	h.forwarder.mu.RLock()
+	defer h.forwarder.mu.RUnlock()
+	return h.state.listener.Addr()
+}
+
+// forwardLoop accepts connections from listener and forwards them.
+func (f *StreamingForwarder) forwardLoop(ctx context.Context, state *forwardState, listener net.Listener) {
 	for {
 		select {
 		case <-ctx.Done():
@@ -362,12 +453,17 @@ func (f *StreamingForwarder) forwardLoop(ctx context.Context, state *forwardStat
 		default:
 		}
 
-		conn, err := state.listener.Accept()
+		conn, err := session.AcceptAllowed(listener, state.acl, state.throttle)
 		if err != nil {
+			// A closed listener does not recover; after an I2CP reconnect
+			// RegisterManager starts a new loop on its replacement.
+			if errors.Is(err, net.ErrClosed) {
+				return
+			}
 			select {
 			case <-ctx.Done():
 				return
-			default:
+			case <-time.After(session.ForwardAcceptRetryDelay):
 				continue
 			}
 		}
@@ -378,54 +474,29 @@ func (f *StreamingForwarder) forwardLoop(ctx context.Context, state *forwardStat
 
 // handleForward handles a single forwarded connection.
 func (f *StreamingForwarder) handleForward(ctx context.Context, i2pConn net.Conn, state *forwardState) {
+	state.active.Add(1)
+	defer state.active.Add(-1)
 	defer i2pConn.Close()
 
-	// Connect to local target (use JoinHostPort for IPv6 compatibility)
-	addr := net.JoinHostPort(state.targetHost, strconv.Itoa(state.targetPort))
-	var localConn net.Conn
-	var err error
-
-	if state.ssl {
-		// Use TLS for local connection per SAM 3.2+ SSL option
-		tlsCfg := state.tlsClientConfig
-		if tlsCfg == nil {
-			tlsCfg = &tls.Config{}
-		}
-		localConn, err = tls.Dial("tcp", addr, tlsCfg)
-	} else {
-		localConn, err = net.Dial("tcp", addr)
+	// Connect to local target, using TLS per SAM 3.2+ SSL option
+	opts := session.ForwardOptions{
+		Host:       state.targetHost,
+		Path:       state.targetPath,
+		SSLEnabled: state.ssl,
 	}
-
+	localConn, err := session.DialForward(state.targetHost, state.targetPort, opts, state.tlsClientConfig, session.ForwardConnectTimeout)
 	if err != nil {
 		return // Silent failure per SAM spec
 	}
 	defer localConn.Close()
 
-	// Bidirectional copy with proper goroutine lifecycle.
-	// Both goroutines must complete to avoid leaks.
-	done := make(chan struct{}, 2)
-
-	go func() {
-		io.Copy(localConn, i2pConn)
-		done <- struct{}{}
-	}()
-
-	go func() {
-		io.Copy(i2pConn, localConn)
-		done <- struct{}{}
-	}()
-
-	// Wait for one direction to finish or context cancellation
-	select {
-	case <-done:
-	case <-ctx.Done():
+	if !state.silent {
+		if err := session.WriteForwardHeader(localConn, i2pConn); err != nil {
+			return
+		}
 	}
 
-	// Close both connections to unblock the remaining io.Copy goroutine.
-	// The deferred Close calls are idempotent, so double-close is safe.
-	localConn.Close()
-	i2pConn.Close()
-
-	// Wait for the second goroutine to finish
-	<-done
+	// Copy in both directions, closing each write side at EOF so that
+	// replies to half-closed requests still arrive.
+	_ = session.Splice(ctx, localConn, i2pConn, state.linger)
 }
@@ -4,10 +4,15 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"io"
 	"net"
+	"path/filepath"
 	"strings"
+	"sync/atomic"
 	"testing"
+	"time"
 
+	"github.com/go-i2p/go-sam-bridge/lib/metrics"
 	"github.com/go-i2p/go-sam-bridge/lib/session"
 )
 
@@ -21,6 +26,10 @@ type mockStreamManager struct {
 	lookupCount int
 	lastDest    interface{}
 	lastPort    uint16
+	lastMTU     int
+	listenMTU   int
+	dialDelay   time.Duration
+	listener    net.Listener
 }
 
 func (m *mockStreamManager) LookupDestination(ctx context.Context, hostname string) (interface{}, error) {
@@ -35,6 +44,8 @@ func (m *mockStreamManager) Dial(dest interface{}, port uint16, mtu int) (net.Co
 	m.dialCount++
 	m.lastDest = dest
 	m.lastPort = port
+	m.lastMTU = mtu
+	time.Sleep(m.dialDelay)
 	if m.dialError != nil {
 		return nil, m.dialError
 	}
@@ -46,9 +57,13 @@ func (m *mockStreamManager) Dial(dest interface{}, port uint16, mtu int) (net.Co
 
 func (m *mockStreamManager) Listen(port uint16, mtu int) (net.Listener, error) {
 	m.listenCount++
+	m.listenMTU = mtu
 	if m.listenError != nil {
 		return nil, m.listenError
 	}
+	if m.listener != nil {
+		return m.listener, nil
+	}
 	// Return a mock listener
 	return &streamMockListener{}, nil
 }
@@ -200,6 +215,37 @@ func TestStreamingConnector_Connect(t *testing.T) {
 	})
 }
 
+// TestStreamingConnector_ConnectOptions tests that Connect applies the
+// session's i2p.streaming options.
+func TestStreamingConnector_ConnectOptions(t *testing.T) {
+	connector := NewStreamingConnector()
+	manager := &mockStreamManager{}
+	connector.RegisterManager("opts-session", manager)
+
+	cfg := session.DefaultSessionConfig()
+	cfg.Streaming.MaxMessageSize = 1024
+	cfg.Streaming.ConnectTimeout = 50 * time.Millisecond
+	sess := session.NewBaseSession("opts-session", session.StyleStream, nil, nil, cfg)
+
+	conn, err := connector.Connect(sess, "base64destination", 0, 80)
+	if err != nil {
+		t.Fatalf("Connect failed: %v", err)
+	}
+	conn.Close()
+	if manager.lastMTU != 1024 {
+		t.Errorf("Dial MTU = %d, want i2p.streaming.maxMessageSize 1024", manager.lastMTU)
+	}
+
+	manager.dialDelay = time.Second
+	start := time.Now()
+	if _, err := connector.Connect(sess, "base64destination", 0, 80); err == nil {
+		t.Error("Expected a timeout error")
+	}
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Errorf("Connect returned after %v, want about the 50ms connectTimeout", elapsed)
+	}
+}
+
 // TestStreamingAcceptor_Accept tests the Accept method.
 func TestStreamingAcceptor_Accept(t *testing.T) {
 	acceptor := NewStreamingAcceptor()
@@ -214,7 +260,7 @@ func TestStreamingAcceptor_Accept(t *testing.T) {
 	})
 
 	t.Run("successful accept", func(t *testing.T) {
-		err := acceptor.RegisterManager("test-session", manager)
+		err := acceptor.RegisterManager("test-session", manager, session.DefaultStreamingOptions().MaxMessageSize)
 		if err != nil {
 			t.Fatalf("RegisterManager failed: %v", err)
 		}
@@ -251,7 +297,7 @@ func TestStreamingForwarder_Forward(t *testing.T) {
 	forwarder.RegisterManager("test-session", manager)
 
 	t.Run("successful forward setup", func(t *testing.T) {
-		listener, err := forwarder.Forward(sess, "127.0.0.1", 8080, false)
+		listener, err := forwarder.Forward(sess, session.ForwardOptions{Host: "127.0.0.1", Port: 8080})
 		if err != nil {
 			t.Fatalf("Forward failed: %v", err)
 		}
@@ -265,7 +311,7 @@ func TestStreamingForwarder_Forward(t *testing.T) {
 	})
 
 	t.Run("duplicate forward fails", func(t *testing.T) {
-		_, err := forwarder.Forward(sess, "127.0.0.1", 8081, false)
+		_, err := forwarder.Forward(sess, session.ForwardOptions{Host: "127.0.0.1", Port: 8081})
 		if err == nil {
 			t.Error("Expected error for duplicate forward")
 		}
@@ -275,7 +321,7 @@ func TestStreamingForwarder_Forward(t *testing.T) {
 		forwarder.UnregisterManager("test-session")
 		forwarder.RegisterManager("test-session", manager)
 
-		listener, err := forwarder.Forward(sess, "127.0.0.1", 443, true)
+		listener, err := forwarder.Forward(sess, session.ForwardOptions{Host: "127.0.0.1", Port: 443, SSLEnabled: true})
 		if err != nil {
 			t.Fatalf("Forward with SSL failed: %v", err)
 		}
@@ -286,13 +332,117 @@ func TestStreamingForwarder_Forward(t *testing.T) {
 
 	t.Run("forward fails without manager", func(t *testing.T) {
 		unknownSess := &streamMockSession{id: "unknown", style: session.StyleStream}
-		_, err := forwarder.Forward(unknownSess, "127.0.0.1", 8080, false)
+		_, err := forwarder.Forward(unknownSess, session.ForwardOptions{Host: "127.0.0.1", Port: 8080})
 		if err == nil {
 			t.Error("Expected error for unregistered session")
 		}
 	})
 }
 
+// TestStreamingForwarder_RegisterManagerRestartsForward tests that an active
+// forward moves onto a replacement manager, as after an I2CP reconnect.
+func TestStreamingForwarder_RegisterManagerRestartsForward(t *testing.T) {
+	forwarder := NewStreamingForwarder()
+	first := &mockStreamManager{}
+	sess := &streamMockSession{id: "test-session", style: session.StyleStream}
+
+	forwarder.RegisterManager("test-session", first)
+	handle, err := forwarder.Forward(sess, session.ForwardOptions{Host: "127.0.0.1", Port: 8080})
+	if err != nil {
+		t.Fatalf("Forward failed: %v", err)
+	}
+
+	second := &mockStreamManager{}
+	forwarder.RegisterManager("test-session", second)
+
+	if second.listenCount != 1 {
+		t.Errorf("Expected forward to listen on new manager, got %d listen calls", second.listenCount)
+	}
+
+	if err := handle.Close(); err != nil {
+		t.Errorf("Close failed: %v", err)
+	}
+
+	forwarder.mu.RLock()
+	_, active := forwarder.forwarders["test-session"]
+	forwarder.mu.RUnlock()
+	if active {
+		t.Error("Expected forward to be stopped after closing handle")
+	}
+}
+
+// TestStreamingForwarder_MTU tests that forwards listen with the session's
+// i2p.streaming.maxMessageSize, also after RegisterManager restarts them.
+func TestStreamingForwarder_MTU(t *testing.T) {
+	forwarder := NewStreamingForwarder()
+	first := &mockStreamManager{}
+	forwarder.RegisterManager("mtu-session", first)
+
+	cfg := session.DefaultSessionConfig()
+	cfg.Streaming.MaxMessageSize = 1024
+	sess := session.NewBaseSession("mtu-session", session.StyleStream, nil, nil, cfg)
+
+	handle, err := forwarder.Forward(sess, session.ForwardOptions{Host: "127.0.0.1", Port: 8080})
+	if err != nil {
+		t.Fatalf("Forward failed: %v", err)
+	}
+	defer handle.Close()
+	if first.listenMTU != 1024 {
+		t.Errorf("Listen MTU = %d, want i2p.streaming.maxMessageSize 1024", first.listenMTU)
+	}
+
+	second := &mockStreamManager{}
+	forwarder.RegisterManager("mtu-session", second)
+	if second.listenMTU != 1024 {
+		t.Errorf("Listen MTU = %d after RegisterManager, want 1024", second.listenMTU)
+	}
+}
+
+// failingListener fails every Accept with err and counts the calls.
+type failingListener struct {
+	streamMockListener
+	err     error
+	accepts atomic.Int32
+}
+
+func (l *failingListener) Accept() (net.Conn, error) {
+	l.accepts.Add(1)
+	return nil, l.err
+}
+
+// TestStreamingForwarder_ForwardLoopErrors tests that the forward loop stops
+// when its listener is closed and backs off when it fails otherwise, rather
+// than spinning.
+func TestStreamingForwarder_ForwardLoopErrors(t *testing.T) {
+	forwarder := NewStreamingForwarder()
+
+	closed := &failingListener{err: fmt.Errorf("accept: %w", net.ErrClosed)}
+	done := make(chan struct{})
+	go func() {
+		forwarder.forwardLoop(context.Background(), &forwardState{}, closed)
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("forwardLoop did not return for a closed listener")
+	}
+
+	failing := &failingListener{err: errors.New("router unreachable")}
+	ctx, cancel := context.WithCancel(context.Background())
+	done = make(chan struct{})
+	go func() {
+		forwarder.forwardLoop(ctx, &forwardState{}, failing)
+		close(done)
+	}()
+	time.Sleep(5 * session.ForwardAcceptRetryDelay / 2)
+	cancel()
+	<-done
+	if n := failing.accepts.Load(); n > 4 {
+		t.Errorf("forwardLoop accepted %d times in %v, want it to wait %v between failures", n, 5*session.ForwardAcceptRetryDelay/2, session.ForwardAcceptRetryDelay)
+	}
+}
+
 // TestIsHostnameOrB32 tests the hostname/b32 detection.
 func TestIsHostnameOrB32(t *testing.T) {
 	tests := []struct {
@@ -317,3 +467,331 @@ func TestIsHostnameOrB32(t *testing.T) {
 		})
 	}
 }
+
+// TestStreamingForwarder_ActiveConns tests that forwarded connections are
+// counted while open so a graceful shutdown can wait for them.
+func TestStreamingForwarder_ActiveConns(t *testing.T) {
+	target, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("net.Listen failed: %v", err)
+	}
+	defer target.Close()
+
+	addr := target.Addr().(*net.TCPAddr)
+	state := &forwardState{targetHost: "127.0.0.1", targetPort: addr.Port}
+	handle := &forwardHandle{state: state}
+
+	forwarder := NewStreamingForwarder()
+	i2pSide, peer := net.Pipe()
+	defer peer.Close()
+
+	done := make(chan struct{})
+	go func() {
+		forwarder.handleForward(context.Background(), i2pSide, state)
+		close(done)
+	}()
+
+	local, err := target.Accept()
+	if err != nil {
+		t.Fatalf("Accept failed: %v", err)
+	}
+	if got := handle.ActiveConns(); got != 1 {
+		t.Errorf("ActiveConns() = %d while forwarding, want 1", got)
+	}
+
+	local.Close()
+	<-done
+	if got := handle.ActiveConns(); got != 0 {
+		t.Errorf("ActiveConns() = %d after forward ended, want 0", got)
+	}
+}
+
+// TestStreamingForwarder_HalfClose tests that a forwarded target's reply
+// reaches the I2P peer after the peer has shut down its write side.
+func TestStreamingForwarder_HalfClose(t *testing.T) {
+	target, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("net.Listen failed: %v", err)
+	}
+	defer target.Close()
+
+	// A TCP pair stands in for the I2P stream, which can be half-closed.
+	i2pListener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("net.Listen failed: %v", err)
+	}
+	defer i2pListener.Close()
+	peer, err := net.Dial("tcp", i2pListener.Addr().String())
+	if err != nil {
+		t.Fatalf("net.Dial failed: %v", err)
+	}
+	defer peer.Close()
+	i2pSide, err := i2pListener.Accept()
+	if err != nil {
+		t.Fatalf("Accept failed: %v", err)
+	}
+	peer.SetDeadline(time.Now().Add(5 * time.Second))
+
+	forwarder := NewStreamingForwarder()
+	addr := target.Addr().(*net.TCPAddr)
+	state := &forwardState{targetHost: "127.0.0.1", targetPort: addr.Port, linger: 5 * time.Second}
+	go forwarder.handleForward(context.Background(), i2pSide, state)
+
+	local, err := target.Accept()
+	if err != nil {
+		t.Fatalf("Accept failed: %v", err)
+	}
+	defer local.Close()
+	local.SetDeadline(time.Now().Add(5 * time.Second))
+
+	peer.Write([]byte("GET / HTTP/1.0\r\n\r\n"))
+	peer.(*net.TCPConn).CloseWrite()
+	if _, err := io.ReadAll(local); err != nil {
+		t.Fatalf("target ReadAll failed: %v", err)
+	}
+	local.Write([]byte("HTTP/1.0 200 OK\r\n\r\n"))
+	local.Close()
+
+	reply, err := io.ReadAll(peer)
+	if err != nil || string(reply) != "HTTP/1.0 200 OK\r\n\r\n" {
+		t.Errorf("peer read %q, %v; want the reply", reply, err)
+	}
+}
+
+// peerStream is one end of a pipe, from the peer at addr.
+type peerStream struct {
+	net.Conn
+	addr net.Addr
+}
+
+func (c *peerStream) RemoteAddr() net.Addr { return c.addr }
+
+// peerStreamListener accepts streams from the given peers, then blocks until
+// it is closed.
+type peerStreamListener struct {
+	conns  chan net.Conn
+	closed chan struct{}
+}
+
+// newPeerStreamListener returns a listener of a stream from each peer, and
+// the peers' ends of the streams.
+func newPeerStreamListener(peers ...string) (*peerStreamListener, []net.Conn) {
+	l := &peerStreamListener{conns: make(chan net.Conn, len(peers)), closed: make(chan struct{})}
+	var ends []net.Conn
+	for _, peer := range peers {
+		local, remote := net.Pipe()
+		l.conns <- &peerStream{Conn: local, addr: &net.UnixAddr{Name: peer, Net: "i2p"}}
+		ends = append(ends, remote)
+	}
+	return l, ends
+}
+
+func (l *peerStreamListener) Accept() (net.Conn, error) {
+	select {
+	case conn := <-l.conns:
+		return conn, nil
+	case <-l.closed:
+		return nil, net.ErrClosed
+	}
+}
+
+func (l *peerStreamListener) Close() error {
+	select {
+	case <-l.closed:
+	default:
+		close(l.closed)
+	}
+	return nil
+}
+
+func (l *peerStreamListener) Addr() net.Addr { return nil }
+
+// aclSession returns a STREAM session whose access list denies the peer at b32.
+func aclSession(id, b32 string) session.Session {
+	hash, _ := session.ParseDestinationHash(b32)
+	cfg := session.DefaultSessionConfig()
+	cfg.AccessList = session.NewAccessList(session.AccessListDeny, hash)
+	return session.NewBaseSession(id, session.StyleStream, nil, nil, cfg)
+}
+
+// expectReset checks that the peer's end of a stream was closed.
+func expectReset(t *testing.T, end net.Conn) {
+	t.Helper()
+	end.SetReadDeadline(time.Now().Add(5 * time.Second))
+	if _, err := end.Read(make([]byte, 1)); err != io.EOF {
+		t.Errorf("denied peer read error = %v, want EOF", err)
+	}
+}
+
+const (
+	deniedPeer  = "aeaqcaibaeaqcaibaeaqcaibaeaqcaibaeaqcaibaeaqcaibaeaq.b32.i2p"
+	allowedPeer = "aibaeaqcaibaeaqcaibaeaqcaibaeaqcaibaeaqcaibaeaqcaiba.b32.i2p"
+)
+
+func TestStreamingAcceptor_AccessList(t *testing.T) {
+	listener, ends := newPeerStreamListener(deniedPeer, allowedPeer)
+	defer listener.Close()
+	acceptor := NewStreamingAcceptor()
+	if err := acceptor.RegisterManager("acl-session", &mockStreamManager{listener: listener}, session.DefaultStreamingOptions().MaxMessageSize); err != nil {
+		t.Fatalf("RegisterManager failed: %v", err)
+	}
+
+	conn, info, err := acceptor.Accept(aclSession("acl-session", deniedPeer))
+	if err != nil {
+		t.Fatalf("Accept failed: %v", err)
+	}
+	defer conn.Close()
+	if info.Destination != allowedPeer {
+		t.Errorf("Accept() peer = %q, want the allowed peer", info.Destination)
+	}
+	expectReset(t, ends[0])
+}
+
+func TestStreamingForwarder_AccessList(t *testing.T) {
+	target, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("net.Listen failed: %v", err)
+	}
+	defer target.Close()
+
+	listener, ends := newPeerStreamListener(deniedPeer, allowedPeer)
+	forwarder := NewStreamingForwarder()
+	forwarder.RegisterManager("acl-session", &mockStreamManager{listener: listener})
+
+	addr := target.Addr().(*net.TCPAddr)
+	handle, err := forwarder.Forward(aclSession("acl-session", deniedPeer), session.ForwardOptions{Host: "127.0.0.1", Port: addr.Port, Silent: true})
+	if err != nil {
+		t.Fatalf("Forward failed: %v", err)
+	}
+	defer handle.Close()
+
+	expectReset(t, ends[0])
+	local, err := target.Accept()
+	if err != nil {
+		t.Fatalf("Accept failed: %v", err)
+	}
+	defer local.Close()
+	go ends[1].Write([]byte("hello"))
+	local.SetReadDeadline(time.Now().Add(5 * time.Second))
+	buf := make([]byte, 5)
+	if _, err := io.ReadFull(local, buf); err != nil || string(buf) != "hello" {
+		t.Errorf("target read %q, %v; want the allowed peer's data", buf, err)
+	}
+}
+
+func TestStreamingForwarder_UnixSocket(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.sock")
+	target, err := net.Listen("unix", path)
+	if err != nil {
+		t.Fatalf("net.Listen failed: %v", err)
+	}
+	defer target.Close()
+
+	listener, ends := newPeerStreamListener(allowedPeer)
+	forwarder := NewStreamingForwarder()
+	forwarder.RegisterManager("unix-session", &mockStreamManager{listener: listener})
+
+	sess := &streamMockSession{id: "unix-session", style: session.StyleStream}
+	handle, err := forwarder.Forward(sess, session.ForwardOptions{Path: path})
+	if err != nil {
+		t.Fatalf("Forward failed: %v", err)
+	}
+	defer handle.Close()
+
+	local, err := target.Accept()
+	if err != nil {
+		t.Fatalf("Accept failed: %v", err)
+	}
+	defer local.Close()
+	go ends[0].Write([]byte("hello"))
+	local.SetReadDeadline(time.Now().Add(5 * time.Second))
+
+	// Without SILENT=true the peer's destination comes first.
+	want := allowedPeer + " FROM_PORT=0 TO_PORT=0\nhello"
+	buf := make([]byte, len(want))
+	if _, err := io.ReadFull(local, buf); err != nil || string(buf) != want {
+		t.Errorf("target read %q, %v; want %q", buf, err, want)
+	}
+}
+
+func TestStreamingForwarder_TCPHeader(t *testing.T) {
+	target, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("net.Listen failed: %v", err)
+	}
+	defer target.Close()
+
+	listener, ends := newPeerStreamListener(allowedPeer)
+	forwarder := NewStreamingForwarder()
+	forwarder.RegisterManager("tcp-session", &mockStreamManager{listener: listener})
+
+	sess := &streamMockSession{id: "tcp-session", style: session.StyleStream}
+	handle, err := forwarder.Forward(sess, session.ForwardOptions{Host: "127.0.0.1", Port: target.Addr().(*net.TCPAddr).Port})
+	if err != nil {
+		t.Fatalf("Forward failed: %v", err)
+	}
+	defer handle.Close()
+
+	local, err := target.Accept()
+	if err != nil {
+		t.Fatalf("Accept failed: %v", err)
+	}
+	defer local.Close()
+	go ends[0].Write([]byte("hello"))
+	local.SetReadDeadline(time.Now().Add(5 * time.Second))
+
+	// TCP targets get the same header line as Unix sockets.
+	want := allowedPeer + " FROM_PORT=0 TO_PORT=0\nhello"
+	buf := make([]byte, len(want))
+	if _, err := io.ReadFull(local, buf); err != nil || string(buf) != want {
+		t.Errorf("target read %q, %v; want %q", buf, err, want)
+	}
+}
+
+// throttledSession is a STREAM session whose inbound throttle allows one
+// stream per peer per minute.
+type throttledSession struct {
+	*session.BaseSession
+	throttle *session.InboundThrottle
+}
+
+func (s *throttledSession) InboundThrottle() *session.InboundThrottle { return s.throttle }
+
+func newThrottledSession(id string) *throttledSession {
+	opts := session.DefaultStreamingOptions()
+	opts.MaxConnsPerMinute = 1
+	return &throttledSession{
+		BaseSession: session.NewBaseSession(id, session.StyleStream, nil, nil, session.DefaultSessionConfig()),
+		throttle:    session.NewInboundThrottle(opts),
+	}
+}
+
+func TestStreamingAcceptor_InboundThrottle(t *testing.T) {
+	listener, ends := newPeerStreamListener(allowedPeer, allowedPeer, deniedPeer)
+	defer listener.Close()
+	acceptor := NewStreamingAcceptor()
+	if err := acceptor.RegisterManager("throttled", &mockStreamManager{listener: listener}, session.DefaultStreamingOptions().MaxMessageSize); err != nil {
+		t.Fatalf("RegisterManager failed: %v", err)
+	}
+	sess := newThrottledSession("throttled")
+
+	first, _, err := acceptor.Accept(sess)
+	if err != nil {
+		t.Fatalf("Accept failed: %v", err)
+	}
+	defer first.Close()
+	second, info, err := acceptor.Accept(sess)
+	if err != nil {
+		t.Fatalf("Accept failed: %v", err)
+	}
+	defer second.Close()
+	if info.Destination != deniedPeer {
+		t.Errorf("Accept() peer = %q, want the other peer", info.Destination)
+	}
+	expectReset(t, ends[1])
+
+	stats := sess.throttle.Stats()
+	if stats.Active != 2 || stats.Rejected[metrics.RejectPeerRate] != 1 {
+		t.Errorf("Stats() = %+v, want 2 active streams and 1 rejected", stats)
+	}
+}
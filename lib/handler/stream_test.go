@@ -6,6 +6,7 @@ import (
 	"net"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/go-i2p/go-sam-bridge/lib/protocol"
 	"github.com/go-i2p/go-sam-bridge/lib/session"
@@ -51,13 +52,11 @@ type mockStreamForwarder struct {
 
 type forwardRequest struct {
 	sess session.Session
-	host string
-	port int
-	ssl  bool
+	opts session.ForwardOptions
 }
 
-func (m *mockStreamForwarder) Forward(sess session.Session, host string, port int, ssl bool) (net.Listener, error) {
-	m.lastReq = &forwardRequest{sess: sess, host: host, port: port, ssl: ssl}
+func (m *mockStreamForwarder) Forward(sess session.Session, opts session.ForwardOptions) (net.Listener, error) {
+	m.lastReq = &forwardRequest{sess: sess, opts: opts}
 	return m.listener, m.err
 }
 
@@ -75,6 +74,7 @@ type mockStreamSession struct {
 	id    string
 	style session.Style
 	conn  net.Conn
+	owner string
 }
 
 func (m *mockStreamSession) ID() string                        { return m.id }
@@ -83,6 +83,7 @@ func (m *mockStreamSession) Destination() *session.Destination { return nil }
 func (m *mockStreamSession) Status() session.Status            { return session.StatusActive }
 func (m *mockStreamSession) Close() error                      { return nil }
 func (m *mockStreamSession) ControlConn() net.Conn             { return m.conn }
+func (m *mockStreamSession) Owner() string                     { return m.owner }
 
 // mockStreamRegistry implements session.Registry for testing.
 type mockStreamRegistry struct {
@@ -582,8 +583,10 @@ func TestStreamHandler_HandleForward(t *testing.T) {
 		remoteAddr     string
 		registeredSess session.Session
 		forwarder      *mockStreamForwarder
+		socketDir      string
 		wantResult     string
 		wantHost       string
+		wantPath       string
 	}{
 		{
 			name:          "missing ID",
@@ -722,6 +725,42 @@ func TestStreamHandler_HandleForward(t *testing.T) {
 			forwarder:      &mockStreamForwarder{listener: &mockListener{}},
 			wantResult:     protocol.ResultOK,
 		},
+		{
+			name: "forward to Unix socket",
+			cmd: &protocol.Command{Verb: "STREAM", Action: "FORWARD", Options: map[string]string{
+				"ID":   "test-session",
+				"PATH": "app.sock",
+			}},
+			handshakeDone:  true,
+			registeredSess: &mockStreamSession{id: "test-session", style: session.StyleStream},
+			forwarder:      &mockStreamForwarder{listener: &mockListener{}},
+			socketDir:      "/run/sam-bridge",
+			wantResult:     protocol.ResultOK,
+			wantPath:       "/run/sam-bridge/app.sock",
+		},
+		{
+			name: "Unix socket forwarding not enabled",
+			cmd: &protocol.Command{Verb: "STREAM", Action: "FORWARD", Options: map[string]string{
+				"ID":   "test-session",
+				"PATH": "app.sock",
+			}},
+			handshakeDone:  true,
+			registeredSess: &mockStreamSession{id: "test-session", style: session.StyleStream},
+			forwarder:      &mockStreamForwarder{listener: &mockListener{}},
+			wantResult:     protocol.ResultI2PError,
+		},
+		{
+			name: "Unix socket outside the directory",
+			cmd: &protocol.Command{Verb: "STREAM", Action: "FORWARD", Options: map[string]string{
+				"ID":   "test-session",
+				"PATH": "/var/run/docker.sock",
+			}},
+			handshakeDone:  true,
+			registeredSess: &mockStreamSession{id: "test-session", style: session.StyleStream},
+			forwarder:      &mockStreamForwarder{listener: &mockListener{}},
+			socketDir:      "/run/sam-bridge",
+			wantResult:     protocol.ResultI2PError,
+		},
 	}
 
 	for _, tt := range tests {
@@ -747,6 +786,7 @@ func TestStreamHandler_HandleForward(t *testing.T) {
 				Conn:              &mockConn{remoteAddr: remoteAddr},
 				Registry:          registry,
 				HandshakeComplete: tt.handshakeDone,
+				ForwardSocketDir:  tt.socketDir,
 			}
 
 			resp, err := handler.Handle(ctx, tt.cmd)
@@ -763,16 +803,50 @@ func TestStreamHandler_HandleForward(t *testing.T) {
 				t.Errorf("response = %q, want RESULT=%s", respStr, tt.wantResult)
 			}
 
+			if tt.wantPath != "" && (tt.forwarder.lastReq == nil || tt.forwarder.lastReq.opts.Path != tt.wantPath) {
+				t.Errorf("forward request = %+v, want PATH %q", tt.forwarder.lastReq, tt.wantPath)
+			}
+
 			// Verify forwarded host if applicable
 			if tt.wantHost != "" && tt.forwarder != nil && tt.forwarder.lastReq != nil {
-				if tt.forwarder.lastReq.host != tt.wantHost {
-					t.Errorf("forwarded host = %q, want %q", tt.forwarder.lastReq.host, tt.wantHost)
+				if tt.forwarder.lastReq.opts.Host != tt.wantHost {
+					t.Errorf("forwarded host = %q, want %q", tt.forwarder.lastReq.opts.Host, tt.wantHost)
 				}
 			}
 		})
 	}
 }
 
+func TestStreamHandler_HandleForwardLinger(t *testing.T) {
+	forwarder := &mockStreamForwarder{listener: &mockListener{}}
+	handler := NewStreamHandler(nil, nil, forwarder)
+
+	// Each forward gets the linger of the connection that started it.
+	for _, linger := range []time.Duration{5 * time.Second, 0} {
+		registry := newMockStreamRegistry()
+		registry.Register(&mockStreamSession{id: "test-session", style: session.StyleStream})
+		ctx := &Context{
+			Conn:              &mockConn{},
+			Registry:          registry,
+			HandshakeComplete: true,
+			StreamLinger:      linger,
+		}
+		cmd := &protocol.Command{Verb: "STREAM", Action: "FORWARD", Options: map[string]string{
+			"ID":   "test-session",
+			"PORT": "8080",
+			"HOST": "127.0.0.1",
+		}}
+
+		resp, err := handler.Handle(ctx, cmd)
+		if err != nil || !strings.Contains(resp.String(), "RESULT=OK") {
+			t.Fatalf("Handle() = %v, %v; want RESULT=OK", resp, err)
+		}
+		if got := forwarder.lastReq.opts.Linger; got != linger {
+			t.Errorf("forward linger = %v, want the connection's %v", got, linger)
+		}
+	}
+}
+
 func TestStreamHandler_UnknownAction(t *testing.T) {
 	handler := NewStreamHandler(nil, nil, nil)
 	ctx := &Context{
@@ -914,6 +988,38 @@ func TestStreamHandler_LookupSession(t *testing.T) {
 	}
 }
 
+func TestStreamHandler_LookupSessionOwner(t *testing.T) {
+	handler := NewStreamHandler(nil, nil, nil)
+	registry := newMockStreamRegistry()
+	registry.Register(&mockStreamSession{id: "alice-app", style: session.StyleStream, owner: "alice"})
+	registry.Register(&mockStreamSession{id: "anonymous", style: session.StyleStream})
+
+	tests := []struct {
+		user  string
+		id    string
+		found bool
+	}{
+		{"alice", "alice-app", true},
+		{"bob", "alice-app", false},
+		{"", "alice-app", false},
+		{"", "anonymous", true},
+		{"alice", "anonymous", false},
+	}
+	for _, tt := range tests {
+		ctx := &Context{Registry: registry, User: tt.user}
+		if found := handler.lookupSession(ctx, tt.id) != nil; found != tt.found {
+			t.Errorf("lookupSession(%q) as %q found = %v, want %v", tt.id, tt.user, found, tt.found)
+		}
+	}
+
+	// Another user's session is reported like an unknown one.
+	ctx := &Context{Conn: &mockConn{}, Registry: registry, User: "bob", HandshakeComplete: true}
+	resp, _ := handler.Handle(ctx, &protocol.Command{Verb: "STREAM", Action: "CONNECT", Options: map[string]string{"ID": "alice-app", "DESTINATION": "test.i2p"}})
+	if resp == nil || !strings.Contains(resp.String(), "RESULT=INVALID_ID") {
+		t.Errorf("STREAM CONNECT to another user's session = %v, want INVALID_ID", resp)
+	}
+}
+
 // TestStreamHandler_LookupSubsession tests subsession lookup in PRIMARY sessions.
 // Per SAMv3.md, STREAM commands use subsession IDs when operating on PRIMARY sessions.
 func TestStreamHandler_LookupSubsession(t *testing.T) {
@@ -48,6 +48,12 @@ type Client struct {
 
 	// callbacks holds the client-level callbacks.
 	callbacks *ClientCallbacks
+
+	// closed is set by Close and stops any reconnection in progress.
+	closed bool
+
+	// reconnectCancel stops the running reconnection loop, if any.
+	reconnectCancel context.CancelFunc
 }
 
 // ClientConfig holds configuration for connecting to the I2P router.
@@ -72,15 +78,39 @@ type ClientConfig struct {
 
 	// SessionTimeout is the timeout for session creation.
 	SessionTimeout time.Duration
+
+	// ReconnectEnabled makes the client reconnect to the router after the
+	// connection is lost and re-establish every registered session.
+	ReconnectEnabled bool
+
+	// ReconnectInitialBackoff is the delay before the first reconnection
+	// attempt (default: 1s). It doubles after each failed attempt.
+	ReconnectInitialBackoff time.Duration
+
+	// ReconnectMaxBackoff caps the delay between attempts (default: 1m).
+	ReconnectMaxBackoff time.Duration
+
+	// ReconnectMaxAttempts limits the number of attempts (0 = unlimited).
+	ReconnectMaxAttempts int
 }
 
+// Default reconnection backoff bounds, used when the corresponding
+// ClientConfig fields are zero.
+const (
+	DefaultReconnectInitialBackoff = time.Second
+	DefaultReconnectMaxBackoff     = time.Minute
+)
+
 // DefaultClientConfig returns a ClientConfig with sensible defaults.
 // Uses standard I2CP port 7654 on localhost.
 func DefaultClientConfig() *ClientConfig {
 	return &ClientConfig{
-		RouterAddr:     "127.0.0.1:7654",
-		ConnectTimeout: 30 * time.Second,
-		SessionTimeout: 60 * time.Second,
+		RouterAddr:              "127.0.0.1:7654",
+		ConnectTimeout:          30 * time.Second,
+		SessionTimeout:          60 * time.Second,
+		ReconnectEnabled:        true,
+		ReconnectInitialBackoff: DefaultReconnectInitialBackoff,
+		ReconnectMaxBackoff:     DefaultReconnectMaxBackoff,
 	}
 }
 
@@ -194,11 +224,18 @@ func (c *Client) Connect(ctx context.Context) error {
 }
 
 // Close closes the connection to the I2P router and all sessions.
+// Any reconnection in progress is abandoned.
 // Safe to call multiple times.
 func (c *Client) Close() error {
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
+	c.closed = true
+	if c.reconnectCancel != nil {
+		c.reconnectCancel()
+		c.reconnectCancel = nil
+	}
+
 	if !c.connected {
 		return nil
 	}
@@ -297,18 +334,137 @@ func (c *Client) onConnect(client *go_i2cp.Client) {
 
 // onDisconnect is called when the I2CP connection is lost.
 // Matches go-i2cp ClientCallBacks.OnDisconnect signature.
+//
+// Both go-i2cp and our ProcessIO goroutine may report the same loss, and a
+// replaced client may report late; only the first report for the current
+// client is acted on. Registered sessions are suspended and, if enabled,
+// a reconnection loop is started to re-establish them.
 func (c *Client) onDisconnect(client *go_i2cp.Client, reason string, opaque *interface{}) {
 	c.mu.Lock()
+	if client != c.i2cpClient || !c.connected {
+		c.mu.Unlock()
+		return
+	}
 	c.connected = false
+	sessions := c.sessionsLocked()
+	reconnect := client != nil && c.config.ReconnectEnabled && !c.closed
+	var ctx context.Context
+	if reconnect {
+		ctx, c.reconnectCancel = context.WithCancel(context.Background())
+	}
 	c.mu.Unlock()
 
+	var err error
+	if reason != "" {
+		err = fmt.Errorf("disconnected: %s", reason)
+	}
+
+	log.WithFields(logger.Fields{"pkg": "i2cp", "func": "Client.onDisconnect", "reason": reason, "sessions": len(sessions), "reconnect": reconnect}).Warn("Lost connection to I2P router")
+
+	for _, sess := range sessions {
+		sess.suspend(err)
+	}
+
 	if c.callbacks != nil && c.callbacks.OnDisconnected != nil {
-		var err error
-		if reason != "" {
-			err = fmt.Errorf("disconnected: %s", reason)
-		}
 		c.callbacks.OnDisconnected(err)
 	}
+
+	if reconnect {
+		go c.reconnectLoop(ctx)
+	}
+}
+
+// sessionsLocked returns a snapshot of the registered sessions.
+// The caller must hold c.mu.
+func (c *Client) sessionsLocked() []*I2CPSession {
+	sessions := make([]*I2CPSession, 0, len(c.sessions))
+	for _, sess := range c.sessions {
+		sessions = append(sessions, sess)
+	}
+	return sessions
+}
+
+// reconnectLoop reconnects to the router with exponential backoff and then
+// re-establishes every registered session with its original destination.
+// If the attempts are exhausted, the suspended sessions are abandoned.
+func (c *Client) reconnectLoop(ctx context.Context) {
+	backoff := c.config.ReconnectInitialBackoff
+	if backoff <= 0 {
+		backoff = DefaultReconnectInitialBackoff
+	}
+	maxBackoff := c.config.ReconnectMaxBackoff
+	if maxBackoff <= 0 {
+		maxBackoff = DefaultReconnectMaxBackoff
+	}
+
+	for attempt := 1; c.config.ReconnectMaxAttempts <= 0 || attempt <= c.config.ReconnectMaxAttempts; attempt++ {
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(backoff):
+		}
+
+		c.discardClient()
+		err := c.Connect(ctx)
+		if err == nil && ctx.Err() != nil {
+			// Close raced with a successful attempt; drop the new connection.
+			c.Close()
+			return
+		}
+		if err == nil {
+			log.WithFields(logger.Fields{"pkg": "i2cp", "func": "Client.reconnectLoop", "attempt": attempt}).Info("Reconnected to I2P router")
+			c.restoreSessions(ctx)
+			return
+		}
+
+		log.WithFields(logger.Fields{"pkg": "i2cp", "func": "Client.reconnectLoop", "attempt": attempt, "backoff": backoff}).WithError(err).Warn("Reconnection attempt failed")
+		backoff *= 2
+		if backoff > maxBackoff {
+			backoff = maxBackoff
+		}
+	}
+
+	c.mu.RLock()
+	sessions := c.sessionsLocked()
+	c.mu.RUnlock()
+
+	giveUp := fmt.Errorf("gave up reconnecting to I2P router after %d attempts", c.config.ReconnectMaxAttempts)
+	log.WithFields(logger.Fields{"pkg": "i2cp", "func": "Client.reconnectLoop"}).WithError(giveUp).Error("Abandoning I2CP sessions")
+	for _, sess := range sessions {
+		sess.abandon(giveUp)
+	}
+}
+
+// discardClient closes and forgets the go-i2cp client of a lost connection.
+// It must not be called with c.mu held, since closing may invoke onDisconnect.
+func (c *Client) discardClient() {
+	c.mu.Lock()
+	stale := c.i2cpClient
+	c.i2cpClient = nil
+	c.mu.Unlock()
+
+	if stale != nil {
+		stale.Close()
+	}
+}
+
+// restoreSessions recreates every registered session on the new router
+// connection. Sessions that cannot be recreated with their original
+// destination are abandoned.
+func (c *Client) restoreSessions(ctx context.Context) {
+	c.mu.RLock()
+	i2cpClient := c.i2cpClient
+	sessions := c.sessionsLocked()
+	c.mu.RUnlock()
+
+	for _, sess := range sessions {
+		if err := sess.reestablish(ctx, i2cpClient, c.config.SessionTimeout); err != nil {
+			log.WithFields(logger.Fields{"pkg": "i2cp", "func": "Client.restoreSessions", "samSessionID": sess.SAMSessionID()}).WithError(err).Error("Failed to re-establish I2CP session")
+			sess.abandon(err)
+			continue
+		}
+		log.WithFields(logger.Fields{"pkg": "i2cp", "func": "Client.restoreSessions", "samSessionID": sess.SAMSessionID()}).Info("Re-established I2CP session")
+	}
 }
 
 // SetCallbacks sets the client callbacks.
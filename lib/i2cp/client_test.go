@@ -1,8 +1,12 @@
 package i2cp
 
 import (
+	"context"
 	"fmt"
 	"testing"
+	"time"
+
+	go_i2cp "github.com/go-i2p/go-i2cp"
 )
 
 func TestDefaultClientConfig(t *testing.T) {
@@ -287,3 +291,123 @@ func TestClient_SessionManagement_Concurrent(t *testing.T) {
 		<-done
 	}
 }
+
+func TestDefaultClientConfig_Reconnect(t *testing.T) {
+	config := DefaultClientConfig()
+
+	if !config.ReconnectEnabled {
+		t.Error("expected reconnection to be enabled by default")
+	}
+	if config.ReconnectInitialBackoff != DefaultReconnectInitialBackoff {
+		t.Errorf("expected initial backoff %v, got %v", DefaultReconnectInitialBackoff, config.ReconnectInitialBackoff)
+	}
+	if config.ReconnectMaxBackoff != DefaultReconnectMaxBackoff {
+		t.Errorf("expected max backoff %v, got %v", DefaultReconnectMaxBackoff, config.ReconnectMaxBackoff)
+	}
+}
+
+func TestClient_onDisconnect_SuspendsSessions(t *testing.T) {
+	client := NewClient(nil)
+	client.connected = true
+
+	var suspendErr error
+	sess := &I2CPSession{client: client, samSessionID: "s1", active: true}
+	sess.SetCallbacks(&SessionCallbacks{
+		OnSuspended: func(err error) {
+			suspendErr = err
+		},
+	})
+	client.RegisterSession("s1", sess)
+
+	client.onDisconnect(nil, "router restart", nil)
+
+	if !sess.IsSuspended() {
+		t.Error("session should be suspended after disconnect")
+	}
+	if suspendErr == nil {
+		t.Error("expected OnSuspended to receive the disconnect error")
+	}
+	if client.GetSession("s1") != sess {
+		t.Error("suspended session should stay registered")
+	}
+}
+
+func TestClient_onDisconnect_IgnoresDuplicateAndStale(t *testing.T) {
+	client := NewClient(nil)
+	client.connected = true
+
+	calls := 0
+	client.SetCallbacks(&ClientCallbacks{
+		OnDisconnected: func(err error) {
+			calls++
+		},
+	})
+
+	client.onDisconnect(nil, "first", nil)
+	client.onDisconnect(nil, "second", nil)
+
+	// A report from a client other than the current one is stale.
+	client.connected = true
+	client.onDisconnect(&go_i2cp.Client{}, "stale", nil)
+
+	if calls != 1 {
+		t.Errorf("expected 1 OnDisconnected call, got %d", calls)
+	}
+	if !client.IsConnected() {
+		t.Error("stale disconnect should not change connection state")
+	}
+}
+
+func TestClient_reconnectLoop_GivesUp(t *testing.T) {
+	client := NewClient(&ClientConfig{
+		RouterAddr:              "invalid-address",
+		ReconnectEnabled:        true,
+		ReconnectInitialBackoff: time.Millisecond,
+		ReconnectMaxBackoff:     time.Millisecond,
+		ReconnectMaxAttempts:    2,
+	})
+
+	abandoned := make(chan error, 1)
+	sess := &I2CPSession{client: client, samSessionID: "s1", active: true, suspended: true}
+	sess.SetCallbacks(&SessionCallbacks{
+		OnAbandoned: func(err error) {
+			abandoned <- err
+		},
+	})
+	client.RegisterSession("s1", sess)
+
+	client.reconnectLoop(context.Background())
+
+	select {
+	case err := <-abandoned:
+		if err == nil {
+			t.Error("expected OnAbandoned to receive an error")
+		}
+	default:
+		t.Fatal("session should have been abandoned")
+	}
+	if sess.IsActive() {
+		t.Error("abandoned session should be inactive")
+	}
+	if client.GetSession("s1") != nil {
+		t.Error("abandoned session should be unregistered")
+	}
+}
+
+func TestClient_reconnectLoop_StopsOnCancel(t *testing.T) {
+	client := NewClient(&ClientConfig{
+		RouterAddr:              "invalid-address",
+		ReconnectEnabled:        true,
+		ReconnectInitialBackoff: time.Hour,
+	})
+	sess := &I2CPSession{client: client, samSessionID: "s1", active: true, suspended: true}
+	client.RegisterSession("s1", sess)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	client.reconnectLoop(ctx)
+
+	if !sess.IsActive() {
+		t.Error("cancelled reconnection should not abandon sessions")
+	}
+}
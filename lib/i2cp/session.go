@@ -4,9 +4,13 @@ package i2cp
 import (
 	"context"
 	"fmt"
+	"os"
+	"path/filepath"
 	"sync"
 	"time"
 
+	"github.com/go-i2p/logger"
+
 	go_i2cp "github.com/go-i2p/go-i2cp"
 )
 
@@ -36,6 +40,10 @@ type I2CPSession struct {
 	// active indicates if the session is active.
 	active bool
 
+	// suspended is set while the router connection is down and cleared
+	// once the session has been re-established on a new connection.
+	suspended bool
+
 	// created is when the session was created.
 	created time.Time
 
@@ -45,11 +53,9 @@ type I2CPSession struct {
 	// tunnelReady is closed when tunnels are built and ready.
 	// Per SAMv3.md: "the router builds tunnels before responding with SESSION STATUS"
 	// ISSUE-003: Used to block SESSION STATUS response until tunnels are ready.
+	// Replaced with a fresh channel when the session is re-established.
 	tunnelReady chan struct{}
 
-	// tunnelReadyOnce ensures tunnelReady is only closed once.
-	tunnelReadyOnce sync.Once
-
 	// pendingLookups tracks pending async destination lookups by address.
 	// Each entry is a list of channels waiting for the result.
 	pendingLookups   map[string][]chan *go_i2cp.Destination
@@ -124,6 +130,19 @@ type SessionCallbacks struct {
 
 	// OnMessageStatus is called with message delivery status.
 	OnMessageStatus func(nonce uint32, status int)
+
+	// OnSuspended is called when the router connection carrying the session
+	// is lost. The session stays registered while the client reconnects.
+	OnSuspended func(err error)
+
+	// OnReestablished is called after the session has been recreated on a
+	// new router connection with its original destination. The underlying
+	// go-i2cp session has changed, so transports built on it must be rebuilt.
+	OnReestablished func()
+
+	// OnAbandoned is called when the session could not be re-established
+	// and has been unregistered from the client.
+	OnAbandoned func(err error)
 }
 
 // CreateSession creates a new I2CP session with the given configuration.
@@ -333,6 +352,177 @@ func (sess *I2CPSession) SetCallbacks(callbacks *SessionCallbacks) {
 	sess.callbacks = callbacks
 }
 
+// Callbacks returns the session callbacks, or nil if none are set.
+func (sess *I2CPSession) Callbacks() *SessionCallbacks {
+	sess.mu.RLock()
+	defer sess.mu.RUnlock()
+	return sess.callbacks
+}
+
+// IsSuspended returns true while the session is waiting for the client
+// to reconnect to the router.
+func (sess *I2CPSession) IsSuspended() bool {
+	sess.mu.RLock()
+	defer sess.mu.RUnlock()
+	return sess.suspended
+}
+
+// suspend marks the session as waiting for a router reconnection.
+func (sess *I2CPSession) suspend(err error) {
+	sess.mu.Lock()
+	if !sess.active {
+		sess.mu.Unlock()
+		return
+	}
+	sess.suspended = true
+	callbacks := sess.callbacks
+	sess.mu.Unlock()
+
+	if callbacks != nil && callbacks.OnSuspended != nil {
+		callbacks.OnSuspended(err)
+	}
+}
+
+// abandon deactivates a session that could not be re-established and
+// unregisters it from the client.
+func (sess *I2CPSession) abandon(err error) {
+	sess.mu.Lock()
+	if !sess.active {
+		sess.mu.Unlock()
+		return
+	}
+	sess.active = false
+	sess.suspended = false
+	session := sess.session
+	callbacks := sess.callbacks
+	sess.mu.Unlock()
+
+	if sess.client != nil {
+		sess.client.UnregisterSession(sess.samSessionID)
+	}
+	if session != nil {
+		_ = session.Close() // Best effort; the router connection may be gone
+	}
+
+	if callbacks != nil && callbacks.OnAbandoned != nil {
+		callbacks.OnAbandoned(err)
+	}
+}
+
+// reestablish recreates the session on a new router connection, keeping
+// its destination keys, configuration and callbacks.
+func (sess *I2CPSession) reestablish(ctx context.Context, i2cpClient *go_i2cp.Client, timeout time.Duration) error {
+	sess.mu.RLock()
+	config := sess.config
+	dest := sess.destination
+	sess.mu.RUnlock()
+
+	if dest == nil {
+		return fmt.Errorf("session has no destination to restore")
+	}
+	if config == nil {
+		config = DefaultSessionConfig()
+	}
+
+	i2cpSession := go_i2cp.NewSession(i2cpClient, go_i2cp.SessionCallbacks{
+		OnMessage:       sess.onMessage,
+		OnStatus:        sess.onStatus,
+		OnDestination:   sess.onDestination,
+		OnMessageStatus: sess.onMessageStatus,
+	})
+	if err := restoreDestination(i2cpSession, dest); err != nil {
+		return err
+	}
+
+	sess.mu.Lock()
+	sess.session = i2cpSession
+	sess.tunnelReady = make(chan struct{})
+	sess.mu.Unlock()
+
+	sess.applyConfig(config)
+
+	sessionCtx := ctx
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		sessionCtx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	if err := i2cpClient.CreateSession(sessionCtx, i2cpSession); err != nil {
+		return fmt.Errorf("failed to recreate I2CP session: %w", err)
+	}
+
+	restored := i2cpSession.Destination()
+	if restored == nil || restored.Base64() != dest.Base64() {
+		return fmt.Errorf("recreated I2CP session has a different destination")
+	}
+
+	if err := sess.WaitForTunnels(sessionCtx); err != nil {
+		log.WithFields(logger.Fields{"pkg": "i2cp", "func": "I2CPSession.reestablish", "samSessionID": sess.samSessionID}).WithError(err).Warn("Tunnels not ready yet for re-established session")
+	}
+
+	sess.mu.Lock()
+	if !sess.active {
+		// Closed by the SAM side while we were reconnecting.
+		sess.mu.Unlock()
+		_ = i2cpSession.Close()
+		return nil
+	}
+	sess.destination = restored
+	sess.suspended = false
+	callbacks := sess.callbacks
+	sess.mu.Unlock()
+
+	if callbacks != nil && callbacks.OnReestablished != nil {
+		callbacks.OnReestablished()
+	}
+	return nil
+}
+
+// restoreDestination installs dest, including its private keys, as the
+// destination of a new go-i2cp session. go-i2cp has no way to build a
+// session config from an in-memory destination; it only accepts existing
+// keys through NewSessionConfigFromDestinationFile. The keys are therefore
+// written to a new directory that only the bridge's user can open, and
+// removed as soon as the config is loaded, so that other local users can
+// neither read them nor substitute a file of their own.
+func restoreDestination(i2cpSession *go_i2cp.Session, dest *go_i2cp.Destination) error {
+	// MkdirTemp creates the directory with mode 0700 and fails rather than
+	// reuse an existing one.
+	dir, err := os.MkdirTemp("", "sam-bridge-dest-")
+	if err != nil {
+		return fmt.Errorf("failed to create destination directory: %w", err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "destination.dat")
+	if err := writeDestinationFile(path, dest); err != nil {
+		return err
+	}
+
+	*i2cpSession.Config() = go_i2cp.NewSessionConfigFromDestinationFile(path, go_i2cp.NewCrypto())
+	return nil
+}
+
+// writeDestinationFile writes dest, including its private keys, to a new
+// file at path that only the bridge's user can read.
+func writeDestinationFile(path string, dest *go_i2cp.Destination) error {
+	stream := go_i2cp.NewStream(nil)
+	if err := dest.WriteToStream(stream); err != nil {
+		return fmt.Errorf("failed to save destination: %w", err)
+	}
+
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o600)
+	if err != nil {
+		return fmt.Errorf("failed to create destination file: %w", err)
+	}
+	if _, err := stream.WriteTo(f); err != nil {
+		f.Close()
+		return fmt.Errorf("failed to save destination: %w", err)
+	}
+	return f.Close()
+}
+
 // onMessage handles incoming messages from the I2CP session.
 // Matches go-i2cp SessionCallbacks.OnMessage signature.
 func (sess *I2CPSession) onMessage(session *go_i2cp.Session, srcDest *go_i2cp.Destination, protocol uint8, srcPort, destPort uint16, payload *go_i2cp.Stream) {
@@ -380,12 +570,23 @@ func (sess *I2CPSession) onStatus(session *go_i2cp.Session, status go_i2cp.Sessi
 // Safe to call multiple times - only signals once.
 // Safe to call even if tunnelReady channel is nil (e.g., in tests).
 func (sess *I2CPSession) signalTunnelReady() {
+	sess.mu.Lock()
+	defer sess.mu.Unlock()
 	if sess.tunnelReady == nil {
 		return // Channel not initialized, nothing to signal
 	}
-	sess.tunnelReadyOnce.Do(func() {
+	select {
+	case <-sess.tunnelReady:
+	default:
 		close(sess.tunnelReady)
-	})
+	}
+}
+
+// tunnelReadyChan returns the current tunnel readiness channel.
+func (sess *I2CPSession) tunnelReadyChan() chan struct{} {
+	sess.mu.RLock()
+	defer sess.mu.RUnlock()
+	return sess.tunnelReady
 }
 
 // WaitForTunnels blocks until tunnels are built or context is cancelled.
@@ -396,7 +597,7 @@ func (sess *I2CPSession) signalTunnelReady() {
 // ISSUE-003: Use this to block SESSION STATUS response until tunnels are ready.
 func (sess *I2CPSession) WaitForTunnels(ctx context.Context) error {
 	select {
-	case <-sess.tunnelReady:
+	case <-sess.tunnelReadyChan():
 		return nil
 	case <-ctx.Done():
 		return ctx.Err()
@@ -406,7 +607,7 @@ func (sess *I2CPSession) WaitForTunnels(ctx context.Context) error {
 // IsTunnelReady returns true if tunnels are built and ready.
 func (sess *I2CPSession) IsTunnelReady() bool {
 	select {
-	case <-sess.tunnelReady:
+	case <-sess.tunnelReadyChan():
 		return true
 	default:
 		return false
@@ -501,6 +702,10 @@ func (sess *I2CPSession) SendMessage(dest *go_i2cp.Destination, protocol uint8,
 		sess.mu.RUnlock()
 		return fmt.Errorf("session is not active")
 	}
+	if sess.suspended {
+		sess.mu.RUnlock()
+		return fmt.Errorf("session is suspended while reconnecting to the I2P router")
+	}
 	session := sess.session
 	sess.mu.RUnlock()
 
@@ -1,6 +1,9 @@
 package i2cp
 
 import (
+	"context"
+	"os"
+	"path/filepath"
 	"testing"
 
 	go_i2cp "github.com/go-i2p/go-i2cp"
@@ -344,3 +347,98 @@ func TestI2CPSession_SendMessage_InactiveSession(t *testing.T) {
 		t.Errorf("expected 'session is not active' error, got: %v", err)
 	}
 }
+
+func TestI2CPSession_SendMessage_SuspendedSession(t *testing.T) {
+	sess := &I2CPSession{
+		active:    true,
+		suspended: true,
+	}
+
+	err := sess.SendMessage(nil, 0, 0, 0, nil, 0)
+	if err == nil {
+		t.Error("expected error when session is suspended")
+	}
+}
+
+func TestI2CPSession_suspend_InactiveSession(t *testing.T) {
+	called := false
+	sess := &I2CPSession{
+		callbacks: &SessionCallbacks{
+			OnSuspended: func(err error) {
+				called = true
+			},
+		},
+	}
+
+	sess.suspend(nil)
+
+	if sess.IsSuspended() || called {
+		t.Error("inactive session should not be suspended")
+	}
+}
+
+func TestI2CPSession_reestablish_NoDestination(t *testing.T) {
+	sess := &I2CPSession{active: true}
+
+	if err := sess.reestablish(context.Background(), nil, 0); err == nil {
+		t.Error("expected error when session has no destination")
+	}
+}
+
+func TestWriteDestinationFile(t *testing.T) {
+	crypto := go_i2cp.NewCrypto()
+	dest, err := go_i2cp.NewDestination(crypto)
+	if err != nil {
+		t.Fatalf("NewDestination() error = %v", err)
+	}
+	path := filepath.Join(t.TempDir(), "destination.dat")
+
+	if err := writeDestinationFile(path, dest); err != nil {
+		t.Fatalf("writeDestinationFile() error = %v", err)
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatalf("Stat() error = %v", err)
+	}
+	if mode := info.Mode().Perm(); mode != 0o600 {
+		t.Errorf("destination file mode = %v, want 0600", mode)
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	defer f.Close()
+	loaded, err := go_i2cp.NewDestinationFromFile(f, crypto)
+	if err != nil {
+		t.Fatalf("NewDestinationFromFile() error = %v", err)
+	}
+	if loaded.Base64() != dest.Base64() {
+		t.Error("destination read back differs from the one written")
+	}
+
+	// An existing file, possibly planted by someone else, is not reused.
+	if err := writeDestinationFile(path, dest); err == nil {
+		t.Error("writeDestinationFile() overwrote an existing file")
+	}
+}
+
+func TestI2CPSession_signalTunnelReady_Replaced(t *testing.T) {
+	sess := &I2CPSession{tunnelReady: make(chan struct{})}
+	sess.signalTunnelReady()
+	sess.signalTunnelReady() // Must not panic on double close
+
+	if !sess.IsTunnelReady() {
+		t.Fatal("tunnels should be ready after signal")
+	}
+
+	// A re-established session starts with a fresh channel.
+	sess.tunnelReady = make(chan struct{})
+	if sess.IsTunnelReady() {
+		t.Error("fresh channel should not be ready")
+	}
+	sess.signalTunnelReady()
+	if !sess.IsTunnelReady() {
+		t.Error("fresh channel should be ready after signal")
+	}
+}
@@ -0,0 +1,103 @@
+package metrics
+
+// Default is the registry holding the bridge's metrics.
+// embedding.Bridge serves it on the metrics listener when one is configured.
+var Default = NewRegistry()
+
+// Metrics recorded by the bridge. Label values are SAM protocol tokens
+// (verbs, actions, RESULT codes, session styles), so cardinality is bounded.
+var (
+	// Commands counts SAM commands dispatched by the control socket server.
+	Commands = Default.NewCounter("sam_commands_total",
+		"SAM commands processed, by verb, action and RESULT.",
+		"verb", "action", "result")
+
+	// CommandDuration observes how long each command took to handle.
+	CommandDuration = Default.NewHistogram("sam_command_duration_seconds",
+		"Time taken to handle SAM commands, by verb and action.",
+		DefaultBuckets, "verb", "action")
+
+	// CommandsThrottled counts commands refused by the rate limits, by the
+	// limit ("connection" or "client") that refused them.
+	CommandsThrottled = Default.NewCounter("sam_commands_throttled_total",
+		"SAM commands refused by the rate limits, by limit.",
+		"limit")
+
+	// StreamResults counts STREAM CONNECT and STREAM ACCEPT outcomes.
+	StreamResults = Default.NewCounter("sam_stream_results_total",
+		"STREAM CONNECT and STREAM ACCEPT outcomes, by action and RESULT.",
+		"action", "result")
+
+	// StreamsRejected counts inbound streams the bridge reset before a
+	// client saw them, by a session's access list or stream limits.
+	StreamsRejected = Default.NewCounter("sam_streams_rejected_total",
+		"Inbound streams rejected before reaching the client, by reason.",
+		"reason")
+
+	// DatagramsSent counts datagrams sent to I2P by repliable, anonymous
+	// and authenticated datagram sessions.
+	DatagramsSent = Default.NewCounter("sam_datagrams_sent_total",
+		"Datagrams sent to I2P, by session style.",
+		"style")
+
+	// DatagramsReceived counts datagrams received from I2P and delivered to
+	// the SAM client, either over the control socket or a forwarding port.
+	DatagramsReceived = Default.NewCounter("sam_datagrams_received_total",
+		"Datagrams received from I2P and delivered to clients, by session style.",
+		"style")
+
+	// DatagramsDropped counts datagrams the bridge discarded.
+	DatagramsDropped = Default.NewCounter("sam_datagrams_dropped_total",
+		"Datagrams dropped by the bridge, by session style and reason.",
+		"style", "reason")
+)
+
+// Reasons used with StreamsRejected.
+const (
+	// RejectAccessList means the peer is not on the session's
+	// i2cp.accessList and i2cp.enableAccessList is set.
+	RejectAccessList = "access_list"
+
+	// RejectBlackList means the peer is on the session's i2cp.accessList
+	// and i2cp.enableBlackList is set.
+	RejectBlackList = "black_list"
+
+	// RejectPeerRate means the peer opened more streams than the session's
+	// i2p.streaming.maxConnsPerMinute, maxConnsPerHour or maxConnsPerDay.
+	RejectPeerRate = "peer_rate"
+
+	// RejectTotalRate means all peers together opened more streams than
+	// the session's i2p.streaming.maxTotalConnsPerMinute, maxTotalConnsPerHour
+	// or maxTotalConnsPerDay.
+	RejectTotalRate = "total_rate"
+
+	// RejectConcurrent means the session already had
+	// i2p.streaming.maxConcurrentStreams inbound streams open.
+	RejectConcurrent = "concurrent"
+)
+
+// Reasons used with DatagramsDropped.
+const (
+	// DropQueueFull means the session's receive channel was full.
+	DropQueueFull = "queue_full"
+
+	// DropReplay means a DATAGRAM2 nonce was already seen.
+	DropReplay = "replay"
+
+	// DropInvalid means a datagram on the UDP port could not be parsed.
+	DropInvalid = "invalid"
+
+	// DropUnknownSession means a datagram on the UDP port named no known session.
+	DropUnknownSession = "unknown_session"
+
+	// DropSendFailed means sending to I2P failed.
+	DropSendFailed = "send_failed"
+
+	// DropDenied means a datagram on the UDP port came from an address the
+	// bridge's access lists do not allow.
+	DropDenied = "denied"
+
+	// DropForbidden means a datagram on the UDP port came from a source
+	// other than the client bound to its session.
+	DropForbidden = "forbidden"
+)
@@ -0,0 +1,434 @@
+// Package metrics provides counters, gauges and histograms for the SAM bridge
+// and exposes them in the Prometheus text exposition format.
+//
+// The package is intentionally small: it implements only what the bridge
+// records, without pulling in the Prometheus client library. Metrics are
+// registered on a Registry, and the package-level Default registry holds
+// the bridge's own metrics (see bridge.go).
+package metrics
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// ContentType is the Content-Type of the Prometheus text exposition format.
+const ContentType = "text/plain; version=0.0.4; charset=utf-8"
+
+// DefaultBuckets are the histogram buckets, in seconds, used for latencies.
+// They match the Prometheus client library defaults.
+var DefaultBuckets = []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+
+// Sample is a single labelled value reported by a GaugeFunc.
+type Sample struct {
+	// LabelValues are the values for the gauge's labels, in order.
+	LabelValues []string
+
+	// Value is the sample value.
+	Value float64
+}
+
+// family is a named metric with HELP and TYPE metadata.
+type family interface {
+	name() string
+	write(w *bufio.Writer)
+}
+
+// Registry holds metric families and renders them in the Prometheus text format.
+//
+// Thread-safety: All methods are safe for concurrent use.
+type Registry struct {
+	mu       sync.Mutex
+	families map[string]family
+}
+
+// NewRegistry creates an empty Registry.
+func NewRegistry() *Registry {
+	return &Registry{families: make(map[string]family)}
+}
+
+// NewCounter registers a counter with the given label names.
+// Panics if a metric with the same name is already registered.
+func (r *Registry) NewCounter(name, help string, labels ...string) *Counter {
+	c := &Counter{vec: newVec(name, help, "counter", labels)}
+	r.register(c)
+	return c
+}
+
+// NewGauge registers a gauge with the given label names.
+// Panics if a metric with the same name is already registered.
+func (r *Registry) NewGauge(name, help string, labels ...string) *Gauge {
+	g := &Gauge{vec: newVec(name, help, "gauge", labels)}
+	r.register(g)
+	return g
+}
+
+// NewHistogram registers a histogram with the given upper bounds and label names.
+// Panics if a metric with the same name is already registered.
+func (r *Registry) NewHistogram(name, help string, buckets []float64, labels ...string) *Histogram {
+	sorted := append([]float64(nil), buckets...)
+	sort.Float64s(sorted)
+	h := &Histogram{
+		metricName: name,
+		help:       help,
+		labels:     labels,
+		buckets:    sorted,
+		series:     make(map[string]*histogramSeries),
+	}
+	r.register(h)
+	return h
+}
+
+// GaugeFunc registers a gauge whose samples are produced by fn at scrape time.
+// Unlike the other constructors, GaugeFunc replaces an existing GaugeFunc of
+// the same name, so a component that is recreated (for example a restarted
+// bridge) can re-register its collector.
+func (r *Registry) GaugeFunc(name, help string, labels []string, fn func() []Sample) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if existing, ok := r.families[name]; ok {
+		if _, isFunc := existing.(*gaugeFunc); !isFunc {
+			panic("metrics: duplicate metric " + name)
+		}
+	}
+	r.families[name] = &gaugeFunc{metricName: name, help: help, labels: labels, fn: fn}
+}
+
+// Unregister removes the metric with the given name. It is a no-op if no
+// such metric is registered.
+func (r *Registry) Unregister(name string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.families, name)
+}
+
+func (r *Registry) register(f family) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.families[f.name()]; ok {
+		panic("metrics: duplicate metric " + f.name())
+	}
+	r.families[f.name()] = f
+}
+
+// WriteText writes all registered metrics to w in the Prometheus text
+// exposition format, sorted by metric name.
+func (r *Registry) WriteText(w io.Writer) error {
+	r.mu.Lock()
+	families := make([]family, 0, len(r.families))
+	for _, f := range r.families {
+		families = append(families, f)
+	}
+	r.mu.Unlock()
+
+	sort.Slice(families, func(i, j int) bool { return families[i].name() < families[j].name() })
+
+	bw := bufio.NewWriter(w)
+	for _, f := range families {
+		f.write(bw)
+	}
+	return bw.Flush()
+}
+
+// Handler returns an http.Handler that serves the registry's metrics.
+func (r *Registry) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("Content-Type", ContentType)
+		_ = r.WriteText(w)
+	})
+}
+
+// vec holds labelled float64 values shared by counters and gauges.
+type vec struct {
+	metricName string
+	help       string
+	kind       string
+	labels     []string
+
+	mu     sync.Mutex
+	values map[string]*series
+}
+
+type series struct {
+	labelValues []string
+	value       float64
+}
+
+func newVec(name, help, kind string, labels []string) *vec {
+	return &vec{metricName: name, help: help, kind: kind, labels: labels, values: make(map[string]*series)}
+}
+
+func (v *vec) name() string { return v.metricName }
+
+// add adds delta to the series identified by labelValues.
+func (v *vec) add(delta float64, labelValues []string) {
+	v.update(labelValues, func(s *series) { s.value += delta })
+}
+
+// set sets the series identified by labelValues to value.
+func (v *vec) set(value float64, labelValues []string) {
+	v.update(labelValues, func(s *series) { s.value = value })
+}
+
+func (v *vec) update(labelValues []string, fn func(*series)) {
+	checkLabels(v.metricName, v.labels, labelValues)
+	key := seriesKey(labelValues)
+
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	s, ok := v.values[key]
+	if !ok {
+		s = &series{labelValues: append([]string(nil), labelValues...)}
+		v.values[key] = s
+	}
+	fn(s)
+}
+
+// get returns the value of the series identified by labelValues.
+func (v *vec) get(labelValues []string) float64 {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	if s, ok := v.values[seriesKey(labelValues)]; ok {
+		return s.value
+	}
+	return 0
+}
+
+func (v *vec) write(w *bufio.Writer) {
+	v.mu.Lock()
+	samples := make([]Sample, 0, len(v.values))
+	for _, s := range v.values {
+		samples = append(samples, Sample{LabelValues: s.labelValues, Value: s.value})
+	}
+	v.mu.Unlock()
+
+	writeHeader(w, v.metricName, v.help, v.kind)
+	writeSamples(w, v.metricName, v.labels, samples)
+}
+
+// Counter is a monotonically increasing value, optionally partitioned by labels.
+type Counter struct {
+	*vec
+}
+
+// Inc increments the counter for the given label values by 1.
+func (c *Counter) Inc(labelValues ...string) {
+	c.add(1, labelValues)
+}
+
+// Add increments the counter for the given label values by delta.
+// Negative deltas are ignored, as counters only go up.
+func (c *Counter) Add(delta float64, labelValues ...string) {
+	if delta < 0 {
+		return
+	}
+	c.add(delta, labelValues)
+}
+
+// Value returns the current value for the given label values.
+func (c *Counter) Value(labelValues ...string) float64 {
+	return c.get(labelValues)
+}
+
+// Gauge is a value that can go up and down, optionally partitioned by labels.
+type Gauge struct {
+	*vec
+}
+
+// Set sets the gauge for the given label values.
+func (g *Gauge) Set(value float64, labelValues ...string) {
+	g.set(value, labelValues)
+}
+
+// Inc increments the gauge for the given label values by 1.
+func (g *Gauge) Inc(labelValues ...string) {
+	g.add(1, labelValues)
+}
+
+// Dec decrements the gauge for the given label values by 1.
+func (g *Gauge) Dec(labelValues ...string) {
+	g.add(-1, labelValues)
+}
+
+// Value returns the current value for the given label values.
+func (g *Gauge) Value(labelValues ...string) float64 {
+	return g.get(labelValues)
+}
+
+// gaugeFunc is a gauge evaluated at scrape time.
+type gaugeFunc struct {
+	metricName string
+	help       string
+	labels     []string
+	fn         func() []Sample
+}
+
+func (g *gaugeFunc) name() string { return g.metricName }
+
+func (g *gaugeFunc) write(w *bufio.Writer) {
+	writeHeader(w, g.metricName, g.help, "gauge")
+	writeSamples(w, g.metricName, g.labels, g.fn())
+}
+
+// Histogram counts observations in cumulative buckets, optionally
+// partitioned by labels.
+type Histogram struct {
+	metricName string
+	help       string
+	labels     []string
+	buckets    []float64
+
+	mu     sync.Mutex
+	series map[string]*histogramSeries
+}
+
+type histogramSeries struct {
+	labelValues []string
+	counts      []uint64 // per bucket, non-cumulative
+	count       uint64
+	sum         float64
+}
+
+func (h *Histogram) name() string { return h.metricName }
+
+// Observe records v for the given label values.
+func (h *Histogram) Observe(v float64, labelValues ...string) {
+	checkLabels(h.metricName, h.labels, labelValues)
+	key := seriesKey(labelValues)
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	s, ok := h.series[key]
+	if !ok {
+		s = &histogramSeries{
+			labelValues: append([]string(nil), labelValues...),
+			counts:      make([]uint64, len(h.buckets)),
+		}
+		h.series[key] = s
+	}
+	for i, upper := range h.buckets {
+		if v <= upper {
+			s.counts[i]++
+			break
+		}
+	}
+	s.count++
+	s.sum += v
+}
+
+// Count returns the number of observations for the given label values.
+func (h *Histogram) Count(labelValues ...string) uint64 {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if s, ok := h.series[seriesKey(labelValues)]; ok {
+		return s.count
+	}
+	return 0
+}
+
+func (h *Histogram) write(w *bufio.Writer) {
+	h.mu.Lock()
+	all := make([]histogramSeries, 0, len(h.series))
+	for _, s := range h.series {
+		copied := *s
+		copied.counts = append([]uint64(nil), s.counts...)
+		all = append(all, copied)
+	}
+	h.mu.Unlock()
+
+	sort.Slice(all, func(i, j int) bool {
+		return seriesKey(all[i].labelValues) < seriesKey(all[j].labelValues)
+	})
+
+	writeHeader(w, h.metricName, h.help, "histogram")
+	bucketLabels := append(append([]string(nil), h.labels...), "le")
+	for _, s := range all {
+		var cumulative uint64
+		for i, upper := range h.buckets {
+			cumulative += s.counts[i]
+			writeSample(w, h.metricName+"_bucket", bucketLabels, append(append([]string(nil), s.labelValues...), formatFloat(upper)), float64(cumulative))
+		}
+		writeSample(w, h.metricName+"_bucket", bucketLabels, append(append([]string(nil), s.labelValues...), "+Inf"), float64(s.count))
+		writeSample(w, h.metricName+"_sum", h.labels, s.labelValues, s.sum)
+		writeSample(w, h.metricName+"_count", h.labels, s.labelValues, float64(s.count))
+	}
+}
+
+// checkLabels panics if the number of label values does not match the
+// number of label names. This is always a programming error.
+func checkLabels(name string, labels, values []string) {
+	if len(labels) != len(values) {
+		panic(fmt.Sprintf("metrics: %s expects %d label values, got %d", name, len(labels), len(values)))
+	}
+}
+
+// seriesKey joins label values into a map key.
+func seriesKey(labelValues []string) string {
+	return strings.Join(labelValues, "\xff")
+}
+
+func writeHeader(w *bufio.Writer, name, help, kind string) {
+	fmt.Fprintf(w, "# HELP %s %s\n", name, escapeHelp(help))
+	fmt.Fprintf(w, "# TYPE %s %s\n", name, kind)
+}
+
+// writeSamples writes samples sorted by label values.
+func writeSamples(w *bufio.Writer, name string, labels []string, samples []Sample) {
+	sort.Slice(samples, func(i, j int) bool {
+		return seriesKey(samples[i].LabelValues) < seriesKey(samples[j].LabelValues)
+	})
+	for _, s := range samples {
+		writeSample(w, name, labels, s.LabelValues, s.Value)
+	}
+}
+
+func writeSample(w *bufio.Writer, name string, labels, values []string, value float64) {
+	w.WriteString(name)
+	if len(labels) > 0 {
+		w.WriteByte('{')
+		for i, label := range labels {
+			if i > 0 {
+				w.WriteByte(',')
+			}
+			var v string
+			if i < len(values) {
+				v = values[i]
+			}
+			w.WriteString(label)
+			w.WriteString(`="`)
+			w.WriteString(escapeLabelValue(v))
+			w.WriteByte('"')
+		}
+		w.WriteByte('}')
+	}
+	w.WriteByte(' ')
+	w.WriteString(formatFloat(value))
+	w.WriteByte('\n')
+}
+
+func formatFloat(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	case math.IsNaN(v):
+		return "NaN"
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+var (
+	helpEscaper  = strings.NewReplacer(`\`, `\\`, "\n", `\n`)
+	labelEscaper = strings.NewReplacer(`\`, `\\`, "\n", `\n`, `"`, `\"`)
+)
+
+func escapeHelp(s string) string       { return helpEscaper.Replace(s) }
+func escapeLabelValue(s string) string { return labelEscaper.Replace(s) }
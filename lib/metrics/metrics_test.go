@@ -0,0 +1,185 @@
+package metrics
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestCounter(t *testing.T) {
+	r := NewRegistry()
+	c := r.NewCounter("test_total", "A test counter.", "verb")
+
+	c.Inc("HELLO")
+	c.Inc("HELLO")
+	c.Add(3, "SESSION")
+	c.Add(-1, "SESSION") // ignored
+
+	if got := c.Value("HELLO"); got != 2 {
+		t.Errorf("Value(HELLO) = %v, want 2", got)
+	}
+	if got := c.Value("SESSION"); got != 3 {
+		t.Errorf("Value(SESSION) = %v, want 3", got)
+	}
+	if got := c.Value("STREAM"); got != 0 {
+		t.Errorf("Value(STREAM) = %v, want 0", got)
+	}
+}
+
+func TestGauge(t *testing.T) {
+	r := NewRegistry()
+	g := r.NewGauge("test_gauge", "A test gauge.")
+
+	g.Inc()
+	g.Inc()
+	g.Dec()
+	if got := g.Value(); got != 1 {
+		t.Errorf("Value() = %v, want 1", got)
+	}
+
+	g.Set(42)
+	if got := g.Value(); got != 42 {
+		t.Errorf("Value() = %v, want 42", got)
+	}
+}
+
+func TestHistogram(t *testing.T) {
+	r := NewRegistry()
+	h := r.NewHistogram("test_seconds", "A test histogram.", []float64{1, 0.1}, "verb")
+
+	h.Observe(0.05, "PING")
+	h.Observe(0.5, "PING")
+	h.Observe(5, "PING")
+
+	if got := h.Count("PING"); got != 3 {
+		t.Errorf("Count(PING) = %d, want 3", got)
+	}
+
+	var sb strings.Builder
+	if err := r.WriteText(&sb); err != nil {
+		t.Fatalf("WriteText() error = %v", err)
+	}
+	out := sb.String()
+
+	for _, want := range []string{
+		"# TYPE test_seconds histogram\n",
+		`test_seconds_bucket{verb="PING",le="0.1"} 1`,
+		`test_seconds_bucket{verb="PING",le="1"} 2`,
+		`test_seconds_bucket{verb="PING",le="+Inf"} 3`,
+		`test_seconds_sum{verb="PING"} 5.55`,
+		`test_seconds_count{verb="PING"} 3`,
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output missing %q:\n%s", want, out)
+		}
+	}
+}
+
+func TestWriteText(t *testing.T) {
+	r := NewRegistry()
+	c := r.NewCounter("b_total", "Second.", "result")
+	r.NewGauge("a_gauge", "First.").Set(7)
+	c.Inc(`say "hi"` + "\n")
+
+	var sb strings.Builder
+	if err := r.WriteText(&sb); err != nil {
+		t.Fatalf("WriteText() error = %v", err)
+	}
+
+	want := "# HELP a_gauge First.\n" +
+		"# TYPE a_gauge gauge\n" +
+		"a_gauge 7\n" +
+		"# HELP b_total Second.\n" +
+		"# TYPE b_total counter\n" +
+		`b_total{result="say \"hi\"\n"} 1` + "\n"
+	if got := sb.String(); got != want {
+		t.Errorf("WriteText() =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestGaugeFunc(t *testing.T) {
+	r := NewRegistry()
+	r.GaugeFunc("sessions", "Sessions.", []string{"style"}, func() []Sample {
+		return []Sample{{LabelValues: []string{"STREAM"}, Value: 1}}
+	})
+	// Re-registering replaces the previous collector.
+	r.GaugeFunc("sessions", "Sessions.", []string{"style"}, func() []Sample {
+		return []Sample{
+			{LabelValues: []string{"RAW"}, Value: 2},
+			{LabelValues: []string{"DATAGRAM"}, Value: 3},
+		}
+	})
+
+	var sb strings.Builder
+	if err := r.WriteText(&sb); err != nil {
+		t.Fatalf("WriteText() error = %v", err)
+	}
+	out := sb.String()
+
+	if strings.Contains(out, "STREAM") {
+		t.Errorf("replaced GaugeFunc still reported:\n%s", out)
+	}
+	if !strings.Contains(out, "sessions{style=\"DATAGRAM\"} 3\nsessions{style=\"RAW\"} 2\n") {
+		t.Errorf("samples missing or unsorted:\n%s", out)
+	}
+}
+
+func TestRegistry_DuplicatePanics(t *testing.T) {
+	r := NewRegistry()
+	r.NewCounter("dup_total", "Duplicate.")
+
+	defer func() {
+		if recover() == nil {
+			t.Error("expected panic on duplicate registration")
+		}
+	}()
+	r.NewGauge("dup_total", "Duplicate.")
+}
+
+func TestCounter_WrongLabelCountPanics(t *testing.T) {
+	r := NewRegistry()
+	c := r.NewCounter("labels_total", "Labels.", "verb", "action")
+
+	defer func() {
+		if recover() == nil {
+			t.Error("expected panic on wrong label count")
+		}
+	}()
+	c.Inc("HELLO")
+}
+
+func TestRegistry_Handler(t *testing.T) {
+	r := NewRegistry()
+	r.NewCounter("served_total", "Served.").Inc()
+
+	rec := httptest.NewRecorder()
+	r.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+
+	if ct := rec.Header().Get("Content-Type"); ct != ContentType {
+		t.Errorf("Content-Type = %q, want %q", ct, ContentType)
+	}
+	if !strings.Contains(rec.Body.String(), "served_total 1\n") {
+		t.Errorf("body = %q, want served_total 1", rec.Body.String())
+	}
+}
+
+func TestDefaultRegistry(t *testing.T) {
+	var sb strings.Builder
+	if err := Default.WriteText(&sb); err != nil {
+		t.Fatalf("WriteText() error = %v", err)
+	}
+	for _, name := range []string{
+		"sam_commands_total",
+		"sam_command_duration_seconds",
+		"sam_commands_throttled_total",
+		"sam_stream_results_total",
+		"sam_datagrams_sent_total",
+		"sam_datagrams_received_total",
+		"sam_datagrams_dropped_total",
+	} {
+		if !strings.Contains(sb.String(), "# TYPE "+name+" ") {
+			t.Errorf("Default registry missing %s", name)
+		}
+	}
+}
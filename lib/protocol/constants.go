@@ -145,3 +145,16 @@ func VersionSupportsPortInfo(version string) bool {
 		return true
 	}
 }
+
+// VersionSupportsPing returns true if the given SAM version supports
+// PING/PONG. Per SAMv3.md, PING and PONG were added in SAM 3.2, so the
+// bridge must not send PING to clients that negotiated an older version.
+func VersionSupportsPing(version string) bool {
+	switch version {
+	case "", "3.0", "3.1":
+		return false
+	default:
+		// 3.2, 3.3, and any future versions support PING/PONG
+		return true
+	}
+}
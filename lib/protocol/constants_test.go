@@ -111,3 +111,28 @@ func TestVersionSupportsPortInfo(t *testing.T) {
 		})
 	}
 }
+
+func TestVersionSupportsPing(t *testing.T) {
+	tests := []struct {
+		version string
+		want    bool
+	}{
+		// PING/PONG was added in SAM 3.2
+		{"3.0", false},
+		{"3.1", false},
+		{"3.2", true},
+		{"3.3", true},
+		// No negotiated version means the handshake has not completed
+		{"", false},
+		{"3.4", true},
+	}
+
+	for _, tt := range tests {
+		t.Run("version_"+tt.version, func(t *testing.T) {
+			got := VersionSupportsPing(tt.version)
+			if got != tt.want {
+				t.Errorf("VersionSupportsPing(%q) = %v, want %v", tt.version, got, tt.want)
+			}
+		})
+	}
+}
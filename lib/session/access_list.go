@@ -0,0 +1,244 @@
+package session
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/go-i2p/common/base32"
+	"github.com/go-i2p/common/base64"
+	"github.com/go-i2p/go-streaming"
+)
+
+// SESSION CREATE options restricting the peers that may open streams to a
+// session. The i2cp.* options are Java I2P's; sam.accessListFile names a
+// file in the bridge's access list directory whose entries are added to
+// i2cp.accessList.
+const (
+	OptionEnableAccessList = "i2cp.enableAccessList"
+	OptionEnableBlackList  = "i2cp.enableBlackList"
+	OptionAccessList       = "i2cp.accessList"
+	OptionAccessListFile   = "sam.accessListFile"
+)
+
+// AccessListMode says how an AccessList treats the peers it lists.
+type AccessListMode int
+
+const (
+	// AccessListOff lets every peer connect.
+	AccessListOff AccessListMode = iota
+
+	// AccessListAllow lets only the listed peers connect
+	// (i2cp.enableAccessList).
+	AccessListAllow
+
+	// AccessListDeny lets every peer but the listed ones connect
+	// (i2cp.enableBlackList).
+	AccessListDeny
+)
+
+// DestinationHash is the SHA-256 of a binary destination, which its
+// .b32.i2p address encodes.
+type DestinationHash [sha256.Size]byte
+
+// String returns the hash as a .b32.i2p address.
+func (h DestinationHash) String() string {
+	return base32.EncodeToStringNoPadding(h[:]) + ".b32.i2p"
+}
+
+// ParseDestinationHash parses an access list entry: a .b32.i2p address
+// (with or without the suffix), a base64 destination hash as used by Java
+// I2P, or a full base64 destination.
+func ParseDestinationHash(s string) (DestinationHash, error) {
+	var h DestinationHash
+	s = strings.TrimSpace(s)
+	if b32 := strings.TrimSuffix(strings.ToLower(s), ".b32.i2p"); len(b32) == 52 {
+		raw, err := base32.DecodeStringNoPadding(b32)
+		if err == nil && len(raw) == len(h) {
+			copy(h[:], raw)
+			return h, nil
+		}
+	}
+	raw, err := base64.DecodeString(s)
+	if err != nil {
+		return h, fmt.Errorf("%q is not a b32 address, destination hash or destination", s)
+	}
+	switch {
+	case len(raw) == len(h):
+		copy(h[:], raw)
+	case len(raw) >= 387:
+		h = sha256.Sum256(raw)
+	default:
+		return h, fmt.Errorf("%q is not a b32 address, destination hash or destination", s)
+	}
+	return h, nil
+}
+
+// PeerHash returns the destination hash of the peer of an inbound stream.
+// It reports false if the address does not identify a destination.
+func PeerHash(addr net.Addr) (DestinationHash, bool) {
+	if addr == nil {
+		return DestinationHash{}, false
+	}
+	if b64, ok := streaming.PeerDestinationBase64(addr); ok && b64 != "" {
+		if raw, err := base64.DecodeString(b64); err == nil {
+			return sha256.Sum256(raw), true
+		}
+	}
+	h, err := ParseDestinationHash(addr.String())
+	return h, err == nil
+}
+
+// AccessList restricts the peers that may open streams to a STREAM session.
+// A nil AccessList lets every peer connect.
+//
+// Thread-safety: an AccessList is not modified after it is made, so it is
+// safe for concurrent use.
+type AccessList struct {
+	// Mode says whether the listed peers are the only ones allowed, or the
+	// only ones denied.
+	Mode AccessListMode
+
+	peers map[DestinationHash]struct{}
+}
+
+// NewAccessList returns an access list of the given peers.
+func NewAccessList(mode AccessListMode, peers ...DestinationHash) *AccessList {
+	l := &AccessList{Mode: mode, peers: make(map[DestinationHash]struct{}, len(peers))}
+	for _, p := range peers {
+		l.peers[p] = struct{}{}
+	}
+	return l
+}
+
+// Len returns the number of peers listed.
+func (l *AccessList) Len() int {
+	if l == nil {
+		return 0
+	}
+	return len(l.peers)
+}
+
+// Allows reports whether the peer at addr may open a stream. Peers whose
+// destination is unknown are denied by an AccessListAllow list and allowed
+// otherwise.
+func (l *AccessList) Allows(addr net.Addr) bool {
+	if l == nil || l.Mode == AccessListOff {
+		return true
+	}
+	h, ok := PeerHash(addr)
+	if !ok {
+		return l.Mode != AccessListAllow
+	}
+	_, listed := l.peers[h]
+	return listed == (l.Mode == AccessListAllow)
+}
+
+// ParseAccessList returns the access list given by the SESSION CREATE
+// options, or nil if neither i2cp.enableAccessList nor i2cp.enableBlackList
+// is true. As in Java I2P, i2cp.enableAccessList wins if both are.
+//
+// Entries of i2cp.accessList are separated by commas or spaces. A
+// sam.accessListFile is read from dir, one or more entries per line, with
+// blank lines and lines starting with # ignored; it is refused if dir is
+// empty or the name is not a plain file name within it.
+func ParseAccessList(options map[string]string, dir string) (*AccessList, error) {
+	mode := AccessListOff
+	for _, opt := range []struct {
+		key  string
+		mode AccessListMode
+	}{{OptionEnableBlackList, AccessListDeny}, {OptionEnableAccessList, AccessListAllow}} {
+		value, ok := options[opt.key]
+		if !ok {
+			continue
+		}
+		enabled, err := strconv.ParseBool(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s: must be true or false", opt.key)
+		}
+		if enabled {
+			mode = opt.mode
+		}
+	}
+	if mode == AccessListOff {
+		return nil, nil
+	}
+
+	l := NewAccessList(mode)
+	if err := l.add(splitAccessList(options[OptionAccessList])); err != nil {
+		return nil, fmt.Errorf("invalid %s: %w", OptionAccessList, err)
+	}
+	if name, ok := options[OptionAccessListFile]; ok {
+		entries, err := readAccessListFile(dir, name)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s: %w", OptionAccessListFile, err)
+		}
+		if err := l.add(entries); err != nil {
+			return nil, fmt.Errorf("invalid %s: %s: %w", OptionAccessListFile, name, err)
+		}
+	}
+	return l, nil
+}
+
+// add parses entries and adds them to the list.
+func (l *AccessList) add(entries []string) error {
+	for _, e := range entries {
+		h, err := ParseDestinationHash(e)
+		if err != nil {
+			return err
+		}
+		l.peers[h] = struct{}{}
+	}
+	return nil
+}
+
+// splitAccessList splits a list of entries separated by commas or spaces.
+func splitAccessList(s string) []string {
+	return strings.FieldsFunc(s, func(r rune) bool { return r == ',' || unicode.IsSpace(r) })
+}
+
+// readAccessListFile returns the entries of the access list file name in dir.
+func readAccessListFile(dir, name string) ([]string, error) {
+	if dir == "" {
+		return nil, errors.New("access list files are not enabled on this bridge")
+	}
+	if name == "" || name != filepath.Base(name) || name == "." || name == ".." {
+		return nil, fmt.Errorf("%q is not a file name", name)
+	}
+	f, err := os.Open(filepath.Join(dir, name))
+	if err != nil {
+		return nil, fmt.Errorf("cannot read %s", name)
+	}
+	defer f.Close()
+
+	var entries []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		entries = append(entries, splitAccessList(line)...)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("cannot read %s", name)
+	}
+	return entries, nil
+}
+
+// AccessListOf returns the access list of sess, or nil if it has none.
+func AccessListOf(sess Session) *AccessList {
+	if c, ok := sess.(interface{ Config() *SessionConfig }); ok {
+		if cfg := c.Config(); cfg != nil {
+			return cfg.AccessList
+		}
+	}
+	return nil
+}
@@ -0,0 +1,231 @@
+package session
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/go-i2p/common/base32"
+	"github.com/go-i2p/common/base64"
+
+	"github.com/go-i2p/go-sam-bridge/lib/metrics"
+)
+
+// testHash returns a destination hash filled with b.
+func testHash(b byte) DestinationHash {
+	var h DestinationHash
+	for i := range h {
+		h[i] = b
+	}
+	return h
+}
+
+// peerAddr is the address of a test peer, given as an access list entry.
+type peerAddr string
+
+func (a peerAddr) Network() string { return "i2p" }
+func (a peerAddr) String() string  { return string(a) }
+
+// peerConn is a mockConn from a given peer.
+type peerConn struct {
+	mockConn
+	peer net.Addr
+}
+
+func (c *peerConn) RemoteAddr() net.Addr { return c.peer }
+
+// queueListener accepts the queued conns, then fails.
+type queueListener struct {
+	conns []net.Conn
+}
+
+func (l *queueListener) Accept() (net.Conn, error) {
+	if len(l.conns) == 0 {
+		return nil, net.ErrClosed
+	}
+	conn := l.conns[0]
+	l.conns = l.conns[1:]
+	return conn, nil
+}
+func (l *queueListener) Close() error   { return nil }
+func (l *queueListener) Addr() net.Addr { return nil }
+
+func TestParseDestinationHash(t *testing.T) {
+	h := testHash(7)
+	b32 := base32.EncodeToStringNoPadding(h[:])
+
+	dest := make([]byte, 391)
+	rand.Read(dest)
+	destHash := DestinationHash(sha256.Sum256(dest))
+
+	tests := []struct {
+		name  string
+		entry string
+		want  DestinationHash
+	}{
+		{"b32 address", b32 + ".b32.i2p", h},
+		{"b32 without suffix", b32, h},
+		{"upper case b32", strings.ToUpper(b32) + ".B32.I2P", h},
+		{"base64 hash", base64.EncodeToString(h[:]), h},
+		{"destination", base64.EncodeToString(dest), destHash},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseDestinationHash(tt.entry)
+			if err != nil {
+				t.Fatalf("ParseDestinationHash() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("ParseDestinationHash() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	for _, entry := range []string{"", "example.i2p", "AAAA", b32[:40] + ".b32.i2p"} {
+		if _, err := ParseDestinationHash(entry); err == nil {
+			t.Errorf("ParseDestinationHash(%q) succeeded, want an error", entry)
+		}
+	}
+}
+
+func TestAccessList_Allows(t *testing.T) {
+	listed := peerAddr(testHash(1).String())
+	other := peerAddr(testHash(2).String())
+	unknown := peerAddr("unknown")
+
+	tests := []struct {
+		name string
+		acl  *AccessList
+		addr net.Addr
+		want bool
+	}{
+		{"nil list", nil, other, true},
+		{"off", NewAccessList(AccessListOff, testHash(1)), other, true},
+		{"allow listed", NewAccessList(AccessListAllow, testHash(1)), listed, true},
+		{"allow other", NewAccessList(AccessListAllow, testHash(1)), other, false},
+		{"allow unknown", NewAccessList(AccessListAllow, testHash(1)), unknown, false},
+		{"deny listed", NewAccessList(AccessListDeny, testHash(1)), listed, false},
+		{"deny other", NewAccessList(AccessListDeny, testHash(1)), other, true},
+		{"deny unknown", NewAccessList(AccessListDeny, testHash(1)), unknown, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.acl.Allows(tt.addr); got != tt.want {
+				t.Errorf("Allows(%v) = %v, want %v", tt.addr, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseAccessList(t *testing.T) {
+	one, two := testHash(1).String(), testHash(2).String()
+
+	tests := []struct {
+		name     string
+		options  map[string]string
+		wantNil  bool
+		wantMode AccessListMode
+		wantLen  int
+	}{
+		{"no options", map[string]string{}, true, 0, 0},
+		{"list without enable", map[string]string{OptionAccessList: one}, true, 0, 0},
+		{"disabled", map[string]string{OptionEnableAccessList: "false", OptionAccessList: one}, true, 0, 0},
+		{"access list", map[string]string{OptionEnableAccessList: "true", OptionAccessList: one + "," + two}, false, AccessListAllow, 2},
+		{"black list", map[string]string{OptionEnableBlackList: "true", OptionAccessList: one + " " + two}, false, AccessListDeny, 2},
+		{"both enabled", map[string]string{OptionEnableAccessList: "true", OptionEnableBlackList: "true", OptionAccessList: one}, false, AccessListAllow, 1},
+		{"empty access list", map[string]string{OptionEnableAccessList: "true"}, false, AccessListAllow, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			acl, err := ParseAccessList(tt.options, "")
+			if err != nil {
+				t.Fatalf("ParseAccessList() error = %v", err)
+			}
+			if tt.wantNil {
+				if acl != nil {
+					t.Errorf("ParseAccessList() = %+v, want nil", acl)
+				}
+				return
+			}
+			if acl == nil || acl.Mode != tt.wantMode || acl.Len() != tt.wantLen {
+				t.Errorf("ParseAccessList() = %+v, want mode %v with %d peers", acl, tt.wantMode, tt.wantLen)
+			}
+		})
+	}
+}
+
+func TestParseAccessList_Invalid(t *testing.T) {
+	tests := []struct {
+		name    string
+		options map[string]string
+		dir     string
+		want    string
+	}{
+		{"enable not a bool", map[string]string{OptionEnableBlackList: "yes please"}, "", OptionEnableBlackList},
+		{"bad entry", map[string]string{OptionEnableAccessList: "true", OptionAccessList: "example.i2p"}, "", OptionAccessList},
+		{"file without dir", map[string]string{OptionEnableAccessList: "true", OptionAccessListFile: "peers"}, "", "not enabled"},
+		{"file outside dir", map[string]string{OptionEnableAccessList: "true", OptionAccessListFile: "../peers"}, t.TempDir(), "not a file name"},
+		{"missing file", map[string]string{OptionEnableAccessList: "true", OptionAccessListFile: "missing"}, t.TempDir(), "cannot read"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseAccessList(tt.options, tt.dir)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("ParseAccessList() error = %v, want one mentioning %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseAccessList_File(t *testing.T) {
+	dir := t.TempDir()
+	content := "# trusted peers\n" + testHash(1).String() + "\n\n  " + testHash(2).String() + ", " + testHash(3).String() + "\n"
+	if err := os.WriteFile(filepath.Join(dir, "peers"), []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	acl, err := ParseAccessList(map[string]string{
+		OptionEnableAccessList: "true",
+		OptionAccessList:       testHash(4).String(),
+		OptionAccessListFile:   "peers",
+	}, dir)
+	if err != nil {
+		t.Fatalf("ParseAccessList() error = %v", err)
+	}
+	if acl.Len() != 4 {
+		t.Errorf("Len() = %d, want the 3 peers of the file and 1 of the option", acl.Len())
+	}
+	if !acl.Allows(peerAddr(testHash(3).String())) {
+		t.Error("Allows() denied a peer listed in the file")
+	}
+}
+
+func TestAcceptAllowed(t *testing.T) {
+	acl := NewAccessList(AccessListDeny, testHash(1))
+	denied := &peerConn{peer: peerAddr(testHash(1).String())}
+	allowed := &peerConn{peer: peerAddr(testHash(2).String())}
+	listener := &queueListener{conns: []net.Conn{denied, allowed}}
+	before := metrics.StreamsRejected.Value(metrics.RejectBlackList)
+
+	conn, err := AcceptAllowed(listener, acl, nil)
+	if err != nil || conn != allowed {
+		t.Fatalf("AcceptAllowed() = %v, %v; want the allowed peer's stream", conn, err)
+	}
+	if !denied.isClosed() {
+		t.Error("denied peer's stream was not closed")
+	}
+	if allowed.isClosed() {
+		t.Error("allowed peer's stream was closed")
+	}
+	if got := metrics.StreamsRejected.Value(metrics.RejectBlackList) - before; got != 1 {
+		t.Errorf("StreamsRejected{black_list} increased by %v, want 1", got)
+	}
+
+	if _, err := AcceptAllowed(listener, acl, nil); err == nil {
+		t.Error("AcceptAllowed() did not return the listener's error")
+	}
+}
@@ -19,6 +19,8 @@ type BaseSession struct {
 	status      Status
 	controlConn net.Conn
 	config      *SessionConfig
+	owner       string
+	udpSource   *net.UDPAddr
 
 	// i2cpSession holds the I2CP session handle for tunnel management.
 	// ISSUE-003: Used to wait for tunnel readiness and manage I2CP lifecycle.
@@ -78,6 +80,60 @@ func (b *BaseSession) ControlConn() net.Conn {
 	return b.controlConn
 }
 
+// Owner returns the SAM user that created the session, or "" if the
+// creating connection was not authenticated.
+func (b *BaseSession) Owner() string {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	return b.owner
+}
+
+// SetOwner records the SAM user that created the session.
+// This is set by SESSION CREATE before the session is registered.
+func (b *BaseSession) SetOwner(owner string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.owner = owner
+}
+
+// UDPSource returns the address that datagrams sent through this session
+// on the SAM UDP port must come from, as set by SetUDPSource. A nil IP
+// means the host of the control connection, and a zero Port allows any
+// source port. Returns nil if no address was set.
+func (b *BaseSession) UDPSource() *net.UDPAddr {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	return b.udpSource
+}
+
+// SetUDPSource sets the address datagrams for this session must come from.
+// This is set by SESSION CREATE from the UDP_SOURCE_HOST and
+// UDP_SOURCE_PORT options.
+func (b *BaseSession) SetUDPSource(addr *net.UDPAddr) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.udpSource = addr
+}
+
+// BindUDPSourcePort binds datagrams for this session to the source port
+// port, unless a port is already bound, and returns the bound port. The
+// UDP listener binds the sessions of authenticated users to the port of
+// the first datagram they accept, so that other local users cannot send
+// through them.
+func (b *BaseSession) BindUDPSourcePort(port int) int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	src := net.UDPAddr{}
+	if b.udpSource != nil {
+		src = *b.udpSource
+	}
+	if src.Port == 0 {
+		src.Port = port
+		b.udpSource = &src
+	}
+	return src.Port
+}
+
 // Config returns the session configuration.
 func (b *BaseSession) Config() *SessionConfig {
 	b.mu.RLock()
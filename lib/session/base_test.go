@@ -215,6 +215,26 @@ func TestBaseSession_IsClosed(t *testing.T) {
 	}
 }
 
+func TestBaseSession_BindUDPSourcePort(t *testing.T) {
+	session := NewBaseSession("test-id", StyleRaw, nil, nil, nil)
+	host := net.ParseIP("192.0.2.10")
+	session.SetUDPSource(&net.UDPAddr{IP: host})
+	source := session.UDPSource()
+
+	if got := session.BindUDPSourcePort(5000); got != 5000 {
+		t.Errorf("BindUDPSourcePort(5000) = %d, want 5000", got)
+	}
+	if got := session.BindUDPSourcePort(6000); got != 5000 {
+		t.Errorf("BindUDPSourcePort(6000) = %d after binding 5000, want 5000", got)
+	}
+	if got := session.UDPSource(); !got.IP.Equal(host) || got.Port != 5000 {
+		t.Errorf("UDPSource() = %v, want %s:5000", got, host)
+	}
+	if source.Port != 0 {
+		t.Error("BindUDPSourcePort() modified an address returned by UDPSource")
+	}
+}
+
 func TestBaseSession_IsActive(t *testing.T) {
 	session := NewBaseSession("test-id", StyleStream, nil, nil, nil)
 
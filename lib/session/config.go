@@ -103,6 +103,15 @@ type SessionConfig struct {
 	//   - i2cp.reduceOnIdle: Reduce tunnels when idle
 	//   - streaming.maxConnsPerMinute: Connection rate limiting
 	I2CPOptions map[string]string
+
+	// Streaming holds the i2p.streaming.* options of STREAM sessions, which
+	// the bridge applies to go-streaming. They are also kept in I2CPOptions.
+	Streaming StreamingOptions
+
+	// AccessList restricts the peers that may open streams to a STREAM
+	// session (i2cp.enableAccessList, i2cp.enableBlackList,
+	// i2cp.accessList). Nil lets every peer connect.
+	AccessList *AccessList
 }
 
 // OfflineSignature represents offline signing capability per SAM 3.3.
@@ -173,6 +182,7 @@ func DefaultSessionConfig() *SessionConfig {
 		FastReceive:            true, // Default true for better I2CP performance
 		OfflineSignature:       nil,
 		I2CPOptions:            make(map[string]string),
+		Streaming:              DefaultStreamingOptions(),
 	}
 }
 
@@ -224,10 +234,18 @@ type ForwardOptions struct {
 	// Host is the local host to forward incoming connections to.
 	// Defaults to "127.0.0.1".
 	Host string
-	// Silent suppresses the response confirmation if true.
+	// Path is a Unix socket to forward incoming connections to instead of
+	// Host:Port (PATH, a go-sam-bridge extension; see ResolveForwardPath).
+	Path string
+	// Silent suppresses the destination line written to the target ahead
+	// of each connection's data if true.
 	Silent bool
 	// SSLEnabled enables TLS/SSL for the forwarded connection.
 	SSLEnabled bool
+	// Linger is how long a forwarded connection keeps copying in one
+	// direction after the other has reached EOF (0 = close both at once).
+	// The bridge sets it from its stream linger timeout.
+	Linger time.Duration
 }
 
 // DatagramSendOptions holds options for DATAGRAM SEND operations.
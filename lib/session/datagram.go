@@ -11,6 +11,8 @@ import (
 	"sync"
 
 	"github.com/go-i2p/go-datagrams"
+
+	"github.com/go-i2p/go-sam-bridge/lib/metrics"
 )
 
 // DatagramSessionImpl implements the DatagramSession interface.
@@ -145,16 +147,20 @@ func (d *DatagramSessionImpl) Send(dest string, data []byte, opts DatagramSendOp
 	}
 	if dgOpts := sam33.buildSAM33Options(); dgOpts != nil {
 		if err := datagramConn.SendToWithOptions(data, dest, toPort, dgOpts); err != nil {
+			metrics.DatagramsDropped.Inc(string(StyleDatagram), metrics.DropSendFailed)
 			return fmt.Errorf("failed to send datagram: %w", err)
 		}
+		metrics.DatagramsSent.Inc(string(StyleDatagram))
 		return nil
 	}
 
 	// Send the datagram using go-datagrams
 	// DATAGRAM uses ProtocolDatagram1 (17) for repliable authenticated datagrams
 	if err := datagramConn.SendTo(data, dest, toPort); err != nil {
+		metrics.DatagramsDropped.Inc(string(StyleDatagram), metrics.DropSendFailed)
 		return fmt.Errorf("failed to send datagram: %w", err)
 	}
+	metrics.DatagramsSent.Inc(string(StyleDatagram))
 	return nil
 }
 
@@ -230,6 +236,7 @@ func (d *DatagramSessionImpl) deliverDatagram(dg ReceivedDatagram) {
 	forwarding := d.forwardPort > 0
 	if forwarding {
 		d.mu.RUnlock()
+		metrics.DatagramsReceived.Inc(string(StyleDatagram))
 		d.forwardDatagram(dg)
 		return
 	}
@@ -238,9 +245,11 @@ func (d *DatagramSessionImpl) deliverDatagram(dg ReceivedDatagram) {
 	// which writes receiveChan = nil under mu.Lock().
 	select {
 	case d.receiveChan <- dg:
+		metrics.DatagramsReceived.Inc(string(StyleDatagram))
 	default:
 		// Channel full, drop datagram
 		// This is expected behavior per SAM spec - datagrams are unreliable
+		metrics.DatagramsDropped.Inc(string(StyleDatagram), metrics.DropQueueFull)
 	}
 	d.mu.RUnlock()
 }
@@ -13,6 +13,8 @@ import (
 	"time"
 
 	"github.com/go-i2p/go-datagrams"
+
+	"github.com/go-i2p/go-sam-bridge/lib/metrics"
 )
 
 // Datagram2SessionImpl implements the DatagramSession interface for DATAGRAM2 style.
@@ -162,8 +164,10 @@ func (d *Datagram2SessionImpl) Send(dest string, data []byte, opts DatagramSendO
 	// DATAGRAM2 uses ProtocolDatagram2 (19) for authenticated datagrams with replay prevention
 	err := datagramConn.SendTo(data, dest, toPort)
 	if err != nil {
+		metrics.DatagramsDropped.Inc(string(StyleDatagram2), metrics.DropSendFailed)
 		return fmt.Errorf("failed to send datagram2: %w", err)
 	}
+	metrics.DatagramsSent.Inc(string(StyleDatagram2))
 
 	return nil
 }
@@ -245,6 +249,7 @@ func (d *Datagram2SessionImpl) CheckReplay(nonce uint64) bool {
 func (d *Datagram2SessionImpl) DeliverDatagram(dg ReceivedDatagram, nonce uint64) bool {
 	// Check for replay
 	if d.CheckReplay(nonce) {
+		metrics.DatagramsDropped.Inc(string(StyleDatagram2), metrics.DropReplay)
 		return false
 	}
 
@@ -256,10 +261,12 @@ func (d *Datagram2SessionImpl) DeliverDatagram(dg ReceivedDatagram, nonce uint64
 	// Non-blocking send to channel (drop if full)
 	select {
 	case d.receiveChan <- dg:
+		metrics.DatagramsReceived.Inc(string(StyleDatagram2))
 		return true
 	default:
 		// Channel full, datagram dropped
 		// This is acceptable per SAM spec (datagrams are best-effort)
+		metrics.DatagramsDropped.Inc(string(StyleDatagram2), metrics.DropQueueFull)
 		return false
 	}
 }
@@ -15,6 +15,8 @@ import (
 	"sync"
 
 	"github.com/go-i2p/go-datagrams"
+
+	"github.com/go-i2p/go-sam-bridge/lib/metrics"
 )
 
 // Datagram3SessionImpl implements the DatagramSession interface for DATAGRAM3 style.
@@ -171,8 +173,10 @@ func (d *Datagram3SessionImpl) Send(dest string, data []byte, opts DatagramSendO
 	// DATAGRAM3 uses ProtocolDatagram3 (20) for repliable unauthenticated datagrams
 	err := datagramConn.SendTo(data, dest, toPort)
 	if err != nil {
+		metrics.DatagramsDropped.Inc(string(StyleDatagram3), metrics.DropSendFailed)
 		return fmt.Errorf("failed to send datagram3: %w", err)
 	}
+	metrics.DatagramsSent.Inc(string(StyleDatagram3))
 
 	return nil
 }
@@ -245,10 +249,12 @@ func (d *Datagram3SessionImpl) DeliverDatagram(dg ReceivedDatagram) bool {
 	// Non-blocking send to channel (drop if full)
 	select {
 	case d.receiveChan <- dg:
+		metrics.DatagramsReceived.Inc(string(StyleDatagram3))
 		return true
 	default:
 		// Channel full, datagram dropped
 		// This is acceptable per SAM spec (datagrams are best-effort)
+		metrics.DatagramsDropped.Inc(string(StyleDatagram3), metrics.DropQueueFull)
 		return false
 	}
 }
@@ -0,0 +1,117 @@
+package session
+
+import (
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-i2p/go-streaming"
+)
+
+// ResolveForwardPath returns the Unix socket a STREAM FORWARD PATH option
+// names. Relative paths are taken from dir; absolute ones must be within
+// it. It refuses every path if dir is empty, so that clients cannot reach
+// sockets the operator did not set aside for them.
+func ResolveForwardPath(path, dir string) (string, error) {
+	if dir == "" {
+		return "", errors.New("forwarding to Unix sockets is not enabled on this bridge")
+	}
+	if path == "" {
+		return "", errors.New("empty PATH")
+	}
+	dir = filepath.Clean(dir)
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(dir, path)
+	}
+	path = filepath.Clean(path)
+	if rel, err := filepath.Rel(dir, path); err != nil || rel == "." || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("PATH %s is not within the bridge's forward socket directory", path)
+	}
+	return path, nil
+}
+
+// DialForward connects to the target of a STREAM FORWARD: the Unix socket
+// opts.Path if it is set, otherwise host:port over TCP. With
+// opts.SSLEnabled the connection uses TLS with tlsConfig, or a default
+// configuration if it is nil. A Unix socket's TLS server name is
+// opts.Host, or localhost if that is empty.
+func DialForward(host string, port int, opts ForwardOptions, tlsConfig *tls.Config, timeout time.Duration) (net.Conn, error) {
+	network, addr := "tcp", net.JoinHostPort(host, strconv.Itoa(port))
+	if opts.Path != "" {
+		network, addr = "unix", opts.Path
+	}
+	dialer := &net.Dialer{Timeout: timeout}
+	if !opts.SSLEnabled {
+		return dialer.Dial(network, addr)
+	}
+
+	if tlsConfig == nil {
+		tlsConfig = &tls.Config{}
+	}
+	if network == "unix" && tlsConfig.ServerName == "" {
+		tlsConfig = tlsConfig.Clone()
+		tlsConfig.ServerName = opts.Host
+		if tlsConfig.ServerName == "" {
+			tlsConfig.ServerName = "localhost"
+		}
+	}
+	return tls.DialWithDialer(dialer, network, addr, tlsConfig)
+}
+
+// PeerDestination returns the base64 destination of the peer at addr, or
+// addr's string form if it is not an I2P address.
+func PeerDestination(addr net.Addr) string {
+	if addr == nil {
+		return ""
+	}
+	if b64, ok := streaming.PeerDestinationBase64(addr); ok && b64 != "" {
+		return b64
+	}
+	return addr.String()
+}
+
+// StreamPorts returns the ports of an accepted stream: the port the peer
+// sent it from and the port it was sent to. A port is 0 if its address
+// does not carry one.
+func StreamPorts(conn net.Conn) (fromPort, toPort int) {
+	return addrPort(conn.RemoteAddr()), addrPort(conn.LocalAddr())
+}
+
+// addrPort returns the port of addr, from its Port field or the port in
+// its "host:port" string form, or 0 if it has none.
+func addrPort(addr net.Addr) int {
+	switch a := addr.(type) {
+	case nil:
+		return 0
+	case *net.TCPAddr:
+		return a.Port
+	case *net.UDPAddr:
+		return a.Port
+	}
+	_, port, err := net.SplitHostPort(addr.String())
+	if err != nil {
+		return 0
+	}
+	n, err := strconv.Atoi(port)
+	if err != nil || n < 0 || n > 65535 {
+		return 0
+	}
+	return n
+}
+
+// WriteForwardHeader writes the line STREAM FORWARD sends to the target
+// ahead of a stream's data unless SILENT=true: the peer's destination and
+// the stream's ports, as in the STREAM ACCEPT reply.
+//
+// Per SAMv3.md: "$destination FROM_PORT=nnn TO_PORT=nnn\n"
+func WriteForwardHeader(w io.Writer, i2pConn net.Conn) error {
+	fromPort, toPort := StreamPorts(i2pConn)
+	_, err := fmt.Fprintf(w, "%s FROM_PORT=%d TO_PORT=%d\n", PeerDestination(i2pConn.RemoteAddr()), fromPort, toPort)
+	return err
+}
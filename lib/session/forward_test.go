@@ -0,0 +1,165 @@
+package session
+
+import (
+	"bufio"
+	"crypto/tls"
+	"crypto/x509"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestResolveForwardPath(t *testing.T) {
+	dir := t.TempDir()
+	tests := []struct {
+		name    string
+		path    string
+		dir     string
+		want    string
+		wantErr string
+	}{
+		{"name in dir", "app.sock", dir, filepath.Join(dir, "app.sock"), ""},
+		{"subdirectory", "web/app.sock", dir, filepath.Join(dir, "web", "app.sock"), ""},
+		{"absolute in dir", filepath.Join(dir, "app.sock"), dir, filepath.Join(dir, "app.sock"), ""},
+		{"not enabled", "app.sock", "", "", "not enabled"},
+		{"empty", "", dir, "", "empty"},
+		{"the dir itself", dir, dir, "", "not within"},
+		{"parent", "../app.sock", dir, "", "not within"},
+		{"absolute outside", "/run/docker.sock", dir, "", "not within"},
+		{"sibling prefix", dir + "-other/app.sock", dir, "", "not within"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ResolveForwardPath(tt.path, tt.dir)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("ResolveForwardPath() = %q, %v; want an error mentioning %q", got, err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil || got != tt.want {
+				t.Errorf("ResolveForwardPath() = %q, %v; want %q", got, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestDialForward_Unix(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.sock")
+	listener, err := net.Listen("unix", path)
+	if err != nil {
+		t.Fatalf("net.Listen failed: %v", err)
+	}
+	defer listener.Close()
+
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		io.Copy(conn, conn)
+	}()
+
+	conn, err := DialForward("", 0, ForwardOptions{Path: path}, nil, time.Second)
+	if err != nil {
+		t.Fatalf("DialForward() error = %v", err)
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(5 * time.Second))
+
+	conn.Write([]byte("ping"))
+	buf := make([]byte, 4)
+	if _, err := io.ReadFull(conn, buf); err != nil || string(buf) != "ping" {
+		t.Errorf("read %q, %v; want the echo", buf, err)
+	}
+}
+
+func TestDialForward_UnixTLS(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.sock")
+	listener, err := net.Listen("unix", path)
+	if err != nil {
+		t.Fatalf("net.Listen failed: %v", err)
+	}
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	srv.Listener.Close()
+	srv.Listener = listener
+	srv.StartTLS()
+	defer srv.Close()
+
+	roots := x509.NewCertPool()
+	roots.AddCert(srv.Certificate())
+	cfg := &tls.Config{RootCAs: roots}
+
+	// The test certificate is for example.com, which HOST names.
+	opts := ForwardOptions{Path: path, Host: "example.com", SSLEnabled: true}
+	conn, err := DialForward(opts.Host, 0, opts, cfg, time.Second)
+	if err != nil {
+		t.Fatalf("DialForward() error = %v", err)
+	}
+	defer conn.Close()
+	if cfg.ServerName != "" {
+		t.Error("DialForward() modified the caller's TLS config")
+	}
+
+	conn.SetDeadline(time.Now().Add(5 * time.Second))
+	conn.Write([]byte("GET / HTTP/1.0\r\n\r\n"))
+	status, err := bufio.NewReader(conn).ReadString('\n')
+	if err != nil || !strings.HasPrefix(status, "HTTP/1.0 200") {
+		t.Errorf("status line = %q, %v; want 200", status, err)
+	}
+
+	opts.Host = "other.example"
+	if _, err := DialForward(opts.Host, 0, opts, cfg, time.Second); err == nil {
+		t.Error("DialForward() accepted a certificate for another server name")
+	}
+}
+
+// portConn is a mockConn with the given addresses.
+type portConn struct {
+	mockConn
+	local, remote net.Addr
+}
+
+func (c *portConn) LocalAddr() net.Addr  { return c.local }
+func (c *portConn) RemoteAddr() net.Addr { return c.remote }
+
+func TestWriteForwardHeader(t *testing.T) {
+	tests := []struct {
+		name string
+		conn net.Conn
+		want string
+	}{
+		{
+			"no ports",
+			&peerConn{peer: peerAddr(testHash(1).String())},
+			testHash(1).String() + " FROM_PORT=0 TO_PORT=0\n",
+		},
+		{
+			"stream ports",
+			&portConn{local: peerAddr("local.b32.i2p:80"), remote: peerAddr("peer.b32.i2p:4321")},
+			"peer.b32.i2p:4321 FROM_PORT=4321 TO_PORT=80\n",
+		},
+		{
+			"TCP addresses",
+			&portConn{local: &net.TCPAddr{IP: net.IPv4(127, 0, 0, 1), Port: 7000}, remote: &net.TCPAddr{IP: net.IPv4(127, 0, 0, 1), Port: 5000}},
+			"127.0.0.1:5000 FROM_PORT=5000 TO_PORT=7000\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var b strings.Builder
+			if err := WriteForwardHeader(&b, tt.conn); err != nil {
+				t.Fatalf("WriteForwardHeader() error = %v", err)
+			}
+			if b.String() != tt.want {
+				t.Errorf("WriteForwardHeader() wrote %q, want %q", b.String(), tt.want)
+			}
+		})
+	}
+}
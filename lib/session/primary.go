@@ -210,6 +210,11 @@ func (p *PrimarySessionImpl) createSubsession(id string, style Style, opts Subse
 		return nil, ErrInvalidSubsessionStyle
 	}
 
+	// Subsessions belong to the owner of the PRIMARY session
+	if o, ok := sess.(ownable); ok {
+		o.SetOwner(OwnerOf(p))
+	}
+
 	// Configure forwarding for DATAGRAM/RAW if specified
 	if opts.Port > 0 {
 		if fwd, ok := sess.(forwardable); ok {
@@ -423,8 +428,12 @@ func (p *PrimarySessionImpl) makeRoutingKey(port, protocol int) string {
 }
 
 // createSubsessionConfig creates a SessionConfig from SubsessionOptions.
+// Subsessions share the primary's I2CP session, so they inherit its
+// i2p.streaming.* options and access list.
 func (p *PrimarySessionImpl) createSubsessionConfig(opts SubsessionOptions) *SessionConfig {
 	cfg := DefaultSessionConfig()
+	cfg.Streaming = StreamingOptionsOf(p)
+	cfg.AccessList = AccessListOf(p)
 	cfg.FromPort = opts.FromPort
 	cfg.ToPort = opts.ToPort
 	cfg.Protocol = opts.Protocol
@@ -438,6 +447,11 @@ type forwardable interface {
 	SetForwarding(host string, port int) error
 }
 
+// ownable is an internal interface for sessions that record their owner.
+type ownable interface {
+	SetOwner(owner string)
+}
+
 // Error definitions for PrimarySession.
 var (
 	// ErrDuplicateSubsessionID indicates the subsession ID already exists.
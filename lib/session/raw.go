@@ -10,6 +10,8 @@ import (
 	"sync"
 
 	"github.com/go-i2p/go-datagrams"
+
+	"github.com/go-i2p/go-sam-bridge/lib/metrics"
 )
 
 // RawSessionImpl implements the RawSession interface.
@@ -185,13 +187,20 @@ func (r *RawSessionImpl) Send(dest string, data []byte, opts RawSendOptions) err
 		Expires: opts.Expires, SendLeaseset: opts.SendLeaseset,
 		SendLeasesetSet: opts.SendLeasesetSet,
 	}
+	var err error
 	if dgOpts := sam33.buildSAM33Options(); dgOpts != nil {
-		return datagramConn.SendToWithOptions(data, dest, toPort, dgOpts)
+		err = datagramConn.SendToWithOptions(data, dest, toPort, dgOpts)
+	} else {
+		// Send via DatagramConn using SendTo
+		// The DatagramConn handles I2CP protocol framing and destination resolution
+		err = datagramConn.SendTo(data, dest, toPort)
 	}
-
-	// Send via DatagramConn using SendTo
-	// The DatagramConn handles I2CP protocol framing and destination resolution
-	return datagramConn.SendTo(data, dest, toPort)
+	if err != nil {
+		metrics.DatagramsDropped.Inc(string(StyleRaw), metrics.DropSendFailed)
+		return err
+	}
+	metrics.DatagramsSent.Inc(string(StyleRaw))
+	return nil
 }
 
 // Receive returns a channel for incoming raw datagrams.
@@ -266,6 +275,7 @@ func (r *RawSessionImpl) deliverDatagram(dg ReceivedRawDatagram) {
 	headerEnabled := r.headerEnabled
 	if forwarding {
 		r.mu.RUnlock()
+		metrics.DatagramsReceived.Inc(string(StyleRaw))
 		r.forwardDatagram(dg, headerEnabled)
 		return
 	}
@@ -274,9 +284,11 @@ func (r *RawSessionImpl) deliverDatagram(dg ReceivedRawDatagram) {
 	// which writes receiveChan = nil under mu.Lock().
 	select {
 	case r.receiveChan <- dg:
+		metrics.DatagramsReceived.Inc(string(StyleRaw))
 	default:
 		// Channel full, drop datagram
 		// This is expected behavior per SAM spec - datagrams are unreliable
+		metrics.DatagramsDropped.Inc(string(StyleRaw), metrics.DropQueueFull)
 	}
 	r.mu.RUnlock()
 }
@@ -207,6 +207,43 @@ func (r *RegistryImpl) MostRecentByStyle(style Style) Session {
 	return nil
 }
 
+// MostRecentOwned is MostRecentByStyle restricted to the sessions of one
+// owner (see OwnerOf), so that DATAGRAM SEND and RAW SEND never pick
+// another user's session. Registries that do not implement
+// MostRecentByOwner only offer their most recent session of the style,
+// returning nil if another owner holds it.
+func MostRecentOwned(r Registry, style Style, owner string) Session {
+	if o, ok := r.(interface {
+		MostRecentByOwner(Style, string) Session
+	}); ok {
+		return o.MostRecentByOwner(style, owner)
+	}
+	if sess := r.MostRecentByStyle(style); sess != nil && OwnerOf(sess) == owner {
+		return sess
+	}
+	return nil
+}
+
+// MostRecentByOwner returns the most recently created session of the given
+// style whose Owner is owner, or nil if there is none.
+func (r *RegistryImpl) MostRecentByOwner(style Style, owner string) Session {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var best Session
+	var bestSeq uint64
+	for id, sess := range r.sessions {
+		if sess.Style() != style || OwnerOf(sess) != owner {
+			continue
+		}
+		if seq := r.sessionSeq[id]; seq > bestSeq {
+			bestSeq = seq
+			best = sess
+		}
+	}
+	return best
+}
+
 // All returns all registered session IDs.
 func (r *RegistryImpl) All() []string {
 	r.mu.RLock()
@@ -365,6 +365,68 @@ func TestRegistry_HasDestination(t *testing.T) {
 	}
 }
 
+func TestRegistry_MostRecentByOwner(t *testing.T) {
+	r := NewRegistry()
+	for _, tc := range []struct{ id, owner string }{
+		{"alice1", "alice"},
+		{"bob1", "bob"},
+		{"alice2", "alice"},
+		{"bob2", "bob"},
+	} {
+		s := &testSession{BaseSession: NewBaseSession(tc.id, StyleRaw, nil, nil, nil)}
+		s.SetOwner(tc.owner)
+		if err := r.Register(s); err != nil {
+			t.Fatalf("Register(%s) error = %v", tc.id, err)
+		}
+	}
+
+	if s := r.MostRecentByOwner(StyleRaw, "alice"); s == nil || s.ID() != "alice2" {
+		t.Errorf("MostRecentByOwner(RAW, alice) = %v, want alice2", s)
+	}
+	if s := r.MostRecentByStyle(StyleRaw); s == nil || s.ID() != "bob2" {
+		t.Errorf("MostRecentByStyle(RAW) = %v, want bob2", s)
+	}
+
+	_ = r.Unregister("alice2")
+	if s := r.MostRecentByOwner(StyleRaw, "alice"); s == nil || s.ID() != "alice1" {
+		t.Errorf("MostRecentByOwner(RAW, alice) after Unregister = %v, want alice1", s)
+	}
+	if s := r.MostRecentByOwner(StyleRaw, ""); s != nil {
+		t.Errorf("MostRecentByOwner(RAW, \"\") = %v, want nil", s.ID())
+	}
+	if s := r.MostRecentByOwner(StyleDatagram, "alice"); s != nil {
+		t.Errorf("MostRecentByOwner(DATAGRAM, alice) = %v, want nil", s.ID())
+	}
+}
+
+func TestMostRecentOwned(t *testing.T) {
+	r := NewRegistry()
+	for _, tc := range []struct{ id, owner string }{
+		{"alice1", "alice"},
+		{"bob1", "bob"},
+	} {
+		s := &testSession{BaseSession: NewBaseSession(tc.id, StyleRaw, nil, nil, nil)}
+		s.SetOwner(tc.owner)
+		if err := r.Register(s); err != nil {
+			t.Fatalf("Register(%s) error = %v", tc.id, err)
+		}
+	}
+
+	if s := MostRecentOwned(r, StyleRaw, "alice"); s == nil || s.ID() != "alice1" {
+		t.Errorf("MostRecentOwned(RAW, alice) = %v, want alice1", s)
+	}
+
+	// A registry without MostRecentByOwner only offers its most recent
+	// session, and only to its owner.
+	plain := struct{ Registry }{r}
+	if s := MostRecentOwned(plain, StyleRaw, "bob"); s == nil || s.ID() != "bob1" {
+		t.Errorf("MostRecentOwned(RAW, bob) = %v, want bob1", s)
+	}
+	if s := MostRecentOwned(plain, StyleRaw, "alice"); s != nil {
+		t.Errorf("MostRecentOwned(RAW, alice) = %v, want nil", s.ID())
+	}
+}
+
 func TestRegistry_ConcurrentAccess(t *testing.T) {
 	r := NewRegistry()
 	var wg sync.WaitGroup
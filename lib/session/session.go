@@ -9,6 +9,9 @@ import (
 	"crypto/sha256"
 	"encoding/hex"
 	"net"
+
+	"github.com/go-i2p/common/base32"
+	"github.com/go-i2p/common/base64"
 )
 
 // I2CPSessionHandle represents a handle to an I2CP session.
@@ -166,6 +169,21 @@ func (d *Destination) Hash() string {
 	return hex.EncodeToString(sum[:])
 }
 
+// Base32 returns the destination's .b32.i2p address: the lowercase, unpadded
+// base32 encoding of the SHA-256 of the binary destination.
+// Returns empty string for nil, empty or undecodable destinations.
+func (d *Destination) Base32() string {
+	if d == nil || len(d.PublicKey) == 0 {
+		return ""
+	}
+	raw, err := base64.DecodeString(string(d.PublicKey))
+	if err != nil {
+		return ""
+	}
+	sum := sha256.Sum256(raw)
+	return base32.EncodeToStringNoPadding(sum[:]) + ".b32.i2p"
+}
+
 // Session defines the base interface for all SAM session types.
 // All session implementations must embed *BaseSession per SAM 3.0 specification.
 type Session interface {
@@ -192,6 +210,24 @@ type Session interface {
 	ControlConn() net.Conn
 }
 
+// Owned is implemented by sessions that record the SAM user that created
+// them. Other control connections may only use such a session if they are
+// authenticated as the same user.
+type Owned interface {
+	// Owner returns the SAM user that created the session, or "" if the
+	// creating connection was not authenticated.
+	Owner() string
+}
+
+// OwnerOf returns the SAM user that created sess, or "" if the creating
+// connection was not authenticated or sess does not implement Owned.
+func OwnerOf(sess Session) string {
+	if o, ok := sess.(Owned); ok {
+		return o.Owner()
+	}
+	return ""
+}
+
 // ReceivedDatagram represents a received datagram with source information.
 type ReceivedDatagram struct {
 	// Source is the I2P destination that sent the datagram.
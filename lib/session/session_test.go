@@ -71,6 +71,32 @@ func TestStyle_IsPrimary(t *testing.T) {
 	}
 }
 
+func TestDestination_Base32(t *testing.T) {
+	tests := []struct {
+		name string
+		dest *Destination
+		want string
+	}{
+		{"nil destination", nil, ""},
+		{"empty public key", &Destination{PublicKey: []byte{}}, ""},
+		{"invalid base64", &Destination{PublicKey: []byte("not base64!")}, ""},
+		{
+			// "AAAA" decodes to three zero bytes
+			"valid destination",
+			&Destination{PublicKey: []byte("AAAA")},
+			"ocpibseeq6rechq64tp3t4rkqykjfuqmi5srkdampffl24hycr6a.b32.i2p",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.dest.Base32(); got != tt.want {
+				t.Errorf("Base32() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
 func TestDestination_Hash(t *testing.T) {
 	t.Run("nil destination", func(t *testing.T) {
 		var d *Destination
@@ -172,3 +198,20 @@ func TestReceivedRawDatagram(t *testing.T) {
 		t.Errorf("Data = %q, want %q", string(dg.Data), "raw data")
 	}
 }
+
+func TestOwnerOf(t *testing.T) {
+	sess := NewBaseSession("owned", StyleStream, nil, nil, nil)
+	if got := OwnerOf(sess); got != "" {
+		t.Errorf("OwnerOf() = %q before SetOwner, want \"\"", got)
+	}
+	sess.SetOwner("alice")
+	if got := OwnerOf(sess); got != "alice" {
+		t.Errorf("OwnerOf() = %q, want %q", got, "alice")
+	}
+
+	// Sessions that do not record an owner belong to no user.
+	var unowned struct{ Session }
+	if got := OwnerOf(unowned); got != "" {
+		t.Errorf("OwnerOf() = %q for a session without Owner, want \"\"", got)
+	}
+}
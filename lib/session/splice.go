@@ -0,0 +1,101 @@
+package session
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"time"
+)
+
+// DefaultStreamLinger is how long Splice keeps copying in one direction
+// after the other direction has reached EOF.
+const DefaultStreamLinger = 30 * time.Second
+
+// closeWriter is implemented by connections that can shut down their write
+// side while still reading, such as *net.TCPConn, *net.UnixConn and
+// *tls.Conn.
+type closeWriter interface {
+	CloseWrite() error
+}
+
+// Splice copies data between a and b in both directions and returns when
+// both directions have finished.
+//
+// EOF is propagated one direction at a time: when one side stops sending,
+// the write side of the other is closed with CloseWrite, so its peer sees
+// EOF while its reply keeps flowing back. This lets request/response
+// protocols that shut down their write side, such as HTTP/1.0 or netcat
+// style uploads, receive the reply. The remaining direction is given
+// linger to drain before both connections are closed; with a linger of 0
+// both are closed as soon as either direction ends. Connections without
+// CloseWrite are left open until then.
+//
+// An error in either direction, or ctx being done, closes both
+// connections at once. Splice returns the first copy error, or ctx.Err();
+// a direction cut off when linger expires is not an error.
+func Splice(ctx context.Context, a, b net.Conn, linger time.Duration) error {
+	errs := make(chan error, 2)
+	go func() { errs <- copyHalf(b, a) }()
+	go func() { errs <- copyHalf(a, b) }()
+
+	closeBoth := func() {
+		a.Close()
+		b.Close()
+	}
+
+	var err error
+	select {
+	case err = <-errs:
+	case <-ctx.Done():
+		closeBoth()
+		<-errs
+		<-errs
+		return ctx.Err()
+	}
+	if err != nil {
+		closeBoth()
+		<-errs
+		return err
+	}
+
+	if linger <= 0 {
+		closeBoth()
+		<-errs
+		return nil
+	}
+	timer := time.NewTimer(linger)
+	defer timer.Stop()
+	select {
+	case err = <-errs:
+	case <-timer.C:
+		closeBoth()
+		<-errs
+	case <-ctx.Done():
+		closeBoth()
+		<-errs
+		err = ctx.Err()
+	}
+	closeBoth()
+	return err
+}
+
+// copyHalf copies src to dst until src reaches EOF, then closes the write
+// side of dst if it supports it.
+func copyHalf(dst, src net.Conn) error {
+	if _, err := io.Copy(dst, src); err != nil {
+		return err
+	}
+	CloseWrite(dst)
+	return nil
+}
+
+// CloseWrite shuts down the write side of conn, so that its peer reads EOF
+// while conn can still be read. It returns errors.ErrUnsupported, leaving
+// conn open, if conn cannot be half-closed.
+func CloseWrite(conn net.Conn) error {
+	if cw, ok := conn.(closeWriter); ok {
+		return cw.CloseWrite()
+	}
+	return errors.ErrUnsupported
+}
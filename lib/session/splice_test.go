@@ -0,0 +1,137 @@
+package session
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"testing"
+	"time"
+)
+
+// tcpPair returns the two ends of a loopback TCP connection.
+func tcpPair(t *testing.T) (*net.TCPConn, *net.TCPConn) {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("net.Listen() error = %v", err)
+	}
+	defer ln.Close()
+
+	dialed, err := net.Dial("tcp", ln.Addr().String())
+	if err != nil {
+		t.Fatalf("net.Dial() error = %v", err)
+	}
+	accepted, err := ln.Accept()
+	if err != nil {
+		t.Fatalf("Accept() error = %v", err)
+	}
+	t.Cleanup(func() {
+		dialed.Close()
+		accepted.Close()
+	})
+	return dialed.(*net.TCPConn), accepted.(*net.TCPConn)
+}
+
+// startSplice splices client to server through the bridge ends of two
+// TCP connections and returns the client end, the server end and
+// Splice's result.
+func startSplice(t *testing.T, ctx context.Context, linger time.Duration) (*net.TCPConn, *net.TCPConn, <-chan error) {
+	t.Helper()
+	client, a := tcpPair(t)
+	b, server := tcpPair(t)
+	client.SetDeadline(time.Now().Add(5 * time.Second))
+	server.SetDeadline(time.Now().Add(5 * time.Second))
+
+	done := make(chan error, 1)
+	go func() { done <- Splice(ctx, a, b, linger) }()
+	return client, server, done
+}
+
+func waitSplice(t *testing.T, done <-chan error) error {
+	t.Helper()
+	select {
+	case err := <-done:
+		return err
+	case <-time.After(5 * time.Second):
+		t.Fatal("Splice did not return")
+		return nil
+	}
+}
+
+func TestSplice_HalfClose(t *testing.T) {
+	client, server, done := startSplice(t, context.Background(), 5*time.Second)
+
+	// The client sends its request and shuts down its write side, like
+	// an HTTP/1.0 client or a netcat upload.
+	client.Write([]byte("request"))
+	client.CloseWrite()
+
+	request, err := io.ReadAll(server)
+	if err != nil || string(request) != "request" {
+		t.Fatalf("server read %q, %v; want the request then EOF", request, err)
+	}
+	server.Write([]byte("reply"))
+	server.Close()
+
+	reply, err := io.ReadAll(client)
+	if err != nil || string(reply) != "reply" {
+		t.Errorf("client read %q, %v; want the reply", reply, err)
+	}
+	if err := waitSplice(t, done); err != nil {
+		t.Errorf("Splice() error = %v", err)
+	}
+}
+
+func TestSplice_LingerExpires(t *testing.T) {
+	client, server, done := startSplice(t, context.Background(), 50*time.Millisecond)
+
+	client.CloseWrite()
+	if _, err := io.ReadAll(server); err != nil {
+		t.Fatalf("server ReadAll() error = %v", err)
+	}
+
+	// The server never replies; the connections are closed after linger.
+	start := time.Now()
+	if err := waitSplice(t, done); err != nil {
+		t.Errorf("Splice() error = %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("Splice returned after %v, want about the 50ms linger", elapsed)
+	}
+	if _, err := io.ReadAll(client); err != nil {
+		t.Errorf("client ReadAll() error = %v, want EOF", err)
+	}
+}
+
+func TestSplice_NoLinger(t *testing.T) {
+	client, server, done := startSplice(t, context.Background(), 0)
+
+	client.Write([]byte("request"))
+	client.CloseWrite()
+	if err := waitSplice(t, done); err != nil {
+		t.Errorf("Splice() error = %v", err)
+	}
+	if request, _ := io.ReadAll(server); string(request) != "request" {
+		t.Errorf("server read %q, want the request", request)
+	}
+}
+
+func TestSplice_ContextDone(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	_, _, done := startSplice(t, ctx, 5*time.Second)
+
+	cancel()
+	if err := waitSplice(t, done); !errors.Is(err, context.Canceled) {
+		t.Errorf("Splice() error = %v, want context.Canceled", err)
+	}
+}
+
+func TestCloseWrite_Unsupported(t *testing.T) {
+	a, b := net.Pipe()
+	defer a.Close()
+	defer b.Close()
+	if err := CloseWrite(a); !errors.Is(err, errors.ErrUnsupported) {
+		t.Errorf("CloseWrite(net.Pipe) error = %v, want errors.ErrUnsupported", err)
+	}
+}
@@ -7,7 +7,6 @@ import (
 	"context"
 	"errors"
 	"fmt"
-	"io"
 	"net"
 	"sync"
 	"time"
@@ -22,13 +21,18 @@ import (
 // the connection from I2P, otherwise it rejects it."
 const ForwardConnectTimeout = 3 * time.Second
 
+// ForwardAcceptRetryDelay is how long a STREAM FORWARD waits before
+// accepting again after its I2P listener fails, so that a listener that
+// keeps failing, e.g. while the router is unreachable, does not spin.
+const ForwardAcceptRetryDelay = 100 * time.Millisecond
+
 // StreamSessionImpl implements the StreamSession interface.
 // It embeds *BaseSession and integrates with go-streaming for I2P stream handling.
 //
 // Per PLAN.md section 1.7 and SAM 3.0 specification:
 //   - Supports STREAM CONNECT for outbound connections
 //   - Supports STREAM ACCEPT for inbound connections (concurrent ACCEPTs per SAM 3.2)
-//   - Supports STREAM FORWARD for forwarding to host:port
+//   - Supports STREAM FORWARD for forwarding to host:port or a Unix socket
 //   - FORWARD and ACCEPT are mutually exclusive
 type StreamSessionImpl struct {
 	*BaseSession
@@ -53,6 +57,7 @@ type StreamSessionImpl struct {
 	forwardingEnabled bool
 	forwardHost       string
 	forwardPort       int
+	forwardPath       string
 	forwardStop       chan struct{}
 	forwardWg         sync.WaitGroup
 
@@ -60,6 +65,9 @@ type StreamSessionImpl struct {
 	activeConns   map[string]net.Conn
 	activeConnsMu sync.RWMutex
 
+	// throttle limits inbound streams per the session's i2p.streaming options
+	throttle *InboundThrottle
+
 	// Context for cancellation
 	ctx    context.Context
 	cancel context.CancelFunc
@@ -87,7 +95,7 @@ func NewStreamSession(
 ) *StreamSessionImpl {
 	ctx, cancel := context.WithCancel(context.Background())
 
-	return &StreamSessionImpl{
+	s := &StreamSessionImpl{
 		BaseSession:   NewBaseSession(id, StyleStream, dest, conn, cfg),
 		i2cpSession:   i2cpSession,
 		streamManager: manager,
@@ -96,6 +104,8 @@ func NewStreamSession(
 		ctx:           ctx,
 		cancel:        cancel,
 	}
+	s.throttle = NewInboundThrottle(StreamingOptionsOf(s))
+	return s
 }
 
 // NewStreamSessionBasic creates a new STREAM session without I2CP components.
@@ -117,13 +127,15 @@ func NewStreamSessionBasic(
 ) *StreamSessionImpl {
 	ctx, cancel := context.WithCancel(context.Background())
 
-	return &StreamSessionImpl{
+	s := &StreamSessionImpl{
 		BaseSession: NewBaseSession(id, StyleStream, dest, conn, cfg),
 		activeConns: make(map[string]net.Conn),
 		forwardStop: make(chan struct{}),
 		ctx:         ctx,
 		cancel:      cancel,
 	}
+	s.throttle = NewInboundThrottle(StreamingOptionsOf(s))
+	return s
 }
 
 // SetI2CPSession sets the I2CP session for stream operations.
@@ -168,6 +180,22 @@ func (s *StreamSessionImpl) ForwardConfig() (host string, port int) {
 	return s.forwardHost, s.forwardPort
 }
 
+// SetForwardPath records the Unix socket STREAM FORWARD forwards to, for
+// management tools.
+func (s *StreamSessionImpl) SetForwardPath(path string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.forwardPath = path
+}
+
+// ForwardPath returns the Unix socket the session forwards to, or empty
+// string if it forwards to host:port or not at all.
+func (s *StreamSessionImpl) ForwardPath() string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.forwardPath
+}
+
 // Activate transitions the session from Creating to Active status.
 // Call this after all I2CP components are configured.
 func (s *StreamSessionImpl) Activate() {
@@ -308,7 +336,7 @@ func (s *StreamSessionImpl) prepareForAccept() (net.Listener, error) {
 			localPort = uint16(cfg.FromPort)
 		}
 
-		listener, err := streaming.ListenWithManager(s.streamManager, localPort, streaming.DefaultMTU)
+		listener, err := streaming.ListenWithManager(s.streamManager, localPort, StreamingOptionsOf(s).MaxMessageSize)
 		if err != nil {
 			return nil, fmt.Errorf("failed to create listener: %w", err)
 		}
@@ -317,13 +345,15 @@ func (s *StreamSessionImpl) prepareForAccept() (net.Listener, error) {
 	return s.listener, nil
 }
 
-// acceptWithTimeout accepts a connection with optional timeout.
+// acceptWithTimeout accepts a connection with optional timeout. Streams
+// from peers the session's access list does not allow, or over its inbound
+// limits, are rejected without consuming the ACCEPT.
 func (s *StreamSessionImpl) acceptWithTimeout(listener net.Listener, timeout time.Duration) (net.Conn, error) {
 	if timeout > 0 {
 		return s.acceptWithTimeoutImpl(listener, timeout)
 	}
 
-	conn, err := listener.Accept()
+	conn, err := AcceptAllowed(listener, AccessListOf(s), s.throttle)
 	if err != nil {
 		return nil, fmt.Errorf("accept failed: %w", err)
 	}
@@ -341,7 +371,7 @@ func (s *StreamSessionImpl) acceptWithTimeoutImpl(listener net.Listener, timeout
 
 	done := make(chan acceptResult, 1)
 	go func() {
-		conn, err := listener.Accept()
+		conn, err := AcceptAllowed(listener, AccessListOf(s), s.throttle)
 		done <- acceptResult{conn, err}
 	}()
 
@@ -410,7 +440,8 @@ func (s *StreamSessionImpl) PendingAcceptCount() int {
 	return s.pendingAccepts
 }
 
-// Forward sets up forwarding of incoming connections to host:port.
+// Forward sets up forwarding of incoming connections to host:port, or to
+// the Unix socket opts.Path if it is set.
 // Implements SAM 3.0 STREAM FORWARD command.
 //
 // FORWARD and ACCEPT are mutually exclusive per SAM specification.
@@ -418,7 +449,7 @@ func (s *StreamSessionImpl) PendingAcceptCount() int {
 // Parameters:
 //   - host: Target host for forwarding
 //   - port: Target port for forwarding
-//   - opts: Forwarding options (SILENT mode)
+//   - opts: Forwarding options (Unix socket path, SILENT mode, SSL, linger)
 //
 // The forwarding runs in background goroutines. Each incoming connection
 // spawns a goroutine that forwards data bidirectionally.
@@ -450,7 +481,7 @@ func (s *StreamSessionImpl) Forward(host string, port int, opts ForwardOptions)
 		localPort = uint16(cfg.FromPort)
 	}
 
-	listener, err := streaming.ListenWithManager(s.streamManager, localPort, streaming.DefaultMTU)
+	listener, err := streaming.ListenWithManager(s.streamManager, localPort, StreamingOptionsOf(s).MaxMessageSize)
 	if err != nil {
 		s.mu.Unlock()
 		return fmt.Errorf("failed to create listener: %w", err)
@@ -460,27 +491,39 @@ func (s *StreamSessionImpl) Forward(host string, port int, opts ForwardOptions)
 	s.forwardingEnabled = true
 	s.forwardHost = host
 	s.forwardPort = port
+	s.forwardPath = opts.Path
 	s.forwardStop = make(chan struct{})
 	s.mu.Unlock()
 
 	// Start forwarding goroutine
 	s.forwardWg.Add(1)
-	go s.forwardLoop(listener, host, port, opts.Silent)
+	go s.forwardLoop(listener, host, port, opts)
 
 	return nil
 }
 
 // forwardLoop accepts incoming connections and forwards them to the target.
-func (s *StreamSessionImpl) forwardLoop(listener net.Listener, host string, port int, silent bool) {
+func (s *StreamSessionImpl) forwardLoop(listener net.Listener, host string, port int, opts ForwardOptions) {
 	defer s.forwardWg.Done()
 
-	target := net.JoinHostPort(host, fmt.Sprintf("%d", port))
-
 	for {
 		if s.shouldStopForwarding() {
 			return
 		}
-		s.acceptAndForward(listener, target)
+		err := s.acceptAndForward(listener, host, port, opts)
+		if err == nil {
+			continue
+		}
+		if errors.Is(err, net.ErrClosed) {
+			return
+		}
+		select {
+		case <-s.forwardStop:
+			return
+		case <-s.ctx.Done():
+			return
+		case <-time.After(ForwardAcceptRetryDelay):
+		}
 	}
 }
 
@@ -497,30 +540,43 @@ func (s *StreamSessionImpl) shouldStopForwarding() bool {
 }
 
 // acceptAndForward accepts a single incoming connection and forwards it.
+// Streams from peers the session's access list does not allow, or over its
+// inbound limits, are rejected.
 // Per SAMv3.md: "If it is accepted in less than 3 seconds, SAM will accept
 // the connection from I2P, otherwise it rejects it."
-func (s *StreamSessionImpl) acceptAndForward(listener net.Listener, target string) {
-	inConn, err := listener.Accept()
+//
+// Unless opts.Silent is set, the peer's destination is written to the
+// target on a line of its own before the stream's data.
+//
+// It returns an error only if listener fails; streams that cannot be
+// forwarded are closed.
+func (s *StreamSessionImpl) acceptAndForward(listener net.Listener, host string, port int, opts ForwardOptions) error {
+	inConn, err := AcceptAllowed(listener, AccessListOf(s), s.throttle)
 	if err != nil {
-		// Check if we should stop on accept error
-		if s.shouldStopForwarding() {
-			return
-		}
-		return
+		return err
 	}
 
-	outConn, err := net.DialTimeout("tcp", target, ForwardConnectTimeout)
+	outConn, err := DialForward(host, port, opts, nil, ForwardConnectTimeout)
 	if err != nil {
 		inConn.Close()
-		return
+		return nil
+	}
+	if !opts.Silent {
+		if err := WriteForwardHeader(outConn, inConn); err != nil {
+			inConn.Close()
+			outConn.Close()
+			return nil
+		}
 	}
 
 	s.forwardWg.Add(1)
-	go s.forwardConnection(inConn, outConn)
+	go s.forwardConnection(inConn, outConn, opts.Linger)
+	return nil
 }
 
-// forwardConnection forwards data between two connections bidirectionally.
-func (s *StreamSessionImpl) forwardConnection(i2pConn, tcpConn net.Conn) {
+// forwardConnection forwards data between two connections bidirectionally,
+// copying in one direction for up to linger after the other reaches EOF.
+func (s *StreamSessionImpl) forwardConnection(i2pConn, tcpConn net.Conn, linger time.Duration) {
 	defer s.forwardWg.Done()
 	defer i2pConn.Close()
 	defer tcpConn.Close()
@@ -537,32 +593,15 @@ func (s *StreamSessionImpl) forwardConnection(i2pConn, tcpConn net.Conn) {
 		s.activeConnsMu.Unlock()
 	}()
 
-	// Bidirectional copy
-	done := make(chan struct{}, 2)
-
-	go func() {
-		io.Copy(tcpConn, i2pConn)
-		done <- struct{}{}
-	}()
-
-	go func() {
-		io.Copy(i2pConn, tcpConn)
-		done <- struct{}{}
-	}()
-
-	// Wait for either direction to complete or context cancellation.
-	select {
-	case <-done:
-	case <-s.ctx.Done():
-	}
+	// Copy in both directions, closing each write side at EOF so that
+	// replies to half-closed requests still arrive.
+	_ = Splice(s.ctx, i2pConn, tcpConn, linger)
+}
 
-	// Close both connections to unblock the still-running io.Copy goroutine,
-	// then wait for it. Without this, forwardWg.Done() would be called while
-	// one goroutine is still executing, violating the WaitGroup contract and
-	// causing the goroutine to outlive Close().
-	i2pConn.Close()
-	tcpConn.Close()
-	<-done
+// InboundThrottle returns the throttle limiting the session's inbound
+// streams, which the STREAM handlers also apply.
+func (s *StreamSessionImpl) InboundThrottle() *InboundThrottle {
+	return s.throttle
 }
 
 // IsForwarding returns true if FORWARD is active on this session.
@@ -2,6 +2,7 @@
 package session
 
 import (
+	"io"
 	"testing"
 	"time"
 )
@@ -172,6 +173,33 @@ func TestStreamSessionImpl_Close(t *testing.T) {
 	})
 }
 
+func TestStreamSessionImpl_ForwardConnectionLinger(t *testing.T) {
+	session := NewStreamSession("test-linger", nil, nil, nil, nil, nil)
+	defer session.Close()
+
+	// The peer half-closes its stream; the target's reply still arrives
+	// within the forward's linger.
+	peer, i2pConn := tcpPair(t)
+	tcpConn, target := tcpPair(t)
+	peer.SetDeadline(time.Now().Add(5 * time.Second))
+	target.SetDeadline(time.Now().Add(5 * time.Second))
+
+	session.forwardWg.Add(1)
+	go session.forwardConnection(i2pConn, tcpConn, 5*time.Second)
+
+	peer.Write([]byte("request"))
+	peer.CloseWrite()
+	if request, err := io.ReadAll(target); err != nil || string(request) != "request" {
+		t.Fatalf("target read %q, %v; want the request then EOF", request, err)
+	}
+	target.Write([]byte("reply"))
+	target.Close()
+
+	if reply, err := io.ReadAll(peer); err != nil || string(reply) != "reply" {
+		t.Errorf("peer read %q, %v; want the reply", reply, err)
+	}
+}
+
 func TestStreamSessionImpl_I2CPSession(t *testing.T) {
 	session := NewStreamSession("test-i2cp", nil, nil, nil, nil, nil)
 
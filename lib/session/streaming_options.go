@@ -0,0 +1,239 @@
+package session
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-i2p/go-streaming"
+)
+
+// StreamingOptionPrefix starts the names of Java I2P streaming options,
+// such as i2p.streaming.maxWindowSize, given in SESSION CREATE.
+const StreamingOptionPrefix = "i2p.streaming."
+
+// Inactivity actions of i2p.streaming.inactivityAction, as in Java I2P.
+const (
+	// InactivityActionNone does nothing when a stream is inactive.
+	InactivityActionNone = 0
+
+	// InactivityActionDisconnect closes an inactive stream.
+	InactivityActionDisconnect = 1
+
+	// InactivityActionSend sends a keepalive on an inactive stream.
+	InactivityActionSend = 2
+)
+
+// Streaming profiles of i2p.streaming.profile, as in Java I2P.
+const (
+	// StreamingProfileBulk favours throughput.
+	StreamingProfileBulk = 1
+
+	// StreamingProfileInteractive favours latency.
+	StreamingProfileInteractive = 2
+)
+
+// DefaultConnectTimeout is how long STREAM CONNECT waits for the
+// destination lookup and stream handshake by default.
+const DefaultConnectTimeout = 60 * time.Second
+
+// maxStreamingWindowSize is the largest window Java I2P allows.
+const maxStreamingWindowSize = 128
+
+// minStreamingMessageSize is the smallest MTU accepted for a stream.
+const minStreamingMessageSize = 512
+
+// StreamingOptions holds the i2p.streaming.* options of a STREAM session.
+// Names, units and defaults follow Java I2P, so that applications tuned
+// for it behave the same here; durations are given in milliseconds.
+type StreamingOptions struct {
+	// MaxMessageSize is the stream MTU in bytes
+	// (i2p.streaming.maxMessageSize, default 1730).
+	MaxMessageSize int
+
+	// ConnectTimeout bounds the destination lookup and handshake of STREAM
+	// CONNECT (i2p.streaming.connectTimeout, default 60s).
+	ConnectTimeout time.Duration
+
+	// ConnectDelay delays the SYN to send it with the first data; negative
+	// disables the delay (i2p.streaming.connectDelay, default -1).
+	ConnectDelay time.Duration
+
+	// InactivityTimeout is how long a stream may carry no data before
+	// InactivityAction is taken (i2p.streaming.inactivityTimeout, default
+	// 90s).
+	InactivityTimeout time.Duration
+
+	// InactivityAction is one of the InactivityAction constants
+	// (i2p.streaming.inactivityAction, default InactivityActionSend).
+	InactivityAction int
+
+	// MaxWindowSize is the largest send window in messages
+	// (i2p.streaming.maxWindowSize, 1-128, default 128).
+	MaxWindowSize int
+
+	// Profile is StreamingProfileBulk or StreamingProfileInteractive
+	// (i2p.streaming.profile, default bulk).
+	Profile int
+
+	// MaxConnsPerMinute, MaxConnsPerHour and MaxConnsPerDay limit the
+	// inbound streams from each peer per period
+	// (i2p.streaming.maxConnsPerMinute etc., 0 = no limit).
+	MaxConnsPerMinute int
+	MaxConnsPerHour   int
+	MaxConnsPerDay    int
+
+	// MaxTotalConnsPerMinute, MaxTotalConnsPerHour and MaxTotalConnsPerDay
+	// limit the inbound streams from all peers per period
+	// (i2p.streaming.maxTotalConnsPerMinute etc., 0 = no limit).
+	MaxTotalConnsPerMinute int
+	MaxTotalConnsPerHour   int
+	MaxTotalConnsPerDay    int
+
+	// MaxConcurrentStreams limits the inbound streams open at once
+	// (i2p.streaming.maxConcurrentStreams, default -1 = no limit).
+	MaxConcurrentStreams int
+
+	// AnswerPings answers streaming pings from peers
+	// (i2p.streaming.answerPings, default true).
+	AnswerPings bool
+}
+
+// DefaultStreamingOptions returns the streaming options used when a
+// session gives none.
+func DefaultStreamingOptions() StreamingOptions {
+	return StreamingOptions{
+		MaxMessageSize:       streaming.DefaultMTU,
+		ConnectTimeout:       DefaultConnectTimeout,
+		ConnectDelay:         -1,
+		InactivityTimeout:    90 * time.Second,
+		InactivityAction:     InactivityActionSend,
+		MaxWindowSize:        maxStreamingWindowSize,
+		Profile:              StreamingProfileBulk,
+		MaxConcurrentStreams: -1,
+		AnswerPings:          true,
+	}
+}
+
+// ParseStreamingOptions returns the default streaming options updated with
+// the i2p.streaming.* entries of options. Other entries, and streaming
+// options the bridge does not know, are ignored. It returns an error
+// naming the first option with an invalid value.
+func ParseStreamingOptions(options map[string]string) (StreamingOptions, error) {
+	opts := DefaultStreamingOptions()
+	for key, value := range options {
+		name, ok := strings.CutPrefix(key, StreamingOptionPrefix)
+		if !ok {
+			continue
+		}
+		if err := opts.set(name, value); err != nil {
+			return opts, fmt.Errorf("invalid %s: %w", key, err)
+		}
+	}
+	return opts, nil
+}
+
+// set parses value into the option called name, without its prefix.
+func (o *StreamingOptions) set(name, value string) error {
+	var err error
+	switch name {
+	case "maxMessageSize":
+		o.MaxMessageSize, err = parseStreamingInt(value, minStreamingMessageSize, 65535)
+	case "connectTimeout":
+		o.ConnectTimeout, err = parseStreamingMillis(value, 1)
+	case "connectDelay":
+		o.ConnectDelay, err = parseStreamingMillis(value, -1)
+	case "inactivityTimeout":
+		o.InactivityTimeout, err = parseStreamingMillis(value, 0)
+	case "inactivityAction":
+		o.InactivityAction, err = parseStreamingInt(value, InactivityActionNone, InactivityActionSend)
+	case "maxWindowSize":
+		o.MaxWindowSize, err = parseStreamingInt(value, 1, maxStreamingWindowSize)
+	case "profile":
+		o.Profile, err = parseStreamingInt(value, StreamingProfileBulk, StreamingProfileInteractive)
+	case "maxConnsPerMinute":
+		o.MaxConnsPerMinute, err = parseStreamingInt(value, 0, -1)
+	case "maxConnsPerHour":
+		o.MaxConnsPerHour, err = parseStreamingInt(value, 0, -1)
+	case "maxConnsPerDay":
+		o.MaxConnsPerDay, err = parseStreamingInt(value, 0, -1)
+	case "maxTotalConnsPerMinute":
+		o.MaxTotalConnsPerMinute, err = parseStreamingInt(value, 0, -1)
+	case "maxTotalConnsPerHour":
+		o.MaxTotalConnsPerHour, err = parseStreamingInt(value, 0, -1)
+	case "maxTotalConnsPerDay":
+		o.MaxTotalConnsPerDay, err = parseStreamingInt(value, 0, -1)
+	case "maxConcurrentStreams":
+		o.MaxConcurrentStreams, err = parseStreamingInt(value, -1, -1)
+	case "answerPings":
+		o.AnswerPings, err = strconv.ParseBool(value)
+		if err != nil {
+			err = fmt.Errorf("must be true or false")
+		}
+	}
+	return err
+}
+
+// parseStreamingInt parses an integer of at least min and, unless max is
+// negative, at most max.
+func parseStreamingInt(value string, min, max int) (int, error) {
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, fmt.Errorf("must be an integer")
+	}
+	if n < min || (max >= 0 && n > max) {
+		if max < 0 {
+			return 0, fmt.Errorf("must be at least %d", min)
+		}
+		return 0, fmt.Errorf("must be %d-%d", min, max)
+	}
+	return n, nil
+}
+
+// parseStreamingMillis parses a duration in milliseconds of at least min.
+// A negative min allows any negative value to mean "disabled".
+func parseStreamingMillis(value string, min int) (time.Duration, error) {
+	ms, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("must be an integer number of milliseconds")
+	}
+	if ms < int64(min) && min >= 0 {
+		return 0, fmt.Errorf("must be at least %d", min)
+	}
+	return time.Duration(ms) * time.Millisecond, nil
+}
+
+// Unsupported returns the names of options set to a value go-streaming
+// cannot honour, so that they keep go-streaming's behaviour.
+func (o StreamingOptions) Unsupported() []string {
+	def := DefaultStreamingOptions()
+	var names []string
+	if o.ConnectDelay >= 0 {
+		names = append(names, StreamingOptionPrefix+"connectDelay")
+	}
+	if o.InactivityAction == InactivityActionSend && o.InactivityTimeout != def.InactivityTimeout {
+		names = append(names, StreamingOptionPrefix+"inactivityTimeout")
+	}
+	if o.MaxWindowSize != def.MaxWindowSize {
+		names = append(names, StreamingOptionPrefix+"maxWindowSize")
+	}
+	if o.Profile != def.Profile {
+		names = append(names, StreamingOptionPrefix+"profile")
+	}
+	if o.AnswerPings != def.AnswerPings {
+		names = append(names, StreamingOptionPrefix+"answerPings")
+	}
+	return names
+}
+
+// StreamingOptionsOf returns the streaming options of sess, or the
+// defaults if its configuration was not made by DefaultSessionConfig.
+func StreamingOptionsOf(sess Session) StreamingOptions {
+	if c, ok := sess.(interface{ Config() *SessionConfig }); ok {
+		if cfg := c.Config(); cfg != nil && cfg.Streaming.MaxMessageSize != 0 {
+			return cfg.Streaming
+		}
+	}
+	return DefaultStreamingOptions()
+}
@@ -0,0 +1,114 @@
+package session
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseStreamingOptions(t *testing.T) {
+	opts, err := ParseStreamingOptions(map[string]string{
+		"i2p.streaming.maxMessageSize":       "1024",
+		"i2p.streaming.connectTimeout":       "5000",
+		"i2p.streaming.inactivityTimeout":    "20000",
+		"i2p.streaming.inactivityAction":     "1",
+		"i2p.streaming.maxConnsPerMinute":    "10",
+		"i2p.streaming.maxConnsPerDay":       "100",
+		"i2p.streaming.maxTotalConnsPerHour": "500",
+		"i2p.streaming.maxConcurrentStreams": "20",
+		"i2p.streaming.unknownOption":        "x",
+		"inbound.length":                     "2",
+	})
+	if err != nil {
+		t.Fatalf("ParseStreamingOptions() error = %v", err)
+	}
+	want := DefaultStreamingOptions()
+	want.MaxMessageSize = 1024
+	want.ConnectTimeout = 5 * time.Second
+	want.InactivityTimeout = 20 * time.Second
+	want.InactivityAction = InactivityActionDisconnect
+	want.MaxConnsPerMinute = 10
+	want.MaxConnsPerDay = 100
+	want.MaxTotalConnsPerHour = 500
+	want.MaxConcurrentStreams = 20
+	if opts != want {
+		t.Errorf("ParseStreamingOptions() = %+v, want %+v", opts, want)
+	}
+	if len(opts.Unsupported()) != 0 {
+		t.Errorf("Unsupported() = %v, want none", opts.Unsupported())
+	}
+}
+
+func TestParseStreamingOptions_Invalid(t *testing.T) {
+	tests := []struct {
+		key, value string
+	}{
+		{"i2p.streaming.maxMessageSize", "100"},
+		{"i2p.streaming.connectTimeout", "0"},
+		{"i2p.streaming.connectTimeout", "soon"},
+		{"i2p.streaming.inactivityAction", "3"},
+		{"i2p.streaming.maxWindowSize", "500"},
+		{"i2p.streaming.profile", "0"},
+		{"i2p.streaming.maxConnsPerMinute", "-1"},
+		{"i2p.streaming.maxTotalConnsPerDay", "many"},
+		{"i2p.streaming.maxConcurrentStreams", "-2"},
+		{"i2p.streaming.answerPings", "maybe"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.key+"="+tt.value, func(t *testing.T) {
+			_, err := ParseStreamingOptions(map[string]string{tt.key: tt.value})
+			if err == nil || !strings.Contains(err.Error(), tt.key) {
+				t.Errorf("ParseStreamingOptions() error = %v, want an error naming %s", err, tt.key)
+			}
+		})
+	}
+}
+
+func TestStreamingOptions_Unsupported(t *testing.T) {
+	opts, err := ParseStreamingOptions(map[string]string{
+		"i2p.streaming.connectDelay":      "1000",
+		"i2p.streaming.inactivityTimeout": "30000",
+		"i2p.streaming.maxWindowSize":     "64",
+		"i2p.streaming.profile":           "2",
+		"i2p.streaming.answerPings":       "false",
+	})
+	if err != nil {
+		t.Fatalf("ParseStreamingOptions() error = %v", err)
+	}
+	want := []string{
+		"i2p.streaming.connectDelay",
+		"i2p.streaming.inactivityTimeout",
+		"i2p.streaming.maxWindowSize",
+		"i2p.streaming.profile",
+		"i2p.streaming.answerPings",
+	}
+	if got := opts.Unsupported(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Unsupported() = %v, want %v", got, want)
+	}
+}
+
+func TestStreamingOptionsOf(t *testing.T) {
+	cfg := DefaultSessionConfig()
+	cfg.Streaming.ConnectTimeout = 5 * time.Second
+	sess := NewBaseSession("opts", StyleStream, nil, nil, cfg)
+	if got := StreamingOptionsOf(sess); got.ConnectTimeout != 5*time.Second {
+		t.Errorf("StreamingOptionsOf() ConnectTimeout = %v, want 5s", got.ConnectTimeout)
+	}
+
+	primary := NewPrimarySession("opts-primary", nil, nil, cfg)
+	primary.SetStatus(StatusActive)
+	defer primary.Close()
+	sub, err := primary.AddSubsession("opts-sub", StyleStream, SubsessionOptions{})
+	if err != nil {
+		t.Fatalf("AddSubsession() error = %v", err)
+	}
+	if got := StreamingOptionsOf(sub); got.ConnectTimeout != 5*time.Second {
+		t.Errorf("subsession ConnectTimeout = %v, want the primary's 5s", got.ConnectTimeout)
+	}
+
+	bare := NewBaseSession("bare", StyleStream, nil, nil, &SessionConfig{})
+	if got := StreamingOptionsOf(bare); got != DefaultStreamingOptions() {
+		t.Errorf("StreamingOptionsOf() = %+v, want the defaults", got)
+	}
+}
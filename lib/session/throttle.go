@@ -0,0 +1,260 @@
+package session
+
+import (
+	"net"
+	"sync"
+	"time"
+
+	"github.com/go-i2p/logger"
+
+	"github.com/go-i2p/go-sam-bridge/lib/metrics"
+)
+
+// InboundThrottle limits the inbound streams of a STREAM session with its
+// i2p.streaming.maxConnsPer*, maxTotalConnsPer* and maxConcurrentStreams
+// options, and records how many streams it and the session's access list
+// rejected.
+//
+// As in Java I2P, streams are counted in fixed periods of a minute, an hour
+// and a day, and rejected streams count too, so a peer that keeps retrying
+// stays throttled until the period ends.
+//
+// Thread-safety: all methods are safe for concurrent use.
+type InboundThrottle struct {
+	mu sync.Mutex
+
+	// counters count streams in each period that has a limit.
+	counters []*periodCounter
+
+	// maxActive limits the streams open at once (<= 0 = no limit).
+	maxActive int
+
+	// active is the number of accepted streams not yet closed.
+	active int
+
+	// rejected counts rejected streams by metrics.Reject* reason.
+	rejected map[string]uint64
+
+	// limits holds the limiting options, for Stats.
+	limits map[string]int
+}
+
+// InboundStats is a snapshot of an InboundThrottle, as shown by the admin API.
+type InboundStats struct {
+	// Active is the number of inbound streams open.
+	Active int `json:"active"`
+
+	// Limits holds the i2p.streaming.* options that limit inbound streams.
+	Limits map[string]int `json:"limits,omitempty"`
+
+	// Rejected counts the streams reset before reaching the client, by
+	// reason (see metrics.StreamsRejected).
+	Rejected map[string]uint64 `json:"rejected,omitempty"`
+}
+
+// periodCounter counts the streams opened in a fixed period, in total and
+// per peer.
+type periodCounter struct {
+	period     time.Duration
+	peerLimit  int
+	totalLimit int
+
+	start time.Time
+	total int
+	peers map[string]int
+}
+
+// NewInboundThrottle returns a throttle enforcing the limits of opts.
+func NewInboundThrottle(opts StreamingOptions) *InboundThrottle {
+	t := &InboundThrottle{
+		maxActive: opts.MaxConcurrentStreams,
+		rejected:  make(map[string]uint64),
+		limits:    make(map[string]int),
+	}
+	periods := []struct {
+		name        string
+		period      time.Duration
+		peer, total int
+	}{
+		{"Minute", time.Minute, opts.MaxConnsPerMinute, opts.MaxTotalConnsPerMinute},
+		{"Hour", time.Hour, opts.MaxConnsPerHour, opts.MaxTotalConnsPerHour},
+		{"Day", 24 * time.Hour, opts.MaxConnsPerDay, opts.MaxTotalConnsPerDay},
+	}
+	for _, p := range periods {
+		if p.peer > 0 {
+			t.limits[StreamingOptionPrefix+"maxConnsPer"+p.name] = p.peer
+		}
+		if p.total > 0 {
+			t.limits[StreamingOptionPrefix+"maxTotalConnsPer"+p.name] = p.total
+		}
+		if p.peer > 0 || p.total > 0 {
+			t.counters = append(t.counters, &periodCounter{
+				period:     p.period,
+				peerLimit:  p.peer,
+				totalLimit: p.total,
+				peers:      make(map[string]int),
+			})
+		}
+	}
+	if opts.MaxConcurrentStreams > 0 {
+		t.limits[StreamingOptionPrefix+"maxConcurrentStreams"] = opts.MaxConcurrentStreams
+	}
+	return t
+}
+
+// InboundThrottleOf returns the inbound throttle of sess, or nil if it has
+// none.
+func InboundThrottleOf(sess Session) *InboundThrottle {
+	if t, ok := sess.(interface{ InboundThrottle() *InboundThrottle }); ok {
+		return t.InboundThrottle()
+	}
+	return nil
+}
+
+// admit counts a stream from peer at now. It returns "" if the stream may
+// be accepted, in which case it is active until release is called, or the
+// metrics.Reject* reason it must be rejected for.
+func (t *InboundThrottle) admit(peer string, now time.Time) string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	// Every period counts the stream, even once one rejects it.
+	reason := ""
+	for _, c := range t.counters {
+		if r := c.add(peer, now); reason == "" {
+			reason = r
+		}
+	}
+	if reason == "" && t.maxActive > 0 && t.active >= t.maxActive {
+		reason = metrics.RejectConcurrent
+	}
+	if reason != "" {
+		t.rejected[reason]++
+		return reason
+	}
+	t.active++
+	return ""
+}
+
+// release ends a stream admitted by admit.
+func (t *InboundThrottle) release() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.active > 0 {
+		t.active--
+	}
+}
+
+// reject records a stream rejected for reason before it was counted, such
+// as by an access list.
+func (t *InboundThrottle) reject(reason string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.rejected[reason]++
+}
+
+// Stats returns a snapshot of the throttle.
+func (t *InboundThrottle) Stats() InboundStats {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	stats := InboundStats{Active: t.active}
+	if len(t.limits) > 0 {
+		stats.Limits = make(map[string]int, len(t.limits))
+		for k, v := range t.limits {
+			stats.Limits[k] = v
+		}
+	}
+	if len(t.rejected) > 0 {
+		stats.Rejected = make(map[string]uint64, len(t.rejected))
+		for k, v := range t.rejected {
+			stats.Rejected[k] = v
+		}
+	}
+	return stats
+}
+
+// add counts a stream from peer at now, starting a new period if the
+// current one has ended, and returns the reason it is over a limit, if any.
+func (c *periodCounter) add(peer string, now time.Time) string {
+	if now.Sub(c.start) >= c.period {
+		c.start = now
+		c.total = 0
+		clear(c.peers)
+	}
+	c.total++
+	if c.peerLimit > 0 {
+		c.peers[peer]++
+		if c.peers[peer] > c.peerLimit {
+			return metrics.RejectPeerRate
+		}
+	}
+	if c.totalLimit > 0 && c.total > c.totalLimit {
+		return metrics.RejectTotalRate
+	}
+	return ""
+}
+
+// peerKey identifies the peer at addr for the per-peer limits.
+func peerKey(addr net.Addr) string {
+	if h, ok := PeerHash(addr); ok {
+		return h.String()
+	}
+	if addr == nil {
+		return ""
+	}
+	return addr.String()
+}
+
+// AcceptAllowed accepts streams from listener until one that acl allows and
+// throttle admits arrives, and returns it. Either may be nil. Other streams
+// are closed, resetting them before a client sees them, and counted in
+// metrics.StreamsRejected and the throttle's Stats. A stream admitted by
+// throttle counts as active until it is closed.
+func AcceptAllowed(listener net.Listener, acl *AccessList, throttle *InboundThrottle) (net.Conn, error) {
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			return nil, err
+		}
+
+		reason := ""
+		if !acl.Allows(conn.RemoteAddr()) {
+			reason = metrics.RejectAccessList
+			if acl.Mode == AccessListDeny {
+				reason = metrics.RejectBlackList
+			}
+			if throttle != nil {
+				throttle.reject(reason)
+			}
+		} else if throttle != nil {
+			if reason = throttle.admit(peerKey(conn.RemoteAddr()), time.Now()); reason == "" {
+				return &inboundConn{Conn: conn, release: throttle.release}, nil
+			}
+		} else {
+			return conn, nil
+		}
+
+		metrics.StreamsRejected.Inc(reason)
+		log.WithFields(logger.Fields{"pkg": "session", "func": "AcceptAllowed", "peer": conn.RemoteAddr(), "reason": reason}).Debug("Rejected inbound stream")
+		conn.Close()
+	}
+}
+
+// inboundConn is an inbound stream admitted by an InboundThrottle, which
+// it releases when closed.
+type inboundConn struct {
+	net.Conn
+	once    sync.Once
+	release func()
+}
+
+// Close closes the stream and releases its place in the throttle.
+func (c *inboundConn) Close() error {
+	c.once.Do(c.release)
+	return c.Conn.Close()
+}
+
+// CloseWrite half-closes the stream, if it supports it.
+func (c *inboundConn) CloseWrite() error {
+	return CloseWrite(c.Conn)
+}
@@ -0,0 +1,149 @@
+package session
+
+import (
+	"net"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/go-i2p/go-sam-bridge/lib/metrics"
+)
+
+func TestInboundThrottle_PeerLimit(t *testing.T) {
+	opts := DefaultStreamingOptions()
+	opts.MaxConnsPerMinute = 2
+	throttle := NewInboundThrottle(opts)
+	now := time.Now()
+
+	for i := 0; i < 2; i++ {
+		if reason := throttle.admit("a", now.Add(time.Duration(i)*time.Second)); reason != "" {
+			t.Fatalf("admit() = %q for a peer within its limit", reason)
+		}
+	}
+	if reason := throttle.admit("a", now.Add(2*time.Second)); reason != metrics.RejectPeerRate {
+		t.Errorf("admit() = %q for a third stream within a minute, want %q", reason, metrics.RejectPeerRate)
+	}
+	if reason := throttle.admit("b", now.Add(2*time.Second)); reason != "" {
+		t.Errorf("admit() = %q for another peer", reason)
+	}
+	// Rejected streams count, so the peer stays throttled until the period
+	// ends rather than until its first stream is a minute old.
+	if reason := throttle.admit("a", now.Add(59*time.Second)); reason != metrics.RejectPeerRate {
+		t.Errorf("admit() = %q within the period, want %q", reason, metrics.RejectPeerRate)
+	}
+	if reason := throttle.admit("a", now.Add(time.Minute)); reason != "" {
+		t.Errorf("admit() = %q once the period ended", reason)
+	}
+}
+
+func TestInboundThrottle_TotalLimit(t *testing.T) {
+	opts := DefaultStreamingOptions()
+	opts.MaxTotalConnsPerHour = 2
+	throttle := NewInboundThrottle(opts)
+	now := time.Now()
+
+	if throttle.admit("a", now) != "" || throttle.admit("b", now) != "" {
+		t.Fatal("admit() rejected streams within the total limit")
+	}
+	if reason := throttle.admit("c", now.Add(time.Minute)); reason != metrics.RejectTotalRate {
+		t.Errorf("admit() = %q over the total limit, want %q", reason, metrics.RejectTotalRate)
+	}
+	if reason := throttle.admit("c", now.Add(time.Hour)); reason != "" {
+		t.Errorf("admit() = %q once the hour ended", reason)
+	}
+}
+
+func TestInboundThrottle_Concurrent(t *testing.T) {
+	opts := DefaultStreamingOptions()
+	opts.MaxConcurrentStreams = 1
+	throttle := NewInboundThrottle(opts)
+
+	if reason := throttle.admit("a", time.Now()); reason != "" {
+		t.Fatalf("admit() = %q for the first stream", reason)
+	}
+	if reason := throttle.admit("b", time.Now()); reason != metrics.RejectConcurrent {
+		t.Errorf("admit() = %q with a stream open, want %q", reason, metrics.RejectConcurrent)
+	}
+	throttle.release()
+	if reason := throttle.admit("b", time.Now()); reason != "" {
+		t.Errorf("admit() = %q after the open stream was released", reason)
+	}
+}
+
+func TestInboundThrottle_Stats(t *testing.T) {
+	opts := DefaultStreamingOptions()
+	opts.MaxConnsPerMinute = 1
+	opts.MaxConcurrentStreams = 5
+	throttle := NewInboundThrottle(opts)
+	now := time.Now()
+
+	throttle.admit("a", now)
+	throttle.admit("a", now)
+	throttle.reject(metrics.RejectAccessList)
+
+	want := InboundStats{
+		Active: 1,
+		Limits: map[string]int{
+			"i2p.streaming.maxConnsPerMinute":    1,
+			"i2p.streaming.maxConcurrentStreams": 5,
+		},
+		Rejected: map[string]uint64{
+			metrics.RejectPeerRate:   1,
+			metrics.RejectAccessList: 1,
+		},
+	}
+	if got := throttle.Stats(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Stats() = %+v, want %+v", got, want)
+	}
+
+	if got := NewInboundThrottle(DefaultStreamingOptions()).Stats(); !reflect.DeepEqual(got, InboundStats{}) {
+		t.Errorf("Stats() = %+v without limits, want none", got)
+	}
+}
+
+func TestAcceptAllowed_Throttle(t *testing.T) {
+	opts := DefaultStreamingOptions()
+	opts.MaxConnsPerMinute = 1
+	opts.MaxConcurrentStreams = 1
+	throttle := NewInboundThrottle(opts)
+
+	first := &peerConn{peer: peerAddr(testHash(1).String())}
+	again := &peerConn{peer: peerAddr(testHash(1).String())}
+	other := &peerConn{peer: peerAddr(testHash(2).String())}
+	later := &peerConn{peer: peerAddr(testHash(3).String())}
+	listener := &queueListener{conns: []net.Conn{first, again}}
+	before := metrics.StreamsRejected.Value(metrics.RejectPeerRate)
+
+	conn, err := AcceptAllowed(listener, nil, throttle)
+	if err != nil {
+		t.Fatalf("AcceptAllowed() error = %v", err)
+	}
+	if _, err := AcceptAllowed(listener, nil, throttle); err == nil {
+		t.Fatal("AcceptAllowed() returned a stream over the peer's limit")
+	}
+	if !again.isClosed() {
+		t.Error("stream over the peer's limit was not closed")
+	}
+	if got := metrics.StreamsRejected.Value(metrics.RejectPeerRate) - before; got != 1 {
+		t.Errorf("StreamsRejected{peer_rate} increased by %v, want 1", got)
+	}
+
+	// The first stream is still open, so the next peer's is over
+	// maxConcurrentStreams until it is closed.
+	listener.conns = []net.Conn{other}
+	if _, err := AcceptAllowed(listener, nil, throttle); err == nil {
+		t.Fatal("AcceptAllowed() returned a stream over maxConcurrentStreams")
+	}
+	conn.Close()
+	conn.Close()
+	listener.conns = []net.Conn{later}
+	if conn, err := AcceptAllowed(listener, nil, throttle); err != nil {
+		t.Fatalf("AcceptAllowed() error = %v after the open stream closed", err)
+	} else {
+		conn.Close()
+	}
+
+	if got := throttle.Stats(); got.Active != 0 || got.Rejected[metrics.RejectConcurrent] != 1 {
+		t.Errorf("Stats() = %+v, want no active streams and 1 rejected as concurrent", got)
+	}
+}
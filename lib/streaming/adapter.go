@@ -12,6 +12,8 @@ import (
 
 	go_i2cp "github.com/go-i2p/go-i2cp"
 	"github.com/go-i2p/go-streaming"
+
+	"github.com/go-i2p/go-sam-bridge/lib/session"
 )
 
 // Adapter wraps go-streaming's StreamManager to implement handler.StreamManager.
@@ -28,6 +30,9 @@ import (
 //	streamingConnector.RegisterManager(sessionID, adapter)
 type Adapter struct {
 	manager *streaming.StreamManager
+
+	// options are the session's i2p.streaming.* options; see SetOptions.
+	options session.StreamingOptions
 }
 
 // NewAdapter creates a new streaming adapter wrapping the given StreamManager.
@@ -42,7 +47,7 @@ func NewAdapter(manager *streaming.StreamManager) (*Adapter, error) {
 		return nil, fmt.Errorf("stream manager has no active session (call StartSession first)")
 	}
 
-	return &Adapter{manager: manager}, nil
+	return &Adapter{manager: manager, options: session.DefaultStreamingOptions()}, nil
 }
 
 // LookupDestination resolves a hostname or B32 address to an I2P destination.
@@ -100,24 +105,28 @@ func (a *Adapter) Dial(dest interface{}, port uint16, mtu int) (net.Conn, error)
 		return nil, fmt.Errorf("dial failed: %w", err)
 	}
 
-	return conn, nil
+	return a.wrapConn(conn), nil
 }
 
 // Listen creates a StreamListener on the specified port.
 // Returns a net.Listener for accepting incoming connections.
+// The session's i2p.streaming.maxMessageSize, if set, replaces mtu.
 //
 // Per SAMv3.md: STREAM ACCEPT waits for and accepts incoming connections.
 func (a *Adapter) Listen(port uint16, mtu int) (net.Listener, error) {
 	if a.manager == nil {
 		return nil, fmt.Errorf("adapter not initialized")
 	}
+	if a.options.MaxMessageSize != 0 {
+		mtu = a.options.MaxMessageSize
+	}
 
 	listener, err := streaming.ListenWithManager(a.manager, port, mtu)
 	if err != nil {
 		return nil, fmt.Errorf("listen failed on port %d: %w", port, err)
 	}
 
-	return listener, nil
+	return &inboundListener{Listener: listener, adapter: a}, nil
 }
 
 // Destination returns the local I2P destination for this session.
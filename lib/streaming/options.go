@@ -0,0 +1,107 @@
+package streaming
+
+import (
+	"errors"
+	"net"
+	"time"
+
+	"github.com/go-i2p/logger"
+
+	"github.com/go-i2p/go-sam-bridge/lib/session"
+)
+
+// SetOptions applies the i2p.streaming.* options of the adapter's session
+// to the streams it creates. It must be called before the adapter is
+// registered with the handlers.
+//
+// The MTU (maxMessageSize) is used for listening, and streams are closed
+// after inactivityTimeout if inactivityAction is disconnect. Inbound stream
+// limits are applied by the session (see session.InboundThrottle). Options that
+// go-streaming cannot honour, such as maxWindowSize, keep go-streaming's
+// behaviour and are logged.
+func (a *Adapter) SetOptions(opts session.StreamingOptions) {
+	a.options = opts
+	if unsupported := opts.Unsupported(); len(unsupported) > 0 {
+		log.WithFields(logger.Fields{"pkg": "streaming", "func": "Adapter.SetOptions", "options": unsupported}).Warn("Streaming options not supported by go-streaming are ignored")
+	}
+}
+
+// wrapConn applies the inactivity options to a new stream.
+func (a *Adapter) wrapConn(conn net.Conn) net.Conn {
+	if a.options.InactivityAction != session.InactivityActionDisconnect || a.options.InactivityTimeout <= 0 {
+		return conn
+	}
+	return newIdleConn(conn, a.options.InactivityTimeout)
+}
+
+// inboundListener applies an Adapter's options to the streams accepted
+// by a listener.
+type inboundListener struct {
+	net.Listener
+	adapter *Adapter
+}
+
+// Accept returns the next inbound stream.
+func (l *inboundListener) Accept() (net.Conn, error) {
+	conn, err := l.Listener.Accept()
+	if err != nil {
+		return nil, err
+	}
+	return l.adapter.wrapConn(conn), nil
+}
+
+// SetDeadline sets the accept deadline of the underlying listener, if it
+// has one.
+func (l *inboundListener) SetDeadline(t time.Time) error {
+	if d, ok := l.Listener.(interface{ SetDeadline(time.Time) error }); ok {
+		return d.SetDeadline(t)
+	}
+	return errors.ErrUnsupported
+}
+
+// idleConn closes a stream that carries no data for timeout, for
+// i2p.streaming.inactivityAction=1.
+type idleConn struct {
+	net.Conn
+	timeout time.Duration
+	timer   *time.Timer
+}
+
+// newIdleConn wraps conn, starting its inactivity timer.
+func newIdleConn(conn net.Conn, timeout time.Duration) *idleConn {
+	c := &idleConn{Conn: conn, timeout: timeout}
+	c.timer = time.AfterFunc(timeout, func() {
+		log.WithFields(logger.Fields{"pkg": "streaming", "func": "idleConn", "timeout": timeout}).Debug("Closing inactive stream")
+		conn.Close()
+	})
+	return c
+}
+
+// Read reads from the stream, restarting the inactivity timer on data.
+func (c *idleConn) Read(b []byte) (int, error) {
+	n, err := c.Conn.Read(b)
+	if n > 0 {
+		c.timer.Reset(c.timeout)
+	}
+	return n, err
+}
+
+// Write writes to the stream, restarting the inactivity timer on data.
+func (c *idleConn) Write(b []byte) (int, error) {
+	n, err := c.Conn.Write(b)
+	if n > 0 {
+		c.timer.Reset(c.timeout)
+	}
+	return n, err
+}
+
+// Close stops the inactivity timer and closes the stream.
+func (c *idleConn) Close() error {
+	c.timer.Stop()
+	return c.Conn.Close()
+}
+
+// CloseWrite half-closes the stream, if it supports it.
+func (c *idleConn) CloseWrite() error {
+	return session.CloseWrite(c.Conn)
+}
@@ -0,0 +1,111 @@
+package streaming
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	"github.com/go-i2p/go-sam-bridge/lib/session"
+)
+
+// peerAddr is the address of a test peer.
+type peerAddr string
+
+func (a peerAddr) Network() string { return "i2p" }
+func (a peerAddr) String() string  { return string(a) }
+
+// peerConn is a mockConn from a given peer.
+type peerConn struct {
+	mockConn
+	peer peerAddr
+}
+
+func (c *peerConn) RemoteAddr() net.Addr { return c.peer }
+
+// queueListener accepts the queued conns, then fails.
+type queueListener struct {
+	mockListener
+	conns []net.Conn
+}
+
+func (l *queueListener) Accept() (net.Conn, error) {
+	if len(l.conns) == 0 {
+		return nil, net.ErrClosed
+	}
+	conn := l.conns[0]
+	l.conns = l.conns[1:]
+	return conn, nil
+}
+
+func TestInboundListener_WrapsStreams(t *testing.T) {
+	adapter := &Adapter{}
+	opts := session.DefaultStreamingOptions()
+	opts.InactivityAction = session.InactivityActionDisconnect
+	adapter.SetOptions(opts)
+
+	first := &peerConn{peer: "a"}
+	listener := &inboundListener{
+		Listener: &queueListener{conns: []net.Conn{first}},
+		adapter:  adapter,
+	}
+
+	conn, err := listener.Accept()
+	if err != nil {
+		t.Fatalf("Accept() error = %v", err)
+	}
+	defer conn.Close()
+	if idle, ok := conn.(*idleConn); !ok || idle.Conn != first {
+		t.Fatalf("Accept() = %T, want the stream wrapped in *idleConn", conn)
+	}
+	if _, err := listener.Accept(); err == nil {
+		t.Error("Accept() did not return the listener's error")
+	}
+}
+
+func TestIdleConn_ClosesWhenInactive(t *testing.T) {
+	a, b := net.Pipe()
+	defer b.Close()
+	go func() {
+		buf := make([]byte, 16)
+		for {
+			if _, err := b.Read(buf); err != nil {
+				return
+			}
+		}
+	}()
+
+	conn := newIdleConn(a, 100*time.Millisecond)
+	defer conn.Close()
+
+	// Writing keeps the stream open past the timeout.
+	for i := 0; i < 4; i++ {
+		time.Sleep(50 * time.Millisecond)
+		if _, err := conn.Write([]byte("x")); err != nil {
+			t.Fatalf("Write() error = %v while active", err)
+		}
+	}
+
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	start := time.Now()
+	if _, err := conn.Read(make([]byte, 1)); err == nil {
+		t.Fatal("Read() succeeded on an inactive stream")
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("inactive stream closed after %v, want about 100ms", elapsed)
+	}
+}
+
+func TestAdapter_WrapConn(t *testing.T) {
+	adapter := &Adapter{options: session.DefaultStreamingOptions()}
+	conn := &mockConn{}
+	if got := adapter.wrapConn(conn); got != conn {
+		t.Error("wrapConn() wrapped a stream with the default inactivity action")
+	}
+
+	adapter.options.InactivityAction = session.InactivityActionDisconnect
+	wrapped := adapter.wrapConn(conn)
+	if _, ok := wrapped.(*idleConn); !ok {
+		t.Fatalf("wrapConn() = %T, want *idleConn", wrapped)
+	}
+	wrapped.Close()
+}